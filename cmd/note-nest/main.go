@@ -3,16 +3,20 @@ package main
 import (
 	"context"
 	"log"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/LaulauChau/note-nest/internal/adapter/http"
 	"github.com/LaulauChau/note-nest/internal/adapter/http/controller"
 	"github.com/LaulauChau/note-nest/internal/adapter/http/router"
+	"github.com/LaulauChau/note-nest/internal/adapter/jobs"
+	appServices "github.com/LaulauChau/note-nest/internal/application/services"
 	"github.com/LaulauChau/note-nest/internal/application/use_cases"
 	"github.com/LaulauChau/note-nest/internal/config"
+	"github.com/LaulauChau/note-nest/internal/infrastructure/persistence/database"
 	"github.com/LaulauChau/note-nest/internal/infrastructure/persistence/repositories"
 	"github.com/LaulauChau/note-nest/internal/infrastructure/services"
-
-	"github.com/jackc/pgx/v5"
 )
 
 func main() {
@@ -23,47 +27,185 @@ func main() {
 	}
 
 	// Initialize database
-	db, err := pgx.Connect(context.Background(), config.DATABASE.URL)
+	pool, err := database.NewPool(context.Background(), config)
 	if err != nil {
 		log.Fatalf("failed to connect to database: %v", err)
 	}
-	defer func() {
-		if err := db.Close(context.Background()); err != nil {
-			log.Printf("error closing database connection: %v", err)
+	defer pool.Close()
+
+	// Run the migrate subcommand instead of the server when requested
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), pool, os.Args[2:]); err != nil {
+			log.Fatalf("migration failed: %v", err)
+		}
+		return
+	}
+
+	// Optionally refuse to serve requests against an outdated schema
+	if config.Migrations.RequireCurrent {
+		migrator, err := database.NewMigrator(pool)
+		if err != nil {
+			log.Fatalf("failed to load migrations: %v", err)
+		}
+		pending, err := migrator.Pending(context.Background())
+		if err != nil {
+			log.Fatalf("failed to check migrations: %v", err)
 		}
-	}()
+		if len(pending) > 0 {
+			log.Fatalf("database schema is behind by %d migrations, run `note-nest migrate up`", len(pending))
+		}
+	}
 
 	// Initialize the SQLC queries struct
-	queries := repositories.New(db)
+	queries := repositories.New(pool)
 
 	// Initialize repository implementations
 	userRepo := repositories.NewUserRepository(queries)
 	sessionRepo := repositories.NewSessionRepository(queries)
 	noteRepo := repositories.NewNoteRepository(queries)
 	labelRepo := repositories.NewLabelRepository(queries)
+	revisionRepo := repositories.NewNoteRevisionRepository(queries)
+	passwordResetRepo := repositories.NewPasswordResetRepository(queries)
+	emailVerificationRepo := repositories.NewEmailVerificationRepository(queries)
+	emailChangeRepo := repositories.NewEmailChangeRepository(queries)
+	twoFactorRepo := repositories.NewTwoFactorRepository(queries)
+	loginAuditRepo := repositories.NewLoginAuditRepository(queries)
+	personalAccessTokenRepo := repositories.NewPersonalAccessTokenRepository(queries)
+	noteShareRepo := repositories.NewNoteShareRepository(queries)
+	shareLinkRepo := repositories.NewShareLinkRepository(queries)
+	checklistItemRepo := repositories.NewChecklistItemRepository(queries)
+	attachmentRepo := repositories.NewAttachmentRepository(queries)
+	txManager := repositories.NewTxManager(pool, queries)
 
 	// Initialize services
 	tokenService := services.NewTokenService()
 	hashService := services.NewArgonHashService()
+	totpService := services.NewTOTPService(config.TwoFactor.Issuer)
+	mailer := newMailer(config)
+	blobStore, err := newBlobStore(config)
+	if err != nil {
+		log.Fatalf("failed to set up attachment storage: %v", err)
+	}
+	loginAttemptStore := services.NewMemoryLoginAttemptStore()
 
 	// Initialize use cases
 	userUseCase := use_cases.NewUserUseCase(userRepo, hashService)
-	sessionUseCase := use_cases.NewSessionUseCase(sessionRepo, userRepo, tokenService)
-	noteUseCase := use_cases.NewNoteUseCase(noteRepo, userRepo, labelRepo)
-	labelUseCase := use_cases.NewLabelUseCase(labelRepo, userRepo, noteRepo)
+	sessionUseCase := use_cases.NewSessionUseCase(sessionRepo, userRepo, tokenService, use_cases.SessionConfig{
+		TTL:              config.Sessions.TTL,
+		RenewalThreshold: config.Sessions.RenewalThreshold,
+		IdleTimeout:      config.Sessions.IdleTimeout,
+		MaxAge:           config.Sessions.MaxAge,
+		PartialTTL:       config.Sessions.PartialTTL,
+		CleanupBatchSize: config.Sessions.CleanupBatchSize,
+	})
+	notePermissions := use_cases.NewNotePermissionService(noteRepo, noteShareRepo)
+	noteUseCase := use_cases.NewNoteUseCase(noteRepo, userRepo, labelRepo, revisionRepo, txManager, notePermissions, blobStore)
+	labelUseCase := use_cases.NewLabelUseCase(labelRepo, userRepo, noteRepo, notePermissions)
+	revisionUseCase := use_cases.NewNoteRevisionUseCase(revisionRepo, noteRepo, userRepo, txManager, notePermissions)
+	emailVerificationUseCase := use_cases.NewEmailVerificationUseCase(userRepo, emailVerificationRepo, tokenService, mailer, use_cases.EmailVerificationPolicy(config.EmailVerification.Policy), config.App.BaseURL, config.EmailVerification.TokenTTL, config.EmailVerification.ResendInterval)
+	noteShareUseCase := use_cases.NewNoteShareUseCase(noteShareRepo, userRepo, emailVerificationUseCase, notePermissions)
+	checklistUseCase := use_cases.NewChecklistUseCase(checklistItemRepo, txManager, notePermissions)
+	attachmentUseCase := use_cases.NewAttachmentUseCase(attachmentRepo, blobStore, notePermissions, config.Attachments.MaxSize, config.Attachments.UserQuota)
+	emailChangeUseCase := use_cases.NewEmailChangeUseCase(userRepo, emailChangeRepo, tokenService, hashService, mailer, config.App.BaseURL, config.EmailVerification.TokenTTL)
+	accountDeletionGrace := time.Duration(config.Accounts.DeletionGraceDays) * 24 * time.Hour
+	personalAccessTokenUseCase := use_cases.NewPersonalAccessTokenUseCase(personalAccessTokenRepo, userRepo, tokenService)
+	twoFactorUseCase := use_cases.NewTwoFactorUseCase(userRepo, twoFactorRepo, totpService, tokenService, txManager)
+	accountUseCase := use_cases.NewAccountUseCase(userRepo, sessionUseCase, hashService, txManager, blobStore, accountDeletionGrace)
+	loginThrottleUseCase := use_cases.NewLoginThrottleUseCase(loginAttemptStore, loginAuditRepo, use_cases.LoginThrottleConfig{
+		Email: use_cases.LoginThrottlePolicy{
+			FreeAttempts:     config.LoginThrottle.EmailFreeAttempts,
+			LockoutThreshold: config.LoginThrottle.EmailLockoutThreshold,
+		},
+		IP: use_cases.LoginThrottlePolicy{
+			FreeAttempts:     config.LoginThrottle.IPFreeAttempts,
+			LockoutThreshold: config.LoginThrottle.IPLockoutThreshold,
+		},
+		BaseDelay:       config.LoginThrottle.BaseDelay,
+		MaxDelay:        config.LoginThrottle.MaxDelay,
+		LockoutDuration: config.LoginThrottle.LockoutDuration,
+		ResetAfter:      config.LoginThrottle.ResetAfter,
+	})
+	shareLinkUseCase := use_cases.NewShareLinkUseCase(shareLinkRepo, noteRepo, checklistItemRepo, tokenService, hashService, notePermissions, loginThrottleUseCase, config.App.BaseURL)
+	passwordResetUseCase := use_cases.NewPasswordResetUseCase(userRepo, passwordResetRepo, txManager, emailVerificationUseCase, tokenService, hashService, mailer, loginAttemptStore, config.App.BaseURL, use_cases.PasswordResetConfig{
+		TokenTTL:       config.PasswordReset.TokenTTL,
+		ResendInterval: config.PasswordReset.ResendInterval,
+		IPLimit:        config.PasswordReset.IPLimit,
+		IPWindow:       config.PasswordReset.IPWindow,
+	})
 
 	// Initialize controllers
-	userController := controller.NewUserController(userUseCase, sessionUseCase)
-	sessionController := controller.NewSessionController(sessionUseCase)
+	userController := controller.NewUserController(userUseCase, sessionUseCase, personalAccessTokenUseCase, emailVerificationUseCase, emailChangeUseCase, twoFactorUseCase, loginThrottleUseCase)
+	sessionController := controller.NewSessionController(sessionUseCase, personalAccessTokenUseCase)
 	noteController := controller.NewNoteController(noteUseCase, labelUseCase)
 	labelController := controller.NewLabelController(labelUseCase)
+	revisionController := controller.NewRevisionController(revisionUseCase)
+	healthController := controller.NewHealthController(database.NewPoolMonitor(pool))
+	passwordController := controller.NewPasswordController(passwordResetUseCase)
+	emailVerificationController := controller.NewEmailVerificationController(emailVerificationUseCase)
+	accountController := controller.NewAccountController(accountUseCase, loginThrottleUseCase)
+	twoFactorController := controller.NewTwoFactorController(twoFactorUseCase, sessionUseCase, loginThrottleUseCase)
+	personalAccessTokenController := controller.NewPersonalAccessTokenController(personalAccessTokenUseCase)
+	noteShareController := controller.NewNoteShareController(noteShareUseCase)
+	shareLinkController := controller.NewShareLinkController(shareLinkUseCase)
+	checklistController := controller.NewChecklistController(checklistUseCase)
+	attachmentController := controller.NewAttachmentController(attachmentUseCase, config.Attachments.TransferTimeout)
+
+	// Start background jobs, stopped when the server shuts down
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	var runningJobs sync.WaitGroup
+	startJob := func(run func(context.Context)) {
+		runningJobs.Add(1)
+		go func() {
+			defer runningJobs.Done()
+			run(jobsCtx)
+		}()
+	}
+
+	trashRetention := time.Duration(config.Trash.RetentionDays) * 24 * time.Hour
+	startJob(jobs.NewTrashPurgeJob(noteUseCase, trashRetention, config.Trash.PurgeInterval).Run)
+	startJob(jobs.NewSessionCleanupJob(sessionUseCase, config.Sessions.CleanupInterval).Run)
+
+	// Accounts are only kept around when there is a grace period
+	if accountDeletionGrace > 0 {
+		startJob(jobs.NewAccountPurgeJob(accountUseCase, config.Accounts.PurgeInterval).Run)
+	}
 
 	// Initialize router
-	r := router.NewRouter(userController, sessionController, noteController, labelController)
+	r := router.NewRouter(userController, sessionController, noteController, labelController, revisionController, healthController, passwordController, emailVerificationController, accountController, twoFactorController, personalAccessTokenController, noteShareController, shareLinkController, checklistController, attachmentController)
 
 	// Initialize and start server
 	server := http.NewServer(r, config.Server.Port)
+	if config.Server.InternalPort != 0 {
+		server.ServeInternal(router.NewInternalRouter(healthController), config.Server.InternalPort)
+	}
 	if err := server.Start(); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
+
+	// Let the jobs finish their current batch before closing the pool
+	stopJobs()
+	runningJobs.Wait()
+}
+
+// newMailer returns the mail sender selected by the configuration
+func newMailer(cfg *config.Config) appServices.Mailer {
+	switch cfg.Mail.Driver {
+	case "smtp":
+		return services.NewSMTPMailer(cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword, cfg.Mail.From)
+	case "file":
+		return services.NewFileMailer(cfg.Mail.FileDir, cfg.Mail.From)
+	default:
+		return services.NewLogMailer()
+	}
+}
+
+// newBlobStore returns the attachment storage selected by the configuration
+func newBlobStore(cfg *config.Config) (appServices.BlobStore, error) {
+	switch cfg.Storage.Driver {
+	case "s3":
+		return services.NewS3BlobStore(cfg.Storage.S3Endpoint, cfg.Storage.S3Region, cfg.Storage.S3Bucket, cfg.Storage.S3AccessKey, cfg.Storage.S3SecretKey)
+	default:
+		return services.NewLocalBlobStore(cfg.Storage.LocalDir), nil
+	}
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/LaulauChau/note-nest/internal/infrastructure/persistence/database"
+)
+
+const migrateUsage = "usage: note-nest migrate up|down|status|to <version>|baseline <version>"
+
+// runMigrate implements the `migrate` subcommand
+func runMigrate(ctx context.Context, pool *pgxpool.Pool, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := database.NewMigrator(pool)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		printMigrations("applied", applied)
+		return err
+	case "down":
+		rolledBack, err := migrator.Down(ctx)
+		printMigrations("rolled back", rolledBack)
+		return err
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid migration version %q", args[1])
+		}
+		changed, err := migrator.To(ctx, version)
+		printMigrations("migrated", changed)
+		return err
+	case "baseline":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version <= 0 {
+			return fmt.Errorf("invalid migration version %q", args[1])
+		}
+		recorded, err := migrator.Baseline(ctx, version)
+		printMigrations("recorded", recorded)
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatus(statuses)
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+func printMigrations(action string, migrations []database.Migration) {
+	if len(migrations) == 0 {
+		fmt.Println("no migrations to run")
+		return
+	}
+	for _, migration := range migrations {
+		fmt.Printf("%s %d_%s\n", action, migration.Version, migration.Name)
+	}
+}
+
+func printStatus(statuses []database.MigrationStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.Applied() {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	w.Flush()
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+type AccountController struct {
+	accountUseCase       *use_cases.AccountUseCase
+	loginThrottleUseCase *use_cases.LoginThrottleUseCase
+}
+
+func NewAccountController(accountUseCase *use_cases.AccountUseCase, loginThrottleUseCase *use_cases.LoginThrottleUseCase) *AccountController {
+	return &AccountController{
+		accountUseCase:       accountUseCase,
+		loginThrottleUseCase: loginThrottleUseCase,
+	}
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+type DeleteAccountResponse struct {
+	PurgeAt string `json:"purge_at"`
+}
+
+type RecoverAccountRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (c *AccountController) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse the request body
+	var req DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate input
+	if req.Password == "" {
+		problem.WriteValidation(w, r, "Password is required", domainerrors.FieldError{Field: "password", Message: "is required"})
+		return
+	}
+
+	// The password is checked like on sign in, and throttled the same way
+	attempt := loginAttempt(r, user.Email)
+	if err := c.loginThrottleUseCase.Check(ctx, attempt); err != nil {
+		problem.WriteError(w, r, err, "Failed to delete account")
+		return
+	}
+
+	// Delete the account
+	purgeAt, err := c.accountUseCase.DeleteAccount(ctx, user.ID, req.Password)
+	if err != nil {
+		if errors.Is(err, use_cases.ErrIncorrectPassword) {
+			if recordErr := c.loginThrottleUseCase.RecordFailure(ctx, attempt, entities.LoginFailureInvalidCredentials); recordErr != nil {
+				log.Printf("error recording failed login attempt: %v", recordErr)
+			}
+		}
+		problem.WriteError(w, r, err, "Failed to delete account")
+		return
+	}
+	if err := c.loginThrottleUseCase.RecordSuccess(ctx, attempt); err != nil {
+		log.Printf("error resetting failed login attempts: %v", err)
+	}
+
+	// Clear the session cookie, every session is gone
+	clearSessionCookie(w)
+
+	// Return success with no content when the account is already gone
+	if purgeAt == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Otherwise tell the client until when it can be recovered
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(DeleteAccountResponse{
+		PurgeAt: purgeAt.Format(time.RFC3339),
+	}); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *AccountController) RecoverAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Parse the request body
+	var req RecoverAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate email format
+	if !isValidEmail(req.Email) {
+		problem.WriteValidation(w, r, "Invalid email format", domainerrors.FieldError{Field: "email", Message: "must be a valid email address"})
+		return
+	}
+
+	// The credentials are checked like on sign in, and throttled the same way
+	attempt := loginAttempt(r, req.Email)
+	if err := c.loginThrottleUseCase.Check(ctx, attempt); err != nil {
+		problem.WriteError(w, r, err, "Failed to recover account")
+		return
+	}
+
+	// Cancel the scheduled deletion
+	if err := c.accountUseCase.RecoverAccount(ctx, req.Email, req.Password); err != nil {
+		if errors.Is(err, domainerrors.ErrUnauthorized) {
+			if recordErr := c.loginThrottleUseCase.RecordFailure(ctx, attempt, entities.LoginFailureInvalidCredentials); recordErr != nil {
+				log.Printf("error recording failed login attempt: %v", recordErr)
+			}
+		}
+		problem.WriteError(w, r, err, "Failed to recover account")
+		return
+	}
+	if err := c.loginThrottleUseCase.RecordSuccess(ctx, attempt); err != nil {
+		log.Printf("error resetting failed login attempts: %v", err)
+	}
+
+	// Return success with no content
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,324 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+// maxMultipartOverhead leaves room for the boundaries and headers of the
+// multipart body around the file itself
+const maxMultipartOverhead = 1 << 20
+
+type AttachmentController struct {
+	attachmentUseCase *use_cases.AttachmentUseCase
+	transferTimeout   time.Duration
+}
+
+// NewAttachmentController creates the controller. Uploads and downloads may
+// take up to transferTimeout, overriding the server timeouts.
+func NewAttachmentController(attachmentUseCase *use_cases.AttachmentUseCase, transferTimeout time.Duration) *AttachmentController {
+	return &AttachmentController{
+		attachmentUseCase: attachmentUseCase,
+		transferTimeout:   transferTimeout,
+	}
+}
+
+type AttachmentResponse struct {
+	ID          string `json:"id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum"`
+	UploadedBy  string `json:"uploaded_by,omitempty"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// UploadAttachment attaches the multipart/form-data field named file to the
+// note. The file is streamed to storage as it is received.
+func (c *AttachmentController) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Give the file time to arrive, and the response time to be sent after
+	c.extendDeadlines(w, true)
+
+	// Find the file in the request body
+	r.Body = http.MaxBytesReader(w, r.Body, c.attachmentUseCase.MaxSize()+maxMultipartOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Request body must be multipart/form-data")
+		return
+	}
+	var part io.Reader
+	var filename, contentType string
+	for part == nil {
+		next, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			problem.WriteValidation(w, r, "File is required", domainerrors.FieldError{Field: "file", Message: "is required"})
+			return
+		}
+		if err != nil {
+			if !writeUploadError(w, r, err) {
+				problem.Write(w, r, http.StatusBadRequest, "Invalid multipart body")
+			}
+			return
+		}
+		if next.FormName() == "file" {
+			part, filename, contentType = next, next.FileName(), next.Header.Get("Content-Type")
+		}
+	}
+
+	// Upload the file
+	attachment, err := c.attachmentUseCase.UploadAttachment(ctx, chi.URLParam(r, "noteID"), user.ID, filename, contentType, part)
+	if err != nil {
+		if !writeUploadError(w, r, err) {
+			problem.WriteError(w, r, err, "Failed to upload attachment")
+		}
+		return
+	}
+
+	// Return the attachment
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(toAttachmentResponse(attachment)); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *AttachmentController) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get the attachments of the note
+	attachments, err := c.attachmentUseCase.ListAttachments(ctx, chi.URLParam(r, "noteID"), user.ID)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to get attachments")
+		return
+	}
+
+	// Convert to response format
+	response := make([]AttachmentResponse, len(attachments))
+	for i, attachment := range attachments {
+		response[i] = toAttachmentResponse(attachment)
+	}
+
+	// Return the attachments
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+// DownloadAttachment streams the content of an attachment. A single byte
+// range can be requested with the Range header, for instance to resume a
+// download.
+func (c *AttachmentController) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get the attachment
+	attachment, err := c.attachmentUseCase.GetAttachment(ctx, chi.URLParam(r, "noteID"), user.ID, chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to get attachment")
+		return
+	}
+
+	// The content never changes, its checksum makes a strong ETag
+	etag := `"` + attachment.Checksum + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", attachment.CreatedAt.UTC().Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", "private")
+	w.Header().Set("Accept-Ranges", "bytes")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// Work out the part to send, a range for another version of the file
+	// gets the whole file
+	offset, length := int64(0), attachment.Size
+	partial := false
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+		if ifRange := r.Header.Get("If-Range"); ifRange == "" || ifRange == etag {
+			offset, length, partial, ok = parseByteRange(rangeHeader, attachment.Size)
+			if !ok {
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", attachment.Size))
+				problem.Write(w, r, http.StatusRequestedRangeNotSatisfiable, "Range is outside of the attachment")
+				return
+			}
+		}
+	}
+
+	// Open the content
+	content, err := c.attachmentUseCase.OpenAttachment(ctx, attachment, offset, length)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to download attachment")
+		return
+	}
+	defer content.Close()
+
+	// Give the content time to be sent
+	c.extendDeadlines(w, false)
+
+	// Never render uploaded files as part of the application
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+
+	// Stream the content, the client is gone when the copy fails
+	if partial {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, attachment.Size))
+		w.WriteHeader(http.StatusPartialContent)
+	}
+	_, _ = io.Copy(w, content)
+}
+
+func (c *AttachmentController) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Delete the attachment
+	if err := c.attachmentUseCase.DeleteAttachment(ctx, chi.URLParam(r, "noteID"), user.ID, chi.URLParam(r, "attachmentID")); err != nil {
+		problem.WriteError(w, r, err, "Failed to delete attachment")
+		return
+	}
+
+	// Return success with no content
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// extendDeadlines replaces the server timeouts, sized for small JSON
+// requests, with the transfer timeout. The read deadline is only extended for
+// uploads.
+func (c *AttachmentController) extendDeadlines(w http.ResponseWriter, read bool) {
+	deadline := time.Now().Add(c.transferTimeout)
+	controller := http.NewResponseController(w)
+	if read {
+		if err := controller.SetReadDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			log.Printf("error extending read deadline: %v", err)
+		}
+	}
+	if err := controller.SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("error extending write deadline: %v", err)
+	}
+}
+
+// writeUploadError reports a body over the size limit as too large, and a
+// body cut short as a bad request. It returns false for other errors, which
+// it leaves to the caller.
+func writeUploadError(w http.ResponseWriter, r *http.Request, err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		problem.Write(w, r, http.StatusRequestEntityTooLarge, "Request body is too large")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		problem.Write(w, r, http.StatusBadRequest, "Request body ended unexpectedly")
+	default:
+		return false
+	}
+	return true
+}
+
+// parseByteRange reads a Range header asking for a single byte range of a
+// file of the given size, returning the offset and length to send. Headers
+// it does not understand, such as multiple ranges, are ignored and the whole
+// file is sent. ok is false when the range is outside of the file.
+func parseByteRange(header string, size int64) (offset, length int64, partial, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, size, false, true
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, size, false, true
+	}
+
+	// A suffix range asks for the last bytes of the file
+	if first == "" {
+		suffix, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || suffix < 0 {
+			return 0, size, false, true
+		}
+		if suffix == 0 {
+			return 0, 0, false, false
+		}
+		suffix = min(suffix, size)
+		return size - suffix, suffix, true, true
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, size, false, true
+	}
+	if start >= size {
+		return 0, 0, false, false
+	}
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, size, false, true
+		}
+		end = min(end, size-1)
+	}
+
+	return start, end - start + 1, true, true
+}
+
+func toAttachmentResponse(attachment *entities.Attachment) AttachmentResponse {
+	return AttachmentResponse{
+		ID:          attachment.ID,
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		Checksum:    attachment.Checksum,
+		UploadedBy:  attachment.UserID,
+		CreatedAt:   attachment.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,292 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+type ChecklistController struct {
+	checklistUseCase *use_cases.ChecklistUseCase
+}
+
+func NewChecklistController(checklistUseCase *use_cases.ChecklistUseCase) *ChecklistController {
+	return &ChecklistController{
+		checklistUseCase: checklistUseCase,
+	}
+}
+
+type CreateChecklistItemRequest struct {
+	Text   string `json:"text"`
+	Indent int    `json:"indent"` // Optional, top level by default
+}
+
+// UpdateChecklistItemRequest changes the fields that are present, the others
+// are left as they are
+type UpdateChecklistItemRequest struct {
+	Text      *string `json:"text"`
+	IsChecked *bool   `json:"is_checked"`
+	Indent    *int    `json:"indent"`
+}
+
+// MoveChecklistItemRequest places an item right before or right after another
+// item of the checklist, exactly one of the two is required
+type MoveChecklistItemRequest struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+type ChecklistItemResponse struct {
+	ID        string `json:"id"`
+	Text      string `json:"text"`
+	IsChecked bool   `json:"is_checked"`
+	Indent    int    `json:"indent"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type ChecklistProgressResponse struct {
+	Total   int `json:"total"`
+	Checked int `json:"checked"`
+}
+
+// ChecklistResponse lists the items of a checklist in order
+type ChecklistResponse struct {
+	Items []ChecklistItemResponse `json:"items"`
+	ChecklistProgressResponse
+}
+
+func (c *ChecklistController) ListItems(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get the items of the checklist
+	items, err := c.checklistUseCase.ListItems(ctx, chi.URLParam(r, "noteID"), user.ID)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to get checklist items")
+		return
+	}
+
+	// Return the items
+	writeChecklist(w, r, items)
+}
+
+func (c *ChecklistController) AddItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse the request body
+	var req CreateChecklistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Add the item
+	item, err := c.checklistUseCase.AddItem(ctx, chi.URLParam(r, "noteID"), user.ID, req.Text, req.Indent)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to add checklist item")
+		return
+	}
+
+	// Return the created item
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(toChecklistItemResponse(item)); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *ChecklistController) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse the request body
+	var req UpdateChecklistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Update the item
+	item, err := c.checklistUseCase.UpdateItem(ctx, chi.URLParam(r, "noteID"), user.ID, chi.URLParam(r, "itemID"), use_cases.ChecklistItemChanges{
+		Text:      req.Text,
+		IsChecked: req.IsChecked,
+		Indent:    req.Indent,
+	})
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to update checklist item")
+		return
+	}
+
+	// Return the updated item
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toChecklistItemResponse(item)); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *ChecklistController) DeleteItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Delete the item
+	if err := c.checklistUseCase.DeleteItem(ctx, chi.URLParam(r, "noteID"), user.ID, chi.URLParam(r, "itemID")); err != nil {
+		problem.WriteError(w, r, err, "Failed to delete checklist item")
+		return
+	}
+
+	// Return success with no content
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *ChecklistController) MoveItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse the request body
+	var req MoveChecklistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate input
+	if (req.Before == "") == (req.After == "") {
+		problem.WriteValidation(w, r, "Either before or after is required",
+			domainerrors.FieldError{Field: "before", Message: "exactly one of before and after is required"},
+			domainerrors.FieldError{Field: "after", Message: "exactly one of before and after is required"},
+		)
+		return
+	}
+	targetID, after := req.Before, false
+	if req.After != "" {
+		targetID, after = req.After, true
+	}
+
+	// Move the item
+	item, err := c.checklistUseCase.MoveItem(ctx, chi.URLParam(r, "noteID"), user.ID, chi.URLParam(r, "itemID"), targetID, after)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to move checklist item")
+		return
+	}
+
+	// Return the moved item
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toChecklistItemResponse(item)); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *ChecklistController) MoveCheckedToBottom(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Move the checked items
+	items, err := c.checklistUseCase.MoveCheckedToBottom(ctx, chi.URLParam(r, "noteID"), user.ID)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to move checked items")
+		return
+	}
+
+	// Return the items in their new order
+	writeChecklist(w, r, items)
+}
+
+func (c *ChecklistController) UncheckAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Uncheck the items
+	items, err := c.checklistUseCase.UncheckAll(ctx, chi.URLParam(r, "noteID"), user.ID)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to uncheck items")
+		return
+	}
+
+	// Return the items
+	writeChecklist(w, r, items)
+}
+
+// writeChecklist returns the items of a checklist with how many are checked
+func writeChecklist(w http.ResponseWriter, r *http.Request, items []*entities.ChecklistItem) {
+	response := ChecklistResponse{
+		Items: make([]ChecklistItemResponse, len(items)),
+	}
+	for i, item := range items {
+		response.Items[i] = toChecklistItemResponse(item)
+		if item.IsChecked {
+			response.Checked++
+		}
+	}
+	response.Total = len(items)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func toChecklistItemResponse(item *entities.ChecklistItem) ChecklistItemResponse {
+	return ChecklistItemResponse{
+		ID:        item.ID,
+		Text:      item.Text,
+		IsChecked: item.IsChecked,
+		Indent:    item.Indent,
+		CreatedAt: item.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: item.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+)
+
+// maxUserAgentLength bounds the stored user agent, the header is client input
+const maxUserAgentLength = 512
+
+// clientIP returns the address of the client. The RealIP middleware already
+// replaced the remote address with the one forwarded by the proxy, if any.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func clientUserAgent(r *http.Request) string {
+	userAgent := []rune(r.UserAgent())
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	return string(userAgent)
+}
+
+// sessionClient describes the device a session is created from
+func sessionClient(r *http.Request) use_cases.SessionClient {
+	return use_cases.SessionClient{
+		UserAgent: clientUserAgent(r),
+		IPAddress: clientIP(r),
+	}
+}
+
+// loginAttempt describes a sign-in attempt for the login throttle
+func loginAttempt(r *http.Request, email string) use_cases.LoginAttempt {
+	return use_cases.LoginAttempt{
+		Email:     email,
+		IPAddress: clientIP(r),
+		UserAgent: clientUserAgent(r),
+	}
+}
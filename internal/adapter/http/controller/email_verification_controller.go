@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+)
+
+type EmailVerificationController struct {
+	emailVerificationUseCase *use_cases.EmailVerificationUseCase
+}
+
+func NewEmailVerificationController(emailVerificationUseCase *use_cases.EmailVerificationUseCase) *EmailVerificationController {
+	return &EmailVerificationController{
+		emailVerificationUseCase: emailVerificationUseCase,
+	}
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+func (c *EmailVerificationController) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Parse the request body
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate input
+	if req.Token == "" {
+		problem.WriteValidation(w, r, "Token is required", domainerrors.FieldError{Field: "token", Message: "is required"})
+		return
+	}
+
+	// Verify the address
+	if err := c.emailVerificationUseCase.VerifyEmail(ctx, req.Token); err != nil {
+		problem.WriteError(w, r, err, "Failed to verify email")
+		return
+	}
+
+	// Return success with no content
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *EmailVerificationController) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Parse the request body
+	var req ResendVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate email format
+	if !isValidEmail(req.Email) {
+		problem.WriteValidation(w, r, "Invalid email format", domainerrors.FieldError{Field: "email", Message: "must be a valid email address"})
+		return
+	}
+
+	// Send a new link if the account exists and is not verified yet
+	if err := c.emailVerificationUseCase.ResendVerification(ctx, req.Email); err != nil {
+		problem.WriteError(w, r, err, "Failed to resend verification email")
+		return
+	}
+
+	// Answer the same way whether the account exists or not
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// setETag exposes the version of a resource so clients can send it back in If-Match
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", `"`+strconv.Itoa(version)+`"`)
+}
+
+// parseIfMatch returns the version required by the If-Match header, or 0 when
+// the header is absent or "*". ok is false when the header does not hold a
+// single strong ETag issued by setETag, which can never match.
+func parseIfMatch(r *http.Request) (version int, ok bool) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, true
+	}
+
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(value[1 : len(value)-1])
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
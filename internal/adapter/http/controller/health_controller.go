@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
+	"github.com/LaulauChau/note-nest/internal/application/services"
+)
+
+type HealthController struct {
+	monitor services.DatabaseMonitor
+}
+
+func NewHealthController(monitor services.DatabaseMonitor) *HealthController {
+	return &HealthController{
+		monitor: monitor,
+	}
+}
+
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+type DetailedHealthResponse struct {
+	Status   string             `json:"status"`
+	Database string             `json:"database"`
+	Pool     services.PoolStats `json:"pool"`
+}
+
+// Health is the public liveness probe. It only tells whether the service can
+// reach its database.
+func (c *HealthController) Health(w http.ResponseWriter, r *http.Request) {
+	response := HealthResponse{Status: "ok"}
+	status := http.StatusOK
+
+	if err := c.ping(r.Context()); err != nil {
+		response.Status = "unavailable"
+		status = http.StatusServiceUnavailable
+	}
+
+	writeHealth(w, r, status, response)
+}
+
+// DetailedHealth adds the connection pool usage to the report. It is only
+// served on the internal port.
+func (c *HealthController) DetailedHealth(w http.ResponseWriter, r *http.Request) {
+	response := DetailedHealthResponse{
+		Status:   "ok",
+		Database: "ok",
+	}
+	status := http.StatusOK
+
+	if err := c.ping(r.Context()); err != nil {
+		response.Status = "unavailable"
+		response.Database = "unreachable"
+		status = http.StatusServiceUnavailable
+	}
+	response.Pool = c.monitor.Stats()
+
+	writeHealth(w, r, status, response)
+}
+
+// ping bounds the check so a stuck database does not hang the probe
+func (c *HealthController) ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	return c.monitor.Ping(ctx)
+}
+
+func writeHealth(w http.ResponseWriter, r *http.Request, status int, response any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
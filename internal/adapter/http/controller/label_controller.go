@@ -2,13 +2,17 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
 	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
 	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
 )
 
 type LabelController struct {
@@ -40,20 +44,20 @@ func (c *LabelController) CreateLabel(w http.ResponseWriter, r *http.Request) {
 	// Get user from context (added by the auth middleware)
 	user, ok := r.Context().Value(UserContextKey).(*entities.User)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Parse the request body
 	var req CreateLabelRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	// Validate input
 	if req.Name == "" {
-		http.Error(w, "Name is required", http.StatusBadRequest)
+		problem.WriteValidation(w, r, "Name is required", domainerrors.FieldError{Field: "name", Message: "is required"})
 		return
 	}
 
@@ -66,11 +70,7 @@ func (c *LabelController) CreateLabel(w http.ResponseWriter, r *http.Request) {
 	// Create the label
 	label, err := c.labelUseCase.CreateLabel(ctx, user.ID, req.Name, req.Color)
 	if err != nil {
-		if err.Error() == "label with this name already exists" {
-			http.Error(w, err.Error(), http.StatusConflict)
-			return
-		}
-		http.Error(w, "Failed to create label", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to create label")
 		return
 	}
 
@@ -84,7 +84,7 @@ func (c *LabelController) CreateLabel(w http.ResponseWriter, r *http.Request) {
 		CreatedAt: label.CreatedAt.Format(time.RFC3339),
 		UpdatedAt: label.UpdatedAt.Format(time.RFC3339),
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
 		return
 	}
 }
@@ -95,29 +95,26 @@ func (c *LabelController) GetLabelByID(w http.ResponseWriter, r *http.Request) {
 	// Get user from context (added by the auth middleware)
 	user, ok := r.Context().Value(UserContextKey).(*entities.User)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Get label ID from URL parameter
 	labelID := chi.URLParam(r, "labelID")
 	if labelID == "" {
-		http.Error(w, "Label ID is required", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Label ID is required")
 		return
 	}
 
 	// Get the label
 	label, err := c.labelUseCase.GetLabelByID(ctx, labelID, user.ID)
 	if err != nil {
-		if err.Error() == "label not found" {
-			http.Error(w, "Label not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Failed to get label", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to get label")
 		return
 	}
 
 	// Return the label
+	setETag(w, label.Version)
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(LabelResponse{
 		ID:        label.ID,
@@ -126,7 +123,7 @@ func (c *LabelController) GetLabelByID(w http.ResponseWriter, r *http.Request) {
 		CreatedAt: label.CreatedAt.Format(time.RFC3339),
 		UpdatedAt: label.UpdatedAt.Format(time.RFC3339),
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
 		return
 	}
 }
@@ -137,14 +134,14 @@ func (c *LabelController) GetLabels(w http.ResponseWriter, r *http.Request) {
 	// Get user from context (added by the auth middleware)
 	user, ok := r.Context().Value(UserContextKey).(*entities.User)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Get labels for the user
 	labels, err := c.labelUseCase.GetLabelsByUser(ctx, user.ID)
 	if err != nil {
-		http.Error(w, "Failed to get labels", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to get labels")
 		return
 	}
 
@@ -163,7 +160,7 @@ func (c *LabelController) GetLabels(w http.ResponseWriter, r *http.Request) {
 	// Return the labels
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
 		return
 	}
 }
@@ -174,25 +171,21 @@ func (c *LabelController) GetNoteLabels(w http.ResponseWriter, r *http.Request)
 	// Get user from context (added by the auth middleware)
 	user, ok := r.Context().Value(UserContextKey).(*entities.User)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Get note ID from URL parameter
 	noteID := chi.URLParam(r, "noteID")
 	if noteID == "" {
-		http.Error(w, "Note ID is required", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Note ID is required")
 		return
 	}
 
 	// Get labels for the note
 	labels, err := c.labelUseCase.GetLabelsForNote(ctx, noteID, user.ID)
 	if err != nil {
-		if err.Error() == "note not found" {
-			http.Error(w, "Note not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Failed to get labels for note", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to get labels for note")
 		return
 	}
 
@@ -211,7 +204,7 @@ func (c *LabelController) GetNoteLabels(w http.ResponseWriter, r *http.Request)
 	// Return the labels
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
 		return
 	}
 }
@@ -222,25 +215,21 @@ func (c *LabelController) GetNotesForLabel(w http.ResponseWriter, r *http.Reques
 	// Get user from context (added by the auth middleware)
 	user, ok := r.Context().Value(UserContextKey).(*entities.User)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Get label ID from URL parameter
 	labelID := chi.URLParam(r, "labelID")
 	if labelID == "" {
-		http.Error(w, "Label ID is required", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Label ID is required")
 		return
 	}
 
 	// Get notes for the label
 	notes, err := c.labelUseCase.GetNotesForLabel(ctx, labelID, user.ID)
 	if err != nil {
-		if err.Error() == "label not found" {
-			http.Error(w, "Label not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Failed to get notes for label", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to get notes for label")
 		return
 	}
 
@@ -252,6 +241,8 @@ func (c *LabelController) GetNotesForLabel(w http.ResponseWriter, r *http.Reques
 			Title:      note.Title,
 			Content:    note.Content,
 			IsArchived: note.IsArchived,
+			IsPinned:   note.IsPinned,
+			Type:       string(note.Type),
 			Label:      note.Label,
 			CreatedAt:  note.CreatedAt.Format(time.RFC3339),
 			UpdatedAt:  note.UpdatedAt.Format(time.RFC3339),
@@ -261,7 +252,7 @@ func (c *LabelController) GetNotesForLabel(w http.ResponseWriter, r *http.Reques
 	// Return the notes
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
 		return
 	}
 }
@@ -278,27 +269,27 @@ func (c *LabelController) UpdateLabel(w http.ResponseWriter, r *http.Request) {
 	// Get user from context (added by the auth middleware)
 	user, ok := r.Context().Value(UserContextKey).(*entities.User)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Get label ID from URL parameter
 	labelID := chi.URLParam(r, "labelID")
 	if labelID == "" {
-		http.Error(w, "Label ID is required", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Label ID is required")
 		return
 	}
 
 	// Parse the request body
 	var req UpdateLabelRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	// Validate input
 	if req.Name == "" {
-		http.Error(w, "Name is required", http.StatusBadRequest)
+		problem.WriteValidation(w, r, "Name is required", domainerrors.FieldError{Field: "name", Message: "is required"})
 		return
 	}
 
@@ -308,22 +299,26 @@ func (c *LabelController) UpdateLabel(w http.ResponseWriter, r *http.Request) {
 		req.Color = "#3498db" // Blue
 	}
 
+	// Only update the version the client last read, when it says which one
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		problem.Write(w, r, http.StatusPreconditionFailed, "Label was modified by another request")
+		return
+	}
+
 	// Update the label
-	label, err := c.labelUseCase.UpdateLabel(ctx, labelID, user.ID, req.Name, req.Color)
+	label, err := c.labelUseCase.UpdateLabel(ctx, labelID, user.ID, req.Name, req.Color, expectedVersion)
 	if err != nil {
-		if err.Error() == "label not found" {
-			http.Error(w, "Label not found", http.StatusNotFound)
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			problem.Write(w, r, http.StatusPreconditionFailed, "Label was modified by another request")
 			return
 		}
-		if err.Error() == "label with this name already exists" {
-			http.Error(w, err.Error(), http.StatusConflict)
-			return
-		}
-		http.Error(w, "Failed to update label", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to update label")
 		return
 	}
 
 	// Return the updated label
+	setETag(w, label.Version)
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(LabelResponse{
 		ID:        label.ID,
@@ -332,7 +327,7 @@ func (c *LabelController) UpdateLabel(w http.ResponseWriter, r *http.Request) {
 		CreatedAt: label.CreatedAt.Format(time.RFC3339),
 		UpdatedAt: label.UpdatedAt.Format(time.RFC3339),
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
 		return
 	}
 }
@@ -343,25 +338,21 @@ func (c *LabelController) DeleteLabel(w http.ResponseWriter, r *http.Request) {
 	// Get user from context (added by the auth middleware)
 	user, ok := r.Context().Value(UserContextKey).(*entities.User)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Get label ID from URL parameter
 	labelID := chi.URLParam(r, "labelID")
 	if labelID == "" {
-		http.Error(w, "Label ID is required", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Label ID is required")
 		return
 	}
 
 	// Delete the label
 	err := c.labelUseCase.DeleteLabel(ctx, labelID, user.ID)
 	if err != nil {
-		if err.Error() == "label not found" {
-			http.Error(w, "Label not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Failed to delete label", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to delete label")
 		return
 	}
 
@@ -375,7 +366,7 @@ func (c *LabelController) AddLabelToNote(w http.ResponseWriter, r *http.Request)
 	// Get user from context (added by the auth middleware)
 	user, ok := r.Context().Value(UserContextKey).(*entities.User)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -384,22 +375,18 @@ func (c *LabelController) AddLabelToNote(w http.ResponseWriter, r *http.Request)
 	labelID := chi.URLParam(r, "labelID")
 
 	if noteID == "" {
-		http.Error(w, "Note ID is required", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Note ID is required")
 		return
 	}
 	if labelID == "" {
-		http.Error(w, "Label ID is required", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Label ID is required")
 		return
 	}
 
 	// Associate the label with the note
 	err := c.labelUseCase.AddLabelToNote(ctx, noteID, labelID, user.ID)
 	if err != nil {
-		if err.Error() == "note not found" || err.Error() == "label not found" {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Failed to add label to note", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to add label to note")
 		return
 	}
 
@@ -413,7 +400,7 @@ func (c *LabelController) RemoveLabelFromNote(w http.ResponseWriter, r *http.Req
 	// Get user from context (added by the auth middleware)
 	user, ok := r.Context().Value(UserContextKey).(*entities.User)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -422,22 +409,18 @@ func (c *LabelController) RemoveLabelFromNote(w http.ResponseWriter, r *http.Req
 	labelID := chi.URLParam(r, "labelID")
 
 	if noteID == "" {
-		http.Error(w, "Note ID is required", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Note ID is required")
 		return
 	}
 	if labelID == "" {
-		http.Error(w, "Label ID is required", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Label ID is required")
 		return
 	}
 
 	// Disassociate the label from the note
 	err := c.labelUseCase.RemoveLabelFromNote(ctx, noteID, labelID, user.ID)
 	if err != nil {
-		if err.Error() == "note not found" || err.Error() == "label not found" {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Failed to remove label from note", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to remove label from note")
 		return
 	}
 
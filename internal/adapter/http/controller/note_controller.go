@@ -2,13 +2,20 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
+	"html"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
 	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
 	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
 )
 
 type NoteController struct {
@@ -35,10 +42,16 @@ type NoteResponse struct {
 	Title      string          `json:"title"`
 	Content    string          `json:"content"`
 	IsArchived bool            `json:"is_archived"`
+	IsPinned   bool            `json:"is_pinned"`
+	Type       string          `json:"type"`
 	Label      string          `json:"label"`  // Keep for backward compatibility
 	Labels     []LabelResponse `json:"labels"` // New field for associated labels
 	CreatedAt  string          `json:"created_at"`
 	UpdatedAt  string          `json:"updated_at"`
+	DeletedAt  *string         `json:"deleted_at,omitempty"` // Only set for notes in the trash
+
+	// Checklist counts the items of checklist notes in listings
+	Checklist *ChecklistProgressResponse `json:"checklist,omitempty"`
 }
 
 func (c *NoteController) CreateNote(w http.ResponseWriter, r *http.Request) {
@@ -47,27 +60,27 @@ func (c *NoteController) CreateNote(w http.ResponseWriter, r *http.Request) {
 	// Get user from context (added by the auth middleware)
 	user, ok := r.Context().Value(UserContextKey).(*entities.User)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Parse the request body
 	var req CreateNoteRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	// Validate input
 	if req.Title == "" {
-		http.Error(w, "Title is required", http.StatusBadRequest)
+		problem.WriteValidation(w, r, "Title is required", domainerrors.FieldError{Field: "title", Message: "is required"})
 		return
 	}
 
 	// Create the note with labels
 	note, err := c.noteUseCase.CreateNoteWithLabels(ctx, user.ID, req.Title, req.Content, req.Label, req.LabelIDs)
 	if err != nil {
-		http.Error(w, "Failed to create note", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to create note")
 		return
 	}
 
@@ -98,12 +111,14 @@ func (c *NoteController) CreateNote(w http.ResponseWriter, r *http.Request) {
 		Title:      note.Title,
 		Content:    note.Content,
 		IsArchived: note.IsArchived,
+		IsPinned:   note.IsPinned,
+		Type:       string(note.Type),
 		Label:      note.Label,
 		Labels:     labelResponses,
 		CreatedAt:  note.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:  note.UpdatedAt.Format(time.RFC3339),
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
 		return
 	}
 }
@@ -114,25 +129,21 @@ func (c *NoteController) GetNoteByID(w http.ResponseWriter, r *http.Request) {
 	// Get user from context (added by the auth middleware)
 	user, ok := r.Context().Value(UserContextKey).(*entities.User)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Get note ID from URL parameter
 	noteID := chi.URLParam(r, "noteID")
 	if noteID == "" {
-		http.Error(w, "Note ID is required", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Note ID is required")
 		return
 	}
 
 	// Get the note with labels
 	note, labels, err := c.noteUseCase.GetNoteWithLabels(ctx, noteID, user.ID)
 	if err != nil {
-		if err.Error() == "note not found" {
-			http.Error(w, "Note not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Failed to get note", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to get note")
 		return
 	}
 
@@ -149,100 +160,281 @@ func (c *NoteController) GetNoteByID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return the note with labels
+	setETag(w, note.Version)
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(NoteResponse{
 		ID:         note.ID,
 		Title:      note.Title,
 		Content:    note.Content,
 		IsArchived: note.IsArchived,
+		IsPinned:   note.IsPinned,
+		Type:       string(note.Type),
 		Label:      note.Label, // Keep for backward compatibility
 		Labels:     labelResponses,
 		CreatedAt:  note.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:  note.UpdatedAt.Format(time.RFC3339),
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
 		return
 	}
 }
 
+type NoteListResponse struct {
+	Notes      []NoteResponse `json:"notes"`
+	NextCursor *string        `json:"next_cursor"`
+}
+
 func (c *NoteController) GetActiveNotes(w http.ResponseWriter, r *http.Request) {
+	// Active notes are listed unless another archived state is requested
+	archived := false
+	isArchived := &archived
+	switch value := r.URL.Query().Get("archived"); value {
+	case "":
+	case "all":
+		isArchived = nil
+	default:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			problem.WriteValidation(w, r, "Invalid archived value", domainerrors.FieldError{Field: "archived", Message: "must be true, false or all"})
+			return
+		}
+		archived = parsed
+	}
+
+	c.listNotes(w, r, isArchived, "Failed to get notes")
+}
+
+func (c *NoteController) GetArchivedNotes(w http.ResponseWriter, r *http.Request) {
+	archived := true
+	c.listNotes(w, r, &archived, "Failed to get archived notes")
+}
+
+// listNotes serves the user's notes using the paging, sorting and filtering
+// options found in the query string. Only requests giving a limit or a cursor
+// get a page wrapped with its next cursor, others get every note as a plain
+// array like before paging existed.
+func (c *NoteController) listNotes(w http.ResponseWriter, r *http.Request, isArchived *bool, failureMessage string) {
 	ctx := r.Context()
 
 	// Get user from context (added by the auth middleware)
 	user, ok := r.Context().Value(UserContextKey).(*entities.User)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Get the notes
-	notes, err := c.noteUseCase.GetActiveNotes(ctx, user.ID)
-	if err != nil {
-		http.Error(w, "Failed to get notes", http.StatusInternalServerError)
+	// Parse the listing options
+	filter, invalidParam := parseNoteFilter(r)
+	if invalidParam != "" {
+		problem.WriteValidation(w, r, "Invalid "+invalidParam+" value", domainerrors.FieldError{Field: invalidParam, Message: "invalid value"})
 		return
 	}
+	filter.IsArchived = isArchived
 
-	// Convert to response format
-	response := make([]NoteResponse, len(notes))
+	var response any
+	query := r.URL.Query()
+	if query.Has("limit") || query.Has("cursor") {
+		// Get a page of notes
+		page, err := c.noteUseCase.ListNotes(ctx, user.ID, filter, query.Get("cursor"))
+		if err != nil {
+			problem.WriteError(w, r, err, failureMessage)
+			return
+		}
+
+		list := NoteListResponse{Notes: noteListItems(page.Notes)}
+		if page.NextCursor != "" {
+			list.NextCursor = &page.NextCursor
+		}
+		response = list
+	} else {
+		// Get all the notes
+		notes, err := c.noteUseCase.ListAllNotes(ctx, user.ID, filter)
+		if err != nil {
+			problem.WriteError(w, r, err, failureMessage)
+			return
+		}
+		response = noteListItems(notes)
+	}
+
+	// Return the notes
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+// noteListItems converts listed notes to their response format
+func noteListItems(notes []*entities.Note) []NoteResponse {
+	items := make([]NoteResponse, len(notes))
 	for i, note := range notes {
-		response[i] = NoteResponse{
+		items[i] = NoteResponse{
 			ID:         note.ID,
 			Title:      note.Title,
 			Content:    note.Content,
 			IsArchived: note.IsArchived,
+			IsPinned:   note.IsPinned,
+			Type:       string(note.Type),
 			Label:      note.Label,
 			CreatedAt:  note.CreatedAt.Format(time.RFC3339),
 			UpdatedAt:  note.UpdatedAt.Format(time.RFC3339),
 		}
+		if note.Checklist != nil {
+			items[i].Checklist = &ChecklistProgressResponse{
+				Total:   note.Checklist.Total,
+				Checked: note.Checklist.Checked,
+			}
+		}
 	}
+	return items
+}
 
-	// Return the notes
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-		return
+// parseNoteFilter reads limit, sort, order, label_ids and the date range
+// parameters from the query string. It returns the name of the first invalid
+// parameter, if any.
+func parseNoteFilter(r *http.Request) (entities.NoteFilter, string) {
+	query := r.URL.Query()
+	filter := entities.NoteFilter{
+		SortBy: entities.NoteSortByPosition,
 	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return filter, "limit"
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("sort"); value != "" {
+		filter.SortBy = entities.NoteSortField(value)
+	}
+
+	// Dates default to newest first, titles to alphabetical order and
+	// positions to the order the user arranged
+	switch query.Get("order") {
+	case "":
+		filter.Descending = filter.SortBy != entities.NoteSortByTitle && filter.SortBy != entities.NoteSortByPosition
+	case "asc":
+		filter.Descending = false
+	case "desc":
+		filter.Descending = true
+	default:
+		return filter, "order"
+	}
+
+	for _, value := range query["label_ids"] {
+		for _, labelID := range strings.Split(value, ",") {
+			if labelID = strings.TrimSpace(labelID); labelID != "" {
+				filter.LabelIDs = append(filter.LabelIDs, labelID)
+			}
+		}
+	}
+
+	dates := []struct {
+		name   string
+		target **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+		{"updated_after", &filter.UpdatedAfter},
+		{"updated_before", &filter.UpdatedBefore},
+	}
+	for _, date := range dates {
+		value := query.Get(date.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, date.name
+		}
+		*date.target = &parsed
+	}
+
+	return filter, ""
 }
 
-func (c *NoteController) GetArchivedNotes(w http.ResponseWriter, r *http.Request) {
+type NoteSearchResponse struct {
+	NoteResponse
+	Rank           float32 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+func (c *NoteController) SearchNotes(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Get user from context (added by the auth middleware)
 	user, ok := r.Context().Value(UserContextKey).(*entities.User)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Get the notes
-	notes, err := c.noteUseCase.GetArchivedNotes(ctx, user.ID)
+	// Get the search query from the URL
+	query := r.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		problem.WriteValidation(w, r, "Search query is required", domainerrors.FieldError{Field: "q", Message: "is required"})
+		return
+	}
+
+	// Archived notes are only searched when explicitly requested
+	includeArchived := false
+	if value := r.URL.Query().Get("include_archived"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			problem.WriteValidation(w, r, "Invalid include_archived value", domainerrors.FieldError{Field: "include_archived", Message: "must be a boolean"})
+			return
+		}
+		includeArchived = parsed
+	}
+
+	// Search the notes
+	results, err := c.noteUseCase.SearchNotes(ctx, user.ID, query, includeArchived)
 	if err != nil {
-		http.Error(w, "Failed to get archived notes", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to search notes")
 		return
 	}
 
 	// Convert to response format
-	response := make([]NoteResponse, len(notes))
-	for i, note := range notes {
-		response[i] = NoteResponse{
-			ID:         note.ID,
-			Title:      note.Title,
-			Content:    note.Content,
-			IsArchived: note.IsArchived,
-			Label:      note.Label,
-			CreatedAt:  note.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:  note.UpdatedAt.Format(time.RFC3339),
+	response := make([]NoteSearchResponse, len(results))
+	for i, result := range results {
+		response[i] = NoteSearchResponse{
+			NoteResponse: NoteResponse{
+				ID:         result.Note.ID,
+				Title:      result.Note.Title,
+				Content:    result.Note.Content,
+				IsArchived: result.Note.IsArchived,
+				IsPinned:   result.Note.IsPinned,
+				Type:       string(result.Note.Type),
+				Label:      result.Note.Label,
+				CreatedAt:  result.Note.CreatedAt.Format(time.RFC3339),
+				UpdatedAt:  result.Note.UpdatedAt.Format(time.RFC3339),
+			},
+			Rank:           result.Rank,
+			TitleHighlight: highlightHTML(result.TitleHighlight),
+			Snippet:        highlightHTML(result.Snippet),
 		}
 	}
 
-	// Return the notes
+	// Return the search results
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
 		return
 	}
 }
 
+// highlightHTML escapes the note text of a search highlight and only then
+// turns the match markers into <mark> tags, so the result is safe to render
+// as HTML whatever the note contains
+func highlightHTML(highlight string) string {
+	escaped := html.EscapeString(highlight)
+	escaped = strings.ReplaceAll(escaped, entities.HighlightStart, "<mark>")
+	return strings.ReplaceAll(escaped, entities.HighlightStop, "</mark>")
+}
+
 type UpdateNoteRequest struct {
 	Title      string   `json:"title"`
 	Content    string   `json:"content"`
@@ -257,38 +449,45 @@ func (c *NoteController) UpdateNote(w http.ResponseWriter, r *http.Request) {
 	// Get user from context (added by the auth middleware)
 	user, ok := r.Context().Value(UserContextKey).(*entities.User)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Get note ID from URL parameter
 	noteID := chi.URLParam(r, "noteID")
 	if noteID == "" {
-		http.Error(w, "Note ID is required", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Note ID is required")
 		return
 	}
 
 	// Parse the request body
 	var req UpdateNoteRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	// Validate input
 	if req.Title == "" {
-		http.Error(w, "Title is required", http.StatusBadRequest)
+		problem.WriteValidation(w, r, "Title is required", domainerrors.FieldError{Field: "title", Message: "is required"})
+		return
+	}
+
+	// Only update the version the client last read, when it says which one
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		problem.Write(w, r, http.StatusPreconditionFailed, "Note was modified by another request")
 		return
 	}
 
 	// Update the note with labels
-	note, err := c.noteUseCase.UpdateNoteWithLabels(ctx, noteID, user.ID, req.Title, req.Content, req.Label, req.IsArchived, req.LabelIDs)
+	note, err := c.noteUseCase.UpdateNoteWithLabels(ctx, noteID, user.ID, req.Title, req.Content, req.Label, req.IsArchived, req.LabelIDs, expectedVersion)
 	if err != nil {
-		if err.Error() == "note not found" {
-			http.Error(w, "Note not found", http.StatusNotFound)
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			problem.Write(w, r, http.StatusPreconditionFailed, "Note was modified by another request")
 			return
 		}
-		http.Error(w, "Failed to update note", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to update note")
 		return
 	}
 
@@ -312,18 +511,21 @@ func (c *NoteController) UpdateNote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return the updated note
+	setETag(w, note.Version)
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(NoteResponse{
 		ID:         note.ID,
 		Title:      note.Title,
 		Content:    note.Content,
 		IsArchived: note.IsArchived,
+		IsPinned:   note.IsPinned,
+		Type:       string(note.Type),
 		Label:      note.Label,
 		Labels:     labelResponses,
 		CreatedAt:  note.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:  note.UpdatedAt.Format(time.RFC3339),
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
 		return
 	}
 }
@@ -334,25 +536,330 @@ func (c *NoteController) DeleteNote(w http.ResponseWriter, r *http.Request) {
 	// Get user from context (added by the auth middleware)
 	user, ok := r.Context().Value(UserContextKey).(*entities.User)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Get note ID from URL parameter
 	noteID := chi.URLParam(r, "noteID")
 	if noteID == "" {
-		http.Error(w, "Note ID is required", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Note ID is required")
 		return
 	}
 
 	// Delete the note
 	err := c.noteUseCase.DeleteNote(ctx, noteID, user.ID)
 	if err != nil {
-		if err.Error() == "note not found" {
-			http.Error(w, "Note not found", http.StatusNotFound)
+		problem.WriteError(w, r, err, "Failed to delete note")
+		return
+	}
+
+	// Return success with no content
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *NoteController) GetTrashedNotes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get the trashed notes
+	notes, err := c.noteUseCase.GetTrashedNotes(ctx, user.ID)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to get trashed notes")
+		return
+	}
+
+	// Convert to response format
+	response := make([]NoteResponse, len(notes))
+	for i, note := range notes {
+		response[i] = NoteResponse{
+			ID:         note.ID,
+			Title:      note.Title,
+			Content:    note.Content,
+			IsArchived: note.IsArchived,
+			IsPinned:   note.IsPinned,
+			Type:       string(note.Type),
+			Label:      note.Label,
+			CreatedAt:  note.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:  note.UpdatedAt.Format(time.RFC3339),
+		}
+		if note.DeletedAt != nil {
+			deletedAt := note.DeletedAt.Format(time.RFC3339)
+			response[i].DeletedAt = &deletedAt
+		}
+	}
+
+	// Return the notes
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *NoteController) RestoreNote(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get note ID from URL parameter
+	noteID := chi.URLParam(r, "noteID")
+	if noteID == "" {
+		problem.Write(w, r, http.StatusBadRequest, "Note ID is required")
+		return
+	}
+
+	// Restore the note
+	note, err := c.noteUseCase.RestoreNote(ctx, noteID, user.ID)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to restore note")
+		return
+	}
+
+	// Fetch labels for the note
+	labels, err := c.labelUseCase.GetLabelsForNote(ctx, note.ID, user.ID)
+	if err != nil {
+		// Continue even if there's an error fetching labels
+		labels = []*entities.Label{}
+	}
+
+	// Convert labels to response format
+	labelResponses := make([]LabelResponse, len(labels))
+	for i, label := range labels {
+		labelResponses[i] = LabelResponse{
+			ID:        label.ID,
+			Name:      label.Name,
+			Color:     label.Color,
+			CreatedAt: label.CreatedAt.Format(time.RFC3339),
+			UpdatedAt: label.UpdatedAt.Format(time.RFC3339),
+		}
+	}
+
+	// Return the restored note
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(NoteResponse{
+		ID:         note.ID,
+		Title:      note.Title,
+		Content:    note.Content,
+		IsArchived: note.IsArchived,
+		IsPinned:   note.IsPinned,
+		Type:       string(note.Type),
+		Label:      note.Label,
+		Labels:     labelResponses,
+		CreatedAt:  note.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  note.UpdatedAt.Format(time.RFC3339),
+	}); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+// MoveNoteRequest places a note right before or right after another note of
+// the same list, exactly one of the two is required
+type MoveNoteRequest struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+func (c *NoteController) PinNote(w http.ResponseWriter, r *http.Request) {
+	c.pinNote(w, r, true)
+}
+
+func (c *NoteController) UnpinNote(w http.ResponseWriter, r *http.Request) {
+	c.pinNote(w, r, false)
+}
+
+func (c *NoteController) pinNote(w http.ResponseWriter, r *http.Request, pinned bool) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get note ID from URL parameter
+	noteID := chi.URLParam(r, "noteID")
+	if noteID == "" {
+		problem.Write(w, r, http.StatusBadRequest, "Note ID is required")
+		return
+	}
+
+	// Pin or unpin the note
+	note, err := c.noteUseCase.PinNote(ctx, noteID, user.ID, pinned)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to pin note")
+		return
+	}
+
+	// Return the note
+	c.writeNote(w, r, note, user.ID)
+}
+
+func (c *NoteController) MoveNote(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get note ID from URL parameter
+	noteID := chi.URLParam(r, "noteID")
+	if noteID == "" {
+		problem.Write(w, r, http.StatusBadRequest, "Note ID is required")
+		return
+	}
+
+	// Parse the request body
+	var req MoveNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate input
+	if (req.Before == "") == (req.After == "") {
+		problem.WriteValidation(w, r, "Either before or after is required",
+			domainerrors.FieldError{Field: "before", Message: "exactly one of before and after is required"},
+			domainerrors.FieldError{Field: "after", Message: "exactly one of before and after is required"},
+		)
+		return
+	}
+	targetID, after := req.Before, false
+	if req.After != "" {
+		targetID, after = req.After, true
+	}
+
+	// Move the note
+	note, err := c.noteUseCase.MoveNote(ctx, noteID, user.ID, targetID, after)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to move note")
+		return
+	}
+
+	// Return the note
+	c.writeNote(w, r, note, user.ID)
+}
+
+// ConvertNoteRequest turns a note into the given type, "text" or "checklist"
+type ConvertNoteRequest struct {
+	Type string `json:"type"`
+}
+
+func (c *NoteController) ConvertNote(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get note ID from URL parameter
+	noteID := chi.URLParam(r, "noteID")
+	if noteID == "" {
+		problem.Write(w, r, http.StatusBadRequest, "Note ID is required")
+		return
+	}
+
+	// Parse the request body
+	var req ConvertNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Only convert the version the client last read, when it says which one
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		problem.Write(w, r, http.StatusPreconditionFailed, "Note was modified by another request")
+		return
+	}
+
+	// Convert the note
+	note, err := c.noteUseCase.ConvertNote(ctx, noteID, user.ID, entities.NoteType(req.Type), expectedVersion)
+	if err != nil {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			problem.Write(w, r, http.StatusPreconditionFailed, "Note was modified by another request")
 			return
 		}
-		http.Error(w, "Failed to delete note", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to convert note")
+		return
+	}
+
+	// Return the converted note
+	c.writeNote(w, r, note, user.ID)
+}
+
+// writeNote returns a note of the user with its labels
+func (c *NoteController) writeNote(w http.ResponseWriter, r *http.Request, note *entities.Note, userID string) {
+	// Fetch labels for the note
+	labels, err := c.labelUseCase.GetLabelsForNote(r.Context(), note.ID, userID)
+	if err != nil {
+		// Continue even if there's an error fetching labels
+		labels = []*entities.Label{}
+	}
+
+	// Convert labels to response format
+	labelResponses := make([]LabelResponse, len(labels))
+	for i, label := range labels {
+		labelResponses[i] = LabelResponse{
+			ID:        label.ID,
+			Name:      label.Name,
+			Color:     label.Color,
+			CreatedAt: label.CreatedAt.Format(time.RFC3339),
+			UpdatedAt: label.UpdatedAt.Format(time.RFC3339),
+		}
+	}
+
+	setETag(w, note.Version)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(NoteResponse{
+		ID:         note.ID,
+		Title:      note.Title,
+		Content:    note.Content,
+		IsArchived: note.IsArchived,
+		IsPinned:   note.IsPinned,
+		Type:       string(note.Type),
+		Label:      note.Label,
+		Labels:     labelResponses,
+		CreatedAt:  note.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  note.UpdatedAt.Format(time.RFC3339),
+	}); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *NoteController) EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Permanently delete the trashed notes
+	if _, err := c.noteUseCase.EmptyTrash(ctx, user.ID); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to empty trash")
 		return
 	}
 
@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+type NoteShareController struct {
+	noteShareUseCase *use_cases.NoteShareUseCase
+}
+
+func NewNoteShareController(noteShareUseCase *use_cases.NoteShareUseCase) *NoteShareController {
+	return &NoteShareController{
+		noteShareUseCase: noteShareUseCase,
+	}
+}
+
+type ShareNoteRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"` // editor or viewer
+}
+
+type NoteShareResponse struct {
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// SharedNoteResponse is a note another user shared, the labels of the
+// owner are never included
+type SharedNoteResponse struct {
+	NoteResponse
+	OwnerID string `json:"owner_id"`
+	Role    string `json:"role"`
+}
+
+func (c *NoteShareController) ShareNote(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse the request body
+	var req ShareNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate input
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email == "" {
+		problem.WriteValidation(w, r, "Email is required", domainerrors.FieldError{Field: "email", Message: "is required"})
+		return
+	}
+
+	// Share the note
+	share, err := c.noteShareUseCase.ShareNote(ctx, chi.URLParam(r, "noteID"), user.ID, req.Email, entities.NoteRole(req.Role))
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to share note")
+		return
+	}
+
+	// Return the share
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toNoteShareResponse(share)); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *NoteShareController) ListShares(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get the shares of the note
+	shares, err := c.noteShareUseCase.ListShares(ctx, chi.URLParam(r, "noteID"), user.ID)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to get note shares")
+		return
+	}
+
+	// Convert to response format
+	response := make([]NoteShareResponse, len(shares))
+	for i, share := range shares {
+		response[i] = toNoteShareResponse(share)
+	}
+
+	// Return the shares
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+// RevokeShare removes the access of a user to a note. Users the note is
+// shared with may revoke their own access to leave it.
+func (c *NoteShareController) RevokeShare(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Revoke the share
+	if err := c.noteShareUseCase.RevokeShare(ctx, chi.URLParam(r, "noteID"), user.ID, chi.URLParam(r, "userID")); err != nil {
+		problem.WriteError(w, r, err, "Failed to revoke note share")
+		return
+	}
+
+	// Return success with no content
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSharedNotes lists the notes other users shared with the user
+func (c *NoteShareController) GetSharedNotes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get the shared notes
+	sharedNotes, err := c.noteShareUseCase.GetSharedNotes(ctx, user.ID)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to get shared notes")
+		return
+	}
+
+	// Convert to response format
+	response := make([]SharedNoteResponse, len(sharedNotes))
+	for i, shared := range sharedNotes {
+		response[i] = SharedNoteResponse{
+			NoteResponse: NoteResponse{
+				ID:         shared.Note.ID,
+				Title:      shared.Note.Title,
+				Content:    shared.Note.Content,
+				IsArchived: shared.Note.IsArchived,
+				Label:      shared.Note.Label,
+				Labels:     []LabelResponse{},
+				CreatedAt:  shared.Note.CreatedAt.Format(time.RFC3339),
+				UpdatedAt:  shared.Note.UpdatedAt.Format(time.RFC3339),
+			},
+			OwnerID: shared.Note.UserID,
+			Role:    string(shared.Role),
+		}
+	}
+
+	// Return the notes
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func toNoteShareResponse(share *entities.NoteShare) NoteShareResponse {
+	return NoteShareResponse{
+		UserID:    share.UserID,
+		Email:     share.Email,
+		Name:      share.Name,
+		Role:      string(share.Role),
+		CreatedAt: share.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: share.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+)
+
+type PasswordController struct {
+	passwordResetUseCase *use_cases.PasswordResetUseCase
+}
+
+func NewPasswordController(passwordResetUseCase *use_cases.PasswordResetUseCase) *PasswordController {
+	return &PasswordController{
+		passwordResetUseCase: passwordResetUseCase,
+	}
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (c *PasswordController) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Parse the request body
+	var req ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate email format
+	if !isValidEmail(req.Email) {
+		problem.WriteValidation(w, r, "Invalid email format", domainerrors.FieldError{Field: "email", Message: "must be a valid email address"})
+		return
+	}
+
+	// Send the reset link if the account exists
+	if err := c.passwordResetUseCase.RequestReset(ctx, req.Email, clientIP(r)); err != nil {
+		problem.WriteError(w, r, err, "Failed to request password reset")
+		return
+	}
+
+	// Answer the same way whether the account exists or not
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (c *PasswordController) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Parse the request body
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate input
+	if req.Token == "" {
+		problem.WriteValidation(w, r, "Token is required", domainerrors.FieldError{Field: "token", Message: "is required"})
+		return
+	}
+	if violation := passwordPolicyViolation(req.Password); violation != "" {
+		problem.WriteValidation(w, r, "Password "+violation, domainerrors.FieldError{Field: "password", Message: violation})
+		return
+	}
+
+	// Set the new password
+	if err := c.passwordResetUseCase.ResetPassword(ctx, req.Token, req.Password); err != nil {
+		problem.WriteError(w, r, err, "Failed to reset password")
+		return
+	}
+
+	// Return success with no content
+	w.WriteHeader(http.StatusNoContent)
+}
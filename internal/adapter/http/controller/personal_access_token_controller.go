@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+// maxTokenNameLength matches the column size
+const maxTokenNameLength = 100
+
+type PersonalAccessTokenController struct {
+	personalAccessTokenUseCase *use_cases.PersonalAccessTokenUseCase
+}
+
+func NewPersonalAccessTokenController(personalAccessTokenUseCase *use_cases.PersonalAccessTokenUseCase) *PersonalAccessTokenController {
+	return &PersonalAccessTokenController{
+		personalAccessTokenUseCase: personalAccessTokenUseCase,
+	}
+}
+
+type CreatePersonalAccessTokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"` // Optional, the token never expires without it
+}
+
+type PersonalAccessTokenResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  *string  `json:"expires_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// CreatePersonalAccessTokenResponse is the only response holding the token
+// itself
+type CreatePersonalAccessTokenResponse struct {
+	PersonalAccessTokenResponse
+	Token string `json:"token"`
+}
+
+func (c *PersonalAccessTokenController) CreateToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse the request body
+	var req CreatePersonalAccessTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate input
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		problem.WriteValidation(w, r, "Name is required", domainerrors.FieldError{Field: "name", Message: "is required"})
+		return
+	}
+	if utf8.RuneCountInString(req.Name) > maxTokenNameLength {
+		problem.WriteValidation(w, r, "Name is too long", domainerrors.FieldError{Field: "name", Message: "must be at most 100 characters"})
+		return
+	}
+	scopes := make([]entities.TokenScope, len(req.Scopes))
+	for i, scope := range req.Scopes {
+		scopes[i] = entities.TokenScope(scope)
+	}
+
+	// Create the token
+	token, secret, err := c.personalAccessTokenUseCase.CreateToken(ctx, user.ID, req.Name, scopes, req.ExpiresAt)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to create access token")
+		return
+	}
+
+	// Return the token, this is the only time it is shown
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(CreatePersonalAccessTokenResponse{
+		PersonalAccessTokenResponse: toPersonalAccessTokenResponse(token),
+		Token:                       secret,
+	}); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *PersonalAccessTokenController) ListTokens(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get the tokens of the user
+	tokens, err := c.personalAccessTokenUseCase.ListTokens(ctx, user.ID)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to get access tokens")
+		return
+	}
+
+	// Convert to response format
+	response := make([]PersonalAccessTokenResponse, len(tokens))
+	for i, token := range tokens {
+		response[i] = toPersonalAccessTokenResponse(token)
+	}
+
+	// Return the tokens
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *PersonalAccessTokenController) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Revoke the token
+	tokenID := chi.URLParam(r, "tokenID")
+	if err := c.personalAccessTokenUseCase.RevokeToken(ctx, user.ID, tokenID); err != nil {
+		problem.WriteError(w, r, err, "Failed to revoke access token")
+		return
+	}
+
+	// Return success with no content
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toPersonalAccessTokenResponse(token *entities.PersonalAccessToken) PersonalAccessTokenResponse {
+	scopes := make([]string, len(token.Scopes))
+	for i, scope := range token.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	response := PersonalAccessTokenResponse{
+		ID:        token.ID,
+		Name:      token.Name,
+		Scopes:    scopes,
+		CreatedAt: token.CreatedAt.Format(time.RFC3339),
+	}
+	if token.ExpiresAt != nil {
+		expiresAt := token.ExpiresAt.Format(time.RFC3339)
+		response.ExpiresAt = &expiresAt
+	}
+	if token.LastUsedAt != nil {
+		lastUsedAt := token.LastUsedAt.Format(time.RFC3339)
+		response.LastUsedAt = &lastUsedAt
+	}
+
+	return response
+}
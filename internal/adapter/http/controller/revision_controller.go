@@ -0,0 +1,298 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type RevisionController struct {
+	revisionUseCase *use_cases.NoteRevisionUseCase
+}
+
+func NewRevisionController(revisionUseCase *use_cases.NoteRevisionUseCase) *RevisionController {
+	return &RevisionController{
+		revisionUseCase: revisionUseCase,
+	}
+}
+
+type RevisionResponse struct {
+	Revision  int    `json:"revision"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+}
+
+type RevisionRetentionRequest struct {
+	MaxRevisions int `json:"max_revisions"`
+	MaxAgeDays   int `json:"max_age_days"`
+}
+
+type RevisionRetentionResponse struct {
+	MaxRevisions int `json:"max_revisions"`
+	MaxAgeDays   int `json:"max_age_days"`
+}
+
+func (c *RevisionController) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get note ID from URL parameter
+	noteID := chi.URLParam(r, "noteID")
+	if noteID == "" {
+		problem.Write(w, r, http.StatusBadRequest, "Note ID is required")
+		return
+	}
+
+	// Get the revisions
+	revisions, err := c.revisionUseCase.GetRevisions(ctx, noteID, user.ID)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to get revisions")
+		return
+	}
+
+	// Convert to response format
+	response := make([]RevisionResponse, len(revisions))
+	for i, revision := range revisions {
+		response[i] = toRevisionResponse(revision)
+	}
+
+	// Return the revisions
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *RevisionController) GetRevision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get note ID and revision number from URL parameters
+	noteID := chi.URLParam(r, "noteID")
+	if noteID == "" {
+		problem.Write(w, r, http.StatusBadRequest, "Note ID is required")
+		return
+	}
+	revisionNumber, err := strconv.Atoi(chi.URLParam(r, "revision"))
+	if err != nil || revisionNumber < 1 {
+		problem.WriteValidation(w, r, "Invalid revision", domainerrors.FieldError{Field: "revision", Message: "must be a positive integer"})
+		return
+	}
+
+	// Get the revision
+	revision, err := c.revisionUseCase.GetRevision(ctx, noteID, user.ID, revisionNumber)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to get revision")
+		return
+	}
+
+	// Return the revision
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toRevisionResponse(revision)); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *RevisionController) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get note ID from URL parameter
+	noteID := chi.URLParam(r, "noteID")
+	if noteID == "" {
+		problem.Write(w, r, http.StatusBadRequest, "Note ID is required")
+		return
+	}
+
+	// Parse the revisions to compare, the current version being the default target
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil || from < 1 {
+		problem.WriteValidation(w, r, "Invalid from revision", domainerrors.FieldError{Field: "from", Message: "must be a positive integer"})
+		return
+	}
+	to := use_cases.CurrentRevision
+	if value := r.URL.Query().Get("to"); value != "" && value != "current" {
+		to, err = strconv.Atoi(value)
+		if err != nil || to < 1 {
+			problem.WriteValidation(w, r, "Invalid to revision", domainerrors.FieldError{Field: "to", Message: "must be a positive integer or current"})
+			return
+		}
+	}
+
+	// Compute the diff
+	diff, err := c.revisionUseCase.DiffRevisions(ctx, noteID, user.ID, from, to)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to diff revisions")
+		return
+	}
+
+	// Return the diff
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(diff); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *RevisionController) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get note ID and revision number from URL parameters
+	noteID := chi.URLParam(r, "noteID")
+	if noteID == "" {
+		problem.Write(w, r, http.StatusBadRequest, "Note ID is required")
+		return
+	}
+	revisionNumber, err := strconv.Atoi(chi.URLParam(r, "revision"))
+	if err != nil || revisionNumber < 1 {
+		problem.WriteValidation(w, r, "Invalid revision", domainerrors.FieldError{Field: "revision", Message: "must be a positive integer"})
+		return
+	}
+
+	// Only restore over the version the client last read, when it says which one
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		problem.Write(w, r, http.StatusPreconditionFailed, "Note was modified by another request")
+		return
+	}
+
+	// Restore the revision
+	note, err := c.revisionUseCase.RestoreRevision(ctx, noteID, user.ID, revisionNumber, expectedVersion)
+	if err != nil {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			problem.Write(w, r, http.StatusPreconditionFailed, "Note was modified by another request")
+			return
+		}
+		problem.WriteError(w, r, err, "Failed to restore revision")
+		return
+	}
+
+	// Return the restored note
+	setETag(w, note.Version)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(NoteResponse{
+		ID:         note.ID,
+		Title:      note.Title,
+		Content:    note.Content,
+		IsArchived: note.IsArchived,
+		IsPinned:   note.IsPinned,
+		Type:       string(note.Type),
+		Label:      note.Label,
+		Labels:     []LabelResponse{},
+		CreatedAt:  note.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  note.UpdatedAt.Format(time.RFC3339),
+	}); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *RevisionController) GetRetention(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get the retention policy
+	retention, err := c.revisionUseCase.GetRetention(ctx, user.ID)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to get retention policy")
+		return
+	}
+
+	// Return the retention policy
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(RevisionRetentionResponse{
+		MaxRevisions: retention.MaxRevisions,
+		MaxAgeDays:   retention.MaxAgeDays,
+	}); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *RevisionController) UpdateRetention(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse the request body
+	var req RevisionRetentionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Save the retention policy
+	retention, err := c.revisionUseCase.UpdateRetention(ctx, user.ID, req.MaxRevisions, req.MaxAgeDays)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to update retention policy")
+		return
+	}
+
+	// Return the retention policy
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(RevisionRetentionResponse{
+		MaxRevisions: retention.MaxRevisions,
+		MaxAgeDays:   retention.MaxAgeDays,
+	}); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func toRevisionResponse(revision *entities.NoteRevision) RevisionResponse {
+	return RevisionResponse{
+		Revision:  revision.Revision,
+		Title:     revision.Title,
+		Content:   revision.Content,
+		CreatedAt: revision.CreatedAt.Format(time.RFC3339),
+	}
+}
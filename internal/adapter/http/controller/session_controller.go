@@ -3,9 +3,16 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
 	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
 )
 
 // ContextKey is used to identify values in the context
@@ -14,15 +21,26 @@ type ContextKey string
 const (
 	// UserContextKey is the key used to store the user in the context
 	UserContextKey ContextKey = "user"
+	// SessionContextKey is the key used to store the current session in the context
+	SessionContextKey ContextKey = "session"
+	// AccessTokenContextKey is the key used to store the personal access token
+	// in the context, when the request is authenticated by one
+	AccessTokenContextKey ContextKey = "access_token"
 )
 
+// CSRFHeader is the header cookie-authenticated requests carry the CSRF
+// token in
+const CSRFHeader = "X-CSRF-Token"
+
 type SessionController struct {
-	sessionUseCase *use_cases.SessionUseCase
+	sessionUseCase             *use_cases.SessionUseCase
+	personalAccessTokenUseCase *use_cases.PersonalAccessTokenUseCase
 }
 
-func NewSessionController(sessionUseCase *use_cases.SessionUseCase) *SessionController {
+func NewSessionController(sessionUseCase *use_cases.SessionUseCase, personalAccessTokenUseCase *use_cases.PersonalAccessTokenUseCase) *SessionController {
 	return &SessionController{
-		sessionUseCase: sessionUseCase,
+		sessionUseCase:             sessionUseCase,
+		personalAccessTokenUseCase: personalAccessTokenUseCase,
 	}
 }
 
@@ -32,20 +50,37 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	UserID string `json:"user_id"`
+	UserID            string `json:"user_id"`
+	TwoFactorRequired bool   `json:"two_factor_required"`
+}
+
+type CSRFTokenResponse struct {
+	CSRFToken string `json:"csrf_token"`
+}
+
+// SessionResponse describes a device the user is signed in on. The ID is the
+// public identifier of the session, never the hash of its token.
+type SessionResponse struct {
+	ID         string `json:"id"`
+	UserAgent  string `json:"user_agent"`
+	IPAddress  string `json:"ip_address"`
+	CreatedAt  string `json:"created_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Current    bool   `json:"current"`
 }
 
 func (c *SessionController) Login(w http.ResponseWriter, r *http.Request) {
 	// Parse the request body
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	// We need to inject a UserUseCase dependency to authenticate the user
 	// For now, let's implement this in a separate controller
-	http.Error(w, "Not implemented", http.StatusInternalServerError)
+	problem.Write(w, r, http.StatusInternalServerError, "Not implemented")
 }
 
 func (c *SessionController) Logout(w http.ResponseWriter, r *http.Request) {
@@ -54,7 +89,7 @@ func (c *SessionController) Logout(w http.ResponseWriter, r *http.Request) {
 	// Get the session token from the cookie
 	cookie, err := r.Cookie("session")
 	if err != nil {
-		http.Error(w, "No session found", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "No session found")
 		return
 	}
 
@@ -62,31 +97,156 @@ func (c *SessionController) Logout(w http.ResponseWriter, r *http.Request) {
 	token := cookie.Value
 	result, err := c.sessionUseCase.ValidateSessionToken(ctx, token)
 	if err != nil {
-		http.Error(w, "Failed to validate session", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to validate session")
 		return
 	}
 
 	// If session is valid, invalidate it
 	if result.Session != nil {
 		if err := c.sessionUseCase.InvalidateSession(ctx, result.Session.ID); err != nil {
-			http.Error(w, "Failed to invalidate session", http.StatusInternalServerError)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to invalidate session")
 			return
 		}
 	}
 
 	// Clear the session cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   true,
-		MaxAge:   -1,
-	})
+	clearSessionCookie(w)
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (c *SessionController) ListSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user and session from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	current, ok := r.Context().Value(SessionContextKey).(*entities.Session)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get the sessions of the user
+	sessions, err := c.sessionUseCase.ListSessions(ctx, user.ID)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to get sessions")
+		return
+	}
+
+	// Convert to response format
+	response := make([]SessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = SessionResponse{
+			ID:         session.PublicID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt.Format(time.RFC3339),
+			LastSeenAt: session.LastSeenAt.Format(time.RFC3339),
+			ExpiresAt:  session.ExpiresAt.Format(time.RFC3339),
+			Current:    session.PublicID == current.PublicID,
+		}
+	}
+
+	// Return the sessions
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+// RevokeSession signs the user out of a single device
+func (c *SessionController) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user and session from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	current, ok := r.Context().Value(SessionContextKey).(*entities.Session)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Revoke the session
+	sessionID := chi.URLParam(r, "sessionID")
+	if err := c.sessionUseCase.RevokeSession(ctx, user.ID, sessionID); err != nil {
+		problem.WriteError(w, r, err, "Failed to revoke session")
+		return
+	}
+
+	// Revoking the current session is a logout
+	if sessionID == current.PublicID {
+		clearSessionCookie(w)
+	}
+
+	// Return success with no content
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeOtherSessions signs the user out of every device but the current one
+func (c *SessionController) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user and session from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	current, ok := r.Context().Value(SessionContextKey).(*entities.Session)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Revoke the other sessions
+	if err := c.sessionUseCase.InvalidateOtherSessions(ctx, user.ID, current.ID); err != nil {
+		problem.WriteError(w, r, err, "Failed to revoke sessions")
+		return
+	}
+
+	// Return success with no content
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetCSRFToken returns the token the client must send in the X-CSRF-Token
+// header of its state-changing requests
+func (c *SessionController) GetCSRFToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get the session token from the cookie
+	cookie, err := r.Cookie("session")
+	if err != nil {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Derive the CSRF token of the session
+	csrfToken, err := c.sessionUseCase.CSRFToken(ctx, cookie.Value)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to get CSRF token")
+		return
+	}
+
+	// Return the token, it must not be cached
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(CSRFTokenResponse{
+		CSRFToken: csrfToken,
+	}); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
 func (c *SessionController) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -94,7 +254,7 @@ func (c *SessionController) AuthMiddleware(next http.Handler) http.Handler {
 		// Get the session token from the cookie
 		cookie, err := r.Cookie("session")
 		if err != nil {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 
@@ -102,20 +262,133 @@ func (c *SessionController) AuthMiddleware(next http.Handler) http.Handler {
 		token := cookie.Value
 		result, err := c.sessionUseCase.ValidateSessionToken(ctx, token)
 		if err != nil {
-			http.Error(w, "Failed to validate session", http.StatusInternalServerError)
+			problem.WriteError(w, r, err, "Failed to validate session")
 			return
 		}
 
-		// If session is invalid, return unauthorized
-		if result.Session == nil || result.User == nil {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		// If session is invalid or still waits for a second factor, return unauthorized
+		if result.Session == nil || result.User == nil || result.Session.TwoFactorPending {
+			problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 
-		// Add the user to the context
+		// Add the user and the session to the context
 		ctx = context.WithValue(ctx, UserContextKey, result.User)
+		ctx = context.WithValue(ctx, SessionContextKey, result.Session)
 
 		// Call the next handler
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// ScopedAuthMiddleware also accepts personal access tokens in the
+// Authorization header, as long as they were granted the scope. Requests
+// without the header fall back to the session cookie, which grants every
+// scope.
+func (c *SessionController) ScopedAuthMiddleware(scope entities.TokenScope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		sessionAuth := c.AuthMiddleware(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			// Get the token from the header
+			secret, ok := bearerToken(r)
+			if !ok {
+				sessionAuth.ServeHTTP(w, r)
+				return
+			}
+
+			// Validate the token
+			user, token, err := c.personalAccessTokenUseCase.Authenticate(ctx, secret)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				problem.WriteError(w, r, err, "Failed to validate access token")
+				return
+			}
+
+			// Check the scope of the token
+			if !token.HasScope(scope) {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, scope))
+				problem.Write(w, r, http.StatusForbidden, fmt.Sprintf("Access token lacks the %s scope", scope))
+				return
+			}
+
+			// Add the user and the token to the context
+			ctx = context.WithValue(ctx, UserContextKey, user)
+			ctx = context.WithValue(ctx, AccessTokenContextKey, token)
+
+			// Call the next handler
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// CSRFMiddleware rejects state-changing requests authenticated by the
+// session cookie unless they carry the CSRF token of the session. Browsers
+// attach the cookie to cross-site requests but never let another site read
+// the token. Requests authenticated by a personal access token are exempt,
+// browsers never send those on their own. It must run after the auth
+// middleware.
+func (c *SessionController) CSRFMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// Safe methods do not change anything
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Only the session cookie is sent by browsers on their own
+		if _, ok := ctx.Value(SessionContextKey).(*entities.Session); !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cookie, err := r.Cookie("session")
+		if err != nil {
+			problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		// Check the token
+		if err := c.sessionUseCase.ValidateCSRFToken(ctx, cookie.Value, r.Header.Get(CSRFHeader)); err != nil {
+			problem.WriteError(w, r, err, "Failed to validate CSRF token")
+			return
+		}
+
+		// Call the next handler
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clearSessionCookie tells the browser to drop the session cookie. It keeps
+// the attributes the cookie was set with.
+func clearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session",
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   -1,
+	})
+}
+
+// bearerToken returns the token of an Authorization header using the Bearer
+// scheme
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", false
+	}
+
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	return strings.TrimSpace(token), true
+}
@@ -0,0 +1,298 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+const (
+	// ShareLinkPasswordHeader carries the password of a protected link for
+	// API clients, the HTML page posts it as a form field instead so it
+	// never ends up in a URL
+	ShareLinkPasswordHeader = "X-Share-Password"
+	// minShareLinkPasswordLength keeps link passwords from being trivially
+	// guessed
+	minShareLinkPasswordLength = 8
+)
+
+// shareLinkPage renders a shared note, the password prompt of a protected
+// link, or why the link cannot be opened
+var shareLinkPage = template.Must(template.New("share_link").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>{{if .Note}}{{.Note.Title}}{{else}}Note Nest{{end}}</title>
+</head>
+<body>
+{{if .Note}}<article>
+<h1>{{.Note.Title}}</h1>
+<pre>{{.Note.Content}}</pre>
+<p><small>Last updated {{.Note.UpdatedAt}}</small></p>
+</article>
+{{else if .PasswordRequired}}<form method="post">
+<p>{{.Message}}</p>
+<label for="password">Password</label>
+<input id="password" name="password" type="password" required autofocus>
+<button type="submit">Open note</button>
+</form>
+{{else}}<p>{{.Message}}</p>
+{{end}}</body>
+</html>
+`))
+
+type shareLinkPageData struct {
+	Note             *PublicNoteResponse
+	PasswordRequired bool
+	Message          string
+}
+
+type ShareLinkController struct {
+	shareLinkUseCase *use_cases.ShareLinkUseCase
+}
+
+func NewShareLinkController(shareLinkUseCase *use_cases.ShareLinkUseCase) *ShareLinkController {
+	return &ShareLinkController{
+		shareLinkUseCase: shareLinkUseCase,
+	}
+}
+
+type CreateShareLinkRequest struct {
+	Password  string     `json:"password"`   // Optional, the link is open to anybody without it
+	ExpiresAt *time.Time `json:"expires_at"` // Optional, the link never expires without it
+}
+
+type ShareLinkResponse struct {
+	ID          string  `json:"id"`
+	HasPassword bool    `json:"has_password"`
+	ExpiresAt   *string `json:"expires_at"`
+	ViewCount   int64   `json:"view_count"`
+	CreatedAt   string  `json:"created_at"`
+}
+
+// CreateShareLinkResponse is the only response holding the URL of the link
+type CreateShareLinkResponse struct {
+	ShareLinkResponse
+	URL string `json:"url"`
+}
+
+// PublicNoteResponse is what anybody holding a link gets to see of a note
+type PublicNoteResponse struct {
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	UpdatedAt string `json:"updated_at"`
+	ViewCount int64  `json:"view_count"`
+}
+
+func (c *ShareLinkController) CreateLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse the request body
+	var req CreateShareLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate input
+	if req.Password != "" && len(req.Password) < minShareLinkPasswordLength {
+		problem.WriteValidation(w, r, "Password is too short", domainerrors.FieldError{Field: "password", Message: "must be at least 8 characters long"})
+		return
+	}
+
+	// Create the link
+	link, url, err := c.shareLinkUseCase.CreateLink(ctx, chi.URLParam(r, "noteID"), user.ID, req.Password, req.ExpiresAt)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to create share link")
+		return
+	}
+
+	// Return the link, this is the only time its URL is shown
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(CreateShareLinkResponse{
+		ShareLinkResponse: toShareLinkResponse(link),
+		URL:               url,
+	}); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *ShareLinkController) ListLinks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get the links of the note
+	links, err := c.shareLinkUseCase.ListLinks(ctx, chi.URLParam(r, "noteID"), user.ID)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to get share links")
+		return
+	}
+
+	// Convert to response format
+	response := make([]ShareLinkResponse, len(links))
+	for i, link := range links {
+		response[i] = toShareLinkResponse(link)
+	}
+
+	// Return the links
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *ShareLinkController) RevokeLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Revoke the link
+	if err := c.shareLinkUseCase.RevokeLink(ctx, chi.URLParam(r, "noteID"), user.ID, chi.URLParam(r, "linkID")); err != nil {
+		problem.WriteError(w, r, err, "Failed to revoke share link")
+		return
+	}
+
+	// Return success with no content
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ViewLink shows the note behind a public link to anybody, signed in or
+// not. Browsers get an HTML page, clients asking for JSON with the Accept
+// header or format=json get the note as JSON.
+func (c *ShareLinkController) ViewLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// The page must not be cached, indexed or leak its URL to other sites
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Robots-Tag", "noindex")
+
+	asJSON := prefersJSON(r)
+
+	// Get the password from the header, or from the form of the HTML page
+	password := r.Header.Get(ShareLinkPasswordHeader)
+	if password == "" && r.Method == http.MethodPost {
+		password = r.PostFormValue("password")
+	}
+
+	// Open the link
+	note, link, err := c.shareLinkUseCase.OpenLink(ctx, chi.URLParam(r, "token"), password, loginAttempt(r, ""))
+	if err != nil {
+		if asJSON {
+			problem.WriteError(w, r, err, "Failed to open share link")
+			return
+		}
+		writeShareLinkError(w, err, password != "")
+		return
+	}
+
+	response := PublicNoteResponse{
+		Title:     note.Title,
+		Content:   note.Content,
+		UpdatedAt: note.UpdatedAt.Format(time.RFC3339),
+		ViewCount: link.ViewCount,
+	}
+
+	// Return the note
+	if asJSON {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+			return
+		}
+		return
+	}
+	writeShareLinkPage(w, http.StatusOK, shareLinkPageData{Note: &response})
+}
+
+// writeShareLinkError explains in HTML why a link could not be opened, asking
+// for the password again when it was missing or wrong
+func writeShareLinkError(w http.ResponseWriter, err error, passwordSent bool) {
+	status := problem.Status(err)
+	switch {
+	case errors.Is(err, domainerrors.ErrRateLimited):
+		var rateLimited *domainerrors.RateLimitedError
+		if errors.As(err, &rateLimited) && rateLimited.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateLimited.RetryAfter.Seconds()))))
+		}
+		writeShareLinkPage(w, status, shareLinkPageData{PasswordRequired: true, Message: "Too many incorrect passwords, please try again later."})
+	case errors.Is(err, domainerrors.ErrUnauthorized):
+		message := "This note is password protected."
+		if passwordSent {
+			message = "The password is incorrect, please try again."
+		}
+		writeShareLinkPage(w, status, shareLinkPageData{PasswordRequired: true, Message: message})
+	case status == http.StatusInternalServerError:
+		log.Printf("Failed to open share link: %v", err)
+		writeShareLinkPage(w, status, shareLinkPageData{Message: "Something went wrong, please try again later."})
+	default:
+		writeShareLinkPage(w, status, shareLinkPageData{Message: "This link does not exist or is no longer available."})
+	}
+}
+
+func writeShareLinkPage(w http.ResponseWriter, status int, data shareLinkPageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := shareLinkPage.Execute(w, data); err != nil {
+		log.Printf("Failed to render share link page: %v", err)
+	}
+}
+
+// prefersJSON reports whether the client asked for JSON rather than HTML
+func prefersJSON(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "json" {
+		return true
+	}
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html")
+}
+
+func toShareLinkResponse(link *entities.ShareLink) ShareLinkResponse {
+	response := ShareLinkResponse{
+		ID:          link.ID,
+		HasPassword: link.HasPassword(),
+		ViewCount:   link.ViewCount,
+		CreatedAt:   link.CreatedAt.Format(time.RFC3339),
+	}
+	if link.ExpiresAt != nil {
+		expiresAt := link.ExpiresAt.Format(time.RFC3339)
+		response.ExpiresAt = &expiresAt
+	}
+
+	return response
+}
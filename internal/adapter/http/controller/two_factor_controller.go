@@ -0,0 +1,300 @@
+package controller
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+type TwoFactorController struct {
+	twoFactorUseCase     *use_cases.TwoFactorUseCase
+	sessionUseCase       *use_cases.SessionUseCase
+	loginThrottleUseCase *use_cases.LoginThrottleUseCase
+}
+
+func NewTwoFactorController(
+	twoFactorUseCase *use_cases.TwoFactorUseCase,
+	sessionUseCase *use_cases.SessionUseCase,
+	loginThrottleUseCase *use_cases.LoginThrottleUseCase,
+) *TwoFactorController {
+	return &TwoFactorController{
+		twoFactorUseCase:     twoFactorUseCase,
+		sessionUseCase:       sessionUseCase,
+		loginThrottleUseCase: loginThrottleUseCase,
+	}
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+type TwoFactorEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (c *TwoFactorController) GetStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get the status
+	status, err := c.twoFactorUseCase.GetStatus(ctx, user.ID)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to get two-factor status")
+		return
+	}
+
+	// Return the status
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(TwoFactorStatusResponse{
+		Enabled:                status.Enabled,
+		RecoveryCodesRemaining: status.RecoveryCodesRemaining,
+	}); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *TwoFactorController) BeginEnrollment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Generate the secret
+	enrollment, err := c.twoFactorUseCase.BeginEnrollment(ctx, user.ID)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to start two-factor enrollment")
+		return
+	}
+
+	// Return the secret, the client renders the URI as a QR code
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(TwoFactorEnrollmentResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	}); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+func (c *TwoFactorController) ConfirmEnrollment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse the request body
+	code, ok := decodeTwoFactorCode(w, r)
+	if !ok {
+		return
+	}
+
+	// Enable two-factor authentication
+	recoveryCodes, err := c.twoFactorUseCase.ConfirmEnrollment(ctx, user.ID, code)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to confirm two-factor enrollment")
+		return
+	}
+
+	writeRecoveryCodes(w, r, recoveryCodes)
+}
+
+func (c *TwoFactorController) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse the request body
+	code, ok := decodeTwoFactorCode(w, r)
+	if !ok {
+		return
+	}
+
+	// Replace the recovery codes
+	recoveryCodes, err := c.twoFactorUseCase.RegenerateRecoveryCodes(ctx, user.ID, code)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	writeRecoveryCodes(w, r, recoveryCodes)
+}
+
+func (c *TwoFactorController) Disable(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse the request body
+	code, ok := decodeTwoFactorCode(w, r)
+	if !ok {
+		return
+	}
+
+	// Disable two-factor authentication
+	if err := c.twoFactorUseCase.Disable(ctx, user.ID, code); err != nil {
+		problem.WriteError(w, r, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	// Return success with no content
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyLogin upgrades the partial session created by the login to a full
+// session once the user submits a valid code
+func (c *TwoFactorController) VerifyLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get the partial session token from the cookie
+	cookie, err := r.Cookie("session")
+	if err != nil {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Validate the partial session
+	result, err := c.sessionUseCase.ValidateSessionToken(ctx, cookie.Value)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to validate session")
+		return
+	}
+	if result.Session == nil || result.User == nil || !result.Session.TwoFactorPending {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse the request body
+	code, ok := decodeTwoFactorCode(w, r)
+	if !ok {
+		return
+	}
+
+	// Codes are throttled like passwords
+	attempt := loginAttempt(r, result.User.Email)
+	if err := c.loginThrottleUseCase.Check(ctx, attempt); err != nil {
+		problem.WriteError(w, r, err, "Two-factor authentication failed")
+		return
+	}
+
+	// Check the code. A wrong code ends the partial session so that every
+	// guess costs a password check.
+	if err := c.twoFactorUseCase.VerifyCode(ctx, result.User.ID, code); err != nil {
+		if recordErr := c.loginThrottleUseCase.RecordFailure(ctx, attempt, entities.LoginFailureInvalidTwoFactorCode); recordErr != nil {
+			log.Printf("error recording failed login attempt: %v", recordErr)
+		}
+		if invalidateErr := c.sessionUseCase.InvalidateSession(ctx, result.Session.ID); invalidateErr != nil {
+			problem.WriteError(w, r, invalidateErr, "Failed to invalidate session")
+			return
+		}
+		problem.WriteError(w, r, err, "Two-factor authentication failed")
+		return
+	}
+	if err := c.loginThrottleUseCase.RecordSuccess(ctx, attempt); err != nil {
+		log.Printf("error resetting failed login attempts: %v", err)
+	}
+
+	// Replace the partial session with a full one under a new token
+	if err := c.sessionUseCase.InvalidateSession(ctx, result.Session.ID); err != nil {
+		problem.WriteError(w, r, err, "Failed to invalidate session")
+		return
+	}
+	token, err := c.sessionUseCase.GenerateSessionToken(ctx)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to generate session token")
+		return
+	}
+	session, err := c.sessionUseCase.CreateSession(ctx, token, result.User.ID, sessionClient(r))
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to create session")
+		return
+	}
+
+	// Set the session cookie
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session",
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+		Expires:  session.ExpiresAt,
+	})
+
+	// Return the user
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(LoginResponse{
+		UserID: result.User.ID,
+	}); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+// decodeTwoFactorCode parses a request body holding a code, writing the
+// problem details when it is invalid
+func decodeTwoFactorCode(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return "", false
+	}
+
+	// Validate input
+	if req.Code == "" {
+		problem.WriteValidation(w, r, "Code is required", domainerrors.FieldError{Field: "code", Message: "is required"})
+		return "", false
+	}
+
+	return req.Code, true
+}
+
+func writeRecoveryCodes(w http.ResponseWriter, r *http.Request, recoveryCodes []string) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(RecoveryCodesResponse{
+		RecoveryCodes: recoveryCodes,
+	}); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
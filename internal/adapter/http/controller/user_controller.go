@@ -2,25 +2,43 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
-	"regexp"
 
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
 	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
 	"github.com/LaulauChau/note-nest/internal/domain/entities"
 )
 
 type UserController struct {
-	userUseCase    *use_cases.UserUseCase
-	sessionUseCase *use_cases.SessionUseCase
+	userUseCase              *use_cases.UserUseCase
+	sessionUseCase           *use_cases.SessionUseCase
+	tokenUseCase             *use_cases.PersonalAccessTokenUseCase
+	emailVerificationUseCase *use_cases.EmailVerificationUseCase
+	emailChangeUseCase       *use_cases.EmailChangeUseCase
+	twoFactorUseCase         *use_cases.TwoFactorUseCase
+	loginThrottleUseCase     *use_cases.LoginThrottleUseCase
 }
 
 func NewUserController(
 	userUseCase *use_cases.UserUseCase,
 	sessionUseCase *use_cases.SessionUseCase,
+	tokenUseCase *use_cases.PersonalAccessTokenUseCase,
+	emailVerificationUseCase *use_cases.EmailVerificationUseCase,
+	emailChangeUseCase *use_cases.EmailChangeUseCase,
+	twoFactorUseCase *use_cases.TwoFactorUseCase,
+	loginThrottleUseCase *use_cases.LoginThrottleUseCase,
 ) *UserController {
 	return &UserController{
-		userUseCase:    userUseCase,
-		sessionUseCase: sessionUseCase,
+		userUseCase:              userUseCase,
+		sessionUseCase:           sessionUseCase,
+		tokenUseCase:             tokenUseCase,
+		emailVerificationUseCase: emailVerificationUseCase,
+		emailChangeUseCase:       emailChangeUseCase,
+		twoFactorUseCase:         twoFactorUseCase,
+		loginThrottleUseCase:     loginThrottleUseCase,
 	}
 }
 
@@ -31,9 +49,10 @@ type RegisterRequest struct {
 }
 
 type RegisterResponse struct {
-	ID    string `json:"id"`
-	Email string `json:"email"`
-	Name  string `json:"name"`
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	Name          string `json:"name"`
+	EmailVerified bool   `json:"email_verified"`
 }
 
 func (c *UserController) Register(w http.ResponseWriter, r *http.Request) {
@@ -42,63 +61,61 @@ func (c *UserController) Register(w http.ResponseWriter, r *http.Request) {
 	// Parse the request body
 	var req RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	// Validate input
-	if req.Email == "" || req.Name == "" || req.Password == "" {
-		http.Error(w, "Email, name, and password are required", http.StatusBadRequest)
+	var missing []domainerrors.FieldError
+	required := []struct{ field, value string }{
+		{"email", req.Email},
+		{"name", req.Name},
+		{"password", req.Password},
+	}
+	for _, input := range required {
+		if input.value == "" {
+			missing = append(missing, domainerrors.FieldError{Field: input.field, Message: "is required"})
+		}
+	}
+	if len(missing) > 0 {
+		problem.WriteValidation(w, r, "Email, name, and password are required", missing...)
 		return
 	}
 
 	// Validate email format
-	emailRegex := `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
-	match, err := regexp.MatchString(emailRegex, req.Email)
-	if err != nil || !match {
-		http.Error(w, "Invalid email format", http.StatusBadRequest)
+	if !isValidEmail(req.Email) {
+		problem.WriteValidation(w, r, "Invalid email format", domainerrors.FieldError{Field: "email", Message: "must be a valid email address"})
 		return
 	}
 
 	// Validate password requirements
-	if len(req.Password) < 12 {
-		http.Error(w, "Password must be at least 12 characters long", http.StatusBadRequest)
+	if violation := passwordPolicyViolation(req.Password); violation != "" {
+		problem.WriteValidation(w, r, "Password "+violation, domainerrors.FieldError{Field: "password", Message: violation})
 		return
 	}
-	passwordChecks := []string{
-		`[A-Z]`,                              // Uppercase
-		`[a-z]`,                              // Lowercase
-		`[0-9]`,                              // Number
-		`[!@#$%^&*()_+=-{}[\]|\:;"'<>,.?/~]`, // Special character (adjust as needed)
-	}
-	for _, check := range passwordChecks {
-		match, _ := regexp.MatchString(check, req.Password)
-		if !match {
-			http.Error(w, "Password must contain at least 1 uppercase letter, 1 lowercase letter, 1 number, and 1 special character", http.StatusBadRequest)
-			return
-		}
-	}
 
 	// Register the user
 	user, err := c.userUseCase.RegisterUser(ctx, req.Email, req.Name, req.Password)
 	if err != nil {
-		if err.Error() == "email already taken" {
-			http.Error(w, "Email already taken", http.StatusConflict)
-			return
-		}
-		http.Error(w, "Failed to register user", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to register user")
 		return
 	}
 
+	// Send the verification link, the user can ask for another one if it fails
+	if err := c.emailVerificationUseCase.SendVerification(ctx, user); err != nil {
+		log.Printf("error sending verification email: %v", err)
+	}
+
 	// Return the user
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(RegisterResponse{
-		ID:    user.ID,
-		Email: user.Email,
-		Name:  user.Name,
+		ID:            user.ID,
+		Email:         user.Email,
+		Name:          user.Name,
+		EmailVerified: user.EmailVerified(),
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
 		return
 	}
 }
@@ -109,45 +126,80 @@ func (c *UserController) Login(w http.ResponseWriter, r *http.Request) {
 	// Parse the request body
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	// Validate email format
-	emailRegex := `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
-	match, err := regexp.MatchString(emailRegex, req.Email)
-	if err != nil || !match {
-		http.Error(w, "Invalid email format", http.StatusBadRequest)
+	if !isValidEmail(req.Email) {
+		problem.WriteValidation(w, r, "Invalid email format", domainerrors.FieldError{Field: "email", Message: "must be a valid email address"})
+		return
+	}
+
+	// Refuse the attempt while the account or the address is throttled
+	attempt := loginAttempt(r, req.Email)
+	if err := c.loginThrottleUseCase.Check(ctx, attempt); err != nil {
+		problem.WriteError(w, r, err, "Authentication failed")
 		return
 	}
 
 	// Authenticate the user
 	user, err := c.userUseCase.AuthenticateUser(ctx, req.Email, req.Password)
 	if err != nil {
-		http.Error(w, "Authentication failed", http.StatusInternalServerError)
+		if errors.Is(err, domainerrors.ErrUnauthorized) {
+			if recordErr := c.loginThrottleUseCase.RecordFailure(ctx, attempt, entities.LoginFailureInvalidCredentials); recordErr != nil {
+				log.Printf("error recording failed login attempt: %v", recordErr)
+			}
+		}
+		problem.WriteError(w, r, err, "Authentication failed")
 		return
 	}
 
 	// If authentication failed, return unauthorized
 	if user == nil {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Invalid credentials")
+		return
+	}
+
+	// Unverified users may not be allowed to sign in
+	if err := c.emailVerificationUseCase.CheckLogin(user); err != nil {
+		problem.WriteError(w, r, err, "Authentication failed")
+		return
+	}
+
+	// Users with two-factor authentication must submit a code next
+	twoFactorRequired, err := c.twoFactorUseCase.IsEnabled(ctx, user.ID)
+	if err != nil {
+		problem.WriteError(w, r, err, "Authentication failed")
 		return
 	}
 
 	// Generate a new session token
 	token, err := c.sessionUseCase.GenerateSessionToken(ctx)
 	if err != nil {
-		http.Error(w, "Failed to generate session token", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to generate session token")
 		return
 	}
 
-	// Create a new session
-	session, err := c.sessionUseCase.CreateSession(ctx, token, user.ID)
+	// Create a new session, only a partial one until the code is submitted
+	var session *entities.Session
+	if twoFactorRequired {
+		session, err = c.sessionUseCase.CreatePartialSession(ctx, token, user.ID, sessionClient(r))
+	} else {
+		session, err = c.sessionUseCase.CreateSession(ctx, token, user.ID, sessionClient(r))
+	}
 	if err != nil {
-		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Failed to create session")
 		return
 	}
 
+	// The sign-in is complete unless a code is still expected
+	if !twoFactorRequired {
+		if err := c.loginThrottleUseCase.RecordSuccess(ctx, attempt); err != nil {
+			log.Printf("error resetting failed login attempts: %v", err)
+		}
+	}
+
 	// Set the session cookie
 	http.SetCookie(w, &http.Cookie{
 		Name:     "session",
@@ -162,35 +214,250 @@ func (c *UserController) Login(w http.ResponseWriter, r *http.Request) {
 	// Return the user
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(LoginResponse{
-		UserID: user.ID,
+		UserID:            user.ID,
+		TwoFactorRequired: twoFactorRequired,
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
 		return
 	}
 }
 
 type UserResponse struct {
-	ID    string `json:"id"`
-	Email string `json:"email"`
-	Name  string `json:"name"`
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	Name          string `json:"name"`
+	EmailVerified bool   `json:"email_verified"`
 }
 
 func (c *UserController) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 	// Get user from context (added by the auth middleware)
 	user, ok := r.Context().Value(UserContextKey).(*entities.User)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Return the user
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(UserResponse{
+		ID:            user.ID,
+		Email:         user.Email,
+		Name:          user.Name,
+		EmailVerified: user.EmailVerified(),
+	}); err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+type UpdateProfileRequest struct {
+	Name *string `json:"name"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token"`
+}
+
+func (c *UserController) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse the request body
+	var req UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate input, omitted fields are left unchanged
+	name := user.Name
+	if req.Name != nil {
+		if *req.Name == "" {
+			problem.WriteValidation(w, r, "Name cannot be empty", domainerrors.FieldError{Field: "name", Message: "must not be empty"})
+			return
+		}
+		name = *req.Name
+	}
+
+	// Update the profile
+	updatedUser, err := c.userUseCase.UpdateProfile(ctx, user.ID, name)
+	if err != nil {
+		problem.WriteError(w, r, err, "Failed to update profile")
 		return
 	}
 
 	// Return the user
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(UserResponse{
-		ID:    user.ID,
-		Email: user.Email,
-		Name:  user.Name,
+		ID:            updatedUser.ID,
+		Email:         updatedUser.Email,
+		Name:          updatedUser.Name,
+		EmailVerified: updatedUser.EmailVerified(),
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
 		return
 	}
 }
+
+func (c *UserController) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user and session from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	session, ok := r.Context().Value(SessionContextKey).(*entities.Session)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse the request body
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate input
+	if req.CurrentPassword == "" {
+		problem.WriteValidation(w, r, "Current password is required", domainerrors.FieldError{Field: "current_password", Message: "is required"})
+		return
+	}
+	if violation := passwordPolicyViolation(req.NewPassword); violation != "" {
+		problem.WriteValidation(w, r, "Password "+violation, domainerrors.FieldError{Field: "new_password", Message: violation})
+		return
+	}
+
+	// The password is checked like on sign in, and throttled the same way
+	attempt := loginAttempt(r, user.Email)
+	if err := c.loginThrottleUseCase.Check(ctx, attempt); err != nil {
+		problem.WriteError(w, r, err, "Failed to change password")
+		return
+	}
+
+	// Change the password
+	if err := c.userUseCase.ChangePassword(ctx, user.ID, req.CurrentPassword, req.NewPassword); err != nil {
+		if errors.Is(err, use_cases.ErrIncorrectPassword) {
+			if recordErr := c.loginThrottleUseCase.RecordFailure(ctx, attempt, entities.LoginFailureInvalidCredentials); recordErr != nil {
+				log.Printf("error recording failed login attempt: %v", recordErr)
+			}
+		}
+		problem.WriteError(w, r, err, "Failed to change password")
+		return
+	}
+	if err := c.loginThrottleUseCase.RecordSuccess(ctx, attempt); err != nil {
+		log.Printf("error resetting failed login attempts: %v", err)
+	}
+
+	// Sign out every other device, the current one stays signed in
+	if err := c.sessionUseCase.InvalidateOtherSessions(ctx, user.ID, session.ID); err != nil {
+		problem.WriteError(w, r, err, "Failed to invalidate other sessions")
+		return
+	}
+
+	// Access tokens may have been created by whoever knew the old password
+	if err := c.tokenUseCase.RevokeAllTokens(ctx, user.ID); err != nil {
+		problem.WriteError(w, r, err, "Failed to revoke access tokens")
+		return
+	}
+
+	// Return success with no content
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *UserController) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user from context (added by the auth middleware)
+	user, ok := r.Context().Value(UserContextKey).(*entities.User)
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse the request body
+	var req ChangeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate input
+	if !isValidEmail(req.Email) {
+		problem.WriteValidation(w, r, "Invalid email format", domainerrors.FieldError{Field: "email", Message: "must be a valid email address"})
+		return
+	}
+	if req.Password == "" {
+		problem.WriteValidation(w, r, "Password is required", domainerrors.FieldError{Field: "password", Message: "is required"})
+		return
+	}
+
+	// The password is checked like on sign in, and throttled the same way
+	attempt := loginAttempt(r, user.Email)
+	if err := c.loginThrottleUseCase.Check(ctx, attempt); err != nil {
+		problem.WriteError(w, r, err, "Failed to request email change")
+		return
+	}
+
+	// Send the confirmation link to the new address
+	if err := c.emailChangeUseCase.RequestEmailChange(ctx, user.ID, req.Password, req.Email); err != nil {
+		if errors.Is(err, use_cases.ErrIncorrectPassword) {
+			if recordErr := c.loginThrottleUseCase.RecordFailure(ctx, attempt, entities.LoginFailureInvalidCredentials); recordErr != nil {
+				log.Printf("error recording failed login attempt: %v", recordErr)
+			}
+		}
+		problem.WriteError(w, r, err, "Failed to request email change")
+		return
+	}
+	if err := c.loginThrottleUseCase.RecordSuccess(ctx, attempt); err != nil {
+		log.Printf("error resetting failed login attempts: %v", err)
+	}
+
+	// The change happens once the new address is confirmed
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (c *UserController) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Parse the request body
+	var req ConfirmEmailChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate input
+	if req.Token == "" {
+		problem.WriteValidation(w, r, "Token is required", domainerrors.FieldError{Field: "token", Message: "is required"})
+		return
+	}
+
+	// Switch to the new address
+	if err := c.emailChangeUseCase.ConfirmEmailChange(ctx, req.Token); err != nil {
+		problem.WriteError(w, r, err, "Failed to confirm email change")
+		return
+	}
+
+	// Return success with no content
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"regexp"
+)
+
+var (
+	emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+	passwordChecks = []*regexp.Regexp{
+		regexp.MustCompile(`[A-Z]`),                              // Uppercase
+		regexp.MustCompile(`[a-z]`),                              // Lowercase
+		regexp.MustCompile(`[0-9]`),                              // Number
+		regexp.MustCompile(`[!@#$%^&*()_+=-{}[\]|\:;"'<>,.?/~]`), // Special character (adjust as needed)
+	}
+)
+
+func isValidEmail(email string) bool {
+	return emailRegex.MatchString(email)
+}
+
+// passwordPolicyViolation returns why a password is too weak, or an empty
+// string when it meets the requirements
+func passwordPolicyViolation(password string) string {
+	if len(password) < 12 {
+		return "must be at least 12 characters long"
+	}
+	for _, check := range passwordChecks {
+		if !check.MatchString(password) {
+			return "must contain at least 1 uppercase letter, 1 lowercase letter, 1 number, and 1 special character"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+// Package problem writes error responses as RFC 7807 problem details
+// (application/problem+json) so clients can parse every failure the same way.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+)
+
+// ContentType is the media type of problem detail responses
+const ContentType = "application/problem+json"
+
+// Problem types. Generic failures use about:blank, whose title is the HTTP
+// status text.
+const (
+	TypeBlank              = "about:blank"
+	TypeValidation         = "urn:note-nest:problem:validation"
+	TypeNotFound           = "urn:note-nest:problem:not-found"
+	TypeConflict           = "urn:note-nest:problem:conflict"
+	TypeForbidden          = "urn:note-nest:problem:forbidden"
+	TypeUnauthorized       = "urn:note-nest:problem:unauthorized"
+	TypePreconditionFailed = "urn:note-nest:problem:precondition-failed"
+	TypeRateLimited        = "urn:note-nest:problem:rate-limited"
+	TypeTooLarge           = "urn:note-nest:problem:too-large"
+	TypeUnprocessable      = "urn:note-nest:problem:unprocessable"
+)
+
+// Details is the problem+json body
+type Details struct {
+	Type      string                    `json:"type"`
+	Title     string                    `json:"title"`
+	Status    int                       `json:"status"`
+	Detail    string                    `json:"detail,omitempty"`
+	Instance  string                    `json:"instance,omitempty"`
+	RequestID string                    `json:"request_id,omitempty"`
+	Errors    []domainerrors.FieldError `json:"errors,omitempty"`
+}
+
+// Write responds with a problem whose type is derived from the status
+func Write(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	writeDetails(w, r, Details{
+		Type:   typeForStatus(status),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+}
+
+// WriteValidation responds with a 400 listing the rejected fields
+func WriteValidation(w http.ResponseWriter, r *http.Request, detail string, fields ...domainerrors.FieldError) {
+	writeDetails(w, r, Details{
+		Type:   TypeValidation,
+		Title:  http.StatusText(http.StatusBadRequest),
+		Status: http.StatusBadRequest,
+		Detail: detail,
+		Errors: fields,
+	})
+}
+
+// WriteError responds with the problem matching the kind of domain error.
+// Unexpected errors are logged and reported as a 500 with the fallback
+// detail so internal details never reach the client.
+func WriteError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	var validation *domainerrors.ValidationError
+	if errors.As(err, &validation) {
+		WriteValidation(w, r, capitalize(validation.Error()), validation.Fields...)
+		return
+	}
+
+	// Tell the client when it may try again
+	var rateLimited *domainerrors.RateLimitedError
+	if errors.As(err, &rateLimited) && rateLimited.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateLimited.RetryAfter.Seconds()))))
+	}
+
+	status := Status(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("%s: %v", fallback, err)
+		Write(w, r, status, fallback)
+		return
+	}
+
+	Write(w, r, status, capitalize(err.Error()))
+}
+
+// Status maps a domain error kind to its HTTP status
+func Status(err error) int {
+	switch {
+	case errors.Is(err, domainerrors.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domainerrors.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domainerrors.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domainerrors.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domainerrors.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, domainerrors.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, domainerrors.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, domainerrors.ErrUnprocessable):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func typeForStatus(status int) string {
+	switch status {
+	case http.StatusNotFound:
+		return TypeNotFound
+	case http.StatusConflict:
+		return TypeConflict
+	case http.StatusForbidden:
+		return TypeForbidden
+	case http.StatusUnauthorized:
+		return TypeUnauthorized
+	case http.StatusPreconditionFailed:
+		return TypePreconditionFailed
+	case http.StatusTooManyRequests:
+		return TypeRateLimited
+	case http.StatusRequestEntityTooLarge:
+		return TypeTooLarge
+	case http.StatusUnprocessableEntity:
+		return TypeUnprocessable
+	default:
+		return TypeBlank
+	}
+}
+
+func writeDetails(w http.ResponseWriter, r *http.Request, details Details) {
+	details.Instance = r.URL.Path
+	details.RequestID = middleware.GetReqID(r.Context())
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(details.Status)
+	if err := json.NewEncoder(w).Encode(details); err != nil {
+		log.Printf("error encoding problem details: %v", err)
+	}
+}
+
+func capitalize(message string) string {
+	first, size := utf8.DecodeRuneInString(message)
+	if first == utf8.RuneError {
+		return message
+	}
+	return string(unicode.ToUpper(first)) + message[size:]
+}
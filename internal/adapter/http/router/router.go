@@ -8,9 +8,11 @@ import (
 
 	"github.com/LaulauChau/note-nest/internal/adapter/http/controller"
 	httpMiddleware "github.com/LaulauChau/note-nest/internal/adapter/http/middleware"
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
 )
 
-func NewRouter(userController *controller.UserController, sessionController *controller.SessionController, noteController *controller.NoteController, labelController *controller.LabelController) http.Handler {
+func NewRouter(userController *controller.UserController, sessionController *controller.SessionController, noteController *controller.NoteController, labelController *controller.LabelController, revisionController *controller.RevisionController, healthController *controller.HealthController, passwordController *controller.PasswordController, emailVerificationController *controller.EmailVerificationController, accountController *controller.AccountController, twoFactorController *controller.TwoFactorController, personalAccessTokenController *controller.PersonalAccessTokenController, noteShareController *controller.NoteShareController, shareLinkController *controller.ShareLinkController, checklistController *controller.ChecklistController, attachmentController *controller.AttachmentController) http.Handler {
 
 	r := chi.NewRouter()
 
@@ -21,40 +23,156 @@ func NewRouter(userController *controller.UserController, sessionController *con
 	r.Use(middleware.Recoverer)
 	r.Use(httpMiddleware.SecurityHeaders)
 
+	// Unknown routes answer with problem details like every other error
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		problem.Write(w, r, http.StatusNotFound, "Resource not found")
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		problem.Write(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+	})
+
 	// Public routes
 	r.Group(func(r chi.Router) {
+		r.Get("/health", healthController.Health)
 		r.Post("/api/register", userController.Register)
 		r.Post("/api/login", userController.Login)
+		r.Post("/api/login/2fa", twoFactorController.VerifyLogin)
+		r.Post("/api/password/forgot", passwordController.ForgotPassword)
+		r.Post("/api/password/reset", passwordController.ResetPassword)
+		r.Post("/api/email/verify", emailVerificationController.VerifyEmail)
+		r.Post("/api/email/verify/resend", emailVerificationController.ResendVerification)
+		r.Post("/api/email/change/confirm", userController.ConfirmEmailChange)
+		r.Post("/api/account/recover", accountController.RecoverAccount)
+
+		// Public links to notes, the form of protected links posts the password
+		r.Get("/s/{token}", shareLinkController.ViewLink)
+		r.Post("/s/{token}", shareLinkController.ViewLink)
 	})
 
-	// Protected routes
+	// Routes only available to signed in users
 	r.Group(func(r chi.Router) {
 		r.Use(sessionController.AuthMiddleware)
+		r.Use(sessionController.CSRFMiddleware)
 
+		r.Get("/api/csrf", sessionController.GetCSRFToken)
 		r.Post("/api/logout", sessionController.Logout)
+		r.Patch("/api/me", userController.UpdateProfile)
+		r.Delete("/api/me", accountController.DeleteAccount)
+		r.Post("/api/me/password", userController.ChangePassword)
+		r.Post("/api/me/email", userController.RequestEmailChange)
+		r.Get("/api/me/2fa", twoFactorController.GetStatus)
+		r.Delete("/api/me/2fa", twoFactorController.Disable)
+		r.Post("/api/me/2fa/enroll", twoFactorController.BeginEnrollment)
+		r.Post("/api/me/2fa/confirm", twoFactorController.ConfirmEnrollment)
+		r.Post("/api/me/2fa/recovery-codes", twoFactorController.RegenerateRecoveryCodes)
+		r.Get("/api/me/revision-retention", revisionController.GetRetention)
+		r.Put("/api/me/revision-retention", revisionController.UpdateRetention)
+
+		// Session routes
+		r.Get("/api/sessions", sessionController.ListSessions)
+		r.Delete("/api/sessions", sessionController.RevokeOtherSessions)
+		r.Delete("/api/sessions/{sessionID}", sessionController.RevokeSession)
+
+		// Personal access token routes
+		r.Get("/api/tokens", personalAccessTokenController.ListTokens)
+		r.Post("/api/tokens", personalAccessTokenController.CreateToken)
+		r.Delete("/api/tokens/{tokenID}", personalAccessTokenController.RevokeToken)
+
+		// Note sharing routes, granting access to other people needs a session
+		r.Post("/api/notes/{noteID}/shares", noteShareController.ShareNote)
+		r.Delete("/api/notes/{noteID}/shares/{userID}", noteShareController.RevokeShare)
+		r.Post("/api/notes/{noteID}/links", shareLinkController.CreateLink)
+		r.Delete("/api/notes/{noteID}/links/{linkID}", shareLinkController.RevokeLink)
+	})
+
+	// Routes also available to personal access tokens with the scope
+	r.Group(func(r chi.Router) {
+		r.Use(sessionController.ScopedAuthMiddleware(entities.ScopeProfileRead))
+		r.Use(sessionController.CSRFMiddleware)
+
 		r.Get("/api/me", userController.GetCurrentUser)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(sessionController.ScopedAuthMiddleware(entities.ScopeNotesRead))
+		r.Use(sessionController.CSRFMiddleware)
 
-		// Note routes
-		r.Post("/api/notes", noteController.CreateNote)
 		r.Get("/api/notes", noteController.GetActiveNotes)
 		r.Get("/api/notes/archived", noteController.GetArchivedNotes)
+		r.Get("/api/notes/search", noteController.SearchNotes)
+		r.Get("/api/notes/shared", noteShareController.GetSharedNotes)
+		r.Get("/api/notes/trash", noteController.GetTrashedNotes)
 		r.Get("/api/notes/{noteID}", noteController.GetNoteByID)
+		r.Get("/api/notes/{noteID}/revisions", revisionController.GetRevisions)
+		r.Get("/api/notes/{noteID}/revisions/diff", revisionController.DiffRevisions)
+		r.Get("/api/notes/{noteID}/revisions/{revision}", revisionController.GetRevision)
+		r.Get("/api/notes/{noteID}/labels", labelController.GetNoteLabels)
+		r.Get("/api/notes/{noteID}/shares", noteShareController.ListShares)
+		r.Get("/api/notes/{noteID}/links", shareLinkController.ListLinks)
+		r.Get("/api/notes/{noteID}/items", checklistController.ListItems)
+		r.Get("/api/notes/{noteID}/attachments", attachmentController.ListAttachments)
+		r.Get("/api/notes/{noteID}/attachments/{attachmentID}", attachmentController.DownloadAttachment)
+		r.Get("/api/labels/{labelID}/notes", labelController.GetNotesForLabel)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(sessionController.ScopedAuthMiddleware(entities.ScopeNotesWrite))
+		r.Use(sessionController.CSRFMiddleware)
+
+		r.Post("/api/notes", noteController.CreateNote)
+		r.Delete("/api/notes/trash", noteController.EmptyTrash)
 		r.Put("/api/notes/{noteID}", noteController.UpdateNote)
 		r.Delete("/api/notes/{noteID}", noteController.DeleteNote)
+		r.Post("/api/notes/{noteID}/restore", noteController.RestoreNote)
+		r.Put("/api/notes/{noteID}/pin", noteController.PinNote)
+		r.Delete("/api/notes/{noteID}/pin", noteController.UnpinNote)
+		r.Post("/api/notes/{noteID}/move", noteController.MoveNote)
+		r.Post("/api/notes/{noteID}/convert", noteController.ConvertNote)
+		r.Post("/api/notes/{noteID}/items", checklistController.AddItem)
+		r.Post("/api/notes/{noteID}/items/move-checked", checklistController.MoveCheckedToBottom)
+		r.Post("/api/notes/{noteID}/items/uncheck-all", checklistController.UncheckAll)
+		r.Patch("/api/notes/{noteID}/items/{itemID}", checklistController.UpdateItem)
+		r.Delete("/api/notes/{noteID}/items/{itemID}", checklistController.DeleteItem)
+		r.Post("/api/notes/{noteID}/items/{itemID}/move", checklistController.MoveItem)
+		r.Post("/api/notes/{noteID}/attachments", attachmentController.UploadAttachment)
+		r.Delete("/api/notes/{noteID}/attachments/{attachmentID}", attachmentController.DeleteAttachment)
+		r.Post("/api/notes/{noteID}/revisions/{revision}/restore", revisionController.RestoreRevision)
+		r.Put("/api/notes/{noteID}/labels/{labelID}", labelController.AddLabelToNote)
+		r.Delete("/api/notes/{noteID}/labels/{labelID}", labelController.RemoveLabelFromNote)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(sessionController.ScopedAuthMiddleware(entities.ScopeLabelsRead))
+		r.Use(sessionController.CSRFMiddleware)
 
-		// Label routes
-		r.Post("/api/labels", labelController.CreateLabel)
 		r.Get("/api/labels", labelController.GetLabels)
 		r.Get("/api/labels/{labelID}", labelController.GetLabelByID)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(sessionController.ScopedAuthMiddleware(entities.ScopeLabelsWrite))
+		r.Use(sessionController.CSRFMiddleware)
+
+		r.Post("/api/labels", labelController.CreateLabel)
 		r.Put("/api/labels/{labelID}", labelController.UpdateLabel)
 		r.Delete("/api/labels/{labelID}", labelController.DeleteLabel)
-		r.Get("/api/labels/{labelID}/notes", labelController.GetNotesForLabel)
+	})
 
-		// Note-Label relationship routes
-		r.Get("/api/notes/{noteID}/labels", labelController.GetNoteLabels)
-		r.Put("/api/notes/{noteID}/labels/{labelID}", labelController.AddLabelToNote)
-		r.Delete("/api/notes/{noteID}/labels/{labelID}", labelController.RemoveLabelFromNote)
+	return r
+}
+
+// NewInternalRouter serves the operational endpoints meant for monitoring
+// only. Its port must not be exposed to the public.
+func NewInternalRouter(healthController *controller.HealthController) http.Handler {
+	r := chi.NewRouter()
+
+	r.Use(middleware.Recoverer)
+
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		problem.Write(w, r, http.StatusNotFound, "Resource not found")
 	})
 
+	r.Get("/health", healthController.DetailedHealth)
+
 	return r
 }
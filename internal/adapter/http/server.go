@@ -12,7 +12,8 @@ import (
 )
 
 type Server struct {
-	server *http.Server
+	server   *http.Server
+	internal *http.Server
 }
 
 func NewServer(handler http.Handler, port int) *Server {
@@ -27,15 +28,33 @@ func NewServer(handler http.Handler, port int) *Server {
 	}
 }
 
+// ServeInternal also serves the handler on a separate port when the server
+// starts, for endpoints that must stay off the public port
+func (s *Server) ServeInternal(handler http.Handler, port int) {
+	s.internal = &http.Server{
+		Addr:         fmt.Sprintf(":%d", port),
+		Handler:      handler,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  120 * time.Second,
+	}
+}
+
 func (s *Server) Start() error {
-	// Channel to listen for errors coming from the listener
-	serverErrors := make(chan error, 1)
+	// Channel to listen for errors coming from the listeners
+	serverErrors := make(chan error, 2)
 
-	// Start the server in a goroutine
+	// Start the servers in goroutines
 	go func() {
 		log.Printf("Server listening on %s", s.server.Addr)
 		serverErrors <- s.server.ListenAndServe()
 	}()
+	if s.internal != nil {
+		go func() {
+			log.Printf("Internal server listening on %s", s.internal.Addr)
+			serverErrors <- s.internal.ListenAndServe()
+		}()
+	}
 
 	// Channel to listen for an interrupt or terminate signal from the OS
 	shutdown := make(chan os.Signal, 1)
@@ -44,6 +63,7 @@ func (s *Server) Start() error {
 	// Block until a signal is received or an error occurs
 	select {
 	case err := <-serverErrors:
+		s.closeInternal()
 		return fmt.Errorf("error starting server: %w", err)
 	case <-shutdown:
 		log.Println("Server is shutting down...")
@@ -55,10 +75,12 @@ func (s *Server) Start() error {
 		// Define a channel to signal when shutdown is done
 		shutdownDone := make(chan struct{})
 
-		// Shutdown the server gracefully
+		// Shutdown the server gracefully, the internal one has nothing
+		// worth waiting for
 		go func() {
 			defer close(shutdownDone)
 
+			s.closeInternal()
 			if err := s.server.Shutdown(ctx); err != nil {
 				log.Printf("error during shutdown: %v", err)
 			}
@@ -78,3 +100,12 @@ func (s *Server) Start() error {
 
 	return nil
 }
+
+func (s *Server) closeInternal() {
+	if s.internal == nil {
+		return
+	}
+	if err := s.internal.Close(); err != nil {
+		log.Printf("error closing internal server: %v", err)
+	}
+}
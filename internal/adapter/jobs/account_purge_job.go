@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+)
+
+// AccountPurgeJob periodically deletes the accounts whose deletion grace
+// period ended
+type AccountPurgeJob struct {
+	accountUseCase *use_cases.AccountUseCase
+	interval       time.Duration
+}
+
+func NewAccountPurgeJob(accountUseCase *use_cases.AccountUseCase, interval time.Duration) *AccountPurgeJob {
+	return &AccountPurgeJob{
+		accountUseCase: accountUseCase,
+		interval:       interval,
+	}
+}
+
+// Run purges the accounts once immediately, then on every interval until the
+// context is cancelled
+func (j *AccountPurgeJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *AccountPurgeJob) purge(ctx context.Context) {
+	purged, err := j.accountUseCase.PurgeDeletedAccounts(ctx)
+	if err != nil {
+		log.Printf("error purging deleted accounts: %v", err)
+		return
+	}
+	if purged > 0 {
+		log.Printf("purged %d deleted accounts", purged)
+	}
+}
@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+)
+
+// SessionCleanupJob periodically deletes the sessions that can no longer be
+// used, which are otherwise only removed when their token is presented again
+type SessionCleanupJob struct {
+	sessionUseCase *use_cases.SessionUseCase
+	interval       time.Duration
+}
+
+func NewSessionCleanupJob(sessionUseCase *use_cases.SessionUseCase, interval time.Duration) *SessionCleanupJob {
+	return &SessionCleanupJob{
+		sessionUseCase: sessionUseCase,
+		interval:       interval,
+	}
+}
+
+// Run deletes the expired sessions once immediately, then on every interval
+// until the context is cancelled
+func (j *SessionCleanupJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.cleanup(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *SessionCleanupJob) cleanup(ctx context.Context) {
+	purged, err := j.sessionUseCase.PurgeExpiredSessions(ctx)
+	if purged > 0 {
+		log.Printf("deleted %d expired sessions", purged)
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Printf("error deleting expired sessions: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+)
+
+// TrashPurgeJob periodically deletes notes that stayed in the trash longer
+// than the retention period
+type TrashPurgeJob struct {
+	noteUseCase *use_cases.NoteUseCase
+	retention   time.Duration
+	interval    time.Duration
+}
+
+func NewTrashPurgeJob(noteUseCase *use_cases.NoteUseCase, retention, interval time.Duration) *TrashPurgeJob {
+	return &TrashPurgeJob{
+		noteUseCase: noteUseCase,
+		retention:   retention,
+		interval:    interval,
+	}
+}
+
+// Run purges the trash once immediately, then on every interval until the
+// context is cancelled
+func (j *TrashPurgeJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *TrashPurgeJob) purge(ctx context.Context) {
+	purged, err := j.noteUseCase.PurgeTrash(ctx, j.retention)
+	if err != nil {
+		log.Printf("error purging trash: %v", err)
+		return
+	}
+	if purged > 0 {
+		log.Printf("purged %d notes from the trash", purged)
+	}
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned when no blob is stored under the key
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps the content of attachments outside the database. Keys are
+// slash separated paths chosen by the application.
+type BlobStore interface {
+	// Put stores everything read from content under the key, replacing any
+	// blob already stored there
+	Put(ctx context.Context, key string, content io.Reader, contentType string) error
+	// Get streams length bytes of the blob starting at offset, a negative
+	// length reading up to the end
+	Get(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	// Delete removes the blob, deleting a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// PoolStats is a snapshot of the database connection pool usage
+type PoolStats struct {
+	TotalConns           int32         `json:"total_conns"`
+	AcquiredConns        int32         `json:"acquired_conns"`
+	IdleConns            int32         `json:"idle_conns"`
+	ConstructingConns    int32         `json:"constructing_conns"`
+	MaxConns             int32         `json:"max_conns"`
+	AcquireCount         int64         `json:"acquire_count"`
+	EmptyAcquireCount    int64         `json:"empty_acquire_count"`
+	CanceledAcquireCount int64         `json:"canceled_acquire_count"`
+	AcquireDuration      time.Duration `json:"acquire_duration_ns"`
+	NewConnsCount        int64         `json:"new_conns_count"`
+	MaxLifetimeDestroys  int64         `json:"max_lifetime_destroy_count"`
+	MaxIdleDestroys      int64         `json:"max_idle_destroy_count"`
+}
+
+// DatabaseMonitor reports the database reachability and pool usage
+type DatabaseMonitor interface {
+	Ping(ctx context.Context) error
+	Stats() PoolStats
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// LoginAttempts counts the recent failed sign-in attempts of a key, such as
+// an email address or an IP address
+type LoginAttempts struct {
+	Failures      int
+	LastFailureAt time.Time
+}
+
+// LoginAttemptStore keeps track of failed sign-in attempts and of the
+// password reset requests of each client address. The in-memory store only
+// throttles a single server instance, a persistent store lets several
+// instances share the counts.
+type LoginAttemptStore interface {
+	// Get returns the attempts of the key, nil when there are none
+	Get(ctx context.Context, key string) (*LoginAttempts, error)
+
+	// RecordFailure counts a failed attempt. The count is forgotten once ttl
+	// elapses without a new failure.
+	RecordFailure(ctx context.Context, key string, at time.Time, ttl time.Duration) (*LoginAttempts, error)
+
+	Reset(ctx context.Context, key string) error
+}
@@ -0,0 +1,19 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// TOTPService implements time-based one-time passwords (RFC 6238)
+type TOTPService interface {
+	GenerateSecret(ctx context.Context) (string, error)
+
+	// ProvisioningURI returns the otpauth:// URI authenticator apps scan to
+	// register the secret
+	ProvisioningURI(secret, accountName string) string
+
+	// ValidateCode checks the code against the time steps around at. It
+	// returns the time step the code belongs to when it is valid.
+	ValidateCode(secret, code string, at time.Time) (int64, bool)
+}
@@ -0,0 +1,156 @@
+package use_cases
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type AccountUseCase struct {
+	userRepo       repositories.UserRepository
+	sessionUseCase *SessionUseCase
+	hashService    services.HashService
+	txManager      repositories.TxManager
+	blobStore      services.BlobStore
+	gracePeriod    time.Duration
+}
+
+func NewAccountUseCase(
+	userRepo repositories.UserRepository,
+	sessionUseCase *SessionUseCase,
+	hashService services.HashService,
+	txManager repositories.TxManager,
+	blobStore services.BlobStore,
+	gracePeriod time.Duration,
+) *AccountUseCase {
+	return &AccountUseCase{
+		userRepo:       userRepo,
+		sessionUseCase: sessionUseCase,
+		hashService:    hashService,
+		txManager:      txManager,
+		blobStore:      blobStore,
+		gracePeriod:    gracePeriod,
+	}
+}
+
+// DeleteAccount deletes the user and all their data after checking their
+// password. With a grace period the account is only scheduled for deletion
+// and signed out everywhere; the returned time is when it will be purged.
+// Without one the data is removed immediately and nil is returned.
+func (uc *AccountUseCase) DeleteAccount(ctx context.Context, userID, password string) (*time.Time, error) {
+	// Get the user
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, domainerrors.NotFound("user")
+	}
+
+	// Verify the password
+	valid, err := uc.hashService.VerifyPassword(ctx, user.Password, password)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, incorrectPassword("password is incorrect", "password")
+	}
+
+	// Delete right away when there is no grace period
+	if uc.gracePeriod <= 0 {
+		return nil, uc.purgeAccount(ctx, user.ID)
+	}
+
+	// Otherwise keep the data until the grace period ends
+	now := time.Now()
+	if err := uc.userRepo.ScheduleDeletion(ctx, user.ID, now); err != nil {
+		return nil, err
+	}
+	if err := uc.sessionUseCase.InvalidateAllSessions(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	purgeAt := now.Add(uc.gracePeriod)
+	return &purgeAt, nil
+}
+
+// RecoverAccount cancels a scheduled deletion. The credentials are checked
+// the same way as when signing in.
+func (uc *AccountUseCase) RecoverAccount(ctx context.Context, email, password string) error {
+	// Get the user by email
+	user, err := uc.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return domainerrors.Unauthorized("invalid credentials")
+	}
+
+	// Verify the password
+	valid, err := uc.hashService.VerifyPassword(ctx, user.Password, password)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return domainerrors.Unauthorized("invalid credentials")
+	}
+
+	// Nothing to recover
+	if user.DeletionRequestedAt == nil {
+		return nil
+	}
+
+	return uc.userRepo.CancelDeletion(ctx, user.ID)
+}
+
+// PurgeDeletedAccounts removes the accounts whose grace period ended
+func (uc *AccountUseCase) PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	userIDs, err := uc.userRepo.GetPendingDeletionBefore(ctx, time.Now().Add(-uc.gracePeriod))
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, userID := range userIDs {
+		// One failing account should not keep the others around
+		if err := uc.purgeAccount(ctx, userID); err != nil {
+			log.Printf("error purging account %s: %v", userID, err)
+			continue
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// purgeAccount removes the user along with their sessions, labels, notes and
+// attachments in a single transaction, then the content of the attachments
+func (uc *AccountUseCase) purgeAccount(ctx context.Context, userID string) error {
+	var blobKeys []string
+	err := uc.txManager.WithinTx(ctx, func(ctx context.Context, repos repositories.TxRepositories) error {
+		if err := repos.Sessions.DeleteAllByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if err := repos.Labels.DeleteAllByUserID(ctx, userID); err != nil {
+			return err
+		}
+		var err error
+		if blobKeys, err = repos.Attachments.DeleteAllByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if err := repos.Notes.DeleteAllByUserID(ctx, userID); err != nil {
+			return err
+		}
+		return repos.Users.Delete(ctx, userID)
+	})
+	if err != nil {
+		return err
+	}
+	deleteBlobs(ctx, uc.blobStore, blobKeys)
+
+	return nil
+}
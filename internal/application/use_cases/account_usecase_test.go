@@ -0,0 +1,165 @@
+package use_cases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+func TestDeleteAccount_Immediate(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockSessionRepo := new(MockSessionRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockHashService := new(MockHashService)
+	mockTokenService := new(MockTokenService)
+	mockBlobStore := new(MockBlobStore)
+	txManager := &FakeTxManager{repos: repositories.TxRepositories{
+		Users:       mockUserRepo,
+		Sessions:    mockSessionRepo,
+		Notes:       mockNoteRepo,
+		Labels:      mockLabelRepo,
+		Attachments: mockAttachmentRepo,
+	}}
+
+	userID := uuid.New().String()
+	user := &entities.User{ID: userID, Email: "test@example.com", Password: "hashed_password"}
+
+	mockUserRepo.On("GetByID", ctx, userID).Return(user, nil)
+	mockHashService.On("VerifyPassword", ctx, "hashed_password", "password").Return(true, nil)
+	mockSessionRepo.On("DeleteAllByUserID", ctx, userID).Return(nil)
+	mockLabelRepo.On("DeleteAllByUserID", ctx, userID).Return(nil)
+	mockAttachmentRepo.On("DeleteAllByUserID", ctx, userID).Return([]string{"note/attachment"}, nil)
+	mockNoteRepo.On("DeleteAllByUserID", ctx, userID).Return(nil)
+	mockUserRepo.On("Delete", ctx, userID).Return(nil)
+	mockBlobStore.On("Delete", ctx, "note/attachment").Return(nil)
+
+	sessionUseCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
+	useCase := use_cases.NewAccountUseCase(mockUserRepo, sessionUseCase, mockHashService, txManager, mockBlobStore, 0)
+
+	// Act
+	purgeAt, err := useCase.DeleteAccount(ctx, userID, "password")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Nil(t, purgeAt)
+	assert.Equal(t, 1, txManager.Commits)
+
+	mockUserRepo.AssertExpectations(t)
+	mockSessionRepo.AssertExpectations(t)
+	mockLabelRepo.AssertExpectations(t)
+	mockNoteRepo.AssertExpectations(t)
+	mockAttachmentRepo.AssertExpectations(t)
+	mockBlobStore.AssertExpectations(t)
+	mockUserRepo.AssertNotCalled(t, "ScheduleDeletion")
+}
+
+func TestDeleteAccount_GracePeriod(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockSessionRepo := new(MockSessionRepository)
+	mockHashService := new(MockHashService)
+	mockTokenService := new(MockTokenService)
+	txManager := &FakeTxManager{}
+
+	userID := uuid.New().String()
+	user := &entities.User{ID: userID, Email: "test@example.com", Password: "hashed_password"}
+
+	mockUserRepo.On("GetByID", ctx, userID).Return(user, nil)
+	mockHashService.On("VerifyPassword", ctx, "hashed_password", "password").Return(true, nil)
+	mockUserRepo.On("ScheduleDeletion", ctx, userID, mock.AnythingOfType("time.Time")).Return(nil)
+	mockSessionRepo.On("DeleteAllByUserID", ctx, userID).Return(nil)
+
+	sessionUseCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
+	useCase := use_cases.NewAccountUseCase(mockUserRepo, sessionUseCase, mockHashService, txManager, new(MockBlobStore), 7*24*time.Hour)
+
+	// Act
+	purgeAt, err := useCase.DeleteAccount(ctx, userID, "password")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotNil(t, purgeAt)
+	assert.WithinDuration(t, time.Now().Add(7*24*time.Hour), *purgeAt, time.Minute)
+	assert.Equal(t, 0, txManager.Commits)
+
+	mockUserRepo.AssertExpectations(t)
+	mockSessionRepo.AssertExpectations(t)
+	mockUserRepo.AssertNotCalled(t, "Delete")
+}
+
+func TestDeleteAccount_WrongPassword(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockSessionRepo := new(MockSessionRepository)
+	mockHashService := new(MockHashService)
+	mockTokenService := new(MockTokenService)
+	txManager := &FakeTxManager{}
+
+	userID := uuid.New().String()
+	user := &entities.User{ID: userID, Email: "test@example.com", Password: "hashed_password"}
+
+	mockUserRepo.On("GetByID", ctx, userID).Return(user, nil)
+	mockHashService.On("VerifyPassword", ctx, "hashed_password", "wrong").Return(false, nil)
+
+	sessionUseCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
+	useCase := use_cases.NewAccountUseCase(mockUserRepo, sessionUseCase, mockHashService, txManager, new(MockBlobStore), 0)
+
+	// Act
+	purgeAt, err := useCase.DeleteAccount(ctx, userID, "wrong")
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, purgeAt)
+	assert.ErrorIs(t, err, domainerrors.ErrValidation)
+	assert.ErrorIs(t, err, use_cases.ErrIncorrectPassword)
+	assert.Equal(t, 0, txManager.Commits)
+
+	mockSessionRepo.AssertNotCalled(t, "DeleteAllByUserID")
+	mockUserRepo.AssertNotCalled(t, "Delete")
+}
+
+func TestRecoverAccount(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockSessionRepo := new(MockSessionRepository)
+	mockHashService := new(MockHashService)
+	mockTokenService := new(MockTokenService)
+	txManager := &FakeTxManager{}
+
+	requestedAt := time.Now().Add(-time.Hour)
+	user := &entities.User{
+		ID:                  uuid.New().String(),
+		Email:               "test@example.com",
+		Password:            "hashed_password",
+		DeletionRequestedAt: &requestedAt,
+	}
+
+	mockUserRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	mockHashService.On("VerifyPassword", ctx, "hashed_password", "password").Return(true, nil)
+	mockUserRepo.On("CancelDeletion", ctx, user.ID).Return(nil)
+
+	sessionUseCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
+	useCase := use_cases.NewAccountUseCase(mockUserRepo, sessionUseCase, mockHashService, txManager, new(MockBlobStore), 7*24*time.Hour)
+
+	// Act
+	err := useCase.RecoverAccount(ctx, user.Email, "password")
+
+	// Assert
+	assert.NoError(t, err)
+	mockUserRepo.AssertExpectations(t)
+}
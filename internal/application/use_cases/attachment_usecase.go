@@ -0,0 +1,271 @@
+package use_cases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+const (
+	// maxAttachmentFilenameLength matches the size of the filename column
+	maxAttachmentFilenameLength = 255
+
+	// defaultAttachmentContentType is used when the client does not tell
+	// what the file is
+	defaultAttachmentContentType = "application/octet-stream"
+)
+
+type AttachmentUseCase struct {
+	attachmentRepo repositories.AttachmentRepository
+	blobStore      services.BlobStore
+	permissions    *NotePermissionService
+	maxSize        int64
+	userQuota      int64
+}
+
+// NewAttachmentUseCase creates the use case. Files larger than maxSize bytes
+// are rejected, and the attachments of the notes of a user may not take more
+// than userQuota bytes in total, 0 disabling the quota.
+func NewAttachmentUseCase(
+	attachmentRepo repositories.AttachmentRepository,
+	blobStore services.BlobStore,
+	permissions *NotePermissionService,
+	maxSize int64,
+	userQuota int64,
+) *AttachmentUseCase {
+	return &AttachmentUseCase{
+		attachmentRepo: attachmentRepo,
+		blobStore:      blobStore,
+		permissions:    permissions,
+		maxSize:        maxSize,
+		userQuota:      userQuota,
+	}
+}
+
+// MaxSize is the size in bytes of the largest file that can be uploaded
+func (uc *AttachmentUseCase) MaxSize() int64 {
+	return uc.maxSize
+}
+
+// UploadAttachment streams a file to the blob store and attaches it to the
+// note. The file counts against the storage quota of the note owner, even
+// when an editor uploads it. Concurrent uploads are checked against the
+// usage at the time they start, so together they may go over the quota by
+// the size of the files in flight.
+func (uc *AttachmentUseCase) UploadAttachment(ctx context.Context, noteID, userID, filename, contentType string, content io.Reader) (*entities.Attachment, error) {
+	note, err := uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the metadata
+	filename, err = cleanAttachmentFilename(filename)
+	if err != nil {
+		return nil, err
+	}
+	contentType = normalizeAttachmentContentType(contentType)
+
+	// Work out how much the file may take
+	limit := uc.maxSize
+	limitedByQuota := false
+	if uc.userQuota > 0 {
+		used, err := uc.attachmentRepo.GetUsageByUserID(ctx, note.UserID)
+		if err != nil {
+			return nil, err
+		}
+		remaining := uc.userQuota - used
+		if remaining <= 0 {
+			return nil, domainerrors.TooLarge("storage quota exceeded")
+		}
+		if remaining < limit {
+			limit = remaining
+			limitedByQuota = true
+		}
+	}
+
+	now := time.Now()
+	attachment := &entities.Attachment{
+		ID:          uuid.New().String(),
+		NoteID:      note.ID,
+		UserID:      userID,
+		Filename:    filename,
+		ContentType: contentType,
+		CreatedAt:   now,
+	}
+	attachment.StorageKey = note.ID + "/" + attachment.ID
+
+	// Hash and count the content while it streams to the store, reading one
+	// byte past the limit to notice larger files
+	counter := &countingReader{r: io.LimitReader(content, limit+1)}
+	hash := sha256.New()
+	if err := uc.blobStore.Put(ctx, attachment.StorageKey, io.TeeReader(counter, hash), contentType); err != nil {
+		return nil, err
+	}
+	attachment.Size = counter.n
+	attachment.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	switch {
+	case attachment.Size > limit && limitedByQuota:
+		err = domainerrors.TooLarge("storage quota exceeded")
+	case attachment.Size > limit:
+		err = domainerrors.TooLarge(fmt.Sprintf("file must not be larger than %d bytes", uc.maxSize))
+	case attachment.Size == 0:
+		err = domainerrors.InvalidField("file", "file must not be empty")
+	default:
+		err = uc.attachmentRepo.Create(ctx, attachment)
+	}
+	if err != nil {
+		deleteBlobs(ctx, uc.blobStore, []string{attachment.StorageKey})
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// ListAttachments returns the attachments of a note, oldest first
+func (uc *AttachmentUseCase) ListAttachments(ctx context.Context, noteID, userID string) ([]*entities.Attachment, error) {
+	if _, err := uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleViewer); err != nil {
+		return nil, err
+	}
+
+	return uc.attachmentRepo.GetByNoteID(ctx, noteID)
+}
+
+// GetAttachment returns the metadata of an attachment the user may read
+func (uc *AttachmentUseCase) GetAttachment(ctx context.Context, noteID, userID, attachmentID string) (*entities.Attachment, error) {
+	if _, err := uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleViewer); err != nil {
+		return nil, err
+	}
+
+	attachment, err := uc.attachmentRepo.GetByID(ctx, noteID, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if attachment == nil {
+		return nil, domainerrors.NotFound("attachment")
+	}
+
+	return attachment, nil
+}
+
+// OpenAttachment streams length bytes of the content of an attachment
+// returned by GetAttachment, starting at offset. A negative length reads up
+// to the end.
+func (uc *AttachmentUseCase) OpenAttachment(ctx context.Context, attachment *entities.Attachment, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 || offset > attachment.Size || length > attachment.Size-offset {
+		return nil, domainerrors.Validation("range is outside of the attachment")
+	}
+
+	content, err := uc.blobStore.Get(ctx, attachment.StorageKey, offset, length)
+	if err != nil {
+		if errors.Is(err, services.ErrBlobNotFound) {
+			return nil, fmt.Errorf("content of attachment %s is missing: %w", attachment.ID, err)
+		}
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// DeleteAttachment removes an attachment from the note along with its content
+func (uc *AttachmentUseCase) DeleteAttachment(ctx context.Context, noteID, userID, attachmentID string) error {
+	if _, err := uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleEditor); err != nil {
+		return err
+	}
+
+	attachment, err := uc.attachmentRepo.GetByID(ctx, noteID, attachmentID)
+	if err != nil {
+		return err
+	}
+	if attachment == nil {
+		return domainerrors.NotFound("attachment")
+	}
+
+	deleted, err := uc.attachmentRepo.Delete(ctx, noteID, attachmentID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return domainerrors.NotFound("attachment")
+	}
+
+	// The attachment is gone either way, a blob left behind only takes space
+	deleteBlobs(ctx, uc.blobStore, []string{attachment.StorageKey})
+
+	return nil
+}
+
+// deleteBlobs removes the blobs of attachments whose metadata was deleted.
+// Failures are logged rather than returned, as the attachments are already
+// gone for the user.
+func deleteBlobs(ctx context.Context, blobStore services.BlobStore, keys []string) {
+	for _, key := range keys {
+		if err := blobStore.Delete(ctx, key); err != nil {
+			log.Printf("error deleting blob %s: %v", key, err)
+		}
+	}
+}
+
+// cleanAttachmentFilename keeps the last element of the path some browsers
+// send along with the name of the file
+func cleanAttachmentFilename(filename string) (string, error) {
+	if i := strings.LastIndexAny(filename, `/\`); i >= 0 {
+		filename = filename[i+1:]
+	}
+	filename = strings.TrimSpace(filename)
+
+	switch {
+	case filename == "" || filename == "." || filename == "..":
+		return "", domainerrors.InvalidField("filename", "filename is required")
+	case !utf8.ValidString(filename) || strings.IndexFunc(filename, unicode.IsControl) >= 0:
+		return "", domainerrors.InvalidField("filename", "filename contains invalid characters")
+	case utf8.RuneCountInString(filename) > maxAttachmentFilenameLength:
+		return "", domainerrors.InvalidField("filename", fmt.Sprintf("filename must be at most %d characters long", maxAttachmentFilenameLength))
+	}
+
+	return filename, nil
+}
+
+// normalizeAttachmentContentType falls back to a generic binary type when
+// the content type is missing or malformed
+func normalizeAttachmentContentType(contentType string) string {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return defaultAttachmentContentType
+	}
+
+	contentType = mime.FormatMediaType(mediaType, params)
+	if contentType == "" || len(contentType) > 255 {
+		return defaultAttachmentContentType
+	}
+
+	return contentType
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
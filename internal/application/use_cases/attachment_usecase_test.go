@@ -0,0 +1,320 @@
+package use_cases_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+// MockAttachmentRepository mocks the AttachmentRepository interface
+type MockAttachmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockAttachmentRepository) Create(ctx context.Context, attachment *entities.Attachment) error {
+	args := m.Called(ctx, attachment)
+	return args.Error(0)
+}
+
+func (m *MockAttachmentRepository) GetByID(ctx context.Context, noteID, id string) (*entities.Attachment, error) {
+	args := m.Called(ctx, noteID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) GetByNoteID(ctx context.Context, noteID string) ([]*entities.Attachment, error) {
+	args := m.Called(ctx, noteID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) GetUsageByUserID(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) Delete(ctx context.Context, noteID, id string) (bool, error) {
+	args := m.Called(ctx, noteID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) DeleteAllByUserID(ctx context.Context, userID string) ([]string, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) DeleteTrashedByUserID(ctx context.Context, userID string) ([]string, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) DeleteTrashedBefore(ctx context.Context, cutoff time.Time) ([]string, error) {
+	args := m.Called(ctx, cutoff)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// MockBlobStore mocks the BlobStore interface. Put reads the whole content
+// and is matched against it as a string.
+type MockBlobStore struct {
+	mock.Mock
+}
+
+func (m *MockBlobStore) Put(ctx context.Context, key string, content io.Reader, contentType string) error {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	args := m.Called(ctx, key, string(data), contentType)
+	return args.Error(0)
+}
+
+func (m *MockBlobStore) Get(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	args := m.Called(ctx, key, offset, length)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+func (m *MockBlobStore) Delete(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func newTestAttachmentUseCase(mockAttachmentRepo *MockAttachmentRepository, mockBlobStore *MockBlobStore, mockNoteRepo *MockNoteRepository, mockShareRepo *MockNoteShareRepository) *use_cases.AttachmentUseCase {
+	return use_cases.NewAttachmentUseCase(mockAttachmentRepo, mockBlobStore, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo), 16, 64)
+}
+
+func TestUploadAttachment(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockBlobStore := new(MockBlobStore)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+
+	ownerID := uuid.New().String()
+	editorID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: ownerID}
+	checksum := sha256.Sum256([]byte("hello world"))
+
+	// An editor uploads to the storage of the owner
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockShareRepo.On("GetByNoteAndUser", ctx, note.ID, editorID).Return(&entities.NoteShare{Role: entities.NoteRoleEditor}, nil)
+	mockAttachmentRepo.On("GetUsageByUserID", ctx, ownerID).Return(int64(10), nil)
+	mockBlobStore.On("Put", ctx, mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, note.ID+"/")
+	}), "hello world", "text/plain; charset=utf-8").Return(nil)
+	mockAttachmentRepo.On("Create", ctx, mock.MatchedBy(func(attachment *entities.Attachment) bool {
+		return attachment.NoteID == note.ID &&
+			attachment.UserID == editorID &&
+			attachment.Filename == "hello.txt" &&
+			attachment.Size == 11 &&
+			attachment.Checksum == hex.EncodeToString(checksum[:])
+	})).Return(nil)
+
+	useCase := newTestAttachmentUseCase(mockAttachmentRepo, mockBlobStore, mockNoteRepo, mockShareRepo)
+
+	// Act
+	attachment, err := useCase.UploadAttachment(ctx, note.ID, editorID, `C:\Users\me\hello.txt`, "Text/Plain; Charset=utf-8", strings.NewReader("hello world"))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", attachment.ContentType)
+	mockBlobStore.AssertExpectations(t)
+	mockAttachmentRepo.AssertExpectations(t)
+	mockBlobStore.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestUploadAttachment_TooLarge(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockBlobStore := new(MockBlobStore)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+
+	userID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: userID}
+
+	// Only one byte past the limit is read
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockAttachmentRepo.On("GetUsageByUserID", ctx, userID).Return(int64(0), nil)
+	mockBlobStore.On("Put", ctx, mock.Anything, strings.Repeat("a", 17), "application/octet-stream").Return(nil)
+	mockBlobStore.On("Delete", ctx, mock.Anything).Return(nil)
+
+	useCase := newTestAttachmentUseCase(mockAttachmentRepo, mockBlobStore, mockNoteRepo, mockShareRepo)
+
+	// Act
+	_, err := useCase.UploadAttachment(ctx, note.ID, userID, "big.bin", "", strings.NewReader(strings.Repeat("a", 100)))
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrTooLarge)
+	assert.Contains(t, err.Error(), "16 bytes")
+	mockBlobStore.AssertExpectations(t)
+	mockAttachmentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUploadAttachment_QuotaExceeded(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockBlobStore := new(MockBlobStore)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+
+	userID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: userID}
+
+	// 60 of the 64 bytes are used, the file does not fit in the rest
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockAttachmentRepo.On("GetUsageByUserID", ctx, userID).Return(int64(60), nil)
+	mockBlobStore.On("Put", ctx, mock.Anything, "hello", "application/octet-stream").Return(nil)
+	mockBlobStore.On("Delete", ctx, mock.Anything).Return(nil)
+
+	useCase := newTestAttachmentUseCase(mockAttachmentRepo, mockBlobStore, mockNoteRepo, mockShareRepo)
+
+	// Act
+	_, err := useCase.UploadAttachment(ctx, note.ID, userID, "hello.bin", "", strings.NewReader("hello world"))
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrTooLarge)
+	assert.Contains(t, err.Error(), "quota")
+	mockBlobStore.AssertExpectations(t)
+	mockAttachmentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUploadAttachment_Viewer(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockBlobStore := new(MockBlobStore)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+
+	viewerID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: uuid.New().String()}
+
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockShareRepo.On("GetByNoteAndUser", ctx, note.ID, viewerID).Return(&entities.NoteShare{Role: entities.NoteRoleViewer}, nil)
+
+	useCase := newTestAttachmentUseCase(mockAttachmentRepo, mockBlobStore, mockNoteRepo, mockShareRepo)
+
+	// Act
+	_, err := useCase.UploadAttachment(ctx, note.ID, viewerID, "hello.txt", "text/plain", strings.NewReader("hello world"))
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrForbidden)
+	mockBlobStore.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUploadAttachment_CreateFails(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockBlobStore := new(MockBlobStore)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+
+	userID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: userID}
+
+	// The blob is removed when its metadata cannot be saved
+	var storedKey string
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockAttachmentRepo.On("GetUsageByUserID", ctx, userID).Return(int64(0), nil)
+	mockBlobStore.On("Put", ctx, mock.Anything, "hello", "text/plain").Run(func(args mock.Arguments) {
+		storedKey = args.String(1)
+	}).Return(nil)
+	mockAttachmentRepo.On("Create", ctx, mock.Anything).Return(assert.AnError)
+	mockBlobStore.On("Delete", ctx, mock.MatchedBy(func(key string) bool {
+		return key == storedKey
+	})).Return(nil)
+
+	useCase := newTestAttachmentUseCase(mockAttachmentRepo, mockBlobStore, mockNoteRepo, mockShareRepo)
+
+	// Act
+	_, err := useCase.UploadAttachment(ctx, note.ID, userID, "hello.txt", "text/plain", strings.NewReader("hello"))
+
+	// Assert
+	assert.ErrorIs(t, err, assert.AnError)
+	mockBlobStore.AssertExpectations(t)
+}
+
+func TestOpenAttachment_Range(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockBlobStore := new(MockBlobStore)
+
+	attachment := &entities.Attachment{ID: uuid.New().String(), Size: 11, StorageKey: "note/attachment"}
+
+	mockBlobStore.On("Get", ctx, "note/attachment", int64(6), int64(5)).Return(io.NopCloser(strings.NewReader("world")), nil)
+
+	useCase := newTestAttachmentUseCase(mockAttachmentRepo, mockBlobStore, new(MockNoteRepository), new(MockNoteShareRepository))
+
+	// Act
+	content, err := useCase.OpenAttachment(ctx, attachment, 6, 5)
+	_, outsideErr := useCase.OpenAttachment(ctx, attachment, 6, 6)
+
+	// Assert
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(content)
+	assert.Equal(t, "world", string(data))
+	assert.ErrorIs(t, outsideErr, domainerrors.ErrValidation)
+	mockBlobStore.AssertExpectations(t)
+}
+
+func TestDeleteAttachment(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockBlobStore := new(MockBlobStore)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+
+	userID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: userID}
+	attachment := &entities.Attachment{ID: uuid.New().String(), NoteID: note.ID, StorageKey: note.ID + "/blob"}
+
+	// A blob that cannot be deleted does not bring the attachment back
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockAttachmentRepo.On("GetByID", ctx, note.ID, attachment.ID).Return(attachment, nil)
+	mockAttachmentRepo.On("Delete", ctx, note.ID, attachment.ID).Return(true, nil)
+	mockBlobStore.On("Delete", ctx, attachment.StorageKey).Return(assert.AnError)
+
+	useCase := newTestAttachmentUseCase(mockAttachmentRepo, mockBlobStore, mockNoteRepo, mockShareRepo)
+
+	// Act
+	err := useCase.DeleteAttachment(ctx, note.ID, userID, attachment.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	mockAttachmentRepo.AssertExpectations(t)
+	mockBlobStore.AssertExpectations(t)
+}
@@ -0,0 +1,74 @@
+package use_cases
+
+import (
+	"strings"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+// checklistIndentWidth is the number of spaces a nesting level takes when a
+// checklist is written out as text, a tab counts as one level
+const checklistIndentWidth = 2
+
+// parseChecklist turns the lines of a text note into checklist items. Blank
+// lines are dropped, leading whitespace gives the nesting level, and list
+// bullets and Markdown task boxes are stripped. A ticked box ("- [x]") marks
+// the item as checked.
+func parseChecklist(content string) []*entities.ChecklistItem {
+	var items []*entities.ChecklistItem
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+
+		// Measure the leading whitespace
+		width := 0
+		for _, r := range line {
+			if r == ' ' {
+				width++
+			} else if r == '\t' {
+				width += checklistIndentWidth
+			} else {
+				break
+			}
+		}
+		text := strings.TrimSpace(line)
+
+		// Strip the bullet, then the task box
+		for _, bullet := range []string{"- ", "* ", "+ "} {
+			if strings.HasPrefix(text, bullet) {
+				text = strings.TrimSpace(text[len(bullet):])
+				break
+			}
+		}
+		checked := false
+		if len(text) >= 3 && text[0] == '[' && text[2] == ']' && strings.ContainsRune(" xX", rune(text[1])) {
+			checked = text[1] != ' '
+			text = strings.TrimSpace(text[3:])
+		}
+
+		if text == "" {
+			continue
+		}
+		items = append(items, &entities.ChecklistItem{
+			Text:      text,
+			IsChecked: checked,
+			Indent:    min(width/checklistIndentWidth, entities.MaxChecklistIndent),
+		})
+	}
+
+	return items
+}
+
+// renderChecklist writes checklist items out as a Markdown task list, which
+// parseChecklist reads back into the same items
+func renderChecklist(items []*entities.ChecklistItem) string {
+	lines := make([]string, len(items))
+	for i, item := range items {
+		box := "[ ]"
+		if item.IsChecked {
+			box = "[x]"
+		}
+		lines[i] = strings.Repeat(" ", item.Indent*checklistIndentWidth) + "- " + box + " " + item.Text
+	}
+
+	return strings.Join(lines, "\n")
+}
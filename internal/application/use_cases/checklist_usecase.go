@@ -0,0 +1,303 @@
+package use_cases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type ChecklistUseCase struct {
+	itemRepo    repositories.ChecklistItemRepository
+	txManager   repositories.TxManager
+	permissions *NotePermissionService
+}
+
+func NewChecklistUseCase(
+	itemRepo repositories.ChecklistItemRepository,
+	txManager repositories.TxManager,
+	permissions *NotePermissionService,
+) *ChecklistUseCase {
+	return &ChecklistUseCase{
+		itemRepo:    itemRepo,
+		txManager:   txManager,
+		permissions: permissions,
+	}
+}
+
+// ChecklistItemChanges lists the fields of an item to change, nil fields are
+// left as they are
+type ChecklistItemChanges struct {
+	Text      *string
+	IsChecked *bool
+	Indent    *int
+}
+
+// ListItems returns the items of a checklist note in order
+func (uc *ChecklistUseCase) ListItems(ctx context.Context, noteID, userID string) ([]*entities.ChecklistItem, error) {
+	if _, err := uc.authorizeChecklist(ctx, noteID, userID, entities.NoteRoleViewer); err != nil {
+		return nil, err
+	}
+
+	return uc.itemRepo.GetByNoteID(ctx, noteID)
+}
+
+// AddItem adds an item at the bottom of a checklist note
+func (uc *ChecklistUseCase) AddItem(ctx context.Context, noteID, userID, text string, indent int) (*entities.ChecklistItem, error) {
+	// Validate the item
+	text = strings.TrimSpace(text)
+	if err := validateChecklistItem(text, indent); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	item := &entities.ChecklistItem{
+		ID:        uuid.New().String(),
+		NoteID:    noteID,
+		Text:      text,
+		Indent:    indent,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err := uc.changeChecklist(ctx, noteID, userID, func(ctx context.Context, items repositories.ChecklistItemRepository) error {
+		return items.Create(ctx, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// UpdateItem changes the text, checked state or nesting level of an item
+func (uc *ChecklistUseCase) UpdateItem(ctx context.Context, noteID, userID, itemID string, changes ChecklistItemChanges) (*entities.ChecklistItem, error) {
+	var item *entities.ChecklistItem
+
+	err := uc.changeChecklist(ctx, noteID, userID, func(ctx context.Context, items repositories.ChecklistItemRepository) error {
+		// Get the item
+		var err error
+		item, err = items.GetByID(ctx, noteID, itemID)
+		if err != nil {
+			return err
+		}
+		if item == nil {
+			return domainerrors.NotFound("checklist item")
+		}
+
+		// Apply the changes
+		if changes.Text != nil {
+			item.Text = strings.TrimSpace(*changes.Text)
+		}
+		if changes.IsChecked != nil {
+			item.IsChecked = *changes.IsChecked
+		}
+		if changes.Indent != nil {
+			item.Indent = *changes.Indent
+		}
+		if err := validateChecklistItem(item.Text, item.Indent); err != nil {
+			return err
+		}
+		item.UpdatedAt = time.Now()
+
+		return items.Update(ctx, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// DeleteItem deletes an item of a checklist note
+func (uc *ChecklistUseCase) DeleteItem(ctx context.Context, noteID, userID, itemID string) error {
+	return uc.changeChecklist(ctx, noteID, userID, func(ctx context.Context, items repositories.ChecklistItemRepository) error {
+		deleted, err := items.Delete(ctx, noteID, itemID)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return domainerrors.NotFound("checklist item")
+		}
+
+		return nil
+	})
+}
+
+// MoveItem places an item right before or right after another item of the
+// same checklist. Only the moved item is written, halfway between its new
+// neighbors, unless they are too close to fit it between them.
+func (uc *ChecklistUseCase) MoveItem(ctx context.Context, noteID, userID, itemID, targetID string, after bool) (*entities.ChecklistItem, error) {
+	if itemID == targetID {
+		return nil, domainerrors.InvalidField("target_id", "cannot move an item next to itself")
+	}
+
+	var item *entities.ChecklistItem
+
+	err := uc.changeChecklist(ctx, noteID, userID, func(ctx context.Context, items repositories.ChecklistItemRepository) error {
+		// Get the item and the target
+		var err error
+		item, err = items.GetByID(ctx, noteID, itemID)
+		if err != nil {
+			return err
+		}
+		if item == nil {
+			return domainerrors.NotFound("checklist item")
+		}
+		target, err := items.GetByID(ctx, noteID, targetID)
+		if err != nil {
+			return err
+		}
+		if target == nil {
+			return domainerrors.InvalidField("target_id", "checklist item not found")
+		}
+
+		position, fits, err := itemPositionNextTo(ctx, items, target, after, item.ID)
+		if err != nil {
+			return err
+		}
+		if !fits {
+			// Spread the items again to make room, then read the target again
+			if err := items.RebalancePositions(ctx, noteID); err != nil {
+				return err
+			}
+			target, err = items.GetByID(ctx, noteID, targetID)
+			if err != nil {
+				return err
+			}
+			if target == nil {
+				return domainerrors.NotFound("checklist item")
+			}
+			position, fits, err = itemPositionNextTo(ctx, items, target, after, item.ID)
+			if err != nil {
+				return err
+			}
+			if !fits {
+				return errors.New("no room left to move the checklist item")
+			}
+		}
+
+		// Save the new position
+		if err := items.UpdatePosition(ctx, item.ID, position); err != nil {
+			return err
+		}
+		item.Position = position
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// itemPositionNextTo returns a position right before or right after the
+// target item, ignoring the item being moved. It reports false when the
+// neighbor of the target is too close for a position to fit between them.
+func itemPositionNextTo(ctx context.Context, items repositories.ChecklistItemRepository, target *entities.ChecklistItem, after bool, movedID string) (float64, bool, error) {
+	neighbor, err := items.GetAdjacentPosition(ctx, target, after, movedID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	position, fits := positionBetween(target.Position, neighbor, after)
+	return position, fits, nil
+}
+
+// MoveCheckedToBottom lists the checked items of a checklist after the
+// unchecked ones, and returns the items in their new order
+func (uc *ChecklistUseCase) MoveCheckedToBottom(ctx context.Context, noteID, userID string) ([]*entities.ChecklistItem, error) {
+	var result []*entities.ChecklistItem
+
+	err := uc.changeChecklist(ctx, noteID, userID, func(ctx context.Context, items repositories.ChecklistItemRepository) error {
+		if err := items.MoveCheckedToBottom(ctx, noteID); err != nil {
+			return err
+		}
+
+		var err error
+		result, err = items.GetByNoteID(ctx, noteID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UncheckAll unchecks every item of a checklist so it can be used again, and
+// returns the items
+func (uc *ChecklistUseCase) UncheckAll(ctx context.Context, noteID, userID string) ([]*entities.ChecklistItem, error) {
+	var result []*entities.ChecklistItem
+
+	err := uc.changeChecklist(ctx, noteID, userID, func(ctx context.Context, items repositories.ChecklistItemRepository) error {
+		if _, err := items.UncheckAll(ctx, noteID, time.Now()); err != nil {
+			return err
+		}
+
+		var err error
+		result, err = items.GetByNoteID(ctx, noteID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// authorizeChecklist returns the note if it is a checklist the user holds at
+// least the given role on
+func (uc *ChecklistUseCase) authorizeChecklist(ctx context.Context, noteID, userID string, role entities.NoteRole) (*entities.Note, error) {
+	note, err := uc.permissions.Authorize(ctx, noteID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if note.Type != entities.NoteTypeChecklist {
+		return nil, domainerrors.Validation("note is not a checklist")
+	}
+
+	return note, nil
+}
+
+// changeChecklist runs fn on the items of a checklist note the user may edit,
+// marking the note as updated in the same transaction
+func (uc *ChecklistUseCase) changeChecklist(ctx context.Context, noteID, userID string, fn func(ctx context.Context, items repositories.ChecklistItemRepository) error) error {
+	note, err := uc.authorizeChecklist(ctx, noteID, userID, entities.NoteRoleEditor)
+	if err != nil {
+		return err
+	}
+
+	return uc.txManager.WithinTx(ctx, func(ctx context.Context, repos repositories.TxRepositories) error {
+		if err := fn(ctx, repos.Checklists); err != nil {
+			return err
+		}
+
+		return repos.Notes.Touch(ctx, note.ID, time.Now())
+	})
+}
+
+func validateChecklistItem(text string, indent int) error {
+	var fields []domainerrors.FieldError
+	if text == "" {
+		fields = append(fields, domainerrors.FieldError{Field: "text", Message: "is required"})
+	} else if strings.ContainsAny(text, "\r\n") {
+		fields = append(fields, domainerrors.FieldError{Field: "text", Message: "must be a single line"})
+	}
+	if indent < 0 || indent > entities.MaxChecklistIndent {
+		fields = append(fields, domainerrors.FieldError{Field: "indent", Message: fmt.Sprintf("must be between 0 and %d", entities.MaxChecklistIndent)})
+	}
+	if len(fields) > 0 {
+		return domainerrors.Validation("invalid checklist item", fields...)
+	}
+
+	return nil
+}
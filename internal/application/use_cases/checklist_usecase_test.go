@@ -0,0 +1,274 @@
+package use_cases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+// MockChecklistItemRepository mocks the ChecklistItemRepository interface
+type MockChecklistItemRepository struct {
+	mock.Mock
+}
+
+func (m *MockChecklistItemRepository) Create(ctx context.Context, item *entities.ChecklistItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockChecklistItemRepository) GetByID(ctx context.Context, noteID, id string) (*entities.ChecklistItem, error) {
+	args := m.Called(ctx, noteID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ChecklistItem), args.Error(1)
+}
+
+func (m *MockChecklistItemRepository) GetByNoteID(ctx context.Context, noteID string) ([]*entities.ChecklistItem, error) {
+	args := m.Called(ctx, noteID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.ChecklistItem), args.Error(1)
+}
+
+func (m *MockChecklistItemRepository) Update(ctx context.Context, item *entities.ChecklistItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockChecklistItemRepository) GetAdjacentPosition(ctx context.Context, item *entities.ChecklistItem, after bool, excludeID string) (*float64, error) {
+	args := m.Called(ctx, item, after, excludeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*float64), args.Error(1)
+}
+
+func (m *MockChecklistItemRepository) UpdatePosition(ctx context.Context, id string, position float64) error {
+	args := m.Called(ctx, id, position)
+	return args.Error(0)
+}
+
+func (m *MockChecklistItemRepository) RebalancePositions(ctx context.Context, noteID string) error {
+	args := m.Called(ctx, noteID)
+	return args.Error(0)
+}
+
+func (m *MockChecklistItemRepository) MoveCheckedToBottom(ctx context.Context, noteID string) error {
+	args := m.Called(ctx, noteID)
+	return args.Error(0)
+}
+
+func (m *MockChecklistItemRepository) UncheckAll(ctx context.Context, noteID string, at time.Time) (int64, error) {
+	args := m.Called(ctx, noteID, at)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockChecklistItemRepository) Delete(ctx context.Context, noteID, id string) (bool, error) {
+	args := m.Called(ctx, noteID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockChecklistItemRepository) DeleteByNoteID(ctx context.Context, noteID string) error {
+	args := m.Called(ctx, noteID)
+	return args.Error(0)
+}
+
+// newTestChecklistUseCase reads and writes the items through the checklist
+// repository of the transaction manager
+func newTestChecklistUseCase(mockNoteRepo *MockNoteRepository, mockShareRepo *MockNoteShareRepository) (*use_cases.ChecklistUseCase, *FakeTxManager) {
+	txManager := NewFakeTxManager(mockNoteRepo, new(MockLabelRepository), new(MockNoteRevisionRepository))
+	return use_cases.NewChecklistUseCase(txManager.Checklists, txManager, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo)), txManager
+}
+
+func TestAddChecklistItem(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+
+	userID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: userID, Type: entities.NoteTypeChecklist}
+
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockNoteRepo.On("Touch", ctx, note.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	useCase, txManager := newTestChecklistUseCase(mockNoteRepo, mockShareRepo)
+	txManager.Checklists.On("Create", ctx, mock.MatchedBy(func(item *entities.ChecklistItem) bool {
+		return item.NoteID == note.ID &&
+			item.Text == "Buy milk" &&
+			item.Indent == 1 &&
+			!item.IsChecked
+	})).Return(nil)
+
+	// Act
+	item, err := useCase.AddItem(ctx, note.ID, userID, "  Buy milk ", 1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Buy milk", item.Text)
+	assert.Equal(t, 1, txManager.Commits)
+	mockNoteRepo.AssertExpectations(t)
+	txManager.Checklists.AssertExpectations(t)
+}
+
+func TestAddChecklistItem_TextNote(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+
+	userID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: userID, Type: entities.NoteTypeText}
+
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+
+	useCase, txManager := newTestChecklistUseCase(mockNoteRepo, mockShareRepo)
+
+	// Act
+	_, err := useCase.AddItem(ctx, note.ID, userID, "Buy milk", 0)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrValidation)
+	txManager.Checklists.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAddChecklistItem_Invalid(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+
+	useCase, txManager := newTestChecklistUseCase(mockNoteRepo, mockShareRepo)
+
+	// Act
+	_, err := useCase.AddItem(ctx, uuid.New().String(), uuid.New().String(), "Two\nlines", entities.MaxChecklistIndent+1)
+
+	// Assert
+	var validationErr *domainerrors.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Len(t, validationErr.Fields, 2)
+	txManager.Checklists.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUpdateChecklistItem(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+
+	editorID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: uuid.New().String(), Type: entities.NoteTypeChecklist}
+	item := &entities.ChecklistItem{ID: uuid.New().String(), NoteID: note.ID, Text: "Buy milk", Indent: 1}
+	checked := true
+
+	// Editors of a shared checklist may tick its items
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockShareRepo.On("GetByNoteAndUser", ctx, note.ID, editorID).Return(&entities.NoteShare{Role: entities.NoteRoleEditor}, nil)
+	mockNoteRepo.On("Touch", ctx, note.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	useCase, txManager := newTestChecklistUseCase(mockNoteRepo, mockShareRepo)
+	txManager.Checklists.On("GetByID", ctx, note.ID, item.ID).Return(item, nil)
+	txManager.Checklists.On("Update", ctx, mock.MatchedBy(func(updated *entities.ChecklistItem) bool {
+		return updated.IsChecked && updated.Text == "Buy milk" && updated.Indent == 1
+	})).Return(nil)
+
+	// Act
+	updated, err := useCase.UpdateItem(ctx, note.ID, editorID, item.ID, use_cases.ChecklistItemChanges{IsChecked: &checked})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, updated.IsChecked)
+	txManager.Checklists.AssertExpectations(t)
+}
+
+func TestUpdateChecklistItem_Viewer(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+
+	viewerID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: uuid.New().String(), Type: entities.NoteTypeChecklist}
+	checked := true
+
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockShareRepo.On("GetByNoteAndUser", ctx, note.ID, viewerID).Return(&entities.NoteShare{Role: entities.NoteRoleViewer}, nil)
+
+	useCase, txManager := newTestChecklistUseCase(mockNoteRepo, mockShareRepo)
+
+	// Act
+	_, err := useCase.UpdateItem(ctx, note.ID, viewerID, uuid.New().String(), use_cases.ChecklistItemChanges{IsChecked: &checked})
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrForbidden)
+	txManager.Checklists.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestMoveChecklistItem(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+
+	userID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: userID, Type: entities.NoteTypeChecklist}
+	item := &entities.ChecklistItem{ID: uuid.New().String(), NoteID: note.ID, Position: 3072}
+	target := &entities.ChecklistItem{ID: uuid.New().String(), NoteID: note.ID, Position: 1024}
+
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockNoteRepo.On("Touch", ctx, note.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	// The target is the first item, so the moved item steps past it
+	useCase, txManager := newTestChecklistUseCase(mockNoteRepo, mockShareRepo)
+	txManager.Checklists.On("GetByID", ctx, note.ID, item.ID).Return(item, nil)
+	txManager.Checklists.On("GetByID", ctx, note.ID, target.ID).Return(target, nil)
+	txManager.Checklists.On("GetAdjacentPosition", ctx, target, false, item.ID).Return(nil, nil)
+	txManager.Checklists.On("UpdatePosition", ctx, item.ID, float64(0)).Return(nil)
+
+	// Act
+	moved, err := useCase.MoveItem(ctx, note.ID, userID, item.ID, target.ID, false)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, float64(0), moved.Position)
+	txManager.Checklists.AssertExpectations(t)
+}
+
+func TestUncheckAllChecklistItems(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+
+	userID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: userID, Type: entities.NoteTypeChecklist}
+	items := []*entities.ChecklistItem{
+		{ID: uuid.New().String(), NoteID: note.ID, Text: "Eggs"},
+		{ID: uuid.New().String(), NoteID: note.ID, Text: "Flour"},
+	}
+
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockNoteRepo.On("Touch", ctx, note.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	useCase, txManager := newTestChecklistUseCase(mockNoteRepo, mockShareRepo)
+	txManager.Checklists.On("UncheckAll", ctx, note.ID, mock.AnythingOfType("time.Time")).Return(int64(1), nil)
+	txManager.Checklists.On("GetByNoteID", ctx, note.ID).Return(items, nil)
+
+	// Act
+	result, err := useCase.UncheckAll(ctx, note.ID, userID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, items, result)
+	txManager.Checklists.AssertExpectations(t)
+}
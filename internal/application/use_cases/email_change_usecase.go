@@ -0,0 +1,174 @@
+package use_cases
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type EmailChangeUseCase struct {
+	userRepo        repositories.UserRepository
+	emailChangeRepo repositories.EmailChangeRepository
+	tokenService    services.TokenService
+	hashService     services.HashService
+	mailer          services.Mailer
+	baseURL         string
+	tokenTTL        time.Duration
+}
+
+func NewEmailChangeUseCase(
+	userRepo repositories.UserRepository,
+	emailChangeRepo repositories.EmailChangeRepository,
+	tokenService services.TokenService,
+	hashService services.HashService,
+	mailer services.Mailer,
+	baseURL string,
+	tokenTTL time.Duration,
+) *EmailChangeUseCase {
+	return &EmailChangeUseCase{
+		userRepo:        userRepo,
+		emailChangeRepo: emailChangeRepo,
+		tokenService:    tokenService,
+		hashService:     hashService,
+		mailer:          mailer,
+		baseURL:         strings.TrimRight(baseURL, "/"),
+		tokenTTL:        tokenTTL,
+	}
+}
+
+// RequestEmailChange emails a confirmation link to the new address. The
+// account keeps its current address until the link is followed.
+func (uc *EmailChangeUseCase) RequestEmailChange(ctx context.Context, userID, password, newEmail string) error {
+	// Get the user
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return domainerrors.NotFound("user")
+	}
+
+	// Verify the password
+	valid, err := uc.hashService.VerifyPassword(ctx, user.Password, password)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return incorrectPassword("password is incorrect", "password")
+	}
+
+	// Check the new address
+	if strings.EqualFold(newEmail, user.Email) {
+		return domainerrors.Validation("new email must differ from the current one", domainerrors.FieldError{Field: "email", Message: "must differ from the current email"})
+	}
+	existingUser, err := uc.userRepo.GetByEmail(ctx, newEmail)
+	if err != nil {
+		return err
+	}
+	if existingUser != nil {
+		return domainerrors.Conflict("user", "email already taken")
+	}
+
+	// Generate the token, only its hash is stored
+	token, err := uc.tokenService.GenerateToken(ctx)
+	if err != nil {
+		return err
+	}
+	requestID, err := uc.tokenService.HashToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	request := &entities.EmailChangeRequest{
+		ID:        requestID,
+		UserID:    user.ID,
+		NewEmail:  newEmail,
+		ExpiresAt: now.Add(uc.tokenTTL),
+		CreatedAt: now,
+	}
+
+	// Save the request
+	if err := uc.emailChangeRepo.Create(ctx, request); err != nil {
+		return err
+	}
+
+	// Send the confirmation link to the new address
+	link := fmt.Sprintf("%s/confirm-email?token=%s", uc.baseURL, url.QueryEscape(token))
+	return uc.mailer.Send(ctx, services.MailMessage{
+		To:      newEmail,
+		Subject: "Confirm your new Note Nest email address",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nConfirm that your Note Nest account should now use this address by following this link within %s:\n\n%s\n\n"+
+				"If you did not ask for this, you can ignore this email.\n",
+			user.Name, uc.tokenTTL, link,
+		),
+	})
+}
+
+// ConfirmEmailChange switches the account to the confirmed address and lets
+// the previous address know about it. The token can only be used once.
+func (uc *EmailChangeUseCase) ConfirmEmailChange(ctx context.Context, token string) error {
+	invalidToken := domainerrors.InvalidField("token", "invalid or expired confirmation token")
+
+	requestID, err := uc.tokenService.HashToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	// Get the request
+	request, err := uc.emailChangeRepo.GetByID(ctx, requestID)
+	if err != nil {
+		return err
+	}
+	if request == nil || request.UsedAt != nil || time.Now().After(request.ExpiresAt) {
+		return invalidToken
+	}
+
+	// Consume the request, failing if a concurrent request used it first
+	consumed, err := uc.emailChangeRepo.MarkUsed(ctx, requestID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return invalidToken
+	}
+
+	// Verify the user exists
+	user, err := uc.userRepo.GetByID(ctx, request.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return invalidToken
+	}
+
+	// Switch the address, which may have been taken in the meantime
+	previousEmail := user.Email
+	if err := uc.userRepo.UpdateEmail(ctx, user.ID, request.NewEmail); err != nil {
+		return err
+	}
+
+	// Other pending changes are no longer needed
+	if err := uc.emailChangeRepo.DeleteAllByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+
+	// Let the previous address know in case the change was not wanted
+	return uc.mailer.Send(ctx, services.MailMessage{
+		To:      previousEmail,
+		Subject: "Your Note Nest email address was changed",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nYour Note Nest account now uses %s instead of this address.\n\n"+
+				"If you did not make this change, reset your password and contact us.\n",
+			user.Name, request.NewEmail,
+		),
+	})
+}
@@ -0,0 +1,172 @@
+package use_cases_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+// MockEmailChangeRepository mocks the EmailChangeRepository interface
+type MockEmailChangeRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmailChangeRepository) Create(ctx context.Context, request *entities.EmailChangeRequest) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *MockEmailChangeRepository) GetByID(ctx context.Context, id string) (*entities.EmailChangeRequest, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.EmailChangeRequest), args.Error(1)
+}
+
+func (m *MockEmailChangeRepository) MarkUsed(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockEmailChangeRepository) DeleteAllByUserID(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func TestRequestEmailChange(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockEmailChangeRepo := new(MockEmailChangeRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+	mockMailer := new(MockMailer)
+
+	user := &entities.User{ID: uuid.New().String(), Email: "old@example.com", Name: "Test User", Password: "hash"}
+
+	mockUserRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockHashService.On("VerifyPassword", ctx, "hash", "P@ssw0rd!1234").Return(true, nil)
+	mockUserRepo.On("GetByEmail", ctx, "new@example.com").Return(nil, nil)
+	mockTokenService.On("GenerateToken", ctx).Return("changetoken", nil)
+	mockTokenService.On("HashToken", ctx, "changetoken").Return("hashedtoken", nil)
+	mockEmailChangeRepo.On("Create", ctx, mock.MatchedBy(func(request *entities.EmailChangeRequest) bool {
+		return request.ID == "hashedtoken" && request.UserID == user.ID && request.NewEmail == "new@example.com"
+	})).Return(nil)
+
+	// The link goes to the new address, the account is not changed yet
+	mockMailer.On("Send", ctx, mock.MatchedBy(func(message services.MailMessage) bool {
+		return message.To == "new@example.com" && strings.Contains(message.Body, "https://notes.example.com/confirm-email?token=changetoken")
+	})).Return(nil)
+
+	useCase := use_cases.NewEmailChangeUseCase(mockUserRepo, mockEmailChangeRepo, mockTokenService, mockHashService, mockMailer, "https://notes.example.com", time.Hour)
+
+	// Act
+	err := useCase.RequestEmailChange(ctx, user.ID, "P@ssw0rd!1234", "new@example.com")
+
+	// Assert
+	assert.NoError(t, err)
+	mockEmailChangeRepo.AssertExpectations(t)
+	mockMailer.AssertExpectations(t)
+	mockUserRepo.AssertNotCalled(t, "UpdateEmail")
+}
+
+func TestRequestEmailChange_WrongPassword(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockEmailChangeRepo := new(MockEmailChangeRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+	mockMailer := new(MockMailer)
+
+	user := &entities.User{ID: uuid.New().String(), Email: "old@example.com", Password: "hash"}
+
+	mockUserRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockHashService.On("VerifyPassword", ctx, "hash", "wrong").Return(false, nil)
+
+	useCase := use_cases.NewEmailChangeUseCase(mockUserRepo, mockEmailChangeRepo, mockTokenService, mockHashService, mockMailer, "https://notes.example.com", time.Hour)
+
+	// Act
+	err := useCase.RequestEmailChange(ctx, user.ID, "wrong", "new@example.com")
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrValidation)
+	assert.ErrorIs(t, err, use_cases.ErrIncorrectPassword)
+	mockUserRepo.AssertNotCalled(t, "GetByEmail")
+	mockEmailChangeRepo.AssertNotCalled(t, "Create")
+	mockMailer.AssertNotCalled(t, "Send")
+}
+
+func TestRequestEmailChange_EmailTaken(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockEmailChangeRepo := new(MockEmailChangeRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+	mockMailer := new(MockMailer)
+
+	user := &entities.User{ID: uuid.New().String(), Email: "old@example.com", Password: "hash"}
+	other := &entities.User{ID: uuid.New().String(), Email: "taken@example.com"}
+
+	mockUserRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockHashService.On("VerifyPassword", ctx, "hash", "P@ssw0rd!1234").Return(true, nil)
+	mockUserRepo.On("GetByEmail", ctx, "taken@example.com").Return(other, nil)
+
+	useCase := use_cases.NewEmailChangeUseCase(mockUserRepo, mockEmailChangeRepo, mockTokenService, mockHashService, mockMailer, "https://notes.example.com", time.Hour)
+
+	// Act
+	err := useCase.RequestEmailChange(ctx, user.ID, "P@ssw0rd!1234", "taken@example.com")
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrConflict)
+	mockEmailChangeRepo.AssertNotCalled(t, "Create")
+	mockMailer.AssertNotCalled(t, "Send")
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockEmailChangeRepo := new(MockEmailChangeRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+	mockMailer := new(MockMailer)
+
+	user := &entities.User{ID: uuid.New().String(), Email: "old@example.com", Name: "Test User"}
+	request := &entities.EmailChangeRequest{ID: "hashedtoken", UserID: user.ID, NewEmail: "new@example.com", ExpiresAt: time.Now().Add(time.Hour)}
+
+	mockTokenService.On("HashToken", ctx, "changetoken").Return("hashedtoken", nil)
+	mockEmailChangeRepo.On("GetByID", ctx, "hashedtoken").Return(request, nil)
+	mockEmailChangeRepo.On("MarkUsed", ctx, "hashedtoken").Return(true, nil)
+	mockUserRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockUserRepo.On("UpdateEmail", ctx, user.ID, "new@example.com").Return(nil)
+	mockEmailChangeRepo.On("DeleteAllByUserID", ctx, user.ID).Return(nil)
+
+	// The previous address is told about the change
+	mockMailer.On("Send", ctx, mock.MatchedBy(func(message services.MailMessage) bool {
+		return message.To == "old@example.com" && strings.Contains(message.Body, "new@example.com")
+	})).Return(nil)
+
+	useCase := use_cases.NewEmailChangeUseCase(mockUserRepo, mockEmailChangeRepo, mockTokenService, mockHashService, mockMailer, "https://notes.example.com", time.Hour)
+
+	// Act
+	err := useCase.ConfirmEmailChange(ctx, "changetoken")
+
+	// Assert
+	assert.NoError(t, err)
+	mockUserRepo.AssertExpectations(t)
+	mockEmailChangeRepo.AssertExpectations(t)
+	mockMailer.AssertExpectations(t)
+}
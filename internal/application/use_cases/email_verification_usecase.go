@@ -0,0 +1,186 @@
+package use_cases
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+// EmailVerificationPolicy decides what unverified users are allowed to do
+type EmailVerificationPolicy string
+
+const (
+	// EmailVerificationOptional sends verification emails without enforcing them
+	EmailVerificationOptional EmailVerificationPolicy = "optional"
+	// EmailVerificationRestrict lets unverified users sign in but withholds the
+	// features that send mail on their behalf, such as password resets
+	EmailVerificationRestrict EmailVerificationPolicy = "restrict"
+	// EmailVerificationBlockLogin refuses to sign in unverified users
+	EmailVerificationBlockLogin EmailVerificationPolicy = "block_login"
+)
+
+var errEmailNotVerified = domainerrors.Forbidden("email address not verified")
+
+type EmailVerificationUseCase struct {
+	userRepo         repositories.UserRepository
+	verificationRepo repositories.EmailVerificationRepository
+	tokenService     services.TokenService
+	mailer           services.Mailer
+	policy           EmailVerificationPolicy
+	baseURL          string
+	tokenTTL         time.Duration
+	resendInterval   time.Duration
+}
+
+func NewEmailVerificationUseCase(
+	userRepo repositories.UserRepository,
+	verificationRepo repositories.EmailVerificationRepository,
+	tokenService services.TokenService,
+	mailer services.Mailer,
+	policy EmailVerificationPolicy,
+	baseURL string,
+	tokenTTL time.Duration,
+	resendInterval time.Duration,
+) *EmailVerificationUseCase {
+	return &EmailVerificationUseCase{
+		userRepo:         userRepo,
+		verificationRepo: verificationRepo,
+		tokenService:     tokenService,
+		mailer:           mailer,
+		policy:           policy,
+		baseURL:          strings.TrimRight(baseURL, "/"),
+		tokenTTL:         tokenTTL,
+		resendInterval:   resendInterval,
+	}
+}
+
+// SendVerification emails a verification link to the user. Nothing is sent
+// when the address is already verified.
+func (uc *EmailVerificationUseCase) SendVerification(ctx context.Context, user *entities.User) error {
+	if user.EmailVerified() {
+		return nil
+	}
+
+	// Generate the token, only its hash is stored
+	token, err := uc.tokenService.GenerateToken(ctx)
+	if err != nil {
+		return err
+	}
+	tokenID, err := uc.tokenService.HashToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	verificationToken := &entities.EmailVerificationToken{
+		ID:        tokenID,
+		UserID:    user.ID,
+		ExpiresAt: now.Add(uc.tokenTTL),
+		CreatedAt: now,
+	}
+
+	// Save the token
+	if err := uc.verificationRepo.Create(ctx, verificationToken); err != nil {
+		return err
+	}
+
+	// Send the verification link
+	link := fmt.Sprintf("%s/verify-email?token=%s", uc.baseURL, url.QueryEscape(token))
+	return uc.mailer.Send(ctx, services.MailMessage{
+		To:      user.Email,
+		Subject: "Verify your Note Nest email address",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nPlease confirm this is your email address by following this link within %s:\n\n%s\n\n"+
+				"If you did not create a Note Nest account, you can ignore this email.\n",
+			user.Name, uc.tokenTTL, link,
+		),
+	})
+}
+
+// ResendVerification sends a new verification link to the owner of the
+// address. Unknown and already verified addresses are silently ignored, and
+// so are requests made within the resend interval of the previous link, so
+// the answer never reveals which addresses have an account.
+func (uc *EmailVerificationUseCase) ResendVerification(ctx context.Context, email string) error {
+	// Get the user by email
+	user, err := uc.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.EmailVerified() {
+		return nil
+	}
+
+	// Throttle resends based on the last token issued
+	latest, err := uc.verificationRepo.GetLatestByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if latest != nil && time.Since(latest.CreatedAt) < uc.resendInterval {
+		return nil
+	}
+
+	return uc.SendVerification(ctx, user)
+}
+
+// VerifyEmail marks the address of the token's owner as verified. The token
+// can only be used once.
+func (uc *EmailVerificationUseCase) VerifyEmail(ctx context.Context, token string) error {
+	invalidToken := domainerrors.InvalidField("token", "invalid or expired verification token")
+
+	tokenID, err := uc.tokenService.HashToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	// Get the token
+	verificationToken, err := uc.verificationRepo.GetByID(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if verificationToken == nil || verificationToken.UsedAt != nil || time.Now().After(verificationToken.ExpiresAt) {
+		return invalidToken
+	}
+
+	// Consume the token, failing if a concurrent request used it first
+	consumed, err := uc.verificationRepo.MarkUsed(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return invalidToken
+	}
+
+	// Mark the address as verified
+	if err := uc.userRepo.MarkEmailVerified(ctx, verificationToken.UserID); err != nil {
+		return err
+	}
+
+	// Other pending verification links are no longer needed
+	return uc.verificationRepo.DeleteAllByUserID(ctx, verificationToken.UserID)
+}
+
+// CheckLogin rejects unverified users when the policy blocks their login
+func (uc *EmailVerificationUseCase) CheckLogin(user *entities.User) error {
+	if uc.policy == EmailVerificationBlockLogin && !user.EmailVerified() {
+		return errEmailNotVerified
+	}
+	return nil
+}
+
+// RequireVerified rejects unverified users unless verification is optional.
+// Features that send mail to the user's address or share with other people
+// call it first.
+func (uc *EmailVerificationUseCase) RequireVerified(user *entities.User) error {
+	if uc.policy != EmailVerificationOptional && !user.EmailVerified() {
+		return errEmailNotVerified
+	}
+	return nil
+}
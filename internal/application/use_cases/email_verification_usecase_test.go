@@ -0,0 +1,202 @@
+package use_cases_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+// MockEmailVerificationRepository mocks the EmailVerificationRepository interface
+type MockEmailVerificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationRepository) Create(ctx context.Context, token *entities.EmailVerificationToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationRepository) GetByID(ctx context.Context, id string) (*entities.EmailVerificationToken, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.EmailVerificationToken), args.Error(1)
+}
+
+func (m *MockEmailVerificationRepository) GetLatestByUserID(ctx context.Context, userID string) (*entities.EmailVerificationToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.EmailVerificationToken), args.Error(1)
+}
+
+func (m *MockEmailVerificationRepository) MarkUsed(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockEmailVerificationRepository) DeleteAllByUserID(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func newTestEmailVerificationUseCase(
+	mockUserRepo *MockUserRepository,
+	mockVerificationRepo *MockEmailVerificationRepository,
+	mockTokenService *MockTokenService,
+	mockMailer *MockMailer,
+	policy use_cases.EmailVerificationPolicy,
+) *use_cases.EmailVerificationUseCase {
+	return use_cases.NewEmailVerificationUseCase(mockUserRepo, mockVerificationRepo, mockTokenService, mockMailer, policy, "https://notes.example.com", 24*time.Hour, time.Minute)
+}
+
+func TestSendVerification(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockVerificationRepo := new(MockEmailVerificationRepository)
+	mockTokenService := new(MockTokenService)
+	mockMailer := new(MockMailer)
+
+	user := &entities.User{ID: uuid.New().String(), Email: "test@example.com", Name: "Test User"}
+
+	mockTokenService.On("GenerateToken", ctx).Return("verifytoken", nil)
+	mockTokenService.On("HashToken", ctx, "verifytoken").Return("hashedtoken", nil)
+	mockVerificationRepo.On("Create", ctx, mock.MatchedBy(func(token *entities.EmailVerificationToken) bool {
+		return token.ID == "hashedtoken" && token.UserID == user.ID
+	})).Return(nil)
+	mockMailer.On("Send", ctx, mock.MatchedBy(func(message services.MailMessage) bool {
+		return message.To == user.Email && strings.Contains(message.Body, "https://notes.example.com/verify-email?token=verifytoken")
+	})).Return(nil)
+
+	useCase := newTestEmailVerificationUseCase(mockUserRepo, mockVerificationRepo, mockTokenService, mockMailer, use_cases.EmailVerificationRestrict)
+
+	// Act
+	err := useCase.SendVerification(ctx, user)
+
+	// Assert
+	assert.NoError(t, err)
+	mockVerificationRepo.AssertExpectations(t)
+	mockMailer.AssertExpectations(t)
+}
+
+func TestResendVerification_Throttled(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockVerificationRepo := new(MockEmailVerificationRepository)
+	mockTokenService := new(MockTokenService)
+	mockMailer := new(MockMailer)
+
+	user := &entities.User{ID: uuid.New().String(), Email: "test@example.com"}
+	latest := &entities.EmailVerificationToken{ID: "hashedtoken", UserID: user.ID, CreatedAt: time.Now().Add(-10 * time.Second)}
+
+	mockUserRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	mockVerificationRepo.On("GetLatestByUserID", ctx, user.ID).Return(latest, nil)
+
+	useCase := newTestEmailVerificationUseCase(mockUserRepo, mockVerificationRepo, mockTokenService, mockMailer, use_cases.EmailVerificationRestrict)
+
+	// Act
+	err := useCase.ResendVerification(ctx, user.Email)
+
+	// Assert
+	assert.NoError(t, err)
+	mockVerificationRepo.AssertNotCalled(t, "Create")
+	mockMailer.AssertNotCalled(t, "Send")
+}
+
+func TestVerifyEmail(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockVerificationRepo := new(MockEmailVerificationRepository)
+	mockTokenService := new(MockTokenService)
+	mockMailer := new(MockMailer)
+
+	userID := uuid.New().String()
+	token := &entities.EmailVerificationToken{ID: "hashedtoken", UserID: userID, ExpiresAt: time.Now().Add(time.Hour)}
+
+	mockTokenService.On("HashToken", ctx, "verifytoken").Return("hashedtoken", nil)
+	mockVerificationRepo.On("GetByID", ctx, "hashedtoken").Return(token, nil)
+	mockVerificationRepo.On("MarkUsed", ctx, "hashedtoken").Return(true, nil)
+	mockUserRepo.On("MarkEmailVerified", ctx, userID).Return(nil)
+	mockVerificationRepo.On("DeleteAllByUserID", ctx, userID).Return(nil)
+
+	useCase := newTestEmailVerificationUseCase(mockUserRepo, mockVerificationRepo, mockTokenService, mockMailer, use_cases.EmailVerificationRestrict)
+
+	// Act
+	err := useCase.VerifyEmail(ctx, "verifytoken")
+
+	// Assert
+	assert.NoError(t, err)
+	mockUserRepo.AssertExpectations(t)
+	mockVerificationRepo.AssertExpectations(t)
+}
+
+func TestVerifyEmail_ExpiredToken(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockVerificationRepo := new(MockEmailVerificationRepository)
+	mockTokenService := new(MockTokenService)
+	mockMailer := new(MockMailer)
+
+	token := &entities.EmailVerificationToken{ID: "hashedtoken", UserID: uuid.New().String(), ExpiresAt: time.Now().Add(-time.Minute)}
+
+	mockTokenService.On("HashToken", ctx, "verifytoken").Return("hashedtoken", nil)
+	mockVerificationRepo.On("GetByID", ctx, "hashedtoken").Return(token, nil)
+
+	useCase := newTestEmailVerificationUseCase(mockUserRepo, mockVerificationRepo, mockTokenService, mockMailer, use_cases.EmailVerificationRestrict)
+
+	// Act
+	err := useCase.VerifyEmail(ctx, "verifytoken")
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrValidation)
+	mockVerificationRepo.AssertNotCalled(t, "MarkUsed")
+	mockUserRepo.AssertNotCalled(t, "MarkEmailVerified")
+}
+
+func TestCheckLogin_BlockLogin(t *testing.T) {
+	// Arrange
+	verifiedAt := time.Now()
+	verified := &entities.User{ID: uuid.New().String(), EmailVerifiedAt: &verifiedAt}
+	unverified := &entities.User{ID: uuid.New().String()}
+
+	useCase := newTestEmailVerificationUseCase(new(MockUserRepository), new(MockEmailVerificationRepository), new(MockTokenService), new(MockMailer), use_cases.EmailVerificationBlockLogin)
+
+	// Act
+	verifiedErr := useCase.CheckLogin(verified)
+	unverifiedErr := useCase.CheckLogin(unverified)
+
+	// Assert
+	assert.NoError(t, verifiedErr)
+	assert.ErrorIs(t, unverifiedErr, domainerrors.ErrForbidden)
+}
+
+func TestCheckLogin_Restrict(t *testing.T) {
+	// Arrange
+	unverified := &entities.User{ID: uuid.New().String()}
+
+	useCase := newTestEmailVerificationUseCase(new(MockUserRepository), new(MockEmailVerificationRepository), new(MockTokenService), new(MockMailer), use_cases.EmailVerificationRestrict)
+
+	// Act
+	loginErr := useCase.CheckLogin(unverified)
+	featureErr := useCase.RequireVerified(unverified)
+
+	// Assert
+	assert.NoError(t, loginErr)
+	assert.ErrorIs(t, featureErr, domainerrors.ErrForbidden)
+}
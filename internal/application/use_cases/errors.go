@@ -0,0 +1,41 @@
+package use_cases
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+// ErrIncorrectPassword matches the validation error returned when the password
+// confirming a sensitive change is wrong, so callers can throttle guesses.
+var ErrIncorrectPassword = errors.New("incorrect password")
+
+type incorrectPasswordError struct {
+	err error
+}
+
+func (e *incorrectPasswordError) Error() string { return e.err.Error() }
+
+func (e *incorrectPasswordError) Unwrap() error { return e.err }
+
+func (e *incorrectPasswordError) Is(target error) bool { return target == ErrIncorrectPassword }
+
+// incorrectPassword reports a wrong confirmation password on the given field.
+// It matches both domainerrors.ErrValidation and ErrIncorrectPassword.
+func incorrectPassword(message, field string) error {
+	return &incorrectPasswordError{
+		err: domainerrors.Validation(message, domainerrors.FieldError{Field: field, Message: "is incorrect"}),
+	}
+}
+
+// versionConflict reports that a resource was modified since the client read
+// it. It matches both domainerrors.ErrConflict and repositories.ErrVersionConflict.
+func versionConflict(resource string) error {
+	return &domainerrors.ConflictError{
+		Resource: resource,
+		Message:  fmt.Sprintf("%s was modified by another request", resource),
+		Err:      repositories.ErrVersionConflict,
+	}
+}
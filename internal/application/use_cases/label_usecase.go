@@ -7,25 +7,29 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
 	"github.com/LaulauChau/note-nest/internal/domain/entities"
 	"github.com/LaulauChau/note-nest/internal/domain/repositories"
 )
 
 type LabelUseCase struct {
-	labelRepo repositories.LabelRepository
-	userRepo  repositories.UserRepository
-	noteRepo  repositories.NoteRepository
+	labelRepo   repositories.LabelRepository
+	userRepo    repositories.UserRepository
+	noteRepo    repositories.NoteRepository
+	permissions *NotePermissionService
 }
 
 func NewLabelUseCase(
 	labelRepo repositories.LabelRepository,
 	userRepo repositories.UserRepository,
 	noteRepo repositories.NoteRepository,
+	permissions *NotePermissionService,
 ) *LabelUseCase {
 	return &LabelUseCase{
-		labelRepo: labelRepo,
-		userRepo:  userRepo,
-		noteRepo:  noteRepo,
+		labelRepo:   labelRepo,
+		userRepo:    userRepo,
+		noteRepo:    noteRepo,
+		permissions: permissions,
 	}
 }
 
@@ -36,7 +40,7 @@ func (uc *LabelUseCase) CreateLabel(ctx context.Context, userID, name, color str
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, domainerrors.NotFound("user")
 	}
 
 	// Check if label with same name already exists for this user
@@ -45,7 +49,7 @@ func (uc *LabelUseCase) CreateLabel(ctx context.Context, userID, name, color str
 		return nil, err
 	}
 	if existingLabel != nil {
-		return nil, errors.New("label with this name already exists")
+		return nil, domainerrors.Conflict("label", "label with this name already exists")
 	}
 
 	// Create a new label
@@ -76,7 +80,7 @@ func (uc *LabelUseCase) GetLabelByID(ctx context.Context, labelID, userID string
 
 	// If label not found or doesn't belong to the user, return nil
 	if label == nil || label.UserID != userID {
-		return nil, errors.New("label not found")
+		return nil, domainerrors.NotFound("label")
 	}
 
 	return label, nil
@@ -89,25 +93,27 @@ func (uc *LabelUseCase) GetLabelsByUser(ctx context.Context, userID string) ([]*
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, domainerrors.NotFound("user")
 	}
 
 	// Get labels for the user
 	return uc.labelRepo.GetByUserID(ctx, userID)
 }
 
+// GetLabelsForNote returns the labels the user put on a note they can read
 func (uc *LabelUseCase) GetLabelsForNote(ctx context.Context, noteID, userID string) ([]*entities.Label, error) {
-	// Verify the note exists and belongs to the user
-	note, err := uc.noteRepo.GetByID(ctx, noteID)
-	if err != nil {
+	// Verify the user can read the note
+	if _, err := uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleViewer); err != nil {
 		return nil, err
 	}
-	if note == nil || note.UserID != userID {
-		return nil, errors.New("note not found")
-	}
 
 	// Get labels for the note
-	return uc.labelRepo.GetLabelsForNote(ctx, noteID)
+	labels, err := uc.labelRepo.GetLabelsForNote(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ownLabels(labels, userID), nil
 }
 
 func (uc *LabelUseCase) GetNotesForLabel(ctx context.Context, labelID, userID string) ([]*entities.Note, error) {
@@ -117,7 +123,7 @@ func (uc *LabelUseCase) GetNotesForLabel(ctx context.Context, labelID, userID st
 		return nil, err
 	}
 	if label == nil {
-		return nil, errors.New("label not found")
+		return nil, domainerrors.NotFound("label")
 	}
 
 	// Get note IDs for the label
@@ -134,8 +140,15 @@ func (uc *LabelUseCase) GetNotesForLabel(ctx context.Context, labelID, userID st
 			continue // Skip notes with errors
 		}
 
-		// Only include notes that belong to the user
-		if note != nil && note.UserID == userID {
+		// Only include notes the user can still read
+		if note == nil {
+			continue
+		}
+		role, err := uc.permissions.Role(ctx, note, userID)
+		if err != nil {
+			return nil, err
+		}
+		if role != "" {
 			notes = append(notes, note)
 		}
 	}
@@ -143,7 +156,9 @@ func (uc *LabelUseCase) GetNotesForLabel(ctx context.Context, labelID, userID st
 	return notes, nil
 }
 
-func (uc *LabelUseCase) UpdateLabel(ctx context.Context, labelID, userID, name, color string) (*entities.Label, error) {
+// UpdateLabel saves the new label fields. A non-zero expectedVersion makes the
+// update fail with a conflict error unless the label is still at that version.
+func (uc *LabelUseCase) UpdateLabel(ctx context.Context, labelID, userID, name, color string, expectedVersion int) (*entities.Label, error) {
 	// Get the label
 	label, err := uc.labelRepo.GetByID(ctx, labelID)
 	if err != nil {
@@ -152,7 +167,12 @@ func (uc *LabelUseCase) UpdateLabel(ctx context.Context, labelID, userID, name,
 
 	// If label not found or doesn't belong to the user, return error
 	if label == nil || label.UserID != userID {
-		return nil, errors.New("label not found")
+		return nil, domainerrors.NotFound("label")
+	}
+
+	// Reject edits made against an outdated version
+	if expectedVersion != 0 && label.Version != expectedVersion {
+		return nil, versionConflict("label")
 	}
 
 	// Check if another label with the same name already exists for this user
@@ -162,7 +182,7 @@ func (uc *LabelUseCase) UpdateLabel(ctx context.Context, labelID, userID, name,
 			return nil, err
 		}
 		if existingLabel != nil && existingLabel.ID != labelID {
-			return nil, errors.New("label with this name already exists")
+			return nil, domainerrors.Conflict("label", "label with this name already exists")
 		}
 	}
 
@@ -173,6 +193,9 @@ func (uc *LabelUseCase) UpdateLabel(ctx context.Context, labelID, userID, name,
 
 	// Save the updated label
 	if err := uc.labelRepo.Update(ctx, label); err != nil {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			return nil, versionConflict("label")
+		}
 		return nil, err
 	}
 
@@ -188,7 +211,7 @@ func (uc *LabelUseCase) DeleteLabel(ctx context.Context, labelID, userID string)
 
 	// If label not found or doesn't belong to the user, return error
 	if label == nil || label.UserID != userID {
-		return errors.New("label not found")
+		return domainerrors.NotFound("label")
 	}
 
 	// Delete the label
@@ -196,14 +219,10 @@ func (uc *LabelUseCase) DeleteLabel(ctx context.Context, labelID, userID string)
 }
 
 func (uc *LabelUseCase) AddLabelToNote(ctx context.Context, noteID, labelID, userID string) error {
-	// Verify the note exists and belongs to the user
-	note, err := uc.noteRepo.GetByID(ctx, noteID)
-	if err != nil {
+	// Verify the user can read the note, labels are theirs alone
+	if _, err := uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleViewer); err != nil {
 		return err
 	}
-	if note == nil || note.UserID != userID {
-		return errors.New("note not found")
-	}
 
 	// Verify the label exists and belongs to the user
 	label, err := uc.labelRepo.GetByID(ctx, labelID)
@@ -211,7 +230,7 @@ func (uc *LabelUseCase) AddLabelToNote(ctx context.Context, noteID, labelID, use
 		return err
 	}
 	if label == nil || label.UserID != userID {
-		return errors.New("label not found")
+		return domainerrors.NotFound("label")
 	}
 
 	// Associate the label with the note
@@ -219,14 +238,10 @@ func (uc *LabelUseCase) AddLabelToNote(ctx context.Context, noteID, labelID, use
 }
 
 func (uc *LabelUseCase) RemoveLabelFromNote(ctx context.Context, noteID, labelID, userID string) error {
-	// Verify the note exists and belongs to the user
-	note, err := uc.noteRepo.GetByID(ctx, noteID)
-	if err != nil {
+	// Verify the user can read the note, labels are theirs alone
+	if _, err := uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleViewer); err != nil {
 		return err
 	}
-	if note == nil || note.UserID != userID {
-		return errors.New("note not found")
-	}
 
 	// Verify the label exists and belongs to the user
 	label, err := uc.labelRepo.GetByID(ctx, labelID)
@@ -234,7 +249,7 @@ func (uc *LabelUseCase) RemoveLabelFromNote(ctx context.Context, noteID, labelID
 		return err
 	}
 	if label == nil || label.UserID != userID {
-		return errors.New("label not found")
+		return domainerrors.NotFound("label")
 	}
 
 	// Disassociate the label from the note
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
 	"github.com/LaulauChau/note-nest/internal/domain/entities"
 )
 
@@ -52,6 +53,11 @@ func (m *MockLabelRepository) Delete(ctx context.Context, id string) error {
 	return args.Error(0)
 }
 
+func (m *MockLabelRepository) DeleteAllByUserID(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
 func (m *MockLabelRepository) AddLabelToNote(ctx context.Context, noteID, labelID string) error {
 	args := m.Called(ctx, noteID, labelID)
 	return args.Error(0)
@@ -84,6 +90,7 @@ func TestCreateLabel(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	name := "Work"
@@ -107,7 +114,7 @@ func TestCreateLabel(t *testing.T) {
 			label.Color == color
 	})).Return(nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
 	label, err := useCase.CreateLabel(ctx, userID, name, color)
@@ -132,6 +139,7 @@ func TestCreateLabel_UserNotFound(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	name := "Work"
@@ -140,7 +148,7 @@ func TestCreateLabel_UserNotFound(t *testing.T) {
 	// Mock user repository to return nil (user not found)
 	mockUserRepo.On("GetByID", ctx, userID).Return(nil, nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
 	label, err := useCase.CreateLabel(ctx, userID, name, color)
@@ -161,6 +169,7 @@ func TestCreateLabel_DuplicateName(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	name := "Work"
@@ -183,7 +192,7 @@ func TestCreateLabel_DuplicateName(t *testing.T) {
 	}
 	mockLabelRepo.On("GetByName", ctx, userID, name).Return(existingLabel, nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
 	label, err := useCase.CreateLabel(ctx, userID, name, color)
@@ -205,6 +214,7 @@ func TestGetLabelByID(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	labelID := uuid.New().String()
@@ -219,7 +229,7 @@ func TestGetLabelByID(t *testing.T) {
 	// Mock label repository to return a label
 	mockLabelRepo.On("GetByID", ctx, labelID).Return(label, nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
 	result, err := useCase.GetLabelByID(ctx, labelID, userID)
@@ -236,6 +246,7 @@ func TestGetLabelByID_NotFound(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	labelID := uuid.New().String()
@@ -243,7 +254,7 @@ func TestGetLabelByID_NotFound(t *testing.T) {
 	// Mock label repository to return nil (label not found)
 	mockLabelRepo.On("GetByID", ctx, labelID).Return(nil, nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
 	result, err := useCase.GetLabelByID(ctx, labelID, userID)
@@ -261,6 +272,7 @@ func TestGetLabelByID_WrongUser(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	anotherUserID := uuid.New().String()
@@ -276,7 +288,7 @@ func TestGetLabelByID_WrongUser(t *testing.T) {
 	// Mock label repository to return a label that belongs to another user
 	mockLabelRepo.On("GetByID", ctx, labelID).Return(label, nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
 	result, err := useCase.GetLabelByID(ctx, labelID, userID)
@@ -294,6 +306,7 @@ func TestGetLabelsByUser(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 
@@ -324,7 +337,7 @@ func TestGetLabelsByUser(t *testing.T) {
 	// Mock label repository to return labels
 	mockLabelRepo.On("GetByUserID", ctx, userID).Return(labels, nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
 	result, err := useCase.GetLabelsByUser(ctx, userID)
@@ -343,6 +356,7 @@ func TestUpdateLabel(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	labelID := uuid.New().String()
@@ -375,13 +389,13 @@ func TestUpdateLabel(t *testing.T) {
 		label.UpdatedAt = time.Now()
 	}).Return(nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Introduce a small delay to ensure UpdatedAt changes measurably
 	time.Sleep(50 * time.Millisecond)
 
 	// Act
-	updatedLabel, err := useCase.UpdateLabel(ctx, labelID, userID, newName, newColor)
+	updatedLabel, err := useCase.UpdateLabel(ctx, labelID, userID, newName, newColor, 0)
 
 	// Assert
 	assert.NoError(t, err)
@@ -395,12 +409,43 @@ func TestUpdateLabel(t *testing.T) {
 	mockLabelRepo.AssertExpectations(t)
 }
 
+func TestUpdateLabel_VersionMismatch(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockLabelRepo := new(MockLabelRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+
+	userID := uuid.New().String()
+	labelID := uuid.New().String()
+
+	// The label was updated since the client read version 2
+	existingLabel := &entities.Label{ID: labelID, UserID: userID, Name: "Work", Color: "#ff5733", Version: 3}
+	mockLabelRepo.On("GetByID", ctx, labelID).Return(existingLabel, nil)
+
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
+
+	// Act
+	updatedLabel, err := useCase.UpdateLabel(ctx, labelID, userID, "Job", "#33ff57", 2)
+
+	// Assert
+	var conflict *domainerrors.ConflictError
+	assert.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "label", conflict.Resource)
+	assert.Nil(t, updatedLabel)
+
+	mockLabelRepo.AssertExpectations(t)
+	mockLabelRepo.AssertNotCalled(t, "Update")
+}
+
 func TestUpdateLabel_NotFound(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	labelID := uuid.New().String()
@@ -410,10 +455,10 @@ func TestUpdateLabel_NotFound(t *testing.T) {
 	// Mock label repository to return nil (label not found)
 	mockLabelRepo.On("GetByID", ctx, labelID).Return(nil, nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
-	updatedLabel, err := useCase.UpdateLabel(ctx, labelID, userID, newName, newColor)
+	updatedLabel, err := useCase.UpdateLabel(ctx, labelID, userID, newName, newColor, 0)
 
 	// Assert
 	assert.Error(t, err)
@@ -431,6 +476,7 @@ func TestUpdateLabel_WrongUser(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	anotherUserID := uuid.New().String()
@@ -449,10 +495,10 @@ func TestUpdateLabel_WrongUser(t *testing.T) {
 	// Mock label repository to return a label that belongs to another user
 	mockLabelRepo.On("GetByID", ctx, labelID).Return(label, nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
-	updatedLabel, err := useCase.UpdateLabel(ctx, labelID, userID, newName, newColor)
+	updatedLabel, err := useCase.UpdateLabel(ctx, labelID, userID, newName, newColor, 0)
 
 	// Assert
 	assert.Error(t, err)
@@ -470,6 +516,7 @@ func TestUpdateLabel_DuplicateName(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	labelID := uuid.New().String()
@@ -500,10 +547,10 @@ func TestUpdateLabel_DuplicateName(t *testing.T) {
 	// Mock label repository to check if the new name already exists
 	mockLabelRepo.On("GetByName", ctx, userID, newName).Return(anotherLabel, nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
-	updatedLabel, err := useCase.UpdateLabel(ctx, labelID, userID, newName, newColor)
+	updatedLabel, err := useCase.UpdateLabel(ctx, labelID, userID, newName, newColor, 0)
 
 	// Assert
 	assert.Error(t, err)
@@ -521,6 +568,7 @@ func TestDeleteLabel(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	labelID := uuid.New().String()
@@ -539,7 +587,7 @@ func TestDeleteLabel(t *testing.T) {
 	// Mock label repository to delete the label
 	mockLabelRepo.On("Delete", ctx, labelID).Return(nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
 	err := useCase.DeleteLabel(ctx, labelID, userID)
@@ -555,6 +603,7 @@ func TestDeleteLabel_NotFound(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	labelID := uuid.New().String()
@@ -562,7 +611,7 @@ func TestDeleteLabel_NotFound(t *testing.T) {
 	// Mock label repository to return nil (label not found)
 	mockLabelRepo.On("GetByID", ctx, labelID).Return(nil, nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
 	err := useCase.DeleteLabel(ctx, labelID, userID)
@@ -582,6 +631,7 @@ func TestDeleteLabel_WrongUser(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	anotherUserID := uuid.New().String()
@@ -598,7 +648,7 @@ func TestDeleteLabel_WrongUser(t *testing.T) {
 	// Mock label repository to return a label that belongs to another user
 	mockLabelRepo.On("GetByID", ctx, labelID).Return(label, nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
 	err := useCase.DeleteLabel(ctx, labelID, userID)
@@ -618,6 +668,7 @@ func TestAddLabelToNote(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	noteID := uuid.New().String()
@@ -643,7 +694,7 @@ func TestAddLabelToNote(t *testing.T) {
 	// Mock label repository to add the label to the note
 	mockLabelRepo.On("AddLabelToNote", ctx, noteID, labelID).Return(nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
 	err := useCase.AddLabelToNote(ctx, noteID, labelID, userID)
@@ -660,6 +711,7 @@ func TestAddLabelToNote_NoteNotFound(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	noteID := uuid.New().String()
@@ -668,7 +720,7 @@ func TestAddLabelToNote_NoteNotFound(t *testing.T) {
 	// Mock note repository to return nil (note not found)
 	mockNoteRepo.On("GetByID", ctx, noteID).Return(nil, nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
 	err := useCase.AddLabelToNote(ctx, noteID, labelID, userID)
@@ -688,6 +740,7 @@ func TestAddLabelToNote_NoteWrongUser(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	anotherUserID := uuid.New().String()
@@ -702,7 +755,10 @@ func TestAddLabelToNote_NoteWrongUser(t *testing.T) {
 	}
 	mockNoteRepo.On("GetByID", ctx, noteID).Return(note, nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	// The note is not shared with the user either
+	mockShareRepo.On("GetByNoteAndUser", ctx, noteID, userID).Return(nil, nil)
+
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
 	err := useCase.AddLabelToNote(ctx, noteID, labelID, userID)
@@ -722,6 +778,7 @@ func TestAddLabelToNote_LabelNotFound(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	noteID := uuid.New().String()
@@ -738,7 +795,7 @@ func TestAddLabelToNote_LabelNotFound(t *testing.T) {
 	// Mock label repository to return nil (label not found)
 	mockLabelRepo.On("GetByID", ctx, labelID).Return(nil, nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
 	err := useCase.AddLabelToNote(ctx, noteID, labelID, userID)
@@ -758,6 +815,7 @@ func TestAddLabelToNote_LabelWrongUser(t *testing.T) {
 	mockLabelRepo := new(MockLabelRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 
 	userID := uuid.New().String()
 	anotherUserID := uuid.New().String()
@@ -781,7 +839,7 @@ func TestAddLabelToNote_LabelWrongUser(t *testing.T) {
 	}
 	mockLabelRepo.On("GetByID", ctx, labelID).Return(label, nil)
 
-	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo)
+	useCase := use_cases.NewLabelUseCase(mockLabelRepo, mockUserRepo, mockNoteRepo, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
 
 	// Act
 	err := useCase.AddLabelToNote(ctx, noteID, labelID, userID)
@@ -0,0 +1,87 @@
+package use_cases
+
+import (
+	"strings"
+
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+// maxDiffCells bounds the size of the LCS table, which is the product of the
+// number of changed lines on each side. It keeps a diff under 16 MB.
+const maxDiffCells = 4_000_000
+
+// diffLines computes a line based diff between two texts using the longest
+// common subsequence of their lines. The lines both texts start and end with
+// are set aside first, so only the changed region needs the LCS table.
+func diffLines(from, to string) ([]entities.DiffLine, error) {
+	a := splitLines(from)
+	b := splitLines(to)
+
+	// Skip the common prefix and suffix
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	changedA := a[prefix : len(a)-suffix]
+	changedB := b[prefix : len(b)-suffix]
+
+	if len(changedA)*len(changedB) > maxDiffCells {
+		return nil, domainerrors.Unprocessable("revisions differ too much to be compared")
+	}
+
+	// lcs[i*width+j] holds the LCS length of changedA[i:] and changedB[j:]
+	width := len(changedB) + 1
+	lcs := make([]int32, (len(changedA)+1)*width)
+	for i := len(changedA) - 1; i >= 0; i-- {
+		for j := len(changedB) - 1; j >= 0; j-- {
+			if changedA[i] == changedB[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else {
+				lcs[i*width+j] = max(lcs[(i+1)*width+j], lcs[i*width+j+1])
+			}
+		}
+	}
+
+	lines := make([]entities.DiffLine, 0, max(len(a), len(b)))
+	for _, line := range a[:prefix] {
+		lines = append(lines, entities.DiffLine{Op: entities.DiffEqual, Text: line})
+	}
+	i, j := 0, 0
+	for i < len(changedA) && j < len(changedB) {
+		switch {
+		case changedA[i] == changedB[j]:
+			lines = append(lines, entities.DiffLine{Op: entities.DiffEqual, Text: changedA[i]})
+			i++
+			j++
+		case lcs[(i+1)*width+j] >= lcs[i*width+j+1]:
+			lines = append(lines, entities.DiffLine{Op: entities.DiffDelete, Text: changedA[i]})
+			i++
+		default:
+			lines = append(lines, entities.DiffLine{Op: entities.DiffInsert, Text: changedB[j]})
+			j++
+		}
+	}
+	for ; i < len(changedA); i++ {
+		lines = append(lines, entities.DiffLine{Op: entities.DiffDelete, Text: changedA[i]})
+	}
+	for ; j < len(changedB); j++ {
+		lines = append(lines, entities.DiffLine{Op: entities.DiffInsert, Text: changedB[j]})
+	}
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, entities.DiffLine{Op: entities.DiffEqual, Text: line})
+	}
+
+	return lines, nil
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,179 @@
+package use_cases
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+// LoginAttempt identifies where a sign-in attempt comes from. Attempts at
+// the password of a share link set ShareLinkID instead of Email and are
+// throttled per link.
+type LoginAttempt struct {
+	Email       string
+	ShareLinkID string
+	IPAddress   string
+	UserAgent   string
+}
+
+// LoginThrottlePolicy sets how many failures a key is allowed. The first
+// FreeAttempts failures are not throttled, each further one doubles the
+// delay before the next attempt, and LockoutThreshold failures lock the key
+// out. A zero LockoutThreshold never locks out.
+type LoginThrottlePolicy struct {
+	FreeAttempts     int
+	LockoutThreshold int
+}
+
+type LoginThrottleConfig struct {
+	// Email applies per account or share link, IP per client address.
+	// Addresses are shared behind NATs and proxies, so they are usually
+	// given more room.
+	Email LoginThrottlePolicy
+	IP    LoginThrottlePolicy
+
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutDuration time.Duration
+	// ResetAfter is how long failures are remembered after the last one
+	ResetAfter time.Duration
+}
+
+type LoginThrottleUseCase struct {
+	store     services.LoginAttemptStore
+	auditRepo repositories.LoginAuditRepository
+	config    LoginThrottleConfig
+}
+
+func NewLoginThrottleUseCase(
+	store services.LoginAttemptStore,
+	auditRepo repositories.LoginAuditRepository,
+	config LoginThrottleConfig,
+) *LoginThrottleUseCase {
+	return &LoginThrottleUseCase{
+		store:     store,
+		auditRepo: auditRepo,
+		config:    config,
+	}
+}
+
+// Check rejects the attempt while its account or address is throttled. It
+// must run before the password is hashed, which is what makes guessing
+// expensive for the server.
+func (uc *LoginThrottleUseCase) Check(ctx context.Context, attempt LoginAttempt) error {
+	now := time.Now()
+
+	var wait time.Duration
+	for _, key := range uc.keys(attempt) {
+		attempts, err := uc.store.Get(ctx, key.name)
+		if err != nil {
+			return err
+		}
+		if keyWait := uc.retryAfter(attempts, key.policy, now); keyWait > wait {
+			wait = keyWait
+		}
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	uc.audit(ctx, attempt, entities.LoginFailureRateLimited)
+	return domainerrors.RateLimited("too many failed sign-in attempts, try again later", wait)
+}
+
+// RecordFailure counts a failed attempt against its account and address and
+// keeps an audit record of it
+func (uc *LoginThrottleUseCase) RecordFailure(ctx context.Context, attempt LoginAttempt, reason entities.LoginFailureReason) error {
+	now := time.Now()
+
+	// Remember the failures for as long as they can block an attempt
+	ttl := max(uc.config.ResetAfter, uc.config.MaxDelay, uc.config.LockoutDuration)
+	for _, key := range uc.keys(attempt) {
+		if _, err := uc.store.RecordFailure(ctx, key.name, now, ttl); err != nil {
+			return err
+		}
+	}
+
+	uc.audit(ctx, attempt, reason)
+	return nil
+}
+
+// RecordSuccess clears the failures of the account once the user signed in.
+// The failures of the address are kept, a single known account must not let
+// a client reset them.
+func (uc *LoginThrottleUseCase) RecordSuccess(ctx context.Context, attempt LoginAttempt) error {
+	return uc.store.Reset(ctx, subjectKey(attempt))
+}
+
+type throttleKey struct {
+	name   string
+	policy LoginThrottlePolicy
+}
+
+func (uc *LoginThrottleUseCase) keys(attempt LoginAttempt) []throttleKey {
+	keys := []throttleKey{{name: subjectKey(attempt), policy: uc.config.Email}}
+	if attempt.IPAddress != "" {
+		keys = append(keys, throttleKey{name: "ip:" + attempt.IPAddress, policy: uc.config.IP})
+	}
+	return keys
+}
+
+// retryAfter returns how long the key must wait before its next attempt
+func (uc *LoginThrottleUseCase) retryAfter(attempts *services.LoginAttempts, policy LoginThrottlePolicy, now time.Time) time.Duration {
+	if attempts == nil || attempts.Failures < policy.FreeAttempts {
+		return 0
+	}
+
+	var delay time.Duration
+	if policy.LockoutThreshold > 0 && attempts.Failures >= policy.LockoutThreshold {
+		delay = uc.config.LockoutDuration
+	} else {
+		// Double the delay for every failure past the free ones, up to the maximum
+		delay = uc.config.MaxDelay
+		if exponent := attempts.Failures - policy.FreeAttempts; exponent < 32 {
+			delay = min(uc.config.BaseDelay<<exponent, uc.config.MaxDelay)
+		}
+	}
+
+	return attempts.LastFailureAt.Add(delay).Sub(now)
+}
+
+// audit records the failed attempt. Failing to write the audit record must
+// not change the outcome of the sign-in, so errors are only logged.
+func (uc *LoginThrottleUseCase) audit(ctx context.Context, attempt LoginAttempt, reason entities.LoginFailureReason) {
+	if err := uc.auditRepo.RecordFailure(ctx, &entities.FailedLoginAttempt{
+		ID:        uuid.New().String(),
+		Email:     normalizeEmail(attempt.Email),
+		IPAddress: attempt.IPAddress,
+		UserAgent: attempt.UserAgent,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		log.Printf("error recording failed login attempt: %v", err)
+	}
+}
+
+// subjectKey identifies what the attempt tries to open, an account or a
+// share link
+func subjectKey(attempt LoginAttempt) string {
+	if attempt.ShareLinkID != "" {
+		return "share-link:" + attempt.ShareLinkID
+	}
+	return emailKey(attempt.Email)
+}
+
+func emailKey(email string) string {
+	return "email:" + normalizeEmail(email)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,184 @@
+package use_cases_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+// MockLoginAttemptStore mocks the LoginAttemptStore interface
+type MockLoginAttemptStore struct {
+	mock.Mock
+}
+
+func (m *MockLoginAttemptStore) Get(ctx context.Context, key string) (*services.LoginAttempts, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.LoginAttempts), args.Error(1)
+}
+
+func (m *MockLoginAttemptStore) RecordFailure(ctx context.Context, key string, at time.Time, ttl time.Duration) (*services.LoginAttempts, error) {
+	args := m.Called(ctx, key, at, ttl)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.LoginAttempts), args.Error(1)
+}
+
+func (m *MockLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+// MockLoginAuditRepository mocks the LoginAuditRepository interface
+type MockLoginAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginAuditRepository) RecordFailure(ctx context.Context, attempt *entities.FailedLoginAttempt) error {
+	args := m.Called(ctx, attempt)
+	return args.Error(0)
+}
+
+var testLoginThrottleConfig = use_cases.LoginThrottleConfig{
+	Email:           use_cases.LoginThrottlePolicy{FreeAttempts: 3, LockoutThreshold: 10},
+	IP:              use_cases.LoginThrottlePolicy{FreeAttempts: 20},
+	BaseDelay:       time.Second,
+	MaxDelay:        time.Minute,
+	LockoutDuration: 15 * time.Minute,
+	ResetAfter:      15 * time.Minute,
+}
+
+var testLoginAttempt = use_cases.LoginAttempt{
+	Email:     "Test@Example.com",
+	IPAddress: "192.0.2.1",
+	UserAgent: "test-agent",
+}
+
+func TestCheckLogin_UnderFreeAttempts(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockStore := new(MockLoginAttemptStore)
+	mockAuditRepo := new(MockLoginAuditRepository)
+
+	mockStore.On("Get", ctx, "email:test@example.com").Return(&services.LoginAttempts{Failures: 2, LastFailureAt: time.Now()}, nil)
+	mockStore.On("Get", ctx, "ip:192.0.2.1").Return(nil, nil)
+
+	useCase := use_cases.NewLoginThrottleUseCase(mockStore, mockAuditRepo, testLoginThrottleConfig)
+
+	// Act
+	err := useCase.Check(ctx, testLoginAttempt)
+
+	// Assert
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockAuditRepo.AssertNotCalled(t, "RecordFailure", mock.Anything, mock.Anything)
+}
+
+func TestCheckLogin_Backoff(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockStore := new(MockLoginAttemptStore)
+	mockAuditRepo := new(MockLoginAuditRepository)
+
+	// Two failures past the free ones wait four times the base delay
+	mockStore.On("Get", ctx, "email:test@example.com").Return(&services.LoginAttempts{Failures: 5, LastFailureAt: time.Now()}, nil)
+	mockStore.On("Get", ctx, "ip:192.0.2.1").Return(&services.LoginAttempts{Failures: 5, LastFailureAt: time.Now()}, nil)
+	mockAuditRepo.On("RecordFailure", ctx, mock.MatchedBy(func(attempt *entities.FailedLoginAttempt) bool {
+		return attempt.Email == "test@example.com" && attempt.Reason == entities.LoginFailureRateLimited
+	})).Return(nil)
+
+	useCase := use_cases.NewLoginThrottleUseCase(mockStore, mockAuditRepo, testLoginThrottleConfig)
+
+	// Act
+	err := useCase.Check(ctx, testLoginAttempt)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrRateLimited)
+	var rateLimited *domainerrors.RateLimitedError
+	assert.True(t, errors.As(err, &rateLimited))
+	assert.InDelta(t, (4 * time.Second).Seconds(), rateLimited.RetryAfter.Seconds(), 1)
+
+	mockStore.AssertExpectations(t)
+	mockAuditRepo.AssertExpectations(t)
+}
+
+func TestCheckLogin_Lockout(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockStore := new(MockLoginAttemptStore)
+	mockAuditRepo := new(MockLoginAuditRepository)
+
+	mockStore.On("Get", ctx, "email:test@example.com").Return(&services.LoginAttempts{Failures: 10, LastFailureAt: time.Now()}, nil)
+	mockStore.On("Get", ctx, "ip:192.0.2.1").Return(nil, nil)
+	mockAuditRepo.On("RecordFailure", ctx, mock.Anything).Return(nil)
+
+	useCase := use_cases.NewLoginThrottleUseCase(mockStore, mockAuditRepo, testLoginThrottleConfig)
+
+	// Act
+	err := useCase.Check(ctx, testLoginAttempt)
+
+	// Assert
+	var rateLimited *domainerrors.RateLimitedError
+	assert.True(t, errors.As(err, &rateLimited))
+	assert.Greater(t, rateLimited.RetryAfter, 14*time.Minute)
+
+	mockStore.AssertExpectations(t)
+	mockAuditRepo.AssertExpectations(t)
+}
+
+func TestRecordLoginFailure(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockStore := new(MockLoginAttemptStore)
+	mockAuditRepo := new(MockLoginAuditRepository)
+
+	mockStore.On("RecordFailure", ctx, "email:test@example.com", mock.AnythingOfType("time.Time"), 15*time.Minute).Return(&services.LoginAttempts{Failures: 1}, nil)
+	mockStore.On("RecordFailure", ctx, "ip:192.0.2.1", mock.AnythingOfType("time.Time"), 15*time.Minute).Return(&services.LoginAttempts{Failures: 1}, nil)
+	mockAuditRepo.On("RecordFailure", ctx, mock.MatchedBy(func(attempt *entities.FailedLoginAttempt) bool {
+		return attempt.ID != "" &&
+			attempt.IPAddress == "192.0.2.1" &&
+			attempt.UserAgent == "test-agent" &&
+			attempt.Reason == entities.LoginFailureInvalidCredentials
+	})).Return(nil)
+
+	useCase := use_cases.NewLoginThrottleUseCase(mockStore, mockAuditRepo, testLoginThrottleConfig)
+
+	// Act
+	err := useCase.RecordFailure(ctx, testLoginAttempt, entities.LoginFailureInvalidCredentials)
+
+	// Assert
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockAuditRepo.AssertExpectations(t)
+}
+
+func TestRecordLoginSuccess(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockStore := new(MockLoginAttemptStore)
+	mockAuditRepo := new(MockLoginAuditRepository)
+
+	mockStore.On("Reset", ctx, "email:test@example.com").Return(nil)
+
+	useCase := use_cases.NewLoginThrottleUseCase(mockStore, mockAuditRepo, testLoginThrottleConfig)
+
+	// Act
+	err := useCase.RecordSuccess(ctx, testLoginAttempt)
+
+	// Assert
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "Reset", ctx, "ip:192.0.2.1")
+}
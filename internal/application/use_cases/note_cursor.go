@@ -0,0 +1,66 @@
+package use_cases
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+// noteCursorPayload is the opaque content of a pagination cursor.
+// The ordering is embedded so a cursor cannot be replayed against another sort.
+type noteCursorPayload struct {
+	SortBy     entities.NoteSortField `json:"s"`
+	Descending bool                   `json:"d"`
+	entities.NoteCursor
+}
+
+func encodeNoteCursor(sortBy entities.NoteSortField, descending bool, note *entities.Note) string {
+	payload := noteCursorPayload{
+		SortBy:     sortBy,
+		Descending: descending,
+		NoteCursor: entities.NoteCursor{
+			SortValue: noteSortValue(sortBy, note),
+			ID:        note.ID,
+			Pinned:    sortBy == entities.NoteSortByPosition && note.IsPinned,
+		},
+	}
+
+	// Marshalling a struct of strings and a bool cannot fail
+	data, _ := json.Marshal(payload)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeNoteCursor(cursor string, sortBy entities.NoteSortField, descending bool) (*entities.NoteCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, domainerrors.InvalidField("cursor", "invalid cursor")
+	}
+
+	var payload noteCursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil || payload.ID == "" {
+		return nil, domainerrors.InvalidField("cursor", "invalid cursor")
+	}
+
+	if payload.SortBy != sortBy || payload.Descending != descending {
+		return nil, domainerrors.InvalidField("cursor", "invalid cursor")
+	}
+
+	return &payload.NoteCursor, nil
+}
+
+func noteSortValue(sortBy entities.NoteSortField, note *entities.Note) string {
+	switch sortBy {
+	case entities.NoteSortByCreatedAt:
+		return note.CreatedAt.Format(time.RFC3339Nano)
+	case entities.NoteSortByTitle:
+		return note.Title
+	case entities.NoteSortByPosition:
+		return strconv.FormatFloat(note.Position, 'g', -1, 64)
+	default:
+		return note.UpdatedAt.Format(time.RFC3339Nano)
+	}
+}
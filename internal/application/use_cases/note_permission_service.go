@@ -0,0 +1,82 @@
+package use_cases
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+// NotePermissionService decides what a user may do with a note. The owner
+// may do anything, users the note is shared with get the role of their
+// share, and everybody else does not get to know the note exists.
+type NotePermissionService struct {
+	noteRepo  repositories.NoteRepository
+	shareRepo repositories.NoteShareRepository
+}
+
+func NewNotePermissionService(
+	noteRepo repositories.NoteRepository,
+	shareRepo repositories.NoteShareRepository,
+) *NotePermissionService {
+	return &NotePermissionService{
+		noteRepo:  noteRepo,
+		shareRepo: shareRepo,
+	}
+}
+
+// Role returns the role of the user on the note, empty when they have no
+// access to it
+func (s *NotePermissionService) Role(ctx context.Context, note *entities.Note, userID string) (entities.NoteRole, error) {
+	if note.UserID == userID {
+		return entities.NoteRoleOwner, nil
+	}
+
+	share, err := s.shareRepo.GetByNoteAndUser(ctx, note.ID, userID)
+	if err != nil {
+		return "", err
+	}
+	if share == nil {
+		return "", nil
+	}
+
+	return share.Role, nil
+}
+
+// Authorize gets the note, checking that the user holds at least the
+// required role on it
+func (s *NotePermissionService) Authorize(ctx context.Context, noteID, userID string, required entities.NoteRole) (*entities.Note, error) {
+	note, err := s.noteRepo.GetByID(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if note == nil {
+		return nil, domainerrors.NotFound("note")
+	}
+
+	if err := s.Check(ctx, note, userID, required); err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+// Check verifies that the user holds at least the required role on a note
+// that was already loaded. Users without any access get a not found error,
+// users whose role is too low a forbidden one.
+func (s *NotePermissionService) Check(ctx context.Context, note *entities.Note, userID string, required entities.NoteRole) error {
+	role, err := s.Role(ctx, note, userID)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return domainerrors.NotFound("note")
+	}
+	if !role.Includes(required) {
+		return domainerrors.Forbidden(fmt.Sprintf("%s access to the note is required", required))
+	}
+
+	return nil
+}
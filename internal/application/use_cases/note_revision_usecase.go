@@ -0,0 +1,285 @@
+package use_cases
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+// CurrentRevision refers to the live version of a note when diffing
+const CurrentRevision = 0
+
+// defaultRevisionRetention applies to users who never configured a policy
+var defaultRevisionRetention = entities.RevisionRetention{
+	MaxRevisions: 50,
+	MaxAgeDays:   0,
+}
+
+type NoteRevisionUseCase struct {
+	revisionRepo repositories.NoteRevisionRepository
+	noteRepo     repositories.NoteRepository
+	userRepo     repositories.UserRepository
+	txManager    repositories.TxManager
+	permissions  *NotePermissionService
+}
+
+func NewNoteRevisionUseCase(
+	revisionRepo repositories.NoteRevisionRepository,
+	noteRepo repositories.NoteRepository,
+	userRepo repositories.UserRepository,
+	txManager repositories.TxManager,
+	permissions *NotePermissionService,
+) *NoteRevisionUseCase {
+	return &NoteRevisionUseCase{
+		revisionRepo: revisionRepo,
+		noteRepo:     noteRepo,
+		userRepo:     userRepo,
+		txManager:    txManager,
+		permissions:  permissions,
+	}
+}
+
+func (uc *NoteRevisionUseCase) GetRevisions(ctx context.Context, noteID, userID string) ([]*entities.NoteRevision, error) {
+	// Verify the user can read the note
+	if _, err := uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleViewer); err != nil {
+		return nil, err
+	}
+
+	// Get the revisions, newest first
+	return uc.revisionRepo.GetByNoteID(ctx, noteID)
+}
+
+func (uc *NoteRevisionUseCase) GetRevision(ctx context.Context, noteID, userID string, revision int) (*entities.NoteRevision, error) {
+	// Verify the user can read the note
+	if _, err := uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleViewer); err != nil {
+		return nil, err
+	}
+
+	return getNoteRevision(ctx, uc.revisionRepo, noteID, revision)
+}
+
+func (uc *NoteRevisionUseCase) DiffRevisions(ctx context.Context, noteID, userID string, from, to int) (*entities.RevisionDiff, error) {
+	// Verify the user can read the note
+	note, err := uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve both sides, CurrentRevision being the live note
+	resolve := func(revision int) (string, string, error) {
+		if revision == CurrentRevision {
+			return note.Title, note.Content, nil
+		}
+		found, err := getNoteRevision(ctx, uc.revisionRepo, noteID, revision)
+		if err != nil {
+			return "", "", err
+		}
+		return found.Title, found.Content, nil
+	}
+
+	fromTitle, fromContent, err := resolve(from)
+	if err != nil {
+		return nil, err
+	}
+	toTitle, toContent, err := resolve(to)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := diffLines(fromContent, toContent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.RevisionDiff{
+		From:      from,
+		To:        to,
+		FromTitle: fromTitle,
+		ToTitle:   toTitle,
+		Lines:     lines,
+	}, nil
+}
+
+// RestoreRevision brings back the text of a revision. A non-zero
+// expectedVersion rejects the restore when the note changed since the client
+// read it, like an update does.
+func (uc *NoteRevisionUseCase) RestoreRevision(ctx context.Context, noteID, userID string, revision, expectedVersion int) (*entities.Note, error) {
+	var note *entities.Note
+
+	// Save the restored note and the revision of its current version together
+	err := uc.txManager.WithinTx(ctx, func(ctx context.Context, repos repositories.TxRepositories) error {
+		var err error
+		note, err = restoreRevision(ctx, repos, uc.permissions, noteID, userID, revision, expectedVersion)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+func restoreRevision(ctx context.Context, repos repositories.TxRepositories, permissions *NotePermissionService, noteID, userID string, revision, expectedVersion int) (*entities.Note, error) {
+	// Get and lock the note
+	note, err := repos.Notes.GetByIDForUpdate(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if note == nil {
+		return nil, domainerrors.NotFound("note")
+	}
+
+	// Verify the user can edit the note
+	if err := permissions.Check(ctx, note, userID, entities.NoteRoleEditor); err != nil {
+		return nil, err
+	}
+
+	// Reject restores made against an outdated version
+	if expectedVersion != 0 && note.Version != expectedVersion {
+		return nil, versionConflict("note")
+	}
+
+	// Get the revision to restore
+	restored, err := getNoteRevision(ctx, repos.Revisions, noteID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	if note.Type == entities.NoteTypeChecklist && restored.Content != "" {
+		return nil, domainerrors.Validation("convert the checklist to a text note before restoring this revision")
+	}
+
+	// Keep the current version so the restore itself can be undone
+	if err := recordNoteRevision(ctx, repos.Revisions, note); err != nil {
+		return nil, err
+	}
+
+	// Update the note fields
+	note.Title = restored.Title
+	note.Content = restored.Content
+	note.UpdatedAt = time.Now()
+
+	// Save the restored note
+	if err := repos.Notes.Update(ctx, note); err != nil {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			return nil, versionConflict("note")
+		}
+		return nil, err
+	}
+
+	return note, nil
+}
+
+func (uc *NoteRevisionUseCase) GetRetention(ctx context.Context, userID string) (*entities.RevisionRetention, error) {
+	return getRevisionRetention(ctx, uc.revisionRepo, userID)
+}
+
+func (uc *NoteRevisionUseCase) UpdateRetention(ctx context.Context, userID string, maxRevisions, maxAgeDays int) (*entities.RevisionRetention, error) {
+	// Validate the policy
+	var fields []domainerrors.FieldError
+	if maxRevisions < 0 {
+		fields = append(fields, domainerrors.FieldError{Field: "max_revisions", Message: "must not be negative"})
+	}
+	if maxAgeDays < 0 {
+		fields = append(fields, domainerrors.FieldError{Field: "max_age_days", Message: "must not be negative"})
+	}
+	if len(fields) > 0 {
+		return nil, domainerrors.Validation("invalid retention policy", fields...)
+	}
+
+	// Verify the user exists
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, domainerrors.NotFound("user")
+	}
+
+	retention := &entities.RevisionRetention{
+		UserID:       userID,
+		MaxRevisions: maxRevisions,
+		MaxAgeDays:   maxAgeDays,
+		UpdatedAt:    time.Now(),
+	}
+
+	// Save the policy
+	if err := uc.revisionRepo.SaveRetention(ctx, retention); err != nil {
+		return nil, err
+	}
+
+	return retention, nil
+}
+
+// getNoteRevision returns the revision of the note, failing when it does not
+// exist
+func getNoteRevision(ctx context.Context, revisionRepo repositories.NoteRevisionRepository, noteID string, revision int) (*entities.NoteRevision, error) {
+	found, err := revisionRepo.GetByRevision(ctx, noteID, revision)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, domainerrors.NotFound("revision")
+	}
+
+	return found, nil
+}
+
+// getRevisionRetention returns the user's retention policy or the default one
+func getRevisionRetention(ctx context.Context, revisionRepo repositories.NoteRevisionRepository, userID string) (*entities.RevisionRetention, error) {
+	retention, err := revisionRepo.GetRetention(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if retention == nil {
+		retention = &entities.RevisionRetention{
+			UserID:       userID,
+			MaxRevisions: defaultRevisionRetention.MaxRevisions,
+			MaxAgeDays:   defaultRevisionRetention.MaxAgeDays,
+		}
+	}
+
+	return retention, nil
+}
+
+// recordNoteRevision snapshots the stored version of a note before it is
+// overwritten, then prunes revisions according to the owner's retention policy
+func recordNoteRevision(ctx context.Context, revisionRepo repositories.NoteRevisionRepository, note *entities.Note) error {
+	revision := &entities.NoteRevision{
+		ID:        uuid.New().String(),
+		NoteID:    note.ID,
+		Title:     note.Title,
+		Content:   note.Content,
+		CreatedAt: time.Now(),
+	}
+
+	if err := revisionRepo.Create(ctx, revision); err != nil {
+		return err
+	}
+
+	retention, err := getRevisionRetention(ctx, revisionRepo, note.UserID)
+	if err != nil {
+		return err
+	}
+
+	if retention.MaxRevisions > 0 {
+		if err := revisionRepo.DeleteAllButLatest(ctx, note.ID, retention.MaxRevisions); err != nil {
+			return err
+		}
+	}
+
+	if retention.MaxAgeDays > 0 {
+		cutoff := time.Now().AddDate(0, 0, -retention.MaxAgeDays)
+		if err := revisionRepo.DeleteOlderThan(ctx, note.ID, cutoff); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,428 @@
+package use_cases_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+// MockNoteRevisionRepository mocks the NoteRevisionRepository interface
+type MockNoteRevisionRepository struct {
+	mock.Mock
+}
+
+func (m *MockNoteRevisionRepository) Create(ctx context.Context, revision *entities.NoteRevision) error {
+	args := m.Called(ctx, revision)
+	return args.Error(0)
+}
+
+func (m *MockNoteRevisionRepository) GetByNoteID(ctx context.Context, noteID string) ([]*entities.NoteRevision, error) {
+	args := m.Called(ctx, noteID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.NoteRevision), args.Error(1)
+}
+
+func (m *MockNoteRevisionRepository) GetByRevision(ctx context.Context, noteID string, revision int) (*entities.NoteRevision, error) {
+	args := m.Called(ctx, noteID, revision)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.NoteRevision), args.Error(1)
+}
+
+func (m *MockNoteRevisionRepository) DeleteAllButLatest(ctx context.Context, noteID string, keep int) error {
+	args := m.Called(ctx, noteID, keep)
+	return args.Error(0)
+}
+
+func (m *MockNoteRevisionRepository) DeleteOlderThan(ctx context.Context, noteID string, cutoff time.Time) error {
+	args := m.Called(ctx, noteID, cutoff)
+	return args.Error(0)
+}
+
+func (m *MockNoteRevisionRepository) GetRetention(ctx context.Context, userID string) (*entities.RevisionRetention, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.RevisionRetention), args.Error(1)
+}
+
+func (m *MockNoteRevisionRepository) SaveRetention(ctx context.Context, retention *entities.RevisionRetention) error {
+	args := m.Called(ctx, retention)
+	return args.Error(0)
+}
+
+func newTestNoteRevisionUseCase(
+	mockRevisionRepo *MockNoteRevisionRepository,
+	mockNoteRepo *MockNoteRepository,
+	mockShareRepo *MockNoteShareRepository,
+	mockUserRepo *MockUserRepository,
+) (*use_cases.NoteRevisionUseCase, *FakeTxManager) {
+	txManager := NewFakeTxManager(mockNoteRepo, new(MockLabelRepository), mockRevisionRepo)
+	permissions := use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo)
+	return use_cases.NewNoteRevisionUseCase(mockRevisionRepo, mockNoteRepo, mockUserRepo, txManager, permissions), txManager
+}
+
+func TestGetRevisions(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	userID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	note := &entities.Note{ID: noteID, UserID: userID, Title: "Title", Content: "Content"}
+	revisions := []*entities.NoteRevision{
+		{ID: uuid.New().String(), NoteID: noteID, Revision: 2, Title: "Second"},
+		{ID: uuid.New().String(), NoteID: noteID, Revision: 1, Title: "First"},
+	}
+
+	mockNoteRepo.On("GetByID", ctx, noteID).Return(note, nil)
+	mockRevisionRepo.On("GetByNoteID", ctx, noteID).Return(revisions, nil)
+
+	useCase, _ := newTestNoteRevisionUseCase(mockRevisionRepo, mockNoteRepo, mockShareRepo, mockUserRepo)
+
+	// Act
+	result, err := useCase.GetRevisions(ctx, noteID, userID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, revisions, result)
+
+	mockNoteRepo.AssertExpectations(t)
+	mockRevisionRepo.AssertExpectations(t)
+}
+
+func TestGetRevisions_WrongUser(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	noteID := uuid.New().String()
+	note := &entities.Note{ID: noteID, UserID: uuid.New().String()}
+
+	mockNoteRepo.On("GetByID", ctx, noteID).Return(note, nil)
+
+	// The note is not shared with the user either
+	mockShareRepo.On("GetByNoteAndUser", ctx, noteID, mock.Anything).Return(nil, nil)
+
+	useCase, _ := newTestNoteRevisionUseCase(mockRevisionRepo, mockNoteRepo, mockShareRepo, mockUserRepo)
+
+	// Act
+	result, err := useCase.GetRevisions(ctx, noteID, uuid.New().String())
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "note not found")
+
+	mockNoteRepo.AssertExpectations(t)
+	mockRevisionRepo.AssertNotCalled(t, "GetByNoteID")
+}
+
+func TestDiffRevisions_AgainstCurrent(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	userID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	note := &entities.Note{ID: noteID, UserID: userID, Title: "New", Content: "one\nthree\nfour"}
+	revision := &entities.NoteRevision{NoteID: noteID, Revision: 1, Title: "Old", Content: "one\ntwo\nthree"}
+
+	mockNoteRepo.On("GetByID", ctx, noteID).Return(note, nil)
+	mockRevisionRepo.On("GetByRevision", ctx, noteID, 1).Return(revision, nil)
+
+	useCase, _ := newTestNoteRevisionUseCase(mockRevisionRepo, mockNoteRepo, mockShareRepo, mockUserRepo)
+
+	// Act
+	diff, err := useCase.DiffRevisions(ctx, noteID, userID, 1, use_cases.CurrentRevision)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Old", diff.FromTitle)
+	assert.Equal(t, "New", diff.ToTitle)
+	assert.Equal(t, []entities.DiffLine{
+		{Op: entities.DiffEqual, Text: "one"},
+		{Op: entities.DiffDelete, Text: "two"},
+		{Op: entities.DiffEqual, Text: "three"},
+		{Op: entities.DiffInsert, Text: "four"},
+	}, diff.Lines)
+
+	mockNoteRepo.AssertExpectations(t)
+	mockRevisionRepo.AssertExpectations(t)
+}
+
+func TestDiffRevisions_RevisionNotFound(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	userID := uuid.New().String()
+	noteID := uuid.New().String()
+	note := &entities.Note{ID: noteID, UserID: userID}
+
+	mockNoteRepo.On("GetByID", ctx, noteID).Return(note, nil)
+	mockRevisionRepo.On("GetByRevision", ctx, noteID, 7).Return(nil, nil)
+
+	useCase, _ := newTestNoteRevisionUseCase(mockRevisionRepo, mockNoteRepo, mockShareRepo, mockUserRepo)
+
+	// Act
+	diff, err := useCase.DiffRevisions(ctx, noteID, userID, 7, use_cases.CurrentRevision)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, diff)
+	assert.Contains(t, err.Error(), "revision not found")
+}
+
+func TestDiffRevisions_TooLarge(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	userID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	// Every line changed on both sides, with a common first and last line
+	// that do not count against the limit
+	oldLines := []string{"start"}
+	newLines := []string{"start"}
+	for i := 0; i < 2001; i++ {
+		oldLines = append(oldLines, fmt.Sprintf("old %d", i))
+		newLines = append(newLines, fmt.Sprintf("new %d", i))
+	}
+	oldLines = append(oldLines, "end")
+	newLines = append(newLines, "end")
+
+	note := &entities.Note{ID: noteID, UserID: userID, Content: strings.Join(newLines, "\n")}
+	revision := &entities.NoteRevision{NoteID: noteID, Revision: 1, Content: strings.Join(oldLines, "\n")}
+
+	mockNoteRepo.On("GetByID", ctx, noteID).Return(note, nil)
+	mockRevisionRepo.On("GetByRevision", ctx, noteID, 1).Return(revision, nil)
+
+	useCase, _ := newTestNoteRevisionUseCase(mockRevisionRepo, mockNoteRepo, mockShareRepo, mockUserRepo)
+
+	// Act
+	diff, err := useCase.DiffRevisions(ctx, noteID, userID, 1, use_cases.CurrentRevision)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrUnprocessable)
+	assert.Nil(t, diff)
+}
+
+func TestRestoreRevision(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	userID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	note := &entities.Note{ID: noteID, UserID: userID, Title: "Current", Content: "Current content"}
+	revision := &entities.NoteRevision{NoteID: noteID, Revision: 1, Title: "Old", Content: "Old content"}
+
+	mockNoteRepo.On("GetByIDForUpdate", ctx, noteID).Return(note, nil)
+	mockRevisionRepo.On("GetByRevision", ctx, noteID, 1).Return(revision, nil)
+
+	// The current version is kept before being overwritten
+	mockRevisionRepo.On("Create", ctx, mock.MatchedBy(func(r *entities.NoteRevision) bool {
+		return r.NoteID == noteID && r.Title == "Current" && r.Content == "Current content"
+	})).Return(nil)
+	mockRevisionRepo.On("GetRetention", ctx, userID).Return(&entities.RevisionRetention{
+		UserID:       userID,
+		MaxRevisions: 0,
+		MaxAgeDays:   30,
+	}, nil)
+	mockRevisionRepo.On("DeleteOlderThan", ctx, noteID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	mockNoteRepo.On("Update", ctx, mock.MatchedBy(func(n *entities.Note) bool {
+		return n.Title == "Old" && n.Content == "Old content"
+	})).Return(nil)
+
+	useCase, txManager := newTestNoteRevisionUseCase(mockRevisionRepo, mockNoteRepo, mockShareRepo, mockUserRepo)
+
+	// Act
+	restored, err := useCase.RestoreRevision(ctx, noteID, userID, 1, 0)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Old", restored.Title)
+	assert.Equal(t, "Old content", restored.Content)
+
+	mockNoteRepo.AssertExpectations(t)
+	mockRevisionRepo.AssertExpectations(t)
+	mockRevisionRepo.AssertNotCalled(t, "DeleteAllButLatest")
+	assert.Equal(t, 1, txManager.Commits)
+}
+
+func TestRestoreRevision_VersionConflict(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	userID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	note := &entities.Note{ID: noteID, UserID: userID, Title: "Current", Content: "Current content", Version: 3}
+	revision := &entities.NoteRevision{NoteID: noteID, Revision: 1, Title: "Old", Content: "Old content"}
+
+	mockNoteRepo.On("GetByIDForUpdate", ctx, noteID).Return(note, nil)
+	mockRevisionRepo.On("GetByRevision", ctx, noteID, 1).Return(revision, nil)
+	mockRevisionRepo.On("Create", ctx, mock.Anything).Return(nil)
+	mockRevisionRepo.On("GetRetention", ctx, userID).Return(nil, nil)
+	mockRevisionRepo.On("DeleteAllButLatest", ctx, noteID, 50).Return(nil)
+
+	// The note changed between the read and the update
+	mockNoteRepo.On("Update", ctx, mock.Anything).Return(repositories.ErrVersionConflict)
+
+	useCase, txManager := newTestNoteRevisionUseCase(mockRevisionRepo, mockNoteRepo, mockShareRepo, mockUserRepo)
+
+	// Act
+	restored, err := useCase.RestoreRevision(ctx, noteID, userID, 1, 0)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrConflict)
+	assert.Nil(t, restored)
+
+	// The revision recorded for the current version is rolled back with it
+	assert.Equal(t, 0, txManager.Commits)
+	assert.Equal(t, 1, txManager.Rollbacks)
+}
+
+func TestRestoreRevision_OutdatedVersion(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	userID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	note := &entities.Note{ID: noteID, UserID: userID, Title: "Current", Content: "Current content", Version: 3}
+	mockNoteRepo.On("GetByIDForUpdate", ctx, noteID).Return(note, nil)
+
+	useCase, _ := newTestNoteRevisionUseCase(mockRevisionRepo, mockNoteRepo, mockShareRepo, mockUserRepo)
+
+	// Act
+	restored, err := useCase.RestoreRevision(ctx, noteID, userID, 1, 2)
+
+	// Assert
+	assert.ErrorIs(t, err, repositories.ErrVersionConflict)
+	assert.Nil(t, restored)
+	mockRevisionRepo.AssertNotCalled(t, "Create")
+	mockNoteRepo.AssertNotCalled(t, "Update")
+}
+
+func TestGetRetention_Default(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	userID := uuid.New().String()
+	mockRevisionRepo.On("GetRetention", ctx, userID).Return(nil, nil)
+
+	useCase, _ := newTestNoteRevisionUseCase(mockRevisionRepo, mockNoteRepo, mockShareRepo, mockUserRepo)
+
+	// Act
+	retention, err := useCase.GetRetention(ctx, userID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, userID, retention.UserID)
+	assert.Equal(t, 50, retention.MaxRevisions)
+	assert.Equal(t, 0, retention.MaxAgeDays)
+}
+
+func TestUpdateRetention(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	userID := uuid.New().String()
+	mockUserRepo.On("GetByID", ctx, userID).Return(&entities.User{ID: userID}, nil)
+	mockRevisionRepo.On("SaveRetention", ctx, mock.MatchedBy(func(r *entities.RevisionRetention) bool {
+		return r.UserID == userID && r.MaxRevisions == 10 && r.MaxAgeDays == 90
+	})).Return(nil)
+
+	useCase, _ := newTestNoteRevisionUseCase(mockRevisionRepo, mockNoteRepo, mockShareRepo, mockUserRepo)
+
+	// Act
+	retention, err := useCase.UpdateRetention(ctx, userID, 10, 90)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 10, retention.MaxRevisions)
+	assert.Equal(t, 90, retention.MaxAgeDays)
+
+	mockUserRepo.AssertExpectations(t)
+	mockRevisionRepo.AssertExpectations(t)
+}
+
+func TestUpdateRetention_Invalid(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	useCase, _ := newTestNoteRevisionUseCase(mockRevisionRepo, mockNoteRepo, mockShareRepo, mockUserRepo)
+
+	// Act
+	retention, err := useCase.UpdateRetention(ctx, uuid.New().String(), -1, 0)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, retention)
+	assert.Contains(t, err.Error(), "invalid retention policy")
+
+	var validation *domainerrors.ValidationError
+	assert.ErrorAs(t, err, &validation)
+	assert.Equal(t, []domainerrors.FieldError{{Field: "max_revisions", Message: "must not be negative"}}, validation.Fields)
+	mockRevisionRepo.AssertNotCalled(t, "SaveRetention")
+}
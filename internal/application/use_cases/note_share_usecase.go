@@ -0,0 +1,133 @@
+package use_cases
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type NoteShareUseCase struct {
+	shareRepo                repositories.NoteShareRepository
+	userRepo                 repositories.UserRepository
+	emailVerificationUseCase *EmailVerificationUseCase
+	permissions              *NotePermissionService
+}
+
+func NewNoteShareUseCase(
+	shareRepo repositories.NoteShareRepository,
+	userRepo repositories.UserRepository,
+	emailVerificationUseCase *EmailVerificationUseCase,
+	permissions *NotePermissionService,
+) *NoteShareUseCase {
+	return &NoteShareUseCase{
+		shareRepo:                shareRepo,
+		userRepo:                 userRepo,
+		emailVerificationUseCase: emailVerificationUseCase,
+		permissions:              permissions,
+	}
+}
+
+// ShareNote grants the user registered under the email a role on a note of
+// the owner, or changes the role they already have. Both addresses must be
+// verified unless verification is optional, so notes only go to people who
+// proved who they are.
+func (uc *NoteShareUseCase) ShareNote(ctx context.Context, noteID, ownerID, email string, role entities.NoteRole) (*entities.NoteShare, error) {
+	// Validate input
+	if !role.IsShareable() {
+		return nil, domainerrors.InvalidField("role", "must be editor or viewer")
+	}
+
+	// Only the owner may share the note
+	if _, err := uc.permissions.Authorize(ctx, noteID, ownerID, entities.NoteRoleOwner); err != nil {
+		return nil, err
+	}
+	owner, err := uc.userRepo.GetByID(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if owner == nil {
+		return nil, domainerrors.NotFound("user")
+	}
+	if err := uc.emailVerificationUseCase.RequireVerified(owner); err != nil {
+		return nil, err
+	}
+
+	// Find the user to share with
+	user, err := uc.userRepo.GetByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.DeletionRequestedAt != nil {
+		return nil, domainerrors.NotFound("user")
+	}
+	if user.ID == ownerID {
+		return nil, domainerrors.InvalidField("email", "cannot share a note with yourself")
+	}
+	if err := uc.emailVerificationUseCase.RequireVerified(user); err != nil {
+		return nil, domainerrors.InvalidField("email", "address not verified")
+	}
+
+	// Save the share, keeping the original one when the role changes
+	now := time.Now()
+	share := &entities.NoteShare{
+		ID:        uuid.New().String(),
+		NoteID:    noteID,
+		UserID:    user.ID,
+		Role:      role,
+		Email:     user.Email,
+		Name:      user.Name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := uc.shareRepo.Upsert(ctx, share); err != nil {
+		return nil, err
+	}
+
+	return share, nil
+}
+
+// ListShares returns who a note is shared with. Everybody with access to the
+// note may see it.
+func (uc *NoteShareUseCase) ListShares(ctx context.Context, noteID, userID string) ([]*entities.NoteShare, error) {
+	// Verify the user can read the note
+	if _, err := uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleViewer); err != nil {
+		return nil, err
+	}
+
+	return uc.shareRepo.GetByNoteID(ctx, noteID)
+}
+
+// RevokeShare removes the access of a user to a note. The owner may revoke
+// anybody, the other users may only leave the note themselves.
+func (uc *NoteShareUseCase) RevokeShare(ctx context.Context, noteID, userID, sharedUserID string) error {
+	// Check the user may revoke the share
+	required := entities.NoteRoleOwner
+	if sharedUserID == userID {
+		required = entities.NoteRoleViewer
+	}
+	if _, err := uc.permissions.Authorize(ctx, noteID, userID, required); err != nil {
+		return err
+	}
+
+	// Revoke the share
+	deleted, err := uc.shareRepo.Delete(ctx, noteID, sharedUserID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return domainerrors.NotFound("share")
+	}
+
+	return nil
+}
+
+// GetSharedNotes returns the notes other users shared with the user
+func (uc *NoteShareUseCase) GetSharedNotes(ctx context.Context, userID string) ([]*entities.SharedNote, error) {
+	return uc.shareRepo.GetSharedWithUser(ctx, userID)
+}
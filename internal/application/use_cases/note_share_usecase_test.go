@@ -0,0 +1,204 @@
+package use_cases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+// MockNoteShareRepository mocks the NoteShareRepository interface
+type MockNoteShareRepository struct {
+	mock.Mock
+}
+
+func (m *MockNoteShareRepository) Upsert(ctx context.Context, share *entities.NoteShare) error {
+	args := m.Called(ctx, share)
+	return args.Error(0)
+}
+
+func (m *MockNoteShareRepository) GetByNoteAndUser(ctx context.Context, noteID, userID string) (*entities.NoteShare, error) {
+	args := m.Called(ctx, noteID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.NoteShare), args.Error(1)
+}
+
+func (m *MockNoteShareRepository) GetByNoteID(ctx context.Context, noteID string) ([]*entities.NoteShare, error) {
+	args := m.Called(ctx, noteID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.NoteShare), args.Error(1)
+}
+
+func (m *MockNoteShareRepository) GetSharedWithUser(ctx context.Context, userID string) ([]*entities.SharedNote, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.SharedNote), args.Error(1)
+}
+
+func (m *MockNoteShareRepository) Delete(ctx context.Context, noteID, userID string) (bool, error) {
+	args := m.Called(ctx, noteID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func newTestNoteShareUseCase(mockShareRepo *MockNoteShareRepository, mockNoteRepo *MockNoteRepository, mockUserRepo *MockUserRepository) *use_cases.NoteShareUseCase {
+	emailVerificationUseCase := use_cases.NewEmailVerificationUseCase(mockUserRepo, new(MockEmailVerificationRepository), new(MockTokenService), new(MockMailer), use_cases.EmailVerificationRestrict, "https://notes.example.com/", 24*time.Hour, time.Minute)
+	return use_cases.NewNoteShareUseCase(mockShareRepo, mockUserRepo, emailVerificationUseCase, use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo))
+}
+
+func TestShareNote(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockShareRepo := new(MockNoteShareRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	verifiedAt := time.Now()
+	owner := &entities.User{ID: uuid.New().String(), Email: "owner@example.com", EmailVerifiedAt: &verifiedAt}
+	friend := &entities.User{ID: uuid.New().String(), Email: "friend@example.com", Name: "Friend", EmailVerifiedAt: &verifiedAt}
+	note := &entities.Note{ID: uuid.New().String(), UserID: owner.ID}
+
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockUserRepo.On("GetByID", ctx, owner.ID).Return(owner, nil)
+	mockUserRepo.On("GetByEmail", ctx, "friend@example.com").Return(friend, nil)
+	mockShareRepo.On("Upsert", ctx, mock.MatchedBy(func(share *entities.NoteShare) bool {
+		return share.NoteID == note.ID &&
+			share.UserID == friend.ID &&
+			share.Role == entities.NoteRoleEditor
+	})).Return(nil)
+
+	useCase := newTestNoteShareUseCase(mockShareRepo, mockNoteRepo, mockUserRepo)
+
+	// Act
+	share, err := useCase.ShareNote(ctx, note.ID, owner.ID, " friend@example.com ", entities.NoteRoleEditor)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Friend", share.Name)
+	mockShareRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestShareNote_OwnerRoleNotShareable(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockShareRepo := new(MockNoteShareRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	useCase := newTestNoteShareUseCase(mockShareRepo, mockNoteRepo, mockUserRepo)
+
+	// Act
+	share, err := useCase.ShareNote(ctx, uuid.New().String(), uuid.New().String(), "friend@example.com", entities.NoteRoleOwner)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrValidation)
+	assert.Nil(t, share)
+	mockShareRepo.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+}
+
+func TestShareNote_EditorCannotShare(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockShareRepo := new(MockNoteShareRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	editorID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: uuid.New().String()}
+
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockShareRepo.On("GetByNoteAndUser", ctx, note.ID, editorID).Return(&entities.NoteShare{Role: entities.NoteRoleEditor}, nil)
+
+	useCase := newTestNoteShareUseCase(mockShareRepo, mockNoteRepo, mockUserRepo)
+
+	// Act
+	_, err := useCase.ShareNote(ctx, note.ID, editorID, "friend@example.com", entities.NoteRoleViewer)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrForbidden)
+	mockShareRepo.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+}
+
+func TestShareNote_UnverifiedOwner(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockShareRepo := new(MockNoteShareRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	owner := &entities.User{ID: uuid.New().String(), Email: "owner@example.com"}
+	note := &entities.Note{ID: uuid.New().String(), UserID: owner.ID}
+
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockUserRepo.On("GetByID", ctx, owner.ID).Return(owner, nil)
+
+	useCase := newTestNoteShareUseCase(mockShareRepo, mockNoteRepo, mockUserRepo)
+
+	// Act
+	_, err := useCase.ShareNote(ctx, note.ID, owner.ID, "friend@example.com", entities.NoteRoleViewer)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrForbidden)
+	mockUserRepo.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+}
+
+func TestRevokeShare_Leave(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockShareRepo := new(MockNoteShareRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	viewerID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: uuid.New().String()}
+
+	// Viewers may remove themselves from a note
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockShareRepo.On("GetByNoteAndUser", ctx, note.ID, viewerID).Return(&entities.NoteShare{Role: entities.NoteRoleViewer}, nil)
+	mockShareRepo.On("Delete", ctx, note.ID, viewerID).Return(true, nil)
+
+	useCase := newTestNoteShareUseCase(mockShareRepo, mockNoteRepo, mockUserRepo)
+
+	// Act
+	err := useCase.RevokeShare(ctx, note.ID, viewerID, viewerID)
+
+	// Assert
+	assert.NoError(t, err)
+	mockShareRepo.AssertExpectations(t)
+}
+
+func TestRevokeShare_ViewerCannotRevokeOthers(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockShareRepo := new(MockNoteShareRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockUserRepo := new(MockUserRepository)
+
+	viewerID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: uuid.New().String()}
+
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockShareRepo.On("GetByNoteAndUser", ctx, note.ID, viewerID).Return(&entities.NoteShare{Role: entities.NoteRoleViewer}, nil)
+
+	useCase := newTestNoteShareUseCase(mockShareRepo, mockNoteRepo, mockUserRepo)
+
+	// Act
+	err := useCase.RevokeShare(ctx, note.ID, viewerID, uuid.New().String())
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrForbidden)
+	mockShareRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -3,117 +3,304 @@ package use_cases
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
 	"github.com/LaulauChau/note-nest/internal/domain/entities"
 	"github.com/LaulauChau/note-nest/internal/domain/repositories"
 )
 
+const (
+	// defaultPageSize is used when a listing does not specify a limit
+	defaultPageSize = 20
+
+	// maxPageSize caps the number of notes returned by a single listing
+	maxPageSize = 100
+
+	// maxSearchResults caps the number of notes returned by a single search
+	maxSearchResults = 50
+
+	// notePositionGap separates the positions of notes placed at either end
+	// of a list, it matches the gap the database leaves between notes
+	notePositionGap = 1024
+)
+
 type NoteUseCase struct {
-	noteRepo  repositories.NoteRepository
-	userRepo  repositories.UserRepository
-	labelRepo repositories.LabelRepository
+	noteRepo     repositories.NoteRepository
+	userRepo     repositories.UserRepository
+	labelRepo    repositories.LabelRepository
+	revisionRepo repositories.NoteRevisionRepository
+	txManager    repositories.TxManager
+	permissions  *NotePermissionService
+	blobStore    services.BlobStore
 }
 
 func NewNoteUseCase(
 	noteRepo repositories.NoteRepository,
 	userRepo repositories.UserRepository,
 	labelRepo repositories.LabelRepository,
+	revisionRepo repositories.NoteRevisionRepository,
+	txManager repositories.TxManager,
+	permissions *NotePermissionService,
+	blobStore services.BlobStore,
 ) *NoteUseCase {
 	return &NoteUseCase{
-		noteRepo:  noteRepo,
-		userRepo:  userRepo,
-		labelRepo: labelRepo,
+		noteRepo:     noteRepo,
+		userRepo:     userRepo,
+		labelRepo:    labelRepo,
+		revisionRepo: revisionRepo,
+		txManager:    txManager,
+		permissions:  permissions,
+		blobStore:    blobStore,
 	}
 }
 
 func (uc *NoteUseCase) CreateNote(ctx context.Context, userID, title, content, label string) (*entities.Note, error) {
+	// Create a new note
+	note, err := uc.newNote(ctx, userID, title, content, label)
+	if err != nil {
+		return nil, err
+	}
+
+	// Save the note
+	if err := uc.noteRepo.Create(ctx, note); err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+// newNote builds a note for an existing user without saving it
+func (uc *NoteUseCase) newNote(ctx context.Context, userID, title, content, label string) (*entities.Note, error) {
 	// Verify the user exists
 	user, err := uc.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, domainerrors.NotFound("user")
 	}
 
-	// Create a new note
 	now := time.Now()
-	note := &entities.Note{
+	return &entities.Note{
 		ID:         uuid.New().String(),
 		UserID:     userID,
 		Title:      title,
 		Content:    content,
+		Type:       entities.NoteTypeText,
 		IsArchived: false,
 		Label:      label,
 		CreatedAt:  now,
 		UpdatedAt:  now,
-	}
+	}, nil
+}
 
-	// Save the note
-	if err := uc.noteRepo.Create(ctx, note); err != nil {
+// GetNoteByID returns a note the user owns or that was shared with them
+func (uc *NoteUseCase) GetNoteByID(ctx context.Context, noteID, userID string) (*entities.Note, error) {
+	return uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleViewer)
+}
+
+func (uc *NoteUseCase) GetActiveNotes(ctx context.Context, userID string) ([]*entities.Note, error) {
+	// Verify the user exists
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
 		return nil, err
 	}
+	if user == nil {
+		return nil, domainerrors.NotFound("user")
+	}
 
-	return note, nil
+	// Get active notes for the user
+	return uc.noteRepo.GetByUserID(ctx, userID)
 }
 
-func (uc *NoteUseCase) GetNoteByID(ctx context.Context, noteID, userID string) (*entities.Note, error) {
-	// Get the note
-	note, err := uc.noteRepo.GetByID(ctx, noteID)
+func (uc *NoteUseCase) GetArchivedNotes(ctx context.Context, userID string) ([]*entities.Note, error) {
+	// Verify the user exists
+	user, err := uc.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
-
-	// If note not found or doesn't belong to the user, return nil
-	if note == nil || note.UserID != userID {
-		return nil, errors.New("note not found")
+	if user == nil {
+		return nil, domainerrors.NotFound("user")
 	}
 
-	return note, nil
+	// Get archived notes for the user
+	return uc.noteRepo.GetArchivedByUserID(ctx, userID)
 }
 
-func (uc *NoteUseCase) GetActiveNotes(ctx context.Context, userID string) ([]*entities.Note, error) {
+func (uc *NoteUseCase) ListNotes(ctx context.Context, userID string, filter entities.NoteFilter, cursor string) (*entities.NotePage, error) {
+	// Apply defaults and validate the paging options
+	if filter.SortBy == "" {
+		filter.SortBy = entities.NoteSortByPosition
+		filter.Descending = false
+	}
+	switch filter.SortBy {
+	case entities.NoteSortByCreatedAt, entities.NoteSortByUpdatedAt, entities.NoteSortByTitle, entities.NoteSortByPosition:
+	default:
+		return nil, domainerrors.InvalidField("sort", "invalid sort field")
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+
 	// Verify the user exists
 	user, err := uc.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, domainerrors.NotFound("user")
 	}
 
-	// Get active notes for the user
-	return uc.noteRepo.GetByUserID(ctx, userID)
+	// Resume after the cursor, which must have been issued for the same ordering
+	filter.Cursor = nil
+	if cursor != "" {
+		decoded, err := decodeNoteCursor(cursor, filter.SortBy, filter.Descending)
+		if err != nil {
+			return nil, err
+		}
+		filter.Cursor = decoded
+	}
+
+	// Fetch one extra note to know whether another page follows
+	pageSize := filter.Limit
+	filter.UserID = userID
+	filter.Limit = pageSize + 1
+
+	notes, err := uc.noteRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &entities.NotePage{Notes: notes}
+	if len(notes) > pageSize {
+		page.Notes = notes[:pageSize]
+		last := page.Notes[pageSize-1]
+		page.NextCursor = encodeNoteCursor(filter.SortBy, filter.Descending, last)
+	}
+
+	return page, nil
 }
 
-func (uc *NoteUseCase) GetArchivedNotes(ctx context.Context, userID string) ([]*entities.Note, error) {
+// ListAllNotes returns every note matching the filter, one page after the
+// other. It serves the listing of clients that do not page through results.
+func (uc *NoteUseCase) ListAllNotes(ctx context.Context, userID string, filter entities.NoteFilter) ([]*entities.Note, error) {
+	filter.Limit = maxPageSize
+
+	notes := []*entities.Note{}
+	cursor := ""
+	for {
+		page, err := uc.ListNotes(ctx, userID, filter, cursor)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, page.Notes...)
+
+		if page.NextCursor == "" {
+			return notes, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func (uc *NoteUseCase) SearchNotes(ctx context.Context, userID, query string, includeArchived bool) ([]*entities.NoteSearchResult, error) {
+	// Validate the query
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, domainerrors.InvalidField("q", "search query is required")
+	}
+
 	// Verify the user exists
 	user, err := uc.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, domainerrors.NotFound("user")
 	}
 
-	// Get archived notes for the user
-	return uc.noteRepo.GetArchivedByUserID(ctx, userID)
+	// Search the user's notes
+	results, err := uc.noteRepo.Search(ctx, userID, query, includeArchived, maxSearchResults)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only include notes that belong to the user
+	owned := make([]*entities.NoteSearchResult, 0, len(results))
+	for _, result := range results {
+		if result.Note != nil && result.Note.UserID == userID {
+			owned = append(owned, result)
+		}
+	}
+
+	return owned, nil
 }
 
-func (uc *NoteUseCase) UpdateNote(ctx context.Context, noteID, userID, title, content, label string, isArchived bool) (*entities.Note, error) {
-	// Get the note
-	note, err := uc.noteRepo.GetByID(ctx, noteID)
+// UpdateNote saves the new note fields. A non-zero expectedVersion makes the
+// update fail with a conflict error unless the note is still at that version.
+// Editors may update the note, only its owner may archive or unarchive it.
+func (uc *NoteUseCase) UpdateNote(ctx context.Context, noteID, userID, title, content, label string, isArchived bool, expectedVersion int) (*entities.Note, error) {
+	var note *entities.Note
+
+	// Save the note and its previous revision together
+	err := uc.txManager.WithinTx(ctx, func(ctx context.Context, repos repositories.TxRepositories) error {
+		var err error
+		note, err = updateNote(ctx, repos, uc.permissions, noteID, userID, title, content, label, isArchived, expectedVersion)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// If note not found or doesn't belong to the user, return error
-	if note == nil || note.UserID != userID {
-		return nil, errors.New("note not found")
+	return note, nil
+}
+
+func updateNote(ctx context.Context, repos repositories.TxRepositories, permissions *NotePermissionService, noteID, userID, title, content, label string, isArchived bool, expectedVersion int) (*entities.Note, error) {
+	// Get and lock the note
+	note, err := repos.Notes.GetByIDForUpdate(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if note == nil {
+		return nil, domainerrors.NotFound("note")
+	}
+
+	// Check the user may make the change
+	required := entities.NoteRoleEditor
+	if isArchived != note.IsArchived {
+		required = entities.NoteRoleOwner
+	}
+	if err := permissions.Check(ctx, note, userID, required); err != nil {
+		return nil, err
+	}
+
+	// Reject edits made against an outdated version. Without an expected
+	// version the edit applies to the locked note, whatever its version.
+	if expectedVersion != 0 && note.Version != expectedVersion {
+		return nil, versionConflict("note")
 	}
+	if note.Type == entities.NoteTypeChecklist && content != "" {
+		return nil, domainerrors.InvalidField("content", "checklist notes keep their content in their items")
+	}
+
+	// Keep the previous version when the text changes
+	if note.Title != title || note.Content != content {
+		if err := recordNoteRevision(ctx, repos.Revisions, note); err != nil {
+			return nil, err
+		}
+	}
+
+	// Public links of a note stop working for good once it is archived
+	archiving := isArchived && !note.IsArchived
 
 	// Update the note fields
 	note.Title = title
@@ -123,27 +310,341 @@ func (uc *NoteUseCase) UpdateNote(ctx context.Context, noteID, userID, title, co
 	note.UpdatedAt = time.Now() // Make sure this line is present
 
 	// Save the updated note
-	if err := uc.noteRepo.Update(ctx, note); err != nil {
+	if err := repos.Notes.Update(ctx, note); err != nil {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			return nil, versionConflict("note")
+		}
 		return nil, err
 	}
+	if archiving {
+		if err := repos.ShareLinks.DeleteByNoteID(ctx, note.ID); err != nil {
+			return nil, err
+		}
+	}
 
 	return note, nil
 }
 
 func (uc *NoteUseCase) DeleteNote(ctx context.Context, noteID, userID string) error {
-	// Get the note
-	note, err := uc.noteRepo.GetByID(ctx, noteID)
-	if err != nil {
+	// Only the owner may trash the note
+	if _, err := uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleOwner); err != nil {
 		return err
 	}
 
+	// Move the note to the trash
+	return uc.noteRepo.Trash(ctx, noteID, time.Now())
+}
+
+func (uc *NoteUseCase) GetTrashedNotes(ctx context.Context, userID string) ([]*entities.Note, error) {
+	// Verify the user exists
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, domainerrors.NotFound("user")
+	}
+
+	// Get trashed notes for the user
+	return uc.noteRepo.GetTrashedByUserID(ctx, userID)
+}
+
+func (uc *NoteUseCase) RestoreNote(ctx context.Context, noteID, userID string) (*entities.Note, error) {
+	// Get the trashed note
+	note, err := uc.noteRepo.GetTrashedByID(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+
 	// If note not found or doesn't belong to the user, return error
 	if note == nil || note.UserID != userID {
-		return errors.New("note not found")
+		return nil, domainerrors.NotFound("note")
 	}
 
-	// Delete the note
-	return uc.noteRepo.Delete(ctx, noteID)
+	// Take the note out of the trash, its labels were left untouched
+	if err := uc.noteRepo.Restore(ctx, noteID); err != nil {
+		return nil, err
+	}
+	note.DeletedAt = nil
+
+	return note, nil
+}
+
+func (uc *NoteUseCase) EmptyTrash(ctx context.Context, userID string) (int64, error) {
+	// Verify the user exists
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if user == nil {
+		return 0, domainerrors.NotFound("user")
+	}
+
+	// Permanently delete the trashed notes along with their attachments
+	var deleted int64
+	var blobKeys []string
+	err = uc.txManager.WithinTx(ctx, func(ctx context.Context, repos repositories.TxRepositories) error {
+		var err error
+		if blobKeys, err = repos.Attachments.DeleteTrashedByUserID(ctx, userID); err != nil {
+			return err
+		}
+		deleted, err = repos.Notes.DeleteTrashedByUserID(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	deleteBlobs(ctx, uc.blobStore, blobKeys)
+
+	return deleted, nil
+}
+
+// PurgeTrash permanently deletes notes that have been in the trash for longer
+// than the retention period
+func (uc *NoteUseCase) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, domainerrors.InvalidField("retention", "invalid trash retention")
+	}
+
+	// Permanently delete the notes along with their attachments
+	cutoff := time.Now().Add(-retention)
+	var purged int64
+	var blobKeys []string
+	err := uc.txManager.WithinTx(ctx, func(ctx context.Context, repos repositories.TxRepositories) error {
+		var err error
+		if blobKeys, err = repos.Attachments.DeleteTrashedBefore(ctx, cutoff); err != nil {
+			return err
+		}
+		purged, err = repos.Notes.DeleteTrashedBefore(ctx, cutoff)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	deleteBlobs(ctx, uc.blobStore, blobKeys)
+
+	return purged, nil
+}
+
+// PinNote pins or unpins a note of the owner. Either way the note moves to
+// the top of its group.
+func (uc *NoteUseCase) PinNote(ctx context.Context, noteID, userID string, pinned bool) (*entities.Note, error) {
+	// Only the owner arranges their notes
+	note, err := uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	if note.IsPinned == pinned {
+		return note, nil
+	}
+
+	if err := uc.noteRepo.SetPinned(ctx, note, pinned); err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+// MoveNote places a note of the owner right before or right after another
+// note of the same list. Only the moved note is written, halfway between its
+// new neighbors, unless they are too close to fit it between them.
+func (uc *NoteUseCase) MoveNote(ctx context.Context, noteID, userID, targetID string, after bool) (*entities.Note, error) {
+	if noteID == targetID {
+		return nil, domainerrors.InvalidField("target_id", "cannot move a note next to itself")
+	}
+
+	var note *entities.Note
+
+	// Read the neighbors and write the position together
+	err := uc.txManager.WithinTx(ctx, func(ctx context.Context, repos repositories.TxRepositories) error {
+		var err error
+		note, err = moveNote(ctx, repos.Notes, uc.permissions, noteID, userID, targetID, after)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+func moveNote(ctx context.Context, noteRepo repositories.NoteRepository, permissions *NotePermissionService, noteID, userID, targetID string, after bool) (*entities.Note, error) {
+	// Get the note
+	note, err := noteRepo.GetByID(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if note == nil {
+		return nil, domainerrors.NotFound("note")
+	}
+	if err := permissions.Check(ctx, note, userID, entities.NoteRoleOwner); err != nil {
+		return nil, err
+	}
+
+	// The target must be another note of the user in the same list
+	target, err := noteRepo.GetByID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil || target.UserID != userID {
+		return nil, domainerrors.InvalidField("target_id", "note not found")
+	}
+	if target.IsArchived != note.IsArchived || target.IsPinned != note.IsPinned {
+		return nil, domainerrors.InvalidField("target_id", "must be in the same list as the note")
+	}
+
+	position, fits, err := positionNextTo(ctx, noteRepo, target, after, note.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !fits {
+		// Spread the notes again to make room, then read the target again
+		if err := noteRepo.RebalancePositions(ctx, userID); err != nil {
+			return nil, err
+		}
+		target, err = noteRepo.GetByID(ctx, targetID)
+		if err != nil {
+			return nil, err
+		}
+		if target == nil {
+			return nil, domainerrors.NotFound("note")
+		}
+		position, fits, err = positionNextTo(ctx, noteRepo, target, after, note.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !fits {
+			return nil, errors.New("no room left to move the note")
+		}
+	}
+
+	// Save the new position
+	if err := noteRepo.UpdatePosition(ctx, note.ID, position); err != nil {
+		return nil, err
+	}
+	note.Position = position
+
+	return note, nil
+}
+
+// positionNextTo returns a position right before or right after the target
+// note, ignoring the note being moved. It reports false when the neighbor of
+// the target is too close for a position to fit between them.
+func positionNextTo(ctx context.Context, noteRepo repositories.NoteRepository, target *entities.Note, after bool, movedID string) (float64, bool, error) {
+	neighbor, err := noteRepo.GetAdjacentPosition(ctx, target, after, movedID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	position, fits := positionBetween(target.Position, neighbor, after)
+	return position, fits, nil
+}
+
+// positionBetween returns the position halfway between a target and its
+// neighbor, or one gap past the target when it has no neighbor on that side.
+// It reports false when no position fits between the two.
+func positionBetween(target float64, neighbor *float64, after bool) (float64, bool) {
+	// Nothing on that side, step past the target
+	if neighbor == nil {
+		if after {
+			return target + notePositionGap, true
+		}
+		return target - notePositionGap, true
+	}
+
+	// Halving the gap eventually runs out of floating point precision
+	position := target + (*neighbor-target)/2
+	return position, position != target && position != *neighbor
+}
+
+// ConvertNote turns a text note into a checklist, one item per line of its
+// content, or a checklist into a text note, one Markdown task per item.
+// Converting a note to the type it already has changes nothing.
+func (uc *NoteUseCase) ConvertNote(ctx context.Context, noteID, userID string, noteType entities.NoteType, expectedVersion int) (*entities.Note, error) {
+	if !noteType.IsValid() {
+		return nil, domainerrors.InvalidField("type", fmt.Sprintf("must be %q or %q", entities.NoteTypeText, entities.NoteTypeChecklist))
+	}
+
+	var note *entities.Note
+
+	// Save the note, its previous revision and its items together
+	err := uc.txManager.WithinTx(ctx, func(ctx context.Context, repos repositories.TxRepositories) error {
+		var err error
+		note, err = convertNote(ctx, repos, uc.permissions, noteID, userID, noteType, expectedVersion)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+func convertNote(ctx context.Context, repos repositories.TxRepositories, permissions *NotePermissionService, noteID, userID string, noteType entities.NoteType, expectedVersion int) (*entities.Note, error) {
+	// Get and lock the note
+	note, err := repos.Notes.GetByIDForUpdate(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if note == nil {
+		return nil, domainerrors.NotFound("note")
+	}
+	if err := permissions.Check(ctx, note, userID, entities.NoteRoleEditor); err != nil {
+		return nil, err
+	}
+
+	// Reject conversions made against an outdated version
+	if expectedVersion != 0 && note.Version != expectedVersion {
+		return nil, versionConflict("note")
+	}
+	if note.Type == noteType {
+		return note, nil
+	}
+
+	// Keep the text the checklist is made from
+	if note.Content != "" {
+		if err := recordNoteRevision(ctx, repos.Revisions, note); err != nil {
+			return nil, err
+		}
+	}
+
+	var items []*entities.ChecklistItem
+	if noteType == entities.NoteTypeChecklist {
+		items = parseChecklist(note.Content)
+		note.Content = ""
+	} else {
+		current, err := repos.Checklists.GetByNoteID(ctx, note.ID)
+		if err != nil {
+			return nil, err
+		}
+		note.Content = renderChecklist(current)
+	}
+	note.Type = noteType
+	note.UpdatedAt = time.Now()
+
+	// Save the converted note
+	if err := repos.Notes.Update(ctx, note); err != nil {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			return nil, versionConflict("note")
+		}
+		return nil, err
+	}
+
+	// Replace the items
+	if noteType == entities.NoteTypeText {
+		return note, repos.Checklists.DeleteByNoteID(ctx, note.ID)
+	}
+	for _, item := range items {
+		item.ID = uuid.New().String()
+		item.NoteID = note.ID
+		item.CreatedAt = note.UpdatedAt
+		item.UpdatedAt = note.UpdatedAt
+		if err := repos.Checklists.Create(ctx, item); err != nil {
+			return nil, err
+		}
+	}
+
+	return note, nil
 }
 
 func (uc *NoteUseCase) GetNoteWithLabels(ctx context.Context, noteID, userID string) (*entities.Note, []*entities.Label, error) {
@@ -159,46 +660,61 @@ func (uc *NoteUseCase) GetNoteWithLabels(ctx context.Context, noteID, userID str
 		return note, nil, err
 	}
 
-	return note, labels, nil
+	return note, ownLabels(labels, userID), nil
 }
 
 func (uc *NoteUseCase) CreateNoteWithLabels(ctx context.Context, userID, title, content, label string, labelIDs []string) (*entities.Note, error) {
-	// Create the note
-	note, err := uc.CreateNote(ctx, userID, title, content, label)
+	// Create a new note
+	note, err := uc.newNote(ctx, userID, title, content, label)
 	if err != nil {
 		return nil, err
 	}
 
-	// Associate labels with the note
-	for _, labelID := range labelIDs {
-		// Verify label exists and belongs to the user
-		label, err := uc.labelRepo.GetByID(ctx, labelID)
-		if err != nil || label == nil || label.UserID != userID {
-			continue // Skip invalid labels
+	// Save the note and its labels together
+	err = uc.txManager.WithinTx(ctx, func(ctx context.Context, repos repositories.TxRepositories) error {
+		if err := repos.Notes.Create(ctx, note); err != nil {
+			return err
 		}
 
-		// Associate label with note
-		err = uc.labelRepo.AddLabelToNote(ctx, note.ID, labelID)
-		if err != nil {
-			return nil, err
-		}
+		return syncNoteLabels(ctx, repos.Labels, note.ID, userID, labelIDs)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return note, nil
 }
 
-func (uc *NoteUseCase) UpdateNoteWithLabels(ctx context.Context, noteID, userID, title, content, label string, isArchived bool, labelIDs []string) (*entities.Note, error) {
-	// Update the note
-	note, err := uc.UpdateNote(ctx, noteID, userID, title, content, label, isArchived)
+func (uc *NoteUseCase) UpdateNoteWithLabels(ctx context.Context, noteID, userID, title, content, label string, isArchived bool, labelIDs []string, expectedVersion int) (*entities.Note, error) {
+	var note *entities.Note
+
+	// Save the note, its previous revision and its labels together
+	err := uc.txManager.WithinTx(ctx, func(ctx context.Context, repos repositories.TxRepositories) error {
+		var err error
+		note, err = updateNote(ctx, repos, uc.permissions, noteID, userID, title, content, label, isArchived, expectedVersion)
+		if err != nil {
+			return err
+		}
+
+		return syncNoteLabels(ctx, repos.Labels, noteID, userID, labelIDs)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Get current labels for the note
-	currentLabels, err := uc.labelRepo.GetLabelsForNote(ctx, noteID)
+	return note, nil
+}
+
+// syncNoteLabels makes the labels the user put on a note match labelIDs,
+// skipping labels that do not exist or belong to another user. The labels
+// other users put on a shared note are left alone.
+func syncNoteLabels(ctx context.Context, labelRepo repositories.LabelRepository, noteID, userID string, labelIDs []string) error {
+	// Get current labels of the user for the note
+	currentLabels, err := labelRepo.GetLabelsForNote(ctx, noteID)
 	if err != nil {
-		return note, err
+		return err
 	}
+	currentLabels = ownLabels(currentLabels, userID)
 
 	// Create a map of current label IDs for easy lookup
 	currentLabelMap := make(map[string]bool)
@@ -216,15 +732,17 @@ func (uc *NoteUseCase) UpdateNoteWithLabels(ctx context.Context, noteID, userID,
 	for _, labelID := range labelIDs {
 		if !currentLabelMap[labelID] {
 			// Verify label exists and belongs to the user
-			label, err := uc.labelRepo.GetByID(ctx, labelID)
-			if err != nil || label == nil || label.UserID != userID {
+			label, err := labelRepo.GetByID(ctx, labelID)
+			if err != nil {
+				return err
+			}
+			if label == nil || label.UserID != userID {
 				continue // Skip invalid labels
 			}
 
 			// Associate label with note
-			err = uc.labelRepo.AddLabelToNote(ctx, noteID, labelID)
-			if err != nil {
-				return nil, err
+			if err := labelRepo.AddLabelToNote(ctx, noteID, labelID); err != nil {
+				return err
 			}
 		}
 	}
@@ -232,12 +750,23 @@ func (uc *NoteUseCase) UpdateNoteWithLabels(ctx context.Context, noteID, userID,
 	// Remove labels that are no longer associated
 	for _, label := range currentLabels {
 		if !newLabelMap[label.ID] {
-			err = uc.labelRepo.RemoveLabelFromNote(ctx, noteID, label.ID)
-			if err != nil {
-				return nil, err
+			if err := labelRepo.RemoveLabelFromNote(ctx, noteID, label.ID); err != nil {
+				return err
 			}
 		}
 	}
 
-	return note, nil
+	return nil
+}
+
+// ownLabels keeps the labels that belong to the user. Labels are private, a
+// note shared with other users may carry theirs too.
+func ownLabels(labels []*entities.Label, userID string) []*entities.Label {
+	owned := make([]*entities.Label, 0, len(labels))
+	for _, label := range labels {
+		if label.UserID == userID {
+			owned = append(owned, label)
+		}
+	}
+	return owned
 }
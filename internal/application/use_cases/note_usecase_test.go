@@ -2,6 +2,9 @@ package use_cases_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
 	"testing"
 	"time"
 
@@ -10,7 +13,9 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
 	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
 )
 
 // MockNoteRepository mocks the NoteRepository interface
@@ -31,6 +36,14 @@ func (m *MockNoteRepository) GetByID(ctx context.Context, id string) (*entities.
 	return args.Get(0).(*entities.Note), args.Error(1)
 }
 
+func (m *MockNoteRepository) GetByIDForUpdate(ctx context.Context, id string) (*entities.Note, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Note), args.Error(1)
+}
+
 func (m *MockNoteRepository) GetByUserID(ctx context.Context, userID string) ([]*entities.Note, error) {
 	args := m.Called(ctx, userID)
 	if args.Get(0) == nil {
@@ -47,27 +60,165 @@ func (m *MockNoteRepository) GetArchivedByUserID(ctx context.Context, userID str
 	return args.Get(0).([]*entities.Note), args.Error(1)
 }
 
+func (m *MockNoteRepository) List(ctx context.Context, filter entities.NoteFilter) ([]*entities.Note, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.Note), args.Error(1)
+}
+
+func (m *MockNoteRepository) Search(ctx context.Context, userID, query string, includeArchived bool, limit int) ([]*entities.NoteSearchResult, error) {
+	args := m.Called(ctx, userID, query, includeArchived, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.NoteSearchResult), args.Error(1)
+}
+
 func (m *MockNoteRepository) Update(ctx context.Context, note *entities.Note) error {
 	args := m.Called(ctx, note)
 	return args.Error(0)
 }
 
+func (m *MockNoteRepository) Touch(ctx context.Context, id string, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) SetPinned(ctx context.Context, note *entities.Note, pinned bool) error {
+	args := m.Called(ctx, note, pinned)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) GetAdjacentPosition(ctx context.Context, note *entities.Note, after bool, excludeID string) (*float64, error) {
+	args := m.Called(ctx, note, after, excludeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*float64), args.Error(1)
+}
+
+func (m *MockNoteRepository) UpdatePosition(ctx context.Context, id string, position float64) error {
+	args := m.Called(ctx, id, position)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) RebalancePositions(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
 func (m *MockNoteRepository) Delete(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockNoteRepository) DeleteAllByUserID(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) Trash(ctx context.Context, id string, deletedAt time.Time) error {
+	args := m.Called(ctx, id, deletedAt)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) Restore(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) GetTrashedByID(ctx context.Context, id string) (*entities.Note, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Note), args.Error(1)
+}
+
+func (m *MockNoteRepository) GetTrashedByUserID(ctx context.Context, userID string) ([]*entities.Note, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.Note), args.Error(1)
+}
+
+func (m *MockNoteRepository) DeleteTrashedByUserID(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNoteRepository) DeleteTrashedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	args := m.Called(ctx, cutoff)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 // MockLabelRepository mocks the LabelRepository interface
 type MockLabelRepository struct {
 	mock.Mock
 }
 
+// FakeTxManager runs units of work against the given repositories and records
+// whether each one was committed or rolled back
+type FakeTxManager struct {
+	repos     repositories.TxRepositories
+	Commits   int
+	Rollbacks int
+	// ShareLinks expects the links of notes being archived to be deleted
+	ShareLinks *MockShareLinkRepository
+	// Checklists expects the items of checklist notes to be changed
+	Checklists *MockChecklistItemRepository
+	// Attachments expects the attachments of deleted notes to be deleted
+	Attachments *MockAttachmentRepository
+}
+
+func NewFakeTxManager(noteRepo *MockNoteRepository, labelRepo *MockLabelRepository, revisionRepo *MockNoteRevisionRepository) *FakeTxManager {
+	shareLinkRepo := new(MockShareLinkRepository)
+	checklistRepo := new(MockChecklistItemRepository)
+	attachmentRepo := new(MockAttachmentRepository)
+	return &FakeTxManager{
+		repos: repositories.TxRepositories{
+			Notes:       noteRepo,
+			Labels:      labelRepo,
+			Revisions:   revisionRepo,
+			ShareLinks:  shareLinkRepo,
+			Checklists:  checklistRepo,
+			Attachments: attachmentRepo,
+		},
+		ShareLinks:  shareLinkRepo,
+		Checklists:  checklistRepo,
+		Attachments: attachmentRepo,
+	}
+}
+
+func (m *FakeTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context, repos repositories.TxRepositories) error) error {
+	if err := fn(ctx, m.repos); err != nil {
+		m.Rollbacks++
+		return err
+	}
+
+	m.Commits++
+	return nil
+}
+
+// newTestNoteUseCase builds the use case around the repositories of the fake
+// transaction manager
+func newTestNoteUseCase(mockNoteRepo *MockNoteRepository, mockUserRepo *MockUserRepository, mockShareRepo *MockNoteShareRepository, txManager *FakeTxManager, blobStore *MockBlobStore) *use_cases.NoteUseCase {
+	permissions := use_cases.NewNotePermissionService(mockNoteRepo, mockShareRepo)
+	return use_cases.NewNoteUseCase(mockNoteRepo, mockUserRepo, txManager.repos.Labels, txManager.repos.Revisions, txManager, permissions, blobStore)
+}
+
 func TestCreateNote(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
 
 	userID := uuid.New().String()
 	title := "Test Note"
@@ -91,7 +242,7 @@ func TestCreateNote(t *testing.T) {
 			note.IsArchived == false
 	})).Return(nil)
 
-	useCase := use_cases.NewNoteUseCase(mockNoteRepo, mockUserRepo, mockLabelRepo)
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
 
 	// Act
 	note, err := useCase.CreateNote(ctx, userID, title, content, label)
@@ -116,8 +267,11 @@ func TestCreateNote_UserNotFound(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
 
 	userID := uuid.New().String()
 	title := "Test Note"
@@ -127,7 +281,7 @@ func TestCreateNote_UserNotFound(t *testing.T) {
 	// Mock user repository to return nil (user not found)
 	mockUserRepo.On("GetByID", ctx, userID).Return(nil, nil)
 
-	useCase := use_cases.NewNoteUseCase(mockNoteRepo, mockUserRepo, mockLabelRepo)
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
 
 	// Act
 	note, err := useCase.CreateNote(ctx, userID, title, content, label)
@@ -146,8 +300,11 @@ func TestGetNoteByID(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
 
 	userID := uuid.New().String()
 	noteID := uuid.New().String()
@@ -165,7 +322,7 @@ func TestGetNoteByID(t *testing.T) {
 	// Mock note repository to return a note
 	mockNoteRepo.On("GetByID", ctx, noteID).Return(note, nil)
 
-	useCase := use_cases.NewNoteUseCase(mockNoteRepo, mockUserRepo, mockLabelRepo)
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
 
 	// Act
 	result, err := useCase.GetNoteByID(ctx, noteID, userID)
@@ -180,8 +337,11 @@ func TestGetNoteByID_NotFound(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
 
 	userID := uuid.New().String()
 	noteID := uuid.New().String()
@@ -189,7 +349,7 @@ func TestGetNoteByID_NotFound(t *testing.T) {
 	// Mock note repository to return nil (note not found)
 	mockNoteRepo.On("GetByID", ctx, noteID).Return(nil, nil)
 
-	useCase := use_cases.NewNoteUseCase(mockNoteRepo, mockUserRepo, mockLabelRepo)
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
 
 	// Act
 	result, err := useCase.GetNoteByID(ctx, noteID, userID)
@@ -198,6 +358,7 @@ func TestGetNoteByID_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "note not found")
+	assert.ErrorIs(t, err, domainerrors.ErrNotFound)
 	mockNoteRepo.AssertExpectations(t)
 }
 
@@ -205,8 +366,11 @@ func TestGetNoteByID_WrongUser(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
 
 	userID := uuid.New().String()
 	anotherUserID := uuid.New().String()
@@ -225,7 +389,10 @@ func TestGetNoteByID_WrongUser(t *testing.T) {
 	// Mock note repository to return a note that belongs to another user
 	mockNoteRepo.On("GetByID", ctx, noteID).Return(note, nil)
 
-	useCase := use_cases.NewNoteUseCase(mockNoteRepo, mockUserRepo, mockLabelRepo)
+	// The note is not shared with the user either
+	mockShareRepo.On("GetByNoteAndUser", ctx, noteID, userID).Return(nil, nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
 
 	// Act
 	result, err := useCase.GetNoteByID(ctx, noteID, userID)
@@ -241,8 +408,11 @@ func TestGetActiveNotes(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
 
 	userID := uuid.New().String()
 
@@ -275,7 +445,7 @@ func TestGetActiveNotes(t *testing.T) {
 	// Mock note repository to return notes
 	mockNoteRepo.On("GetByUserID", ctx, userID).Return(notes, nil)
 
-	useCase := use_cases.NewNoteUseCase(mockNoteRepo, mockUserRepo, mockLabelRepo)
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
 
 	// Act
 	result, err := useCase.GetActiveNotes(ctx, userID)
@@ -292,8 +462,11 @@ func TestGetArchivedNotes(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
 
 	userID := uuid.New().String()
 
@@ -326,7 +499,7 @@ func TestGetArchivedNotes(t *testing.T) {
 	// Mock note repository to return archived notes
 	mockNoteRepo.On("GetArchivedByUserID", ctx, userID).Return(archivedNotes, nil)
 
-	useCase := use_cases.NewNoteUseCase(mockNoteRepo, mockUserRepo, mockLabelRepo)
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
 
 	// Act
 	result, err := useCase.GetArchivedNotes(ctx, userID)
@@ -339,240 +512,1450 @@ func TestGetArchivedNotes(t *testing.T) {
 	mockNoteRepo.AssertExpectations(t)
 }
 
-func TestUpdateNote(t *testing.T) {
+func TestListNotes_Defaults(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
 
 	userID := uuid.New().String()
-	noteID := uuid.New().String()
-
-	// Create a timestamp in the past
-	pastTime := time.Now().Add(-1 * time.Hour)
 
-	// Existing note
-	existingNote := &entities.Note{
-		ID:         noteID,
-		UserID:     userID,
-		Title:      "Original Title",
-		Content:    "Original content",
-		IsArchived: false,
-		Label:      "original-label",
-		CreatedAt:  pastTime,
-		UpdatedAt:  pastTime,
+	user := &entities.User{
+		ID:    userID,
+		Email: "test@example.com",
+		Name:  "Test User",
 	}
 
-	// Updated fields
-	newTitle := "Updated Title"
-	newContent := "Updated content"
-	newLabel := "updated-label"
-	newIsArchived := true
+	notes := []*entities.Note{
+		{ID: uuid.New().String(), UserID: userID, Title: "Note 1"},
+		{ID: uuid.New().String(), UserID: userID, Title: "Note 2"},
+	}
 
-	// Mock note repository to return the existing note
-	mockNoteRepo.On("GetByID", ctx, noteID).Return(existingNote, nil)
+	// Mock user repository to return a valid user
+	mockUserRepo.On("GetByID", ctx, userID).Return(user, nil)
 
-	// Use mock.Anything for the note parameter to avoid matching issues
-	mockNoteRepo.On("Update", ctx, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
-		// Verify the updated note properties within the Run function
-		updatedNote := args.Get(1).(*entities.Note)
-		assert.Equal(t, noteID, updatedNote.ID)
-		assert.Equal(t, userID, updatedNote.UserID)
-		assert.Equal(t, newTitle, updatedNote.Title)
-		assert.Equal(t, newContent, updatedNote.Content)
-		assert.Equal(t, newLabel, updatedNote.Label)
-		assert.Equal(t, newIsArchived, updatedNote.IsArchived)
-		assert.Equal(t, pastTime, updatedNote.CreatedAt)
-		assert.True(t, updatedNote.UpdatedAt.After(pastTime))
-	})
+	// Mock note repository to expect the default ordering and one extra row
+	mockNoteRepo.On("List", ctx, mock.MatchedBy(func(filter entities.NoteFilter) bool {
+		return filter.UserID == userID &&
+			filter.SortBy == entities.NoteSortByPosition &&
+			!filter.Descending &&
+			filter.Cursor == nil &&
+			filter.Limit == 21
+	})).Return(notes, nil)
 
-	useCase := use_cases.NewNoteUseCase(mockNoteRepo, mockUserRepo, mockLabelRepo)
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
 
 	// Act
-	updatedNote, err := useCase.UpdateNote(ctx, noteID, userID, newTitle, newContent, newLabel, newIsArchived)
+	page, err := useCase.ListNotes(ctx, userID, entities.NoteFilter{}, "")
 
 	// Assert
 	assert.NoError(t, err)
-	assert.NotNil(t, updatedNote)
-	assert.Equal(t, noteID, updatedNote.ID)
-	assert.Equal(t, userID, updatedNote.UserID)
-	assert.Equal(t, newTitle, updatedNote.Title)
-	assert.Equal(t, newContent, updatedNote.Content)
-	assert.Equal(t, newLabel, updatedNote.Label)
-	assert.Equal(t, newIsArchived, updatedNote.IsArchived)
-	assert.Equal(t, pastTime, updatedNote.CreatedAt)      // Created time should not change
-	assert.True(t, updatedNote.UpdatedAt.After(pastTime)) // Updated time should be newer
-
+	assert.Equal(t, notes, page.Notes)
+	assert.Empty(t, page.NextCursor)
+	mockUserRepo.AssertExpectations(t)
 	mockNoteRepo.AssertExpectations(t)
 }
 
-func TestUpdateNote_NotFound(t *testing.T) {
+func TestListNotes_NextCursor(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
 
 	userID := uuid.New().String()
-	noteID := uuid.New().String()
 
-	// Mock note repository to return nil (note not found)
-	mockNoteRepo.On("GetByID", ctx, noteID).Return(nil, nil)
+	user := &entities.User{
+		ID:    userID,
+		Email: "test@example.com",
+		Name:  "Test User",
+	}
+
+	notes := []*entities.Note{
+		{ID: uuid.New().String(), UserID: userID, Title: "Apples"},
+		{ID: uuid.New().String(), UserID: userID, Title: "Bananas"},
+		{ID: uuid.New().String(), UserID: userID, Title: "Cherries"},
+	}
 
-	useCase := use_cases.NewNoteUseCase(mockNoteRepo, mockUserRepo, mockLabelRepo)
+	mockUserRepo.On("GetByID", ctx, userID).Return(user, nil)
+
+	// First page returns one more note than requested
+	mockNoteRepo.On("List", ctx, mock.MatchedBy(func(filter entities.NoteFilter) bool {
+		return filter.Cursor == nil && filter.Limit == 3
+	})).Return(notes, nil).Once()
+
+	// Second page must resume after the last note of the first page
+	mockNoteRepo.On("List", ctx, mock.MatchedBy(func(filter entities.NoteFilter) bool {
+		return filter.Cursor != nil &&
+			filter.Cursor.SortValue == "Bananas" &&
+			filter.Cursor.ID == notes[1].ID
+	})).Return(notes[2:], nil).Once()
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+	filter := entities.NoteFilter{SortBy: entities.NoteSortByTitle, Limit: 2}
 
 	// Act
-	updatedNote, err := useCase.UpdateNote(ctx, noteID, userID, "Title", "Content", "label", false)
+	firstPage, err := useCase.ListNotes(ctx, userID, filter, "")
+	assert.NoError(t, err)
+	secondPage, err := useCase.ListNotes(ctx, userID, filter, firstPage.NextCursor)
 
 	// Assert
-	assert.Error(t, err)
-	assert.Nil(t, updatedNote)
-	assert.Contains(t, err.Error(), "note not found")
-
+	assert.NoError(t, err)
+	assert.Equal(t, notes[:2], firstPage.Notes)
+	assert.NotEmpty(t, firstPage.NextCursor)
+	assert.Equal(t, notes[2:], secondPage.Notes)
+	assert.Empty(t, secondPage.NextCursor)
 	mockNoteRepo.AssertExpectations(t)
-	// Update should not be called if note is not found
-	mockNoteRepo.AssertNotCalled(t, "Update")
 }
 
-func TestUpdateNote_WrongUser(t *testing.T) {
+func TestListAllNotes(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
 
 	userID := uuid.New().String()
-	anotherUserID := uuid.New().String()
-	noteID := uuid.New().String()
+	mockUserRepo.On("GetByID", ctx, userID).Return(&entities.User{ID: userID}, nil)
 
-	// Note belongs to another user
-	note := &entities.Note{
-		ID:         noteID,
-		UserID:     anotherUserID,
-		Title:      "Original Title",
-		Content:    "Original content",
-		IsArchived: false,
-		Label:      "original-label",
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+	// The first page is full, so a second one is requested after its last note
+	firstPage := make([]*entities.Note, 101)
+	for i := range firstPage {
+		firstPage[i] = &entities.Note{ID: uuid.New().String(), UserID: userID, Title: fmt.Sprintf("Note %d", i), Position: float64(i)}
 	}
+	lastPage := []*entities.Note{firstPage[100]}
 
-	// Mock note repository to return a note that belongs to another user
-	mockNoteRepo.On("GetByID", ctx, noteID).Return(note, nil)
+	mockNoteRepo.On("List", ctx, mock.MatchedBy(func(filter entities.NoteFilter) bool {
+		return filter.Cursor == nil && filter.Limit == 101
+	})).Return(firstPage, nil).Once()
+	mockNoteRepo.On("List", ctx, mock.MatchedBy(func(filter entities.NoteFilter) bool {
+		return filter.Cursor != nil && filter.Limit == 101
+	})).Return(lastPage, nil).Once()
 
-	useCase := use_cases.NewNoteUseCase(mockNoteRepo, mockUserRepo, mockLabelRepo)
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
 
 	// Act
-	updatedNote, err := useCase.UpdateNote(ctx, noteID, userID, "Updated Title", "Updated content", "updated-label", true)
+	notes, err := useCase.ListAllNotes(ctx, userID, entities.NoteFilter{})
 
 	// Assert
-	assert.Error(t, err)
-	assert.Nil(t, updatedNote)
-	assert.Contains(t, err.Error(), "note not found")
-
+	assert.NoError(t, err)
+	assert.Len(t, notes, 101)
+	assert.Equal(t, firstPage[0].ID, notes[0].ID)
+	assert.Equal(t, firstPage[100].ID, notes[100].ID)
 	mockNoteRepo.AssertExpectations(t)
-	// Update should not be called if note belongs to another user
-	mockNoteRepo.AssertNotCalled(t, "Update")
 }
 
-func TestDeleteNote(t *testing.T) {
+func TestListNotes_InvalidCursor(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
 
 	userID := uuid.New().String()
-	noteID := uuid.New().String()
 
-	// Existing note
-	note := &entities.Note{
-		ID:        noteID,
-		UserID:    userID,
-		Title:     "Note to Delete",
-		Content:   "This note will be deleted",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	user := &entities.User{
+		ID:    userID,
+		Email: "test@example.com",
+		Name:  "Test User",
 	}
 
-	// Mock note repository to return the existing note
-	mockNoteRepo.On("GetByID", ctx, noteID).Return(note, nil)
+	notes := []*entities.Note{
+		{ID: uuid.New().String(), UserID: userID, Title: "Note 1", UpdatedAt: time.Now()},
+		{ID: uuid.New().String(), UserID: userID, Title: "Note 2", UpdatedAt: time.Now()},
+	}
 
-	// Mock note repository to delete the note
-	mockNoteRepo.On("Delete", ctx, noteID).Return(nil)
+	mockUserRepo.On("GetByID", ctx, userID).Return(user, nil)
+	mockNoteRepo.On("List", ctx, mock.Anything).Return(notes, nil).Once()
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
 
-	useCase := use_cases.NewNoteUseCase(mockNoteRepo, mockUserRepo, mockLabelRepo)
+	// Get a valid cursor for the default ordering
+	page, err := useCase.ListNotes(ctx, userID, entities.NoteFilter{Limit: 1}, "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, page.NextCursor)
 
 	// Act
-	err := useCase.DeleteNote(ctx, noteID, userID)
+	_, garbageErr := useCase.ListNotes(ctx, userID, entities.NoteFilter{}, "not-a-cursor")
+	_, mismatchErr := useCase.ListNotes(ctx, userID, entities.NoteFilter{SortBy: entities.NoteSortByTitle}, page.NextCursor)
 
 	// Assert
-	assert.NoError(t, err)
-	mockNoteRepo.AssertExpectations(t)
+	assert.EqualError(t, garbageErr, "invalid cursor")
+	assert.EqualError(t, mismatchErr, "invalid cursor")
+	mockNoteRepo.AssertNumberOfCalls(t, "List", 1)
 }
 
-func TestDeleteNote_NotFound(t *testing.T) {
+func TestListNotes_InvalidSortField(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
 
 	userID := uuid.New().String()
-	noteID := uuid.New().String()
 
-	// Mock note repository to return nil (note not found)
-	mockNoteRepo.On("GetByID", ctx, noteID).Return(nil, nil)
-
-	useCase := use_cases.NewNoteUseCase(mockNoteRepo, mockUserRepo, mockLabelRepo)
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
 
 	// Act
-	err := useCase.DeleteNote(ctx, noteID, userID)
+	page, err := useCase.ListNotes(ctx, userID, entities.NoteFilter{SortBy: "content"}, "")
 
 	// Assert
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "note not found")
-
-	mockNoteRepo.AssertExpectations(t)
-	// Delete should not be called if note is not found
-	mockNoteRepo.AssertNotCalled(t, "Delete")
+	assert.Nil(t, page)
+	assert.Contains(t, err.Error(), "invalid sort field")
+	mockNoteRepo.AssertNotCalled(t, "List")
 }
 
-func TestDeleteNote_WrongUser(t *testing.T) {
+func TestSearchNotes(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
 	mockUserRepo := new(MockUserRepository)
 	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
 
 	userID := uuid.New().String()
 	anotherUserID := uuid.New().String()
-	noteID := uuid.New().String()
 
-	// Note belongs to another user
-	note := &entities.Note{
-		ID:        noteID,
-		UserID:    anotherUserID,
-		Title:     "Another User's Note",
-		Content:   "This note belongs to another user",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	user := &entities.User{
+		ID:    userID,
+		Email: "test@example.com",
+		Name:  "Test User",
 	}
 
-	// Mock note repository to return a note that belongs to another user
-	mockNoteRepo.On("GetByID", ctx, noteID).Return(note, nil)
+	ownResult := &entities.NoteSearchResult{
+		Note: &entities.Note{
+			ID:      uuid.New().String(),
+			UserID:  userID,
+			Title:   "Shopping list",
+			Content: "Buy milk and bread",
+		},
+		Rank:           0.6,
+		TitleHighlight: "Shopping list",
+		Snippet:        "Buy milk and " + entities.HighlightStart + "bread" + entities.HighlightStop,
+	}
+	foreignResult := &entities.NoteSearchResult{
+		Note: &entities.Note{
+			ID:      uuid.New().String(),
+			UserID:  anotherUserID,
+			Title:   "Bakery",
+			Content: "Fresh bread",
+		},
+		Rank: 0.9,
+	}
+
+	// Mock user repository to return a valid user
+	mockUserRepo.On("GetByID", ctx, userID).Return(user, nil)
 
-	useCase := use_cases.NewNoteUseCase(mockNoteRepo, mockUserRepo, mockLabelRepo)
+	// Mock note repository to return results, including one owned by another user
+	mockNoteRepo.On("Search", ctx, userID, "bread", true, mock.AnythingOfType("int")).
+		Return([]*entities.NoteSearchResult{foreignResult, ownResult}, nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
 
 	// Act
-	err := useCase.DeleteNote(ctx, noteID, userID)
+	results, err := useCase.SearchNotes(ctx, userID, "  bread ", true)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, ownResult, results[0])
+	mockUserRepo.AssertExpectations(t)
+	mockNoteRepo.AssertExpectations(t)
+}
+
+func TestSearchNotes_EmptyQuery(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	results, err := useCase.SearchNotes(ctx, userID, "   ", false)
 
 	// Assert
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "note not found")
+	assert.Nil(t, results)
+	assert.Contains(t, err.Error(), "search query is required")
 
-	mockNoteRepo.AssertExpectations(t)
-	// Delete should not be called if note belongs to another user
-	mockNoteRepo.AssertNotCalled(t, "Delete")
+	// Search should not be called with an empty query
+	mockNoteRepo.AssertNotCalled(t, "Search")
+}
+
+func TestSearchNotes_UserNotFound(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+
+	// Mock user repository to return nil (user not found)
+	mockUserRepo.On("GetByID", ctx, userID).Return(nil, nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	results, err := useCase.SearchNotes(ctx, userID, "bread", false)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, results)
+	assert.Contains(t, err.Error(), "user not found")
+
+	mockUserRepo.AssertExpectations(t)
+	mockNoteRepo.AssertNotCalled(t, "Search")
+}
+
+func TestUpdateNote(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	// Create a timestamp in the past
+	pastTime := time.Now().Add(-1 * time.Hour)
+
+	// Existing note
+	existingNote := &entities.Note{
+		ID:         noteID,
+		UserID:     userID,
+		Title:      "Original Title",
+		Content:    "Original content",
+		IsArchived: false,
+		Label:      "original-label",
+		CreatedAt:  pastTime,
+		UpdatedAt:  pastTime,
+	}
+
+	// Updated fields
+	newTitle := "Updated Title"
+	newContent := "Updated content"
+	newLabel := "updated-label"
+	newIsArchived := true
+
+	// Mock note repository to return the existing note
+	mockNoteRepo.On("GetByIDForUpdate", ctx, noteID).Return(existingNote, nil)
+
+	// Mock revision repository to snapshot the original version
+	mockRevisionRepo.On("Create", ctx, mock.MatchedBy(func(revision *entities.NoteRevision) bool {
+		return revision.NoteID == noteID &&
+			revision.Title == "Original Title" &&
+			revision.Content == "Original content"
+	})).Return(nil)
+	mockRevisionRepo.On("GetRetention", ctx, userID).Return(nil, nil)
+	mockRevisionRepo.On("DeleteAllButLatest", ctx, noteID, 50).Return(nil)
+
+	// Use mock.Anything for the note parameter to avoid matching issues
+	mockNoteRepo.On("Update", ctx, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		// Verify the updated note properties within the Run function
+		updatedNote := args.Get(1).(*entities.Note)
+		assert.Equal(t, noteID, updatedNote.ID)
+		assert.Equal(t, userID, updatedNote.UserID)
+		assert.Equal(t, newTitle, updatedNote.Title)
+		assert.Equal(t, newContent, updatedNote.Content)
+		assert.Equal(t, newLabel, updatedNote.Label)
+		assert.Equal(t, newIsArchived, updatedNote.IsArchived)
+		assert.Equal(t, pastTime, updatedNote.CreatedAt)
+		assert.True(t, updatedNote.UpdatedAt.After(pastTime))
+	})
+
+	// Archiving the note revokes its public links
+	txManager.ShareLinks.On("DeleteByNoteID", ctx, noteID).Return(nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	updatedNote, err := useCase.UpdateNote(ctx, noteID, userID, newTitle, newContent, newLabel, newIsArchived, 0)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotNil(t, updatedNote)
+	assert.Equal(t, noteID, updatedNote.ID)
+	assert.Equal(t, userID, updatedNote.UserID)
+	assert.Equal(t, newTitle, updatedNote.Title)
+	assert.Equal(t, newContent, updatedNote.Content)
+	assert.Equal(t, newLabel, updatedNote.Label)
+	assert.Equal(t, newIsArchived, updatedNote.IsArchived)
+	assert.Equal(t, pastTime, updatedNote.CreatedAt)      // Created time should not change
+	assert.True(t, updatedNote.UpdatedAt.After(pastTime)) // Updated time should be newer
+
+	mockNoteRepo.AssertExpectations(t)
+	mockRevisionRepo.AssertExpectations(t)
+	txManager.ShareLinks.AssertExpectations(t)
+}
+
+func TestUpdateNote_VersionMismatch(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	// The note was updated since the client read version 1
+	existingNote := &entities.Note{ID: noteID, UserID: userID, Title: "Title", Content: "Content", Version: 2}
+	mockNoteRepo.On("GetByIDForUpdate", ctx, noteID).Return(existingNote, nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	updatedNote, err := useCase.UpdateNote(ctx, noteID, userID, "New Title", "New content", "", false, 1)
+
+	// Assert
+	var conflict *domainerrors.ConflictError
+	assert.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "note", conflict.Resource)
+	assert.Nil(t, updatedNote)
+
+	mockNoteRepo.AssertExpectations(t)
+	mockNoteRepo.AssertNotCalled(t, "Update")
+	mockRevisionRepo.AssertNotCalled(t, "Create")
+}
+
+func TestUpdateNote_ConcurrentUpdate(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	existingNote := &entities.Note{ID: noteID, UserID: userID, Title: "Title", Content: "Content", Version: 1}
+	mockNoteRepo.On("GetByIDForUpdate", ctx, noteID).Return(existingNote, nil)
+
+	// Another request saves the note between the read and the write
+	mockNoteRepo.On("Update", ctx, mock.Anything).Return(repositories.ErrVersionConflict)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	updatedNote, err := useCase.UpdateNote(ctx, noteID, userID, "Title", "Content", "", true, 1)
+
+	// Assert
+	var conflict *domainerrors.ConflictError
+	assert.ErrorAs(t, err, &conflict)
+	assert.Nil(t, updatedNote)
+
+	mockNoteRepo.AssertExpectations(t)
+}
+
+func TestUpdateNote_OverlappingSavesWithoutIfMatch(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	// Both clients read version 1. The second save waits for the lock held by
+	// the first one and then reads the version it left.
+	mockNoteRepo.On("GetByIDForUpdate", ctx, noteID).Return(&entities.Note{ID: noteID, UserID: userID, Title: "Title", Content: "Original", Version: 1}, nil).Once()
+	mockNoteRepo.On("GetByIDForUpdate", ctx, noteID).Return(&entities.Note{ID: noteID, UserID: userID, Title: "Title", Content: "First client", Version: 2}, nil).Once()
+	mockRevisionRepo.On("Create", ctx, mock.Anything).Return(nil)
+	mockRevisionRepo.On("GetRetention", ctx, userID).Return(nil, nil)
+	mockRevisionRepo.On("DeleteAllButLatest", ctx, noteID, 50).Return(nil)
+
+	// Each save is made against the version it read under the lock
+	var savedVersions []int
+	mockNoteRepo.On("Update", ctx, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		savedVersions = append(savedVersions, args.Get(1).(*entities.Note).Version)
+	})
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	first, firstErr := useCase.UpdateNote(ctx, noteID, userID, "Title", "First client", "", false, 0)
+	second, secondErr := useCase.UpdateNote(ctx, noteID, userID, "Title", "Second client", "", false, 0)
+
+	// Assert
+	assert.NoError(t, firstErr)
+	assert.NoError(t, secondErr)
+	assert.Equal(t, "First client", first.Content)
+	assert.Equal(t, "Second client", second.Content)
+	assert.Equal(t, []int{1, 2}, savedVersions)
+	assert.Equal(t, 2, txManager.Commits)
+	mockNoteRepo.AssertExpectations(t)
+}
+
+func TestUpdateNote_NotFound(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	// Mock note repository to return nil (note not found)
+	mockNoteRepo.On("GetByIDForUpdate", ctx, noteID).Return(nil, nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	updatedNote, err := useCase.UpdateNote(ctx, noteID, userID, "Title", "Content", "label", false, 0)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, updatedNote)
+	assert.Contains(t, err.Error(), "note not found")
+
+	mockNoteRepo.AssertExpectations(t)
+	// Update should not be called if note is not found
+	mockNoteRepo.AssertNotCalled(t, "Update")
+}
+
+func TestUpdateNote_WrongUser(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	anotherUserID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	// Note belongs to another user
+	note := &entities.Note{
+		ID:         noteID,
+		UserID:     anotherUserID,
+		Title:      "Original Title",
+		Content:    "Original content",
+		IsArchived: false,
+		Label:      "original-label",
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	// Mock note repository to return a note that belongs to another user
+	mockNoteRepo.On("GetByIDForUpdate", ctx, noteID).Return(note, nil)
+
+	// The note is not shared with the user either
+	mockShareRepo.On("GetByNoteAndUser", ctx, noteID, userID).Return(nil, nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	updatedNote, err := useCase.UpdateNote(ctx, noteID, userID, "Updated Title", "Updated content", "updated-label", true, 0)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, updatedNote)
+	assert.Contains(t, err.Error(), "note not found")
+
+	mockNoteRepo.AssertExpectations(t)
+	// Update should not be called if note belongs to another user
+	mockNoteRepo.AssertNotCalled(t, "Update")
+}
+
+func TestUpdateNote_SharedEditor(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	editorID := uuid.New().String()
+	ownerID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	note := &entities.Note{
+		ID:      noteID,
+		UserID:  ownerID,
+		Title:   "Original Title",
+		Content: "Original content",
+	}
+
+	mockNoteRepo.On("GetByIDForUpdate", ctx, noteID).Return(note, nil)
+	mockShareRepo.On("GetByNoteAndUser", ctx, noteID, editorID).Return(&entities.NoteShare{Role: entities.NoteRoleEditor}, nil)
+
+	// The revision follows the retention policy of the owner
+	mockRevisionRepo.On("Create", ctx, mock.Anything).Return(nil)
+	mockRevisionRepo.On("GetRetention", ctx, ownerID).Return(nil, nil)
+	mockRevisionRepo.On("DeleteAllButLatest", ctx, noteID, 50).Return(nil)
+	mockNoteRepo.On("Update", ctx, mock.Anything).Return(nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	updatedNote, err := useCase.UpdateNote(ctx, noteID, editorID, "Updated Title", "Updated content", "", false, 0)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Updated Title", updatedNote.Title)
+	assert.Equal(t, ownerID, updatedNote.UserID)
+	mockNoteRepo.AssertExpectations(t)
+	mockRevisionRepo.AssertExpectations(t)
+}
+
+func TestUpdateNote_EditorCannotArchive(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	editorID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	note := &entities.Note{
+		ID:      noteID,
+		UserID:  uuid.New().String(),
+		Title:   "Original Title",
+		Content: "Original content",
+	}
+
+	mockNoteRepo.On("GetByIDForUpdate", ctx, noteID).Return(note, nil)
+	mockShareRepo.On("GetByNoteAndUser", ctx, noteID, editorID).Return(&entities.NoteShare{Role: entities.NoteRoleEditor}, nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	updatedNote, err := useCase.UpdateNote(ctx, noteID, editorID, "Original Title", "Original content", "", true, 0)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrForbidden)
+	assert.Nil(t, updatedNote)
+	mockNoteRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestGetNoteWithLabels_SharedNoteHidesOtherLabels(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	viewerID := uuid.New().String()
+	ownerID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	note := &entities.Note{ID: noteID, UserID: ownerID, Title: "Shared"}
+	ownerLabel := &entities.Label{ID: uuid.New().String(), UserID: ownerID, Name: "Private"}
+	viewerLabel := &entities.Label{ID: uuid.New().String(), UserID: viewerID, Name: "Mine"}
+
+	mockNoteRepo.On("GetByID", ctx, noteID).Return(note, nil)
+	mockShareRepo.On("GetByNoteAndUser", ctx, noteID, viewerID).Return(&entities.NoteShare{Role: entities.NoteRoleViewer}, nil)
+	mockLabelRepo.On("GetLabelsForNote", ctx, noteID).Return([]*entities.Label{ownerLabel, viewerLabel}, nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	result, labels, err := useCase.GetNoteWithLabels(ctx, noteID, viewerID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, note, result)
+	assert.Equal(t, []*entities.Label{viewerLabel}, labels)
+}
+
+func TestDeleteNote(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	// Existing note
+	note := &entities.Note{
+		ID:        noteID,
+		UserID:    userID,
+		Title:     "Note to Delete",
+		Content:   "This note will be deleted",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	// Mock note repository to return the existing note
+	mockNoteRepo.On("GetByID", ctx, noteID).Return(note, nil)
+
+	// Mock note repository to move the note to the trash
+	mockNoteRepo.On("Trash", ctx, noteID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	err := useCase.DeleteNote(ctx, noteID, userID)
+
+	// Assert
+	assert.NoError(t, err)
+	mockNoteRepo.AssertExpectations(t)
+}
+
+func TestDeleteNote_NotFound(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	// Mock note repository to return nil (note not found)
+	mockNoteRepo.On("GetByID", ctx, noteID).Return(nil, nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	err := useCase.DeleteNote(ctx, noteID, userID)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "note not found")
+
+	mockNoteRepo.AssertExpectations(t)
+	// Trash should not be called if note is not found
+	mockNoteRepo.AssertNotCalled(t, "Trash")
+}
+
+func TestDeleteNote_WrongUser(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	anotherUserID := uuid.New().String()
+	noteID := uuid.New().String()
+
+	// Note belongs to another user
+	note := &entities.Note{
+		ID:        noteID,
+		UserID:    anotherUserID,
+		Title:     "Another User's Note",
+		Content:   "This note belongs to another user",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	// Mock note repository to return a note that belongs to another user
+	mockNoteRepo.On("GetByID", ctx, noteID).Return(note, nil)
+
+	// The note is not shared with the user either
+	mockShareRepo.On("GetByNoteAndUser", ctx, noteID, userID).Return(nil, nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	err := useCase.DeleteNote(ctx, noteID, userID)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "note not found")
+
+	mockNoteRepo.AssertExpectations(t)
+	// Trash should not be called if note belongs to another user
+	mockNoteRepo.AssertNotCalled(t, "Trash")
+}
+
+func TestGetTrashedNotes(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	deletedAt := time.Now()
+
+	user := &entities.User{ID: userID}
+	notes := []*entities.Note{
+		{ID: uuid.New().String(), UserID: userID, Title: "Trashed", DeletedAt: &deletedAt},
+	}
+
+	mockUserRepo.On("GetByID", ctx, userID).Return(user, nil)
+	mockNoteRepo.On("GetTrashedByUserID", ctx, userID).Return(notes, nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	result, err := useCase.GetTrashedNotes(ctx, userID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, notes, result)
+
+	mockUserRepo.AssertExpectations(t)
+	mockNoteRepo.AssertExpectations(t)
+}
+
+func TestRestoreNote(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	noteID := uuid.New().String()
+	deletedAt := time.Now()
+
+	note := &entities.Note{ID: noteID, UserID: userID, Title: "Trashed", DeletedAt: &deletedAt}
+
+	mockNoteRepo.On("GetTrashedByID", ctx, noteID).Return(note, nil)
+	mockNoteRepo.On("Restore", ctx, noteID).Return(nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	restored, err := useCase.RestoreNote(ctx, noteID, userID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, noteID, restored.ID)
+	assert.Nil(t, restored.DeletedAt)
+
+	mockNoteRepo.AssertExpectations(t)
+}
+
+func TestRestoreNote_WrongUser(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	noteID := uuid.New().String()
+	deletedAt := time.Now()
+
+	note := &entities.Note{ID: noteID, UserID: uuid.New().String(), DeletedAt: &deletedAt}
+
+	mockNoteRepo.On("GetTrashedByID", ctx, noteID).Return(note, nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	restored, err := useCase.RestoreNote(ctx, noteID, uuid.New().String())
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, restored)
+	assert.Contains(t, err.Error(), "note not found")
+
+	mockNoteRepo.AssertExpectations(t)
+	mockNoteRepo.AssertNotCalled(t, "Restore")
+}
+
+func TestEmptyTrash(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+	mockBlobStore := new(MockBlobStore)
+
+	userID := uuid.New().String()
+
+	// The blobs of the attachments go once the notes are deleted
+	mockUserRepo.On("GetByID", ctx, userID).Return(&entities.User{ID: userID}, nil)
+	txManager.Attachments.On("DeleteTrashedByUserID", ctx, userID).Return([]string{"note/one", "note/two"}, nil)
+	mockNoteRepo.On("DeleteTrashedByUserID", ctx, userID).Return(int64(3), nil)
+	mockBlobStore.On("Delete", ctx, "note/one").Return(nil)
+	mockBlobStore.On("Delete", ctx, "note/two").Return(nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, mockBlobStore)
+
+	// Act
+	deleted, err := useCase.EmptyTrash(ctx, userID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+	assert.Equal(t, 1, txManager.Commits)
+
+	mockUserRepo.AssertExpectations(t)
+	mockNoteRepo.AssertExpectations(t)
+	mockBlobStore.AssertExpectations(t)
+}
+
+func TestPurgeTrash(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	retention := 30 * 24 * time.Hour
+	expectedCutoff := time.Now().Add(-retention)
+
+	isCutoff := mock.MatchedBy(func(cutoff time.Time) bool {
+		return cutoff.Sub(expectedCutoff) < time.Minute && expectedCutoff.Sub(cutoff) < time.Minute
+	})
+	mockBlobStore := new(MockBlobStore)
+
+	txManager.Attachments.On("DeleteTrashedBefore", ctx, isCutoff).Return([]string{"note/one"}, nil)
+	mockNoteRepo.On("DeleteTrashedBefore", ctx, isCutoff).Return(int64(2), nil)
+	mockBlobStore.On("Delete", ctx, "note/one").Return(nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, mockBlobStore)
+
+	// Act
+	purged, err := useCase.PurgeTrash(ctx, retention)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+
+	mockNoteRepo.AssertExpectations(t)
+	mockBlobStore.AssertExpectations(t)
+}
+
+func TestCreateNoteWithLabels(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	labelID := uuid.New().String()
+
+	mockUserRepo.On("GetByID", ctx, userID).Return(&entities.User{ID: userID}, nil)
+	mockNoteRepo.On("Create", ctx, mock.AnythingOfType("*entities.Note")).Return(nil)
+	mockLabelRepo.On("GetLabelsForNote", ctx, mock.AnythingOfType("string")).Return([]*entities.Label{}, nil)
+	mockLabelRepo.On("GetByID", ctx, labelID).Return(&entities.Label{ID: labelID, UserID: userID}, nil)
+	mockLabelRepo.On("AddLabelToNote", ctx, mock.AnythingOfType("string"), labelID).Return(nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	note, err := useCase.CreateNoteWithLabels(ctx, userID, "Title", "Content", "", []string{labelID})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotNil(t, note)
+	assert.Equal(t, 1, txManager.Commits)
+	assert.Equal(t, 0, txManager.Rollbacks)
+
+	mockNoteRepo.AssertExpectations(t)
+	mockLabelRepo.AssertExpectations(t)
+}
+
+func TestCreateNoteWithLabels_RollsBackOnLabelFailure(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	labelID := uuid.New().String()
+
+	mockUserRepo.On("GetByID", ctx, userID).Return(&entities.User{ID: userID}, nil)
+	mockNoteRepo.On("Create", ctx, mock.AnythingOfType("*entities.Note")).Return(nil)
+	mockLabelRepo.On("GetLabelsForNote", ctx, mock.AnythingOfType("string")).Return([]*entities.Label{}, nil)
+	mockLabelRepo.On("GetByID", ctx, labelID).Return(&entities.Label{ID: labelID, UserID: userID}, nil)
+
+	// Associating the label fails after the note was written
+	mockLabelRepo.On("AddLabelToNote", ctx, mock.AnythingOfType("string"), labelID).Return(errors.New("connection reset"))
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	note, err := useCase.CreateNoteWithLabels(ctx, userID, "Title", "Content", "", []string{labelID})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, note)
+	assert.Equal(t, 0, txManager.Commits)
+	assert.Equal(t, 1, txManager.Rollbacks)
+}
+
+func TestCreateNoteWithLabels_RollsBackOnLabelLookupFailure(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	labelID := uuid.New().String()
+
+	mockUserRepo.On("GetByID", ctx, userID).Return(&entities.User{ID: userID}, nil)
+	mockNoteRepo.On("Create", ctx, mock.AnythingOfType("*entities.Note")).Return(nil)
+	mockLabelRepo.On("GetLabelsForNote", ctx, mock.AnythingOfType("string")).Return([]*entities.Label{}, nil)
+
+	// Looking the label up fails, the label must not be silently dropped
+	mockLabelRepo.On("GetByID", ctx, labelID).Return(nil, errors.New("connection reset"))
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	note, err := useCase.CreateNoteWithLabels(ctx, userID, "Title", "Content", "", []string{labelID})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, note)
+	assert.Equal(t, 0, txManager.Commits)
+	assert.Equal(t, 1, txManager.Rollbacks)
+	mockLabelRepo.AssertNotCalled(t, "AddLabelToNote")
+}
+
+func TestCreateNoteWithLabels_SkipsUnknownAndForeignLabels(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	unknownLabelID := uuid.New().String()
+	foreignLabelID := uuid.New().String()
+
+	mockUserRepo.On("GetByID", ctx, userID).Return(&entities.User{ID: userID}, nil)
+	mockNoteRepo.On("Create", ctx, mock.AnythingOfType("*entities.Note")).Return(nil)
+	mockLabelRepo.On("GetLabelsForNote", ctx, mock.AnythingOfType("string")).Return([]*entities.Label{}, nil)
+	mockLabelRepo.On("GetByID", ctx, unknownLabelID).Return(nil, nil)
+	mockLabelRepo.On("GetByID", ctx, foreignLabelID).Return(&entities.Label{ID: foreignLabelID, UserID: uuid.New().String()}, nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	note, err := useCase.CreateNoteWithLabels(ctx, userID, "Title", "Content", "", []string{unknownLabelID, foreignLabelID})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotNil(t, note)
+	assert.Equal(t, 1, txManager.Commits)
+	mockLabelRepo.AssertNotCalled(t, "AddLabelToNote")
+}
+
+func TestUpdateNoteWithLabels_RollsBackOnLabelFailure(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	noteID := uuid.New().String()
+	oldLabelID := uuid.New().String()
+
+	existingNote := &entities.Note{ID: noteID, UserID: userID, Title: "Title", Content: "Content", Version: 1}
+	mockNoteRepo.On("GetByIDForUpdate", ctx, noteID).Return(existingNote, nil)
+	mockNoteRepo.On("Update", ctx, mock.Anything).Return(nil)
+	txManager.ShareLinks.On("DeleteByNoteID", ctx, noteID).Return(nil)
+
+	// Removing the old label fails after the note was written
+	mockLabelRepo.On("GetLabelsForNote", ctx, noteID).Return([]*entities.Label{{ID: oldLabelID, UserID: userID}}, nil)
+	mockLabelRepo.On("RemoveLabelFromNote", ctx, noteID, oldLabelID).Return(errors.New("connection reset"))
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	note, err := useCase.UpdateNoteWithLabels(ctx, noteID, userID, "Title", "Content", "", true, []string{}, 0)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, note)
+	assert.Equal(t, 0, txManager.Commits)
+	assert.Equal(t, 1, txManager.Rollbacks)
+
+	mockNoteRepo.AssertExpectations(t)
+	mockLabelRepo.AssertExpectations(t)
+}
+
+func TestPinNote(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: userID, Position: 2048}
+
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockNoteRepo.On("SetPinned", ctx, note, true).Run(func(args mock.Arguments) {
+		pinned := args.Get(1).(*entities.Note)
+		pinned.IsPinned = true
+		pinned.Position = -1024
+	}).Return(nil).Once()
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	pinned, err := useCase.PinNote(ctx, note.ID, userID, true)
+	again, againErr := useCase.PinNote(ctx, note.ID, userID, true)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, pinned.IsPinned)
+	assert.Equal(t, float64(-1024), pinned.Position)
+
+	// Pinning a pinned note changes nothing
+	assert.NoError(t, againErr)
+	assert.True(t, again.IsPinned)
+	mockNoteRepo.AssertExpectations(t)
+}
+
+func TestPinNote_SharedEditor(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	editorID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: uuid.New().String()}
+
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockShareRepo.On("GetByNoteAndUser", ctx, note.ID, editorID).Return(&entities.NoteShare{Role: entities.NoteRoleEditor}, nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	_, err := useCase.PinNote(ctx, note.ID, editorID, true)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrForbidden)
+	mockNoteRepo.AssertNotCalled(t, "SetPinned", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMoveNote_Between(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: userID, Position: 4096}
+	target := &entities.Note{ID: uuid.New().String(), UserID: userID, Position: 1024}
+	next := float64(2048)
+
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockNoteRepo.On("GetByID", ctx, target.ID).Return(target, nil)
+	mockNoteRepo.On("GetAdjacentPosition", ctx, target, true, note.ID).Return(&next, nil)
+	mockNoteRepo.On("UpdatePosition", ctx, note.ID, float64(1536)).Return(nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	moved, err := useCase.MoveNote(ctx, note.ID, userID, target.ID, true)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1536), moved.Position)
+	assert.Equal(t, 1, txManager.Commits)
+	mockNoteRepo.AssertExpectations(t)
+	mockNoteRepo.AssertNotCalled(t, "RebalancePositions", mock.Anything, mock.Anything)
+}
+
+func TestMoveNote_RebalancesWhenNoRoom(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: userID, Position: 4096}
+	target := &entities.Note{ID: uuid.New().String(), UserID: userID, Position: 1}
+	rebalanced := &entities.Note{ID: target.ID, UserID: userID, Position: 2048}
+	tooClose := math.Nextafter(1, 2)
+	previous := float64(1024)
+
+	// No position fits between the target and the note before it
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockNoteRepo.On("GetByID", ctx, target.ID).Return(target, nil).Once()
+	mockNoteRepo.On("GetAdjacentPosition", ctx, target, false, note.ID).Return(&tooClose, nil)
+	mockNoteRepo.On("RebalancePositions", ctx, userID).Return(nil)
+	mockNoteRepo.On("GetByID", ctx, target.ID).Return(rebalanced, nil).Once()
+	mockNoteRepo.On("GetAdjacentPosition", ctx, rebalanced, false, note.ID).Return(&previous, nil)
+	mockNoteRepo.On("UpdatePosition", ctx, note.ID, float64(1536)).Return(nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	moved, err := useCase.MoveNote(ctx, note.ID, userID, target.ID, false)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1536), moved.Position)
+	mockNoteRepo.AssertExpectations(t)
+}
+
+func TestMoveNote_DifferentList(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: userID}
+	target := &entities.Note{ID: uuid.New().String(), UserID: userID, IsPinned: true}
+
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockNoteRepo.On("GetByID", ctx, target.ID).Return(target, nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	moved, err := useCase.MoveNote(ctx, note.ID, userID, target.ID, true)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrValidation)
+	assert.Nil(t, moved)
+	assert.Equal(t, 1, txManager.Rollbacks)
+	mockNoteRepo.AssertNotCalled(t, "UpdatePosition", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestConvertNote_ToChecklist(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	note := &entities.Note{
+		ID:      uuid.New().String(),
+		UserID:  userID,
+		Title:   "Groceries",
+		Content: "- [x] Eggs\n\n  - [ ] Free range\nFlour\r\n\t* Spelt",
+		Type:    entities.NoteTypeText,
+	}
+
+	mockNoteRepo.On("GetByIDForUpdate", ctx, note.ID).Return(note, nil)
+	mockRevisionRepo.On("Create", ctx, mock.MatchedBy(func(revision *entities.NoteRevision) bool {
+		return revision.Content == "- [x] Eggs\n\n  - [ ] Free range\nFlour\r\n\t* Spelt"
+	})).Return(nil)
+	mockRevisionRepo.On("GetRetention", ctx, userID).Return(nil, nil)
+	mockRevisionRepo.On("DeleteAllButLatest", ctx, note.ID, 50).Return(nil)
+	mockNoteRepo.On("Update", ctx, mock.MatchedBy(func(updated *entities.Note) bool {
+		return updated.Type == entities.NoteTypeChecklist && updated.Content == ""
+	})).Return(nil)
+
+	// Each non blank line becomes an item, in order
+	var created []*entities.ChecklistItem
+	txManager.Checklists.On("Create", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		created = append(created, args.Get(1).(*entities.ChecklistItem))
+	}).Return(nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	converted, err := useCase.ConvertNote(ctx, note.ID, userID, entities.NoteTypeChecklist, 0)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, entities.NoteTypeChecklist, converted.Type)
+	expected := []struct {
+		text    string
+		checked bool
+		indent  int
+	}{
+		{"Eggs", true, 0},
+		{"Free range", false, 1},
+		{"Flour", false, 0},
+		{"Spelt", false, 1},
+	}
+	if assert.Len(t, created, len(expected)) {
+		for i, want := range expected {
+			assert.Equal(t, note.ID, created[i].NoteID)
+			assert.Equal(t, want.text, created[i].Text)
+			assert.Equal(t, want.checked, created[i].IsChecked)
+			assert.Equal(t, want.indent, created[i].Indent)
+		}
+	}
+	assert.Equal(t, 1, txManager.Commits)
+	mockNoteRepo.AssertExpectations(t)
+	mockRevisionRepo.AssertExpectations(t)
+}
+
+func TestConvertNote_ToText(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: userID, Title: "Groceries", Type: entities.NoteTypeChecklist}
+	items := []*entities.ChecklistItem{
+		{Text: "Eggs", IsChecked: true},
+		{Text: "Free range", Indent: 1},
+	}
+
+	mockNoteRepo.On("GetByIDForUpdate", ctx, note.ID).Return(note, nil)
+	txManager.Checklists.On("GetByNoteID", ctx, note.ID).Return(items, nil)
+	mockNoteRepo.On("Update", ctx, mock.MatchedBy(func(updated *entities.Note) bool {
+		return updated.Type == entities.NoteTypeText && updated.Content == "- [x] Eggs\n  - [ ] Free range"
+	})).Return(nil)
+	txManager.Checklists.On("DeleteByNoteID", ctx, note.ID).Return(nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	converted, err := useCase.ConvertNote(ctx, note.ID, userID, entities.NoteTypeText, 0)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, entities.NoteTypeText, converted.Type)
+	mockNoteRepo.AssertExpectations(t)
+	txManager.Checklists.AssertExpectations(t)
+
+	// An empty checklist has no text worth keeping
+	mockRevisionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUpdateNote_ChecklistContent(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockNoteRepo := new(MockNoteRepository)
+	mockShareRepo := new(MockNoteShareRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	mockRevisionRepo := new(MockNoteRevisionRepository)
+	txManager := NewFakeTxManager(mockNoteRepo, mockLabelRepo, mockRevisionRepo)
+
+	userID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: userID, Title: "Groceries", Type: entities.NoteTypeChecklist}
+
+	mockNoteRepo.On("GetByIDForUpdate", ctx, note.ID).Return(note, nil)
+
+	useCase := newTestNoteUseCase(mockNoteRepo, mockUserRepo, mockShareRepo, txManager, new(MockBlobStore))
+
+	// Act
+	_, err := useCase.UpdateNote(ctx, note.ID, userID, "Groceries", "Eggs", "", false, 0)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrValidation)
+	mockNoteRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
 }
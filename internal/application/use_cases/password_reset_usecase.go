@@ -0,0 +1,225 @@
+package use_cases
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+// PasswordResetConfig sets how long reset links last and how often they can
+// be requested
+type PasswordResetConfig struct {
+	TokenTTL time.Duration
+	// ResendInterval is the time a user waits between two reset links
+	ResendInterval time.Duration
+	// IPLimit is how many requests a client address may make, the count is
+	// forgotten once IPWindow passes without a request. 0 disables it.
+	IPLimit  int
+	IPWindow time.Duration
+}
+
+type PasswordResetUseCase struct {
+	userRepo                 repositories.UserRepository
+	resetRepo                repositories.PasswordResetRepository
+	txManager                repositories.TxManager
+	emailVerificationUseCase *EmailVerificationUseCase
+	tokenService             services.TokenService
+	hashService              services.HashService
+	mailer                   services.Mailer
+	attemptStore             services.LoginAttemptStore
+	baseURL                  string
+	config                   PasswordResetConfig
+}
+
+func NewPasswordResetUseCase(
+	userRepo repositories.UserRepository,
+	resetRepo repositories.PasswordResetRepository,
+	txManager repositories.TxManager,
+	emailVerificationUseCase *EmailVerificationUseCase,
+	tokenService services.TokenService,
+	hashService services.HashService,
+	mailer services.Mailer,
+	attemptStore services.LoginAttemptStore,
+	baseURL string,
+	config PasswordResetConfig,
+) *PasswordResetUseCase {
+	return &PasswordResetUseCase{
+		userRepo:                 userRepo,
+		resetRepo:                resetRepo,
+		txManager:                txManager,
+		emailVerificationUseCase: emailVerificationUseCase,
+		tokenService:             tokenService,
+		hashService:              hashService,
+		mailer:                   mailer,
+		attemptStore:             attemptStore,
+		baseURL:                  strings.TrimRight(baseURL, "/"),
+		config:                   config,
+	}
+}
+
+// RequestReset emails a reset link to the user owning the address. Unknown
+// addresses are silently ignored so the endpoint does not reveal which
+// emails have an account, and so are unverified ones unless the
+// verification policy is optional. A user gets at most one link per resend
+// interval and a client address a limited number of requests.
+func (uc *PasswordResetUseCase) RequestReset(ctx context.Context, email, ipAddress string) error {
+	if err := uc.checkIPLimit(ctx, ipAddress); err != nil {
+		return err
+	}
+
+	// Get the user by email
+	user, err := uc.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	// Reset links only go to proven addresses
+	if err := uc.emailVerificationUseCase.RequireVerified(user); err != nil {
+		return nil
+	}
+
+	// Throttle the links based on the last token issued. The request is
+	// dropped silently, an error would reveal that the account exists.
+	latest, err := uc.resetRepo.GetLatestByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if latest != nil && time.Since(latest.CreatedAt) < uc.config.ResendInterval {
+		return nil
+	}
+
+	// Generate the token, only its hash is stored
+	token, err := uc.tokenService.GenerateToken(ctx)
+	if err != nil {
+		return err
+	}
+	tokenID, err := uc.tokenService.HashToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	resetToken := &entities.PasswordResetToken{
+		ID:        tokenID,
+		UserID:    user.ID,
+		ExpiresAt: now.Add(uc.config.TokenTTL),
+		CreatedAt: now,
+	}
+
+	// Save the token
+	if err := uc.resetRepo.Create(ctx, resetToken); err != nil {
+		return err
+	}
+
+	// Send the reset link
+	link := fmt.Sprintf("%s/reset-password?token=%s", uc.baseURL, url.QueryEscape(token))
+	return uc.mailer.Send(ctx, services.MailMessage{
+		To:      user.Email,
+		Subject: "Reset your Note Nest password",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nSomeone asked to reset the password of your Note Nest account.\n"+
+				"Choose a new password by following this link within %s:\n\n%s\n\n"+
+				"If you did not ask for this, you can ignore this email.\n",
+			user.Name, uc.config.TokenTTL, link,
+		),
+	})
+}
+
+// checkIPLimit counts the request against the client address and rejects it
+// once the address made too many
+func (uc *PasswordResetUseCase) checkIPLimit(ctx context.Context, ipAddress string) error {
+	if uc.config.IPLimit == 0 || ipAddress == "" {
+		return nil
+	}
+
+	key := "password-reset:ip:" + ipAddress
+	attempts, err := uc.attemptStore.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if attempts != nil && attempts.Failures >= uc.config.IPLimit {
+		if wait := time.Until(attempts.LastFailureAt.Add(uc.config.IPWindow)); wait > 0 {
+			return domainerrors.RateLimited("too many password reset requests, try again later", wait)
+		}
+	}
+
+	_, err = uc.attemptStore.RecordFailure(ctx, key, time.Now(), uc.config.IPWindow)
+	return err
+}
+
+// ResetPassword sets a new password using a reset token. The token can only
+// be used once and every session of the user is invalidated. The password,
+// the reset links, the sessions and the access tokens all change in a single
+// transaction.
+func (uc *PasswordResetUseCase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	invalidToken := domainerrors.InvalidField("token", "invalid or expired reset token")
+
+	tokenID, err := uc.tokenService.HashToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	// Get the token
+	resetToken, err := uc.resetRepo.GetByID(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if resetToken == nil || resetToken.UsedAt != nil || time.Now().After(resetToken.ExpiresAt) {
+		return invalidToken
+	}
+
+	// Hash the new password before the transaction, it takes a while
+	hashedPassword, err := uc.hashService.HashPassword(ctx, newPassword)
+	if err != nil {
+		return err
+	}
+
+	return uc.txManager.WithinTx(ctx, func(ctx context.Context, repos repositories.TxRepositories) error {
+		// Consume the token, failing if a concurrent request used it first
+		consumed, err := repos.PasswordResets.MarkUsed(ctx, tokenID)
+		if err != nil {
+			return err
+		}
+		if !consumed {
+			return invalidToken
+		}
+
+		// Verify the user exists
+		user, err := repos.Users.GetByID(ctx, resetToken.UserID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return invalidToken
+		}
+
+		// Save the new password
+		user.Password = hashedPassword
+		user.UpdatedAt = time.Now()
+		if err := repos.Users.Update(ctx, user); err != nil {
+			return err
+		}
+
+		// Other pending reset links are no longer needed
+		if err := repos.PasswordResets.DeleteAllByUserID(ctx, user.ID); err != nil {
+			return err
+		}
+
+		// Sign out everywhere and revoke the access tokens, the old password
+		// may have been compromised
+		if err := repos.Sessions.DeleteAllByUserID(ctx, user.ID); err != nil {
+			return err
+		}
+		return repos.PersonalAccessTokens.DeleteAllByUserID(ctx, user.ID)
+	})
+}
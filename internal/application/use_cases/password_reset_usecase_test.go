@@ -0,0 +1,384 @@
+package use_cases_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+// MockPasswordResetRepository mocks the PasswordResetRepository interface
+type MockPasswordResetRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetRepository) Create(ctx context.Context, token *entities.PasswordResetToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepository) GetByID(ctx context.Context, id string) (*entities.PasswordResetToken, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PasswordResetToken), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) GetLatestByUserID(ctx context.Context, userID string) (*entities.PasswordResetToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PasswordResetToken), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) MarkUsed(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) DeleteAllByUserID(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockMailer mocks the Mailer interface
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(ctx context.Context, message services.MailMessage) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
+func newTestPasswordResetUseCase(
+	mockUserRepo *MockUserRepository,
+	mockResetRepo *MockPasswordResetRepository,
+	mockSessionRepo *MockSessionRepository,
+	mockPATRepo *MockPersonalAccessTokenRepository,
+	mockTokenService *MockTokenService,
+	mockHashService *MockHashService,
+	mockMailer *MockMailer,
+	mockAttemptStore *MockLoginAttemptStore,
+) (*use_cases.PasswordResetUseCase, *FakeTxManager) {
+	txManager := &FakeTxManager{repos: repositories.TxRepositories{
+		Users:                mockUserRepo,
+		Sessions:             mockSessionRepo,
+		PasswordResets:       mockResetRepo,
+		PersonalAccessTokens: mockPATRepo,
+	}}
+	emailVerificationUseCase := use_cases.NewEmailVerificationUseCase(mockUserRepo, new(MockEmailVerificationRepository), mockTokenService, mockMailer, use_cases.EmailVerificationRestrict, "https://notes.example.com/", 24*time.Hour, time.Minute)
+	return use_cases.NewPasswordResetUseCase(mockUserRepo, mockResetRepo, txManager, emailVerificationUseCase, mockTokenService, mockHashService, mockMailer, mockAttemptStore, "https://notes.example.com/", use_cases.PasswordResetConfig{
+		TokenTTL:       time.Hour,
+		ResendInterval: time.Minute,
+		IPLimit:        10,
+		IPWindow:       time.Hour,
+	}), txManager
+}
+
+func TestRequestReset(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockResetRepo := new(MockPasswordResetRepository)
+	mockSessionRepo := new(MockSessionRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+	mockMailer := new(MockMailer)
+
+	verifiedAt := time.Now()
+	user := &entities.User{ID: uuid.New().String(), Email: "test@example.com", Name: "Test User", EmailVerifiedAt: &verifiedAt}
+
+	mockUserRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	mockResetRepo.On("GetLatestByUserID", ctx, user.ID).Return(nil, nil)
+	mockTokenService.On("GenerateToken", ctx).Return("resettoken", nil)
+	mockTokenService.On("HashToken", ctx, "resettoken").Return("hashedtoken", nil)
+
+	// Only the hash of the token is stored
+	mockResetRepo.On("Create", ctx, mock.MatchedBy(func(token *entities.PasswordResetToken) bool {
+		return token.ID == "hashedtoken" && token.UserID == user.ID && token.ExpiresAt.After(time.Now().Add(59*time.Minute))
+	})).Return(nil)
+	mockMailer.On("Send", ctx, mock.MatchedBy(func(message services.MailMessage) bool {
+		return message.To == user.Email && strings.Contains(message.Body, "https://notes.example.com/reset-password?token=resettoken")
+	})).Return(nil)
+
+	useCase, _ := newTestPasswordResetUseCase(mockUserRepo, mockResetRepo, mockSessionRepo, new(MockPersonalAccessTokenRepository), mockTokenService, mockHashService, mockMailer, new(MockLoginAttemptStore))
+
+	// Act
+	err := useCase.RequestReset(ctx, user.Email, "")
+
+	// Assert
+	assert.NoError(t, err)
+	mockResetRepo.AssertExpectations(t)
+	mockMailer.AssertExpectations(t)
+}
+
+func TestRequestReset_UnknownEmail(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockResetRepo := new(MockPasswordResetRepository)
+	mockSessionRepo := new(MockSessionRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+	mockMailer := new(MockMailer)
+
+	mockUserRepo.On("GetByEmail", ctx, "nobody@example.com").Return(nil, nil)
+
+	useCase, _ := newTestPasswordResetUseCase(mockUserRepo, mockResetRepo, mockSessionRepo, new(MockPersonalAccessTokenRepository), mockTokenService, mockHashService, mockMailer, new(MockLoginAttemptStore))
+
+	// Act
+	err := useCase.RequestReset(ctx, "nobody@example.com", "")
+
+	// Assert
+	assert.NoError(t, err)
+	mockResetRepo.AssertNotCalled(t, "Create")
+	mockMailer.AssertNotCalled(t, "Send")
+}
+
+func TestRequestReset_UnverifiedEmail(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockResetRepo := new(MockPasswordResetRepository)
+	mockSessionRepo := new(MockSessionRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+	mockMailer := new(MockMailer)
+
+	user := &entities.User{ID: uuid.New().String(), Email: "test@example.com", Name: "Test User"}
+	mockUserRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+
+	useCase, _ := newTestPasswordResetUseCase(mockUserRepo, mockResetRepo, mockSessionRepo, new(MockPersonalAccessTokenRepository), mockTokenService, mockHashService, mockMailer, new(MockLoginAttemptStore))
+
+	// Act
+	err := useCase.RequestReset(ctx, user.Email, "")
+
+	// Assert
+	assert.NoError(t, err)
+	mockResetRepo.AssertNotCalled(t, "Create")
+	mockMailer.AssertNotCalled(t, "Send")
+}
+
+func TestRequestReset_ResentTooSoon(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockResetRepo := new(MockPasswordResetRepository)
+	mockSessionRepo := new(MockSessionRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+	mockMailer := new(MockMailer)
+
+	verifiedAt := time.Now()
+	user := &entities.User{ID: uuid.New().String(), Email: "test@example.com", Name: "Test User", EmailVerifiedAt: &verifiedAt}
+	latest := &entities.PasswordResetToken{ID: "hashedtoken", UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour), CreatedAt: time.Now().Add(-10 * time.Second)}
+
+	mockUserRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	mockResetRepo.On("GetLatestByUserID", ctx, user.ID).Return(latest, nil)
+
+	useCase, _ := newTestPasswordResetUseCase(mockUserRepo, mockResetRepo, mockSessionRepo, new(MockPersonalAccessTokenRepository), mockTokenService, mockHashService, mockMailer, new(MockLoginAttemptStore))
+
+	// Act
+	err := useCase.RequestReset(ctx, user.Email, "")
+
+	// Assert
+	assert.NoError(t, err)
+	mockResetRepo.AssertNotCalled(t, "Create")
+	mockMailer.AssertNotCalled(t, "Send")
+}
+
+func TestRequestReset_IPLimited(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockResetRepo := new(MockPasswordResetRepository)
+	mockSessionRepo := new(MockSessionRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+	mockMailer := new(MockMailer)
+	mockAttemptStore := new(MockLoginAttemptStore)
+
+	mockAttemptStore.On("Get", ctx, "password-reset:ip:203.0.113.7").Return(&services.LoginAttempts{Failures: 10, LastFailureAt: time.Now()}, nil)
+
+	useCase, _ := newTestPasswordResetUseCase(mockUserRepo, mockResetRepo, mockSessionRepo, new(MockPersonalAccessTokenRepository), mockTokenService, mockHashService, mockMailer, mockAttemptStore)
+
+	// Act
+	err := useCase.RequestReset(ctx, "test@example.com", "203.0.113.7")
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrRateLimited)
+	mockUserRepo.AssertNotCalled(t, "GetByEmail")
+	mockAttemptStore.AssertNotCalled(t, "RecordFailure")
+	mockMailer.AssertNotCalled(t, "Send")
+}
+
+func TestRequestReset_CountsIPRequests(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockResetRepo := new(MockPasswordResetRepository)
+	mockSessionRepo := new(MockSessionRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+	mockMailer := new(MockMailer)
+	mockAttemptStore := new(MockLoginAttemptStore)
+
+	mockAttemptStore.On("Get", ctx, "password-reset:ip:203.0.113.7").Return(&services.LoginAttempts{Failures: 9, LastFailureAt: time.Now()}, nil)
+	mockAttemptStore.On("RecordFailure", ctx, "password-reset:ip:203.0.113.7", mock.AnythingOfType("time.Time"), time.Hour).Return(&services.LoginAttempts{Failures: 10}, nil)
+	mockUserRepo.On("GetByEmail", ctx, "nobody@example.com").Return(nil, nil)
+
+	useCase, _ := newTestPasswordResetUseCase(mockUserRepo, mockResetRepo, mockSessionRepo, new(MockPersonalAccessTokenRepository), mockTokenService, mockHashService, mockMailer, mockAttemptStore)
+
+	// Act
+	err := useCase.RequestReset(ctx, "nobody@example.com", "203.0.113.7")
+
+	// Assert
+	assert.NoError(t, err)
+	mockAttemptStore.AssertExpectations(t)
+}
+
+func TestResetPassword(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockResetRepo := new(MockPasswordResetRepository)
+	mockSessionRepo := new(MockSessionRepository)
+	mockPATRepo := new(MockPersonalAccessTokenRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+	mockMailer := new(MockMailer)
+
+	user := &entities.User{ID: uuid.New().String(), Email: "test@example.com", Password: "oldhash"}
+	resetToken := &entities.PasswordResetToken{ID: "hashedtoken", UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)}
+
+	mockTokenService.On("HashToken", ctx, "resettoken").Return("hashedtoken", nil)
+	mockResetRepo.On("GetByID", ctx, "hashedtoken").Return(resetToken, nil)
+	mockResetRepo.On("MarkUsed", ctx, "hashedtoken").Return(true, nil)
+	mockUserRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockHashService.On("HashPassword", ctx, "N3w!P@ssw0rd123").Return("newhash", nil)
+	mockUserRepo.On("Update", ctx, mock.MatchedBy(func(u *entities.User) bool {
+		return u.ID == user.ID && u.Password == "newhash"
+	})).Return(nil)
+	mockResetRepo.On("DeleteAllByUserID", ctx, user.ID).Return(nil)
+	mockSessionRepo.On("DeleteAllByUserID", ctx, user.ID).Return(nil)
+	mockPATRepo.On("DeleteAllByUserID", ctx, user.ID).Return(nil)
+
+	useCase, txManager := newTestPasswordResetUseCase(mockUserRepo, mockResetRepo, mockSessionRepo, mockPATRepo, mockTokenService, mockHashService, mockMailer, new(MockLoginAttemptStore))
+
+	// Act
+	err := useCase.ResetPassword(ctx, "resettoken", "N3w!P@ssw0rd123")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, txManager.Commits)
+	mockUserRepo.AssertExpectations(t)
+	mockResetRepo.AssertExpectations(t)
+	mockSessionRepo.AssertExpectations(t)
+	mockPATRepo.AssertExpectations(t)
+}
+
+func TestResetPassword_ExpiredToken(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockResetRepo := new(MockPasswordResetRepository)
+	mockSessionRepo := new(MockSessionRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+	mockMailer := new(MockMailer)
+
+	resetToken := &entities.PasswordResetToken{ID: "hashedtoken", UserID: uuid.New().String(), ExpiresAt: time.Now().Add(-time.Minute)}
+
+	mockTokenService.On("HashToken", ctx, "resettoken").Return("hashedtoken", nil)
+	mockResetRepo.On("GetByID", ctx, "hashedtoken").Return(resetToken, nil)
+
+	useCase, _ := newTestPasswordResetUseCase(mockUserRepo, mockResetRepo, mockSessionRepo, new(MockPersonalAccessTokenRepository), mockTokenService, mockHashService, mockMailer, new(MockLoginAttemptStore))
+
+	// Act
+	err := useCase.ResetPassword(ctx, "resettoken", "N3w!P@ssw0rd123")
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrValidation)
+	mockResetRepo.AssertNotCalled(t, "MarkUsed")
+	mockUserRepo.AssertNotCalled(t, "Update")
+}
+
+func TestResetPassword_TokenAlreadyUsed(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockResetRepo := new(MockPasswordResetRepository)
+	mockSessionRepo := new(MockSessionRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+	mockMailer := new(MockMailer)
+
+	resetToken := &entities.PasswordResetToken{ID: "hashedtoken", UserID: uuid.New().String(), ExpiresAt: time.Now().Add(time.Hour)}
+
+	// A concurrent request consumed the token between the read and the update
+	mockTokenService.On("HashToken", ctx, "resettoken").Return("hashedtoken", nil)
+	mockResetRepo.On("GetByID", ctx, "hashedtoken").Return(resetToken, nil)
+	mockHashService.On("HashPassword", ctx, "N3w!P@ssw0rd123").Return("newhash", nil)
+	mockResetRepo.On("MarkUsed", ctx, "hashedtoken").Return(false, nil)
+
+	useCase, _ := newTestPasswordResetUseCase(mockUserRepo, mockResetRepo, mockSessionRepo, new(MockPersonalAccessTokenRepository), mockTokenService, mockHashService, mockMailer, new(MockLoginAttemptStore))
+
+	// Act
+	err := useCase.ResetPassword(ctx, "resettoken", "N3w!P@ssw0rd123")
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrValidation)
+	mockUserRepo.AssertNotCalled(t, "Update")
+	mockSessionRepo.AssertNotCalled(t, "DeleteAllByUserID")
+}
+
+func TestResetPassword_RollsBackOnRevocationFailure(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockResetRepo := new(MockPasswordResetRepository)
+	mockSessionRepo := new(MockSessionRepository)
+	mockPATRepo := new(MockPersonalAccessTokenRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+	mockMailer := new(MockMailer)
+
+	user := &entities.User{ID: uuid.New().String(), Email: "test@example.com", Password: "oldhash"}
+	resetToken := &entities.PasswordResetToken{ID: "hashedtoken", UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)}
+
+	mockTokenService.On("HashToken", ctx, "resettoken").Return("hashedtoken", nil)
+	mockResetRepo.On("GetByID", ctx, "hashedtoken").Return(resetToken, nil)
+	mockHashService.On("HashPassword", ctx, "N3w!P@ssw0rd123").Return("newhash", nil)
+	mockResetRepo.On("MarkUsed", ctx, "hashedtoken").Return(true, nil)
+	mockUserRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockUserRepo.On("Update", ctx, mock.Anything).Return(nil)
+	mockResetRepo.On("DeleteAllByUserID", ctx, user.ID).Return(nil)
+	mockSessionRepo.On("DeleteAllByUserID", ctx, user.ID).Return(nil)
+
+	// Revoking the access tokens fails after the password was written
+	mockPATRepo.On("DeleteAllByUserID", ctx, user.ID).Return(errors.New("connection reset"))
+
+	useCase, txManager := newTestPasswordResetUseCase(mockUserRepo, mockResetRepo, mockSessionRepo, mockPATRepo, mockTokenService, mockHashService, mockMailer, new(MockLoginAttemptStore))
+
+	// Act
+	err := useCase.ResetPassword(ctx, "resettoken", "N3w!P@ssw0rd123")
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, 0, txManager.Commits)
+	assert.Equal(t, 1, txManager.Rollbacks)
+}
@@ -0,0 +1,160 @@
+package use_cases
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+const (
+	// personalAccessTokenPrefix makes leaked tokens easy to recognize, for
+	// people and for secret scanners alike
+	personalAccessTokenPrefix = "nnpat_"
+	// tokenLastUsedInterval is how stale the last used time of a token may
+	// get before it is written again
+	tokenLastUsedInterval = time.Minute
+)
+
+type PersonalAccessTokenUseCase struct {
+	tokenRepo    repositories.PersonalAccessTokenRepository
+	userRepo     repositories.UserRepository
+	tokenService services.TokenService
+}
+
+func NewPersonalAccessTokenUseCase(
+	tokenRepo repositories.PersonalAccessTokenRepository,
+	userRepo repositories.UserRepository,
+	tokenService services.TokenService,
+) *PersonalAccessTokenUseCase {
+	return &PersonalAccessTokenUseCase{
+		tokenRepo:    tokenRepo,
+		userRepo:     userRepo,
+		tokenService: tokenService,
+	}
+}
+
+// CreateToken issues a new token for the user. The returned secret is only
+// ever shown this once, only its hash is stored.
+func (uc *PersonalAccessTokenUseCase) CreateToken(ctx context.Context, userID, name string, scopes []entities.TokenScope, expiresAt *time.Time) (*entities.PersonalAccessToken, string, error) {
+	// Validate the scopes and the expiry
+	if len(scopes) == 0 {
+		return nil, "", domainerrors.InvalidField("scopes", "at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !scope.IsValid() {
+			return nil, "", domainerrors.InvalidField("scopes", "unknown scope "+string(scope))
+		}
+	}
+	now := time.Now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, "", domainerrors.InvalidField("expires_at", "must be in the future")
+	}
+
+	// Generate the secret
+	secret, err := uc.tokenService.GenerateToken(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	secret = personalAccessTokenPrefix + secret
+	tokenHash, err := uc.tokenService.HashToken(ctx, secret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Sort and deduplicate the scopes
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+
+	token := &entities.PersonalAccessToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Name:      name,
+		TokenHash: tokenHash,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	if err := uc.tokenRepo.Create(ctx, token); err != nil {
+		return nil, "", err
+	}
+
+	return token, secret, nil
+}
+
+func (uc *PersonalAccessTokenUseCase) ListTokens(ctx context.Context, userID string) ([]*entities.PersonalAccessToken, error) {
+	return uc.tokenRepo.GetByUserID(ctx, userID)
+}
+
+func (uc *PersonalAccessTokenUseCase) RevokeToken(ctx context.Context, userID, tokenID string) error {
+	deleted, err := uc.tokenRepo.Delete(ctx, userID, tokenID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return domainerrors.NotFound("personal access token")
+	}
+
+	return nil
+}
+
+// RevokeAllTokens deletes every token of the user, for instance once their
+// password changed and a token may have been created by somebody else
+func (uc *PersonalAccessTokenUseCase) RevokeAllTokens(ctx context.Context, userID string) error {
+	return uc.tokenRepo.DeleteAllByUserID(ctx, userID)
+}
+
+// Authenticate returns the owner of a token along with the token. Unknown and
+// expired tokens, and tokens of accounts scheduled for deletion, are rejected
+// with an unauthorized error.
+func (uc *PersonalAccessTokenUseCase) Authenticate(ctx context.Context, secret string) (*entities.User, *entities.PersonalAccessToken, error) {
+	invalidToken := domainerrors.Unauthorized("invalid or expired access token")
+
+	// Only tokens of ours are looked up
+	if !strings.HasPrefix(secret, personalAccessTokenPrefix) {
+		return nil, nil, invalidToken
+	}
+	tokenHash, err := uc.tokenService.HashToken(ctx, secret)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Get the token
+	token, err := uc.tokenRepo.GetByHash(ctx, tokenHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	if token == nil || token.IsExpired(now) {
+		return nil, nil, invalidToken
+	}
+
+	// Get its owner
+	user, err := uc.userRepo.GetByID(ctx, token.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil || user.DeletionRequestedAt != nil {
+		return nil, nil, invalidToken
+	}
+
+	// Record the use, a failure must not reject the request
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= tokenLastUsedInterval {
+		if err := uc.tokenRepo.UpdateLastUsedAt(ctx, token.ID, now); err != nil {
+			log.Printf("error recording personal access token use: %v", err)
+		} else {
+			token.LastUsedAt = &now
+		}
+	}
+
+	return user, token, nil
+}
@@ -0,0 +1,192 @@
+package use_cases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+// MockPersonalAccessTokenRepository mocks the PersonalAccessTokenRepository interface
+type MockPersonalAccessTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockPersonalAccessTokenRepository) Create(ctx context.Context, token *entities.PersonalAccessToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockPersonalAccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*entities.PersonalAccessToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PersonalAccessToken), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) GetByUserID(ctx context.Context, userID string) ([]*entities.PersonalAccessToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.PersonalAccessToken), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) UpdateLastUsedAt(ctx context.Context, id string, lastUsedAt time.Time) error {
+	args := m.Called(ctx, id, lastUsedAt)
+	return args.Error(0)
+}
+
+func (m *MockPersonalAccessTokenRepository) Delete(ctx context.Context, userID, id string) (bool, error) {
+	args := m.Called(ctx, userID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) DeleteAllByUserID(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func TestCreatePersonalAccessToken(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockTokenRepo := new(MockPersonalAccessTokenRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTokenService := new(MockTokenService)
+
+	userID := uuid.New().String()
+	expiresAt := time.Now().Add(24 * time.Hour)
+
+	mockTokenService.On("GenerateToken", ctx).Return("random-token", nil)
+	mockTokenService.On("HashToken", ctx, "nnpat_random-token").Return("hashed-token", nil)
+	mockTokenRepo.On("Create", ctx, mock.MatchedBy(func(token *entities.PersonalAccessToken) bool {
+		return token.UserID == userID &&
+			token.Name == "backup script" &&
+			token.TokenHash == "hashed-token" &&
+			assert.ObjectsAreEqual([]entities.TokenScope{entities.ScopeNotesRead, entities.ScopeNotesWrite}, token.Scopes)
+	})).Return(nil)
+
+	useCase := use_cases.NewPersonalAccessTokenUseCase(mockTokenRepo, mockUserRepo, mockTokenService)
+
+	// Act
+	token, secret, err := useCase.CreateToken(ctx, userID, "backup script", []entities.TokenScope{entities.ScopeNotesWrite, entities.ScopeNotesRead, entities.ScopeNotesWrite}, &expiresAt)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "nnpat_random-token", secret)
+	assert.Equal(t, &expiresAt, token.ExpiresAt)
+	mockTokenService.AssertExpectations(t)
+	mockTokenRepo.AssertExpectations(t)
+}
+
+func TestCreatePersonalAccessToken_UnknownScope(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockTokenRepo := new(MockPersonalAccessTokenRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTokenService := new(MockTokenService)
+
+	useCase := use_cases.NewPersonalAccessTokenUseCase(mockTokenRepo, mockUserRepo, mockTokenService)
+
+	// Act
+	token, _, err := useCase.CreateToken(ctx, uuid.New().String(), "script", []entities.TokenScope{"notes:admin"}, nil)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrValidation)
+	assert.Nil(t, token)
+	mockTokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAuthenticatePersonalAccessToken(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockTokenRepo := new(MockPersonalAccessTokenRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTokenService := new(MockTokenService)
+
+	userID := uuid.New().String()
+	tokenID := uuid.New().String()
+	user := &entities.User{ID: userID, Email: "test@example.com"}
+	token := &entities.PersonalAccessToken{
+		ID:     tokenID,
+		UserID: userID,
+		Scopes: []entities.TokenScope{entities.ScopeNotesRead},
+	}
+
+	mockTokenService.On("HashToken", ctx, "nnpat_secret").Return("hashed-token", nil)
+	mockTokenRepo.On("GetByHash", ctx, "hashed-token").Return(token, nil)
+	mockUserRepo.On("GetByID", ctx, userID).Return(user, nil)
+	mockTokenRepo.On("UpdateLastUsedAt", ctx, tokenID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	useCase := use_cases.NewPersonalAccessTokenUseCase(mockTokenRepo, mockUserRepo, mockTokenService)
+
+	// Act
+	authenticatedUser, authenticatedToken, err := useCase.Authenticate(ctx, "nnpat_secret")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, user, authenticatedUser)
+	assert.Equal(t, token, authenticatedToken)
+	assert.NotNil(t, authenticatedToken.LastUsedAt)
+	mockTokenRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestAuthenticatePersonalAccessToken_Expired(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockTokenRepo := new(MockPersonalAccessTokenRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTokenService := new(MockTokenService)
+
+	expiresAt := time.Now().Add(-time.Minute)
+	token := &entities.PersonalAccessToken{
+		ID:        uuid.New().String(),
+		UserID:    uuid.New().String(),
+		Scopes:    []entities.TokenScope{entities.ScopeNotesRead},
+		ExpiresAt: &expiresAt,
+	}
+
+	mockTokenService.On("HashToken", ctx, "nnpat_secret").Return("hashed-token", nil)
+	mockTokenRepo.On("GetByHash", ctx, "hashed-token").Return(token, nil)
+
+	useCase := use_cases.NewPersonalAccessTokenUseCase(mockTokenRepo, mockUserRepo, mockTokenService)
+
+	// Act
+	user, _, err := useCase.Authenticate(ctx, "nnpat_secret")
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrUnauthorized)
+	assert.Nil(t, user)
+	mockUserRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+func TestRevokePersonalAccessToken_NotFound(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockTokenRepo := new(MockPersonalAccessTokenRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTokenService := new(MockTokenService)
+
+	userID := uuid.New().String()
+	tokenID := uuid.New().String()
+
+	mockTokenRepo.On("Delete", ctx, userID, tokenID).Return(false, nil)
+
+	useCase := use_cases.NewPersonalAccessTokenUseCase(mockTokenRepo, mockUserRepo, mockTokenService)
+
+	// Act
+	err := useCase.RevokeToken(ctx, userID, tokenID)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrNotFound)
+	mockTokenRepo.AssertExpectations(t)
+}
@@ -2,28 +2,65 @@ package use_cases
 
 import (
 	"context"
+	"crypto/subtle"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
 	"github.com/LaulauChau/note-nest/internal/domain/entities"
 	"github.com/LaulauChau/note-nest/internal/domain/repositories"
 )
 
+// lastSeenInterval is how stale the last seen time of a session may get
+// before it is written again, sparing a write on every request
+const lastSeenInterval = 5 * time.Minute
+
+// csrfTokenPrefix keeps the CSRF token of a session distinct from its ID,
+// which is the hash of the bare token
+const csrfTokenPrefix = "csrf:"
+
+type SessionConfig struct {
+	// TTL is how long a session lasts without being used. Sessions used
+	// within RenewalThreshold of their expiry are extended by another TTL.
+	TTL              time.Duration
+	RenewalThreshold time.Duration
+	// IdleTimeout ends sessions unused for that long, MaxAge ends sessions
+	// that old however active they are. Zero disables either.
+	IdleTimeout time.Duration
+	MaxAge      time.Duration
+	// PartialTTL bounds how long a user has to submit their second factor
+	// after the password check
+	PartialTTL time.Duration
+	// CleanupBatchSize is how many expired sessions are deleted at once
+	CleanupBatchSize int
+}
+
+// SessionClient describes the device a session is created from
+type SessionClient struct {
+	UserAgent string
+	IPAddress string
+}
+
 type SessionUseCase struct {
 	sessionRepo  repositories.SessionRepository
 	userRepo     repositories.UserRepository
 	tokenService services.TokenService
+	config       SessionConfig
 }
 
 func NewSessionUseCase(
 	sessionRepo repositories.SessionRepository,
 	userRepo repositories.UserRepository,
 	tokenService services.TokenService,
+	config SessionConfig,
 ) *SessionUseCase {
 	return &SessionUseCase{
 		sessionRepo:  sessionRepo,
 		userRepo:     userRepo,
 		tokenService: tokenService,
+		config:       config,
 	}
 }
 
@@ -31,20 +68,51 @@ func (uc *SessionUseCase) GenerateSessionToken(ctx context.Context) (string, err
 	return uc.tokenService.GenerateToken(ctx)
 }
 
-func (uc *SessionUseCase) CreateSession(ctx context.Context, token string, userID string) (*entities.Session, error) {
+func (uc *SessionUseCase) CreateSession(ctx context.Context, token string, userID string, client SessionClient) (*entities.Session, error) {
 	sessionID, err := uc.tokenService.HashToken(ctx, token)
 	if err != nil {
 		return nil, err
 	}
 
-	// 30 days from now
-	expiresAt := time.Now().Add(30 * 24 * time.Hour)
+	now := time.Now()
+	session := &entities.Session{
+		ID:         sessionID,
+		PublicID:   uuid.New().String(),
+		UserID:     userID,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		ExpiresAt:  uc.expiresAt(now, now),
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	if err := uc.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// CreatePartialSession creates a short-lived session for a user who passed
+// the password check but still has to submit their second factor. It is
+// rejected by the auth middleware.
+func (uc *SessionUseCase) CreatePartialSession(ctx context.Context, token string, userID string, client SessionClient) (*entities.Session, error) {
+	sessionID, err := uc.tokenService.HashToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
 
+	now := time.Now()
 	session := &entities.Session{
-		ID:        sessionID,
-		UserID:    userID,
-		ExpiresAt: expiresAt,
-		CreatedAt: time.Now(),
+		ID:               sessionID,
+		PublicID:         uuid.New().String(),
+		UserID:           userID,
+		UserAgent:        client.UserAgent,
+		IPAddress:        client.IPAddress,
+		ExpiresAt:        now.Add(uc.config.PartialTTL),
+		CreatedAt:        now,
+		LastSeenAt:       now,
+		TwoFactorPending: true,
 	}
 
 	if err := uc.sessionRepo.Create(ctx, session); err != nil {
@@ -72,18 +140,30 @@ func (uc *SessionUseCase) ValidateSessionToken(ctx context.Context, token string
 	now := time.Now()
 
 	// Check if session is expired
-	if now.After(result.Session.ExpiresAt) {
+	if uc.isExpired(result.Session, now) {
 		if err := uc.sessionRepo.Delete(ctx, result.Session.ID); err != nil {
 			return nil, err
 		}
 		return &entities.SessionValidationResult{Session: nil, User: nil}, nil
 	}
 
-	// If session is going to expire in 15 days, extend it
-	fifteenDaysFromNow := now.Add(15 * 24 * time.Hour)
-	if result.Session.ExpiresAt.Before(fifteenDaysFromNow) {
-		// Extend session by 30 days
-		newExpiresAt := now.Add(30 * 24 * time.Hour)
+	// Partial sessions are never extended
+	if result.Session.TwoFactorPending {
+		return result, nil
+	}
+
+	// Record the activity of the session
+	if now.Sub(result.Session.LastSeenAt) >= uc.lastSeenInterval() {
+		result.Session.LastSeenAt = now
+
+		if err := uc.sessionRepo.UpdateLastSeenAt(ctx, result.Session.ID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	// Extend the session when it is about to expire, never past its maximum age
+	newExpiresAt := uc.expiresAt(result.Session.CreatedAt, now)
+	if result.Session.ExpiresAt.Before(now.Add(uc.config.RenewalThreshold)) && newExpiresAt.After(result.Session.ExpiresAt) {
 		result.Session.ExpiresAt = newExpiresAt
 
 		if err := uc.sessionRepo.UpdateExpiresAt(ctx, result.Session.ID, newExpiresAt); err != nil {
@@ -94,6 +174,25 @@ func (uc *SessionUseCase) ValidateSessionToken(ctx context.Context, token string
 	return result, nil
 }
 
+// ListSessions returns the devices the user is signed in on
+func (uc *SessionUseCase) ListSessions(ctx context.Context, userID string) ([]*entities.Session, error) {
+	return uc.sessionRepo.ListByUserID(ctx, userID)
+}
+
+// RevokeSession signs the user out of one of their sessions, identified by
+// its public ID
+func (uc *SessionUseCase) RevokeSession(ctx context.Context, userID, publicID string) error {
+	deleted, err := uc.sessionRepo.DeleteByPublicID(ctx, userID, publicID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return domainerrors.NotFound("session")
+	}
+
+	return nil
+}
+
 func (uc *SessionUseCase) InvalidateSession(ctx context.Context, sessionID string) error {
 	return uc.sessionRepo.Delete(ctx, sessionID)
 }
@@ -101,3 +200,97 @@ func (uc *SessionUseCase) InvalidateSession(ctx context.Context, sessionID strin
 func (uc *SessionUseCase) InvalidateAllSessions(ctx context.Context, userID string) error {
 	return uc.sessionRepo.DeleteAllByUserID(ctx, userID)
 }
+
+// InvalidateOtherSessions signs the user out everywhere but the current session
+func (uc *SessionUseCase) InvalidateOtherSessions(ctx context.Context, userID, currentSessionID string) error {
+	return uc.sessionRepo.DeleteAllByUserIDExcept(ctx, userID, currentSessionID)
+}
+
+// CSRFToken derives the CSRF token of a session from its token. Only a
+// client holding the session cookie can compute it, and it changes with
+// every new session, so nothing has to be stored.
+func (uc *SessionUseCase) CSRFToken(ctx context.Context, sessionToken string) (string, error) {
+	return uc.tokenService.HashToken(ctx, csrfTokenPrefix+sessionToken)
+}
+
+// ValidateCSRFToken checks the CSRF token sent along a request made with
+// the session
+func (uc *SessionUseCase) ValidateCSRFToken(ctx context.Context, sessionToken, csrfToken string) error {
+	expected, err := uc.CSRFToken(ctx, sessionToken)
+	if err != nil {
+		return err
+	}
+	if csrfToken == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(csrfToken)) != 1 {
+		return domainerrors.Forbidden("missing or invalid CSRF token")
+	}
+
+	return nil
+}
+
+// PurgeExpiredSessions deletes the sessions that expired, went idle or
+// reached their maximum age, a batch at a time so that a large backlog does
+// not hold locks on the table for long. It stops early when the context is
+// cancelled.
+func (uc *SessionUseCase) PurgeExpiredSessions(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	// A zero time disables the idle and maximum age conditions
+	var idleBefore, createdBefore time.Time
+	if uc.config.IdleTimeout > 0 {
+		idleBefore = now.Add(-uc.config.IdleTimeout)
+	}
+	if uc.config.MaxAge > 0 {
+		createdBefore = now.Add(-uc.config.MaxAge)
+	}
+
+	purged := 0
+	for {
+		deleted, err := uc.sessionRepo.DeleteExpired(ctx, now, idleBefore, createdBefore, uc.config.CleanupBatchSize)
+		purged += deleted
+		if err != nil {
+			return purged, err
+		}
+		if deleted < uc.config.CleanupBatchSize {
+			return purged, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+	}
+}
+
+// expiresAt returns the expiry of a session created at createdAt and used at
+// now
+func (uc *SessionUseCase) expiresAt(createdAt, now time.Time) time.Time {
+	expiresAt := now.Add(uc.config.TTL)
+	if uc.config.MaxAge > 0 {
+		expiresAt = minTime(expiresAt, createdAt.Add(uc.config.MaxAge))
+	}
+	return expiresAt
+}
+
+func (uc *SessionUseCase) isExpired(session *entities.Session, now time.Time) bool {
+	if now.After(session.ExpiresAt) {
+		return true
+	}
+	if uc.config.IdleTimeout > 0 && now.Sub(session.LastSeenAt) > uc.config.IdleTimeout {
+		return true
+	}
+	return uc.config.MaxAge > 0 && now.Sub(session.CreatedAt) > uc.config.MaxAge
+}
+
+// lastSeenInterval keeps the last seen time fresh enough for the idle timeout
+// to be accurate
+func (uc *SessionUseCase) lastSeenInterval() time.Duration {
+	if uc.config.IdleTimeout > 0 {
+		return min(lastSeenInterval, uc.config.IdleTimeout/2)
+	}
+	return lastSeenInterval
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
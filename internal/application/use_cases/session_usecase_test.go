@@ -10,9 +10,17 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
 	"github.com/LaulauChau/note-nest/internal/domain/entities"
 )
 
+var testSessionConfig = use_cases.SessionConfig{
+	TTL:              30 * 24 * time.Hour,
+	RenewalThreshold: 15 * 24 * time.Hour,
+	PartialTTL:       5 * time.Minute,
+	CleanupBatchSize: 100,
+}
+
 // MockSessionRepository mocks the SessionRepository interface
 type MockSessionRepository struct {
 	mock.Mock
@@ -39,21 +47,49 @@ func (m *MockSessionRepository) GetSessionWithUser(ctx context.Context, sessionI
 	return args.Get(0).(*entities.SessionValidationResult), args.Error(1)
 }
 
+func (m *MockSessionRepository) ListByUserID(ctx context.Context, userID string) ([]*entities.Session, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.Session), args.Error(1)
+}
+
 func (m *MockSessionRepository) UpdateExpiresAt(ctx context.Context, sessionID string, expiresAt time.Time) error {
 	args := m.Called(ctx, sessionID, expiresAt)
 	return args.Error(0)
 }
 
+func (m *MockSessionRepository) UpdateLastSeenAt(ctx context.Context, sessionID string, lastSeenAt time.Time) error {
+	args := m.Called(ctx, sessionID, lastSeenAt)
+	return args.Error(0)
+}
+
 func (m *MockSessionRepository) Delete(ctx context.Context, sessionID string) error {
 	args := m.Called(ctx, sessionID)
 	return args.Error(0)
 }
 
+func (m *MockSessionRepository) DeleteByPublicID(ctx context.Context, userID, publicID string) (bool, error) {
+	args := m.Called(ctx, userID, publicID)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockSessionRepository) DeleteAllByUserID(ctx context.Context, userID string) error {
 	args := m.Called(ctx, userID)
 	return args.Error(0)
 }
 
+func (m *MockSessionRepository) DeleteAllByUserIDExcept(ctx context.Context, userID, sessionID string) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) DeleteExpired(ctx context.Context, expiredBefore, idleBefore, createdBefore time.Time, limit int) (int, error) {
+	args := m.Called(ctx, expiredBefore, idleBefore, createdBefore, limit)
+	return args.Int(0), args.Error(1)
+}
+
 // MockUserRepository mocks the UserRepository interface
 type MockUserRepository struct {
 	mock.Mock
@@ -85,11 +121,39 @@ func (m *MockUserRepository) Update(ctx context.Context, user *entities.User) er
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) UpdateEmail(ctx context.Context, id, email string) error {
+	args := m.Called(ctx, id, email)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) Delete(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) ScheduleDeletion(ctx context.Context, id string, requestedAt time.Time) error {
+	args := m.Called(ctx, id, requestedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) CancelDeletion(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetPendingDeletionBefore(ctx context.Context, cutoff time.Time) ([]string, error) {
+	args := m.Called(ctx, cutoff)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
 // MockTokenService mocks the TokenService interface
 type MockTokenService struct {
 	mock.Mock
@@ -120,10 +184,10 @@ func TestCreateSession(t *testing.T) {
 	// Use mock.MatchedBy to match any session argument
 	mockSessionRepo.On("Create", ctx, mock.AnythingOfType("*entities.Session")).Return(nil)
 
-	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService)
+	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
 
 	// Act
-	result, err := useCase.CreateSession(ctx, token, userID)
+	result, err := useCase.CreateSession(ctx, token, userID, use_cases.SessionClient{})
 
 	// Assert
 	assert.NoError(t, err)
@@ -147,10 +211,11 @@ func TestValidateSessionToken_ValidSession(t *testing.T) {
 	sessionID := uuid.New().String()
 
 	session := &entities.Session{
-		ID:        sessionID,
-		UserID:    userID,
-		ExpiresAt: time.Now().Add(20 * 24 * time.Hour),
-		CreatedAt: time.Now(),
+		ID:         sessionID,
+		UserID:     userID,
+		ExpiresAt:  time.Now().Add(20 * 24 * time.Hour),
+		CreatedAt:  time.Now(),
+		LastSeenAt: time.Now(),
 	}
 
 	user := &entities.User{
@@ -167,7 +232,7 @@ func TestValidateSessionToken_ValidSession(t *testing.T) {
 	mockTokenService.On("HashToken", ctx, token).Return(hashedToken, nil)
 	mockSessionRepo.On("GetSessionWithUser", ctx, hashedToken).Return(validationResult, nil)
 
-	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService)
+	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
 
 	// Act
 	result, err := useCase.ValidateSessionToken(ctx, token)
@@ -220,7 +285,7 @@ func TestValidateSessionToken_ExpiredSession(t *testing.T) {
 	mockSessionRepo.On("GetSessionWithUser", ctx, hashedToken).Return(validationResult, nil)
 	mockSessionRepo.On("Delete", ctx, sessionID).Return(nil)
 
-	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService)
+	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
 
 	// Act
 	result, err := useCase.ValidateSessionToken(ctx, token)
@@ -237,6 +302,302 @@ func TestValidateSessionToken_ExpiredSession(t *testing.T) {
 	mockSessionRepo.AssertCalled(t, "Delete", ctx, sessionID)
 }
 
+func TestCreatePartialSession(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockSessionRepo := new(MockSessionRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTokenService := new(MockTokenService)
+
+	userID := uuid.New().String()
+	token := "random-token-string"
+	hashedToken := "hashed-token-string"
+
+	mockTokenService.On("HashToken", ctx, token).Return(hashedToken, nil)
+	mockSessionRepo.On("Create", ctx, mock.MatchedBy(func(s *entities.Session) bool {
+		return s.ID == hashedToken && s.TwoFactorPending
+	})).Return(nil)
+
+	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
+
+	// Act
+	session, err := useCase.CreatePartialSession(ctx, token, userID, use_cases.SessionClient{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, session.TwoFactorPending)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), session.ExpiresAt, time.Minute)
+
+	mockTokenService.AssertExpectations(t)
+	mockSessionRepo.AssertExpectations(t)
+}
+
+func TestValidateSessionToken_PartialSessionNotExtended(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockSessionRepo := new(MockSessionRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTokenService := new(MockTokenService)
+
+	token := "random-token-string"
+	hashedToken := "hashed-token-string"
+	userID := uuid.New().String()
+
+	validationResult := &entities.SessionValidationResult{
+		Session: &entities.Session{
+			ID:               hashedToken,
+			UserID:           userID,
+			ExpiresAt:        time.Now().Add(2 * time.Minute),
+			CreatedAt:        time.Now(),
+			TwoFactorPending: true,
+		},
+		User: &entities.User{ID: userID},
+	}
+
+	mockTokenService.On("HashToken", ctx, token).Return(hashedToken, nil)
+	mockSessionRepo.On("GetSessionWithUser", ctx, hashedToken).Return(validationResult, nil)
+
+	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
+
+	// Act
+	result, err := useCase.ValidateSessionToken(ctx, token)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, result.Session.TwoFactorPending)
+	mockSessionRepo.AssertNotCalled(t, "UpdateExpiresAt")
+}
+
+func TestValidateSessionToken_UpdatesLastSeen(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockSessionRepo := new(MockSessionRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTokenService := new(MockTokenService)
+
+	token := "random-token-string"
+	hashedToken := "hashed-token-string"
+	userID := uuid.New().String()
+	lastSeenAt := time.Now().Add(-time.Hour)
+
+	validationResult := &entities.SessionValidationResult{
+		Session: &entities.Session{
+			ID:         hashedToken,
+			UserID:     userID,
+			ExpiresAt:  time.Now().Add(20 * 24 * time.Hour),
+			CreatedAt:  lastSeenAt,
+			LastSeenAt: lastSeenAt,
+		},
+		User: &entities.User{ID: userID},
+	}
+
+	mockTokenService.On("HashToken", ctx, token).Return(hashedToken, nil)
+	mockSessionRepo.On("GetSessionWithUser", ctx, hashedToken).Return(validationResult, nil)
+	mockSessionRepo.On("UpdateLastSeenAt", ctx, hashedToken, mock.AnythingOfType("time.Time")).Return(nil)
+
+	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
+
+	// Act
+	result, err := useCase.ValidateSessionToken(ctx, token)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, result.Session.LastSeenAt.After(lastSeenAt))
+	mockSessionRepo.AssertExpectations(t)
+}
+
+func TestRevokeSession(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockSessionRepo := new(MockSessionRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTokenService := new(MockTokenService)
+
+	userID := uuid.New().String()
+	publicID := uuid.New().String()
+
+	mockSessionRepo.On("DeleteByPublicID", ctx, userID, publicID).Return(true, nil)
+
+	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
+
+	// Act
+	err := useCase.RevokeSession(ctx, userID, publicID)
+
+	// Assert
+	assert.NoError(t, err)
+	mockSessionRepo.AssertExpectations(t)
+}
+
+func TestRevokeSession_NotFound(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockSessionRepo := new(MockSessionRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTokenService := new(MockTokenService)
+
+	userID := uuid.New().String()
+	publicID := uuid.New().String()
+
+	// Sessions of other users are not found either
+	mockSessionRepo.On("DeleteByPublicID", ctx, userID, publicID).Return(false, nil)
+
+	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
+
+	// Act
+	err := useCase.RevokeSession(ctx, userID, publicID)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrNotFound)
+	mockSessionRepo.AssertExpectations(t)
+}
+
+func TestValidateCSRFToken(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockSessionRepo := new(MockSessionRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTokenService := new(MockTokenService)
+
+	// The CSRF token is never the session ID
+	mockTokenService.On("HashToken", ctx, "csrf:session-token").Return("csrf-token", nil)
+
+	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
+
+	// Act
+	csrfToken, err := useCase.CSRFToken(ctx, "session-token")
+	validateErr := useCase.ValidateCSRFToken(ctx, "session-token", csrfToken)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "csrf-token", csrfToken)
+	assert.NoError(t, validateErr)
+	mockTokenService.AssertExpectations(t)
+}
+
+func TestValidateCSRFToken_Invalid(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockSessionRepo := new(MockSessionRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTokenService := new(MockTokenService)
+
+	mockTokenService.On("HashToken", ctx, "csrf:session-token").Return("csrf-token", nil)
+
+	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
+
+	// Act
+	missingErr := useCase.ValidateCSRFToken(ctx, "session-token", "")
+	wrongErr := useCase.ValidateCSRFToken(ctx, "session-token", "other-token")
+
+	// Assert
+	assert.ErrorIs(t, missingErr, domainerrors.ErrForbidden)
+	assert.ErrorIs(t, wrongErr, domainerrors.ErrForbidden)
+}
+
+func TestValidateSessionToken_IdleSession(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockSessionRepo := new(MockSessionRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTokenService := new(MockTokenService)
+
+	token := "random-token-string"
+	hashedToken := "hashed-token-string"
+	userID := uuid.New().String()
+
+	// Unused for two hours with a one hour idle timeout
+	validationResult := &entities.SessionValidationResult{
+		Session: &entities.Session{
+			ID:         hashedToken,
+			UserID:     userID,
+			ExpiresAt:  time.Now().Add(20 * 24 * time.Hour),
+			CreatedAt:  time.Now().Add(-3 * time.Hour),
+			LastSeenAt: time.Now().Add(-2 * time.Hour),
+		},
+		User: &entities.User{ID: userID},
+	}
+
+	mockTokenService.On("HashToken", ctx, token).Return(hashedToken, nil)
+	mockSessionRepo.On("GetSessionWithUser", ctx, hashedToken).Return(validationResult, nil)
+	mockSessionRepo.On("Delete", ctx, hashedToken).Return(nil)
+
+	config := testSessionConfig
+	config.IdleTimeout = time.Hour
+	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, config)
+
+	// Act
+	result, err := useCase.ValidateSessionToken(ctx, token)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Nil(t, result.Session)
+	mockSessionRepo.AssertExpectations(t)
+}
+
+func TestValidateSessionToken_RenewalCappedByMaxAge(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockSessionRepo := new(MockSessionRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTokenService := new(MockTokenService)
+
+	token := "random-token-string"
+	hashedToken := "hashed-token-string"
+	userID := uuid.New().String()
+	createdAt := time.Now().Add(-80 * 24 * time.Hour)
+
+	// Due for renewal, but only ten days away from the maximum age
+	validationResult := &entities.SessionValidationResult{
+		Session: &entities.Session{
+			ID:         hashedToken,
+			UserID:     userID,
+			ExpiresAt:  time.Now().Add(5 * 24 * time.Hour),
+			CreatedAt:  createdAt,
+			LastSeenAt: time.Now(),
+		},
+		User: &entities.User{ID: userID},
+	}
+	maxExpiresAt := createdAt.Add(90 * 24 * time.Hour)
+
+	mockTokenService.On("HashToken", ctx, token).Return(hashedToken, nil)
+	mockSessionRepo.On("GetSessionWithUser", ctx, hashedToken).Return(validationResult, nil)
+	mockSessionRepo.On("UpdateExpiresAt", ctx, hashedToken, maxExpiresAt).Return(nil)
+
+	config := testSessionConfig
+	config.MaxAge = 90 * 24 * time.Hour
+	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, config)
+
+	// Act
+	result, err := useCase.ValidateSessionToken(ctx, token)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, maxExpiresAt, result.Session.ExpiresAt)
+	mockSessionRepo.AssertExpectations(t)
+}
+
+func TestPurgeExpiredSessions(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockSessionRepo := new(MockSessionRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTokenService := new(MockTokenService)
+
+	// A full batch is followed by another one until a partial batch
+	mockSessionRepo.On("DeleteExpired", ctx, mock.AnythingOfType("time.Time"), time.Time{}, time.Time{}, 100).Return(100, nil).Once()
+	mockSessionRepo.On("DeleteExpired", ctx, mock.AnythingOfType("time.Time"), time.Time{}, time.Time{}, 100).Return(42, nil).Once()
+
+	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
+
+	// Act
+	purged, err := useCase.PurgeExpiredSessions(ctx)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 142, purged)
+	mockSessionRepo.AssertExpectations(t)
+}
+
 func TestInvalidateSession(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
@@ -248,7 +609,7 @@ func TestInvalidateSession(t *testing.T) {
 
 	mockSessionRepo.On("Delete", ctx, sessionID).Return(nil)
 
-	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService)
+	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
 
 	// Act
 	err := useCase.InvalidateSession(ctx, sessionID)
@@ -269,7 +630,7 @@ func TestInvalidateAllSessions(t *testing.T) {
 
 	mockSessionRepo.On("DeleteAllByUserID", ctx, userID).Return(nil)
 
-	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService)
+	useCase := use_cases.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockTokenService, testSessionConfig)
 
 	// Act
 	err := useCase.InvalidateAllSessions(ctx, userID)
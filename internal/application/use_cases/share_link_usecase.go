@@ -0,0 +1,198 @@
+package use_cases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type ShareLinkUseCase struct {
+	linkRepo     repositories.ShareLinkRepository
+	noteRepo     repositories.NoteRepository
+	itemRepo     repositories.ChecklistItemRepository
+	tokenService services.TokenService
+	hashService  services.HashService
+	permissions  *NotePermissionService
+	throttle     *LoginThrottleUseCase
+	baseURL      string
+}
+
+func NewShareLinkUseCase(
+	linkRepo repositories.ShareLinkRepository,
+	noteRepo repositories.NoteRepository,
+	itemRepo repositories.ChecklistItemRepository,
+	tokenService services.TokenService,
+	hashService services.HashService,
+	permissions *NotePermissionService,
+	throttle *LoginThrottleUseCase,
+	baseURL string,
+) *ShareLinkUseCase {
+	return &ShareLinkUseCase{
+		linkRepo:     linkRepo,
+		noteRepo:     noteRepo,
+		itemRepo:     itemRepo,
+		tokenService: tokenService,
+		hashService:  hashService,
+		permissions:  permissions,
+		throttle:     throttle,
+		baseURL:      strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// CreateLink creates a public link to a note of the owner. The returned URL
+// holds the token, it is only ever shown this once.
+func (uc *ShareLinkUseCase) CreateLink(ctx context.Context, noteID, userID, password string, expiresAt *time.Time) (*entities.ShareLink, string, error) {
+	// Only the owner may publish the note
+	note, err := uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleOwner)
+	if err != nil {
+		return nil, "", err
+	}
+	if note.IsArchived {
+		return nil, "", domainerrors.Validation("archived notes cannot be shared")
+	}
+
+	// Validate the expiry
+	now := time.Now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, "", domainerrors.InvalidField("expires_at", "must be in the future")
+	}
+
+	// Generate the token
+	token, err := uc.tokenService.GenerateToken(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	tokenHash, err := uc.tokenService.HashToken(ctx, token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	link := &entities.ShareLink{
+		ID:        uuid.New().String(),
+		NoteID:    note.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	if password != "" {
+		link.PasswordHash, err = uc.hashService.HashPassword(ctx, password)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := uc.linkRepo.Create(ctx, link); err != nil {
+		return nil, "", err
+	}
+
+	return link, fmt.Sprintf("%s/s/%s", uc.baseURL, token), nil
+}
+
+// ListLinks returns the public links of a note of the owner
+func (uc *ShareLinkUseCase) ListLinks(ctx context.Context, noteID, userID string) ([]*entities.ShareLink, error) {
+	if _, err := uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleOwner); err != nil {
+		return nil, err
+	}
+
+	return uc.linkRepo.GetByNoteID(ctx, noteID)
+}
+
+// RevokeLink deletes a public link, it cannot be opened anymore
+func (uc *ShareLinkUseCase) RevokeLink(ctx context.Context, noteID, userID, linkID string) error {
+	if _, err := uc.permissions.Authorize(ctx, noteID, userID, entities.NoteRoleOwner); err != nil {
+		return err
+	}
+
+	deleted, err := uc.linkRepo.Delete(ctx, noteID, linkID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return domainerrors.NotFound("share link")
+	}
+
+	return nil
+}
+
+// OpenLink returns the note a public link points to and counts the view.
+// Unknown and expired links, and links to notes that were archived or
+// trashed since, are all reported as not found. A wrong or missing password
+// is reported as unauthorized. Password guesses are throttled per link and
+// per client address like sign-in attempts.
+func (uc *ShareLinkUseCase) OpenLink(ctx context.Context, token, password string, attempt LoginAttempt) (*entities.Note, *entities.ShareLink, error) {
+	// Get the link
+	tokenHash, err := uc.tokenService.HashToken(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	link, err := uc.linkRepo.GetByHash(ctx, tokenHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if link == nil || link.IsExpired(time.Now()) {
+		return nil, nil, domainerrors.NotFound("share link")
+	}
+
+	// Get the note, trashed notes are not returned
+	note, err := uc.noteRepo.GetByID(ctx, link.NoteID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if note == nil || note.IsArchived {
+		return nil, nil, domainerrors.NotFound("share link")
+	}
+
+	// Check the password
+	if link.HasPassword() {
+		if password == "" {
+			return nil, nil, domainerrors.Unauthorized("password required")
+		}
+
+		// Refuse guesses before hashing, which is what makes them expensive
+		attempt.Email = ""
+		attempt.ShareLinkID = link.ID
+		if err := uc.throttle.Check(ctx, attempt); err != nil {
+			return nil, nil, err
+		}
+
+		valid, err := uc.hashService.VerifyPassword(ctx, link.PasswordHash, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !valid {
+			if err := uc.throttle.RecordFailure(ctx, attempt, entities.LoginFailureInvalidLinkPassword); err != nil {
+				log.Printf("error recording failed share link attempt: %v", err)
+			}
+			return nil, nil, domainerrors.Unauthorized("invalid password")
+		}
+		if err := uc.throttle.RecordSuccess(ctx, attempt); err != nil {
+			log.Printf("error resetting failed share link attempts: %v", err)
+		}
+	}
+
+	// Count the view
+	link.ViewCount, err = uc.linkRepo.IncrementViewCount(ctx, link.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Checklists are shown as a task list
+	if note.Type == entities.NoteTypeChecklist {
+		items, err := uc.itemRepo.GetByNoteID(ctx, note.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		note.Content = renderChecklist(items)
+	}
+
+	return note, link, nil
+}
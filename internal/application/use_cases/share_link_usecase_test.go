@@ -0,0 +1,262 @@
+package use_cases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+// MockShareLinkRepository mocks the ShareLinkRepository interface
+type MockShareLinkRepository struct {
+	mock.Mock
+}
+
+func (m *MockShareLinkRepository) Create(ctx context.Context, link *entities.ShareLink) error {
+	args := m.Called(ctx, link)
+	return args.Error(0)
+}
+
+func (m *MockShareLinkRepository) GetByHash(ctx context.Context, tokenHash string) (*entities.ShareLink, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ShareLink), args.Error(1)
+}
+
+func (m *MockShareLinkRepository) GetByNoteID(ctx context.Context, noteID string) ([]*entities.ShareLink, error) {
+	args := m.Called(ctx, noteID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.ShareLink), args.Error(1)
+}
+
+func (m *MockShareLinkRepository) IncrementViewCount(ctx context.Context, id string) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockShareLinkRepository) Delete(ctx context.Context, noteID, id string) (bool, error) {
+	args := m.Called(ctx, noteID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockShareLinkRepository) DeleteByNoteID(ctx context.Context, noteID string) error {
+	args := m.Called(ctx, noteID)
+	return args.Error(0)
+}
+
+var testLinkAttempt = use_cases.LoginAttempt{
+	IPAddress: "192.0.2.1",
+	UserAgent: "test-agent",
+}
+
+func newTestShareLinkUseCase(mockLinkRepo *MockShareLinkRepository, mockNoteRepo *MockNoteRepository, mockTokenService *MockTokenService, mockHashService *MockHashService, mockAttemptStore *MockLoginAttemptStore) *use_cases.ShareLinkUseCase {
+	permissions := use_cases.NewNotePermissionService(mockNoteRepo, new(MockNoteShareRepository))
+	mockAuditRepo := new(MockLoginAuditRepository)
+	mockAuditRepo.On("RecordFailure", mock.Anything, mock.Anything).Return(nil)
+	throttle := use_cases.NewLoginThrottleUseCase(mockAttemptStore, mockAuditRepo, testLoginThrottleConfig)
+	return use_cases.NewShareLinkUseCase(mockLinkRepo, mockNoteRepo, new(MockChecklistItemRepository), mockTokenService, mockHashService, permissions, throttle, "https://notes.example.com/")
+}
+
+func TestCreateShareLink(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockLinkRepo := new(MockShareLinkRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+
+	userID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: userID}
+	expiresAt := time.Now().Add(24 * time.Hour)
+
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockTokenService.On("GenerateToken", ctx).Return("linktoken", nil)
+	mockTokenService.On("HashToken", ctx, "linktoken").Return("hashed_linktoken", nil)
+	mockHashService.On("HashPassword", ctx, "open sesame").Return("hashed_password", nil)
+	mockLinkRepo.On("Create", ctx, mock.MatchedBy(func(link *entities.ShareLink) bool {
+		return link.NoteID == note.ID &&
+			link.TokenHash == "hashed_linktoken" &&
+			link.PasswordHash == "hashed_password" &&
+			link.ExpiresAt.Equal(expiresAt)
+	})).Return(nil)
+
+	useCase := newTestShareLinkUseCase(mockLinkRepo, mockNoteRepo, mockTokenService, mockHashService, new(MockLoginAttemptStore))
+
+	// Act
+	link, url, err := useCase.CreateLink(ctx, note.ID, userID, "open sesame", &expiresAt)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, link.HasPassword())
+	assert.Equal(t, "https://notes.example.com/s/linktoken", url)
+	mockLinkRepo.AssertExpectations(t)
+}
+
+func TestCreateShareLink_ArchivedNote(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockLinkRepo := new(MockShareLinkRepository)
+	mockNoteRepo := new(MockNoteRepository)
+
+	userID := uuid.New().String()
+	note := &entities.Note{ID: uuid.New().String(), UserID: userID, IsArchived: true}
+
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+
+	useCase := newTestShareLinkUseCase(mockLinkRepo, mockNoteRepo, new(MockTokenService), new(MockHashService), new(MockLoginAttemptStore))
+
+	// Act
+	link, _, err := useCase.CreateLink(ctx, note.ID, userID, "", nil)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrValidation)
+	assert.Nil(t, link)
+	mockLinkRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestOpenShareLink(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockLinkRepo := new(MockShareLinkRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockTokenService := new(MockTokenService)
+
+	note := &entities.Note{ID: uuid.New().String(), UserID: uuid.New().String(), Title: "Recipe"}
+	link := &entities.ShareLink{ID: uuid.New().String(), NoteID: note.ID, ViewCount: 2}
+
+	mockTokenService.On("HashToken", ctx, "linktoken").Return("hashed_linktoken", nil)
+	mockLinkRepo.On("GetByHash", ctx, "hashed_linktoken").Return(link, nil)
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockLinkRepo.On("IncrementViewCount", ctx, link.ID).Return(int64(3), nil)
+
+	useCase := newTestShareLinkUseCase(mockLinkRepo, mockNoteRepo, mockTokenService, new(MockHashService), new(MockLoginAttemptStore))
+
+	// Act
+	openedNote, openedLink, err := useCase.OpenLink(ctx, "linktoken", "", testLinkAttempt)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Recipe", openedNote.Title)
+	assert.Equal(t, int64(3), openedLink.ViewCount)
+	mockLinkRepo.AssertExpectations(t)
+}
+
+func TestOpenShareLink_Expired(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockLinkRepo := new(MockShareLinkRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockTokenService := new(MockTokenService)
+
+	expiredAt := time.Now().Add(-time.Minute)
+	link := &entities.ShareLink{ID: uuid.New().String(), NoteID: uuid.New().String(), ExpiresAt: &expiredAt}
+
+	mockTokenService.On("HashToken", ctx, "linktoken").Return("hashed_linktoken", nil)
+	mockLinkRepo.On("GetByHash", ctx, "hashed_linktoken").Return(link, nil)
+
+	useCase := newTestShareLinkUseCase(mockLinkRepo, mockNoteRepo, mockTokenService, new(MockHashService), new(MockLoginAttemptStore))
+
+	// Act
+	_, _, err := useCase.OpenLink(ctx, "linktoken", "", testLinkAttempt)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrNotFound)
+	mockLinkRepo.AssertNotCalled(t, "IncrementViewCount", mock.Anything, mock.Anything)
+}
+
+func TestOpenShareLink_ArchivedNote(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockLinkRepo := new(MockShareLinkRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockTokenService := new(MockTokenService)
+
+	note := &entities.Note{ID: uuid.New().String(), UserID: uuid.New().String(), IsArchived: true}
+	link := &entities.ShareLink{ID: uuid.New().String(), NoteID: note.ID}
+
+	mockTokenService.On("HashToken", ctx, "linktoken").Return("hashed_linktoken", nil)
+	mockLinkRepo.On("GetByHash", ctx, "hashed_linktoken").Return(link, nil)
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+
+	useCase := newTestShareLinkUseCase(mockLinkRepo, mockNoteRepo, mockTokenService, new(MockHashService), new(MockLoginAttemptStore))
+
+	// Act
+	_, _, err := useCase.OpenLink(ctx, "linktoken", "", testLinkAttempt)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrNotFound)
+	mockLinkRepo.AssertNotCalled(t, "IncrementViewCount", mock.Anything, mock.Anything)
+}
+
+func TestOpenShareLink_WrongPassword(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockLinkRepo := new(MockShareLinkRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+	mockAttemptStore := new(MockLoginAttemptStore)
+
+	note := &entities.Note{ID: uuid.New().String(), UserID: uuid.New().String()}
+	link := &entities.ShareLink{ID: uuid.New().String(), NoteID: note.ID, PasswordHash: "hashed_password"}
+
+	mockTokenService.On("HashToken", ctx, "linktoken").Return("hashed_linktoken", nil)
+	mockLinkRepo.On("GetByHash", ctx, "hashed_linktoken").Return(link, nil)
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockHashService.On("VerifyPassword", ctx, "hashed_password", "guess").Return(false, nil)
+	mockAttemptStore.On("Get", ctx, "share-link:"+link.ID).Return(nil, nil)
+	mockAttemptStore.On("Get", ctx, "ip:192.0.2.1").Return(nil, nil)
+	mockAttemptStore.On("RecordFailure", ctx, "share-link:"+link.ID, mock.Anything, mock.Anything).Return(&services.LoginAttempts{Failures: 1}, nil)
+	mockAttemptStore.On("RecordFailure", ctx, "ip:192.0.2.1", mock.Anything, mock.Anything).Return(&services.LoginAttempts{Failures: 1}, nil)
+
+	useCase := newTestShareLinkUseCase(mockLinkRepo, mockNoteRepo, mockTokenService, mockHashService, mockAttemptStore)
+
+	// Act
+	_, _, err := useCase.OpenLink(ctx, "linktoken", "guess", testLinkAttempt)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrUnauthorized)
+	mockAttemptStore.AssertExpectations(t)
+	mockLinkRepo.AssertNotCalled(t, "IncrementViewCount", mock.Anything, mock.Anything)
+}
+
+func TestOpenShareLink_Throttled(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockLinkRepo := new(MockShareLinkRepository)
+	mockNoteRepo := new(MockNoteRepository)
+	mockTokenService := new(MockTokenService)
+	mockHashService := new(MockHashService)
+	mockAttemptStore := new(MockLoginAttemptStore)
+
+	note := &entities.Note{ID: uuid.New().String(), UserID: uuid.New().String()}
+	link := &entities.ShareLink{ID: uuid.New().String(), NoteID: note.ID, PasswordHash: "hashed_password"}
+
+	mockTokenService.On("HashToken", ctx, "linktoken").Return("hashed_linktoken", nil)
+	mockLinkRepo.On("GetByHash", ctx, "hashed_linktoken").Return(link, nil)
+	mockNoteRepo.On("GetByID", ctx, note.ID).Return(note, nil)
+	mockAttemptStore.On("Get", ctx, "share-link:"+link.ID).Return(&services.LoginAttempts{Failures: 10, LastFailureAt: time.Now()}, nil)
+	mockAttemptStore.On("Get", ctx, "ip:192.0.2.1").Return(nil, nil)
+
+	useCase := newTestShareLinkUseCase(mockLinkRepo, mockNoteRepo, mockTokenService, mockHashService, mockAttemptStore)
+
+	// Act
+	_, _, err := useCase.OpenLink(ctx, "linktoken", "guess", testLinkAttempt)
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrRateLimited)
+	mockHashService.AssertNotCalled(t, "VerifyPassword", mock.Anything, mock.Anything, mock.Anything)
+	mockLinkRepo.AssertNotCalled(t, "IncrementViewCount", mock.Anything, mock.Anything)
+}
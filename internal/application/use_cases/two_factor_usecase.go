@@ -0,0 +1,318 @@
+package use_cases
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+const (
+	// recoveryCodeCount is the number of recovery codes issued at once
+	recoveryCodeCount = 10
+	// recoveryCodeLength is the number of random base32 characters (50 bits)
+	// of a recovery code, shorter than a token so that it is easy to type
+	recoveryCodeLength = 10
+)
+
+var (
+	errInvalidTwoFactorCode = domainerrors.InvalidField("code", "invalid two-factor code")
+	errTwoFactorEnabled     = domainerrors.Conflict("two-factor authentication", "two-factor authentication is already enabled")
+	errTwoFactorNotEnabled  = domainerrors.Conflict("two-factor authentication", "two-factor authentication is not enabled")
+)
+
+// TOTPEnrollment is what the user needs to register their authenticator app
+type TOTPEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+type TwoFactorStatus struct {
+	Enabled                bool
+	RecoveryCodesRemaining int
+}
+
+type TwoFactorUseCase struct {
+	userRepo      repositories.UserRepository
+	twoFactorRepo repositories.TwoFactorRepository
+	totpService   services.TOTPService
+	tokenService  services.TokenService
+	txManager     repositories.TxManager
+}
+
+func NewTwoFactorUseCase(
+	userRepo repositories.UserRepository,
+	twoFactorRepo repositories.TwoFactorRepository,
+	totpService services.TOTPService,
+	tokenService services.TokenService,
+	txManager repositories.TxManager,
+) *TwoFactorUseCase {
+	return &TwoFactorUseCase{
+		userRepo:      userRepo,
+		twoFactorRepo: twoFactorRepo,
+		totpService:   totpService,
+		tokenService:  tokenService,
+		txManager:     txManager,
+	}
+}
+
+// IsEnabled reports whether the user must submit a second factor to sign in
+func (uc *TwoFactorUseCase) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	credential, err := uc.twoFactorRepo.GetTOTPCredential(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return credential != nil && credential.Enabled(), nil
+}
+
+func (uc *TwoFactorUseCase) GetStatus(ctx context.Context, userID string) (*TwoFactorStatus, error) {
+	enabled, err := uc.IsEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return &TwoFactorStatus{}, nil
+	}
+
+	remaining, err := uc.twoFactorRepo.CountRemainingRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorStatus{Enabled: true, RecoveryCodesRemaining: remaining}, nil
+}
+
+// BeginEnrollment generates a new secret for the user's authenticator app.
+// Two-factor authentication is only enabled once ConfirmEnrollment receives a
+// code generated from it; starting over replaces the pending secret.
+func (uc *TwoFactorUseCase) BeginEnrollment(ctx context.Context, userID string) (*TOTPEnrollment, error) {
+	// Get the user
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, domainerrors.NotFound("user")
+	}
+
+	// An enabled credential must be disabled first
+	enabled, err := uc.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, errTwoFactorEnabled
+	}
+
+	// Generate and save the secret
+	secret, err := uc.totpService.GenerateSecret(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.twoFactorRepo.SaveTOTPCredential(ctx, &entities.TOTPCredential{
+		UserID:    user.ID,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: uc.totpService.ProvisioningURI(secret, user.Email),
+	}, nil
+}
+
+// ConfirmEnrollment enables two-factor authentication once the user proves
+// their authenticator app works. It returns the recovery codes, which are
+// only ever shown this once.
+func (uc *TwoFactorUseCase) ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	// Get the pending credential
+	credential, err := uc.twoFactorRepo.GetTOTPCredential(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		return nil, domainerrors.NotFound("two-factor enrollment")
+	}
+	if credential.Enabled() {
+		return nil, errTwoFactorEnabled
+	}
+
+	// Check the code, recovery codes do not exist yet
+	valid, err := uc.checkTOTPCode(ctx, credential, code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, errInvalidTwoFactorCode
+	}
+
+	// Enable the credential along with a first set of recovery codes
+	var recoveryCodes []string
+	err = uc.txManager.WithinTx(ctx, func(ctx context.Context, repos repositories.TxRepositories) error {
+		confirmed, err := repos.TwoFactor.ConfirmTOTPCredential(ctx, userID, time.Now())
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return errTwoFactorEnabled
+		}
+
+		recoveryCodes, err = uc.replaceRecoveryCodes(ctx, repos.TwoFactor, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// RegenerateRecoveryCodes replaces all the recovery codes of the user. A code
+// from the authenticator app is required, a lost recovery code can't be used
+// to issue new ones.
+func (uc *TwoFactorUseCase) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	// Get the credential
+	credential, err := uc.twoFactorRepo.GetTOTPCredential(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil || !credential.Enabled() {
+		return nil, errTwoFactorNotEnabled
+	}
+
+	// Check the code
+	valid, err := uc.checkTOTPCode(ctx, credential, code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, errInvalidTwoFactorCode
+	}
+
+	var recoveryCodes []string
+	err = uc.txManager.WithinTx(ctx, func(ctx context.Context, repos repositories.TxRepositories) error {
+		recoveryCodes, err = uc.replaceRecoveryCodes(ctx, repos.TwoFactor, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// Disable turns two-factor authentication off. It requires a code from the
+// authenticator app or a recovery code.
+func (uc *TwoFactorUseCase) Disable(ctx context.Context, userID, code string) error {
+	if err := uc.VerifyCode(ctx, userID, code); err != nil {
+		return err
+	}
+
+	return uc.txManager.WithinTx(ctx, func(ctx context.Context, repos repositories.TxRepositories) error {
+		if err := repos.TwoFactor.DeleteRecoveryCodes(ctx, userID); err != nil {
+			return err
+		}
+		return repos.TwoFactor.DeleteTOTPCredential(ctx, userID)
+	})
+}
+
+// VerifyCode checks a code from the authenticator app or a recovery code, as
+// the second step of signing in. Each code is only accepted once.
+func (uc *TwoFactorUseCase) VerifyCode(ctx context.Context, userID, code string) error {
+	// Get the credential
+	credential, err := uc.twoFactorRepo.GetTOTPCredential(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if credential == nil || !credential.Enabled() {
+		return errTwoFactorNotEnabled
+	}
+
+	// Recovery codes are longer than the codes of the authenticator app
+	code = strings.ReplaceAll(normalizeCode(code), "-", "")
+	var valid bool
+	if len(code) == recoveryCodeLength {
+		valid, err = uc.useRecoveryCode(ctx, userID, code)
+	} else {
+		valid, err = uc.checkTOTPCode(ctx, credential, code)
+	}
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// checkTOTPCode validates a code from the authenticator app and records its
+// time step so that it cannot be replayed
+func (uc *TwoFactorUseCase) checkTOTPCode(ctx context.Context, credential *entities.TOTPCredential, code string) (bool, error) {
+	step, ok := uc.totpService.ValidateCode(credential.Secret, normalizeCode(code), time.Now())
+	if !ok || step <= credential.LastUsedStep {
+		return false, nil
+	}
+
+	// Fails when a concurrent request used the same code first
+	return uc.twoFactorRepo.UseTOTPStep(ctx, credential.UserID, step)
+}
+
+func (uc *TwoFactorUseCase) useRecoveryCode(ctx context.Context, userID, code string) (bool, error) {
+	codeID, err := uc.tokenService.HashToken(ctx, code)
+	if err != nil {
+		return false, err
+	}
+
+	return uc.twoFactorRepo.UseRecoveryCode(ctx, userID, codeID)
+}
+
+// replaceRecoveryCodes deletes the user's recovery codes and issues new ones.
+// Only their hashes are stored.
+func (uc *TwoFactorUseCase) replaceRecoveryCodes(ctx context.Context, twoFactorRepo repositories.TwoFactorRepository, userID string) ([]string, error) {
+	if err := twoFactorRepo.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	codes := make([]string, recoveryCodeCount)
+	recoveryCodes := make([]*entities.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		token, err := uc.tokenService.GenerateToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		code := token[:recoveryCodeLength]
+
+		codeID, err := uc.tokenService.HashToken(ctx, code)
+		if err != nil {
+			return nil, err
+		}
+
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		recoveryCodes[i] = &entities.RecoveryCode{
+			ID:        codeID,
+			UserID:    userID,
+			CreatedAt: now,
+		}
+	}
+
+	if err := twoFactorRepo.CreateRecoveryCodes(ctx, recoveryCodes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// normalizeCode strips the spaces users tend to type and lowercases recovery
+// codes
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+}
@@ -0,0 +1,311 @@
+package use_cases_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+// MockTwoFactorRepository mocks the TwoFactorRepository interface
+type MockTwoFactorRepository struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorRepository) SaveTOTPCredential(ctx context.Context, credential *entities.TOTPCredential) error {
+	args := m.Called(ctx, credential)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) GetTOTPCredential(ctx context.Context, userID string) (*entities.TOTPCredential, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.TOTPCredential), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) ConfirmTOTPCredential(ctx context.Context, userID string, confirmedAt time.Time) (bool, error) {
+	args := m.Called(ctx, userID, confirmedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) DeleteTOTPCredential(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) CreateRecoveryCodes(ctx context.Context, codes []*entities.RecoveryCode) error {
+	args := m.Called(ctx, codes)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID, id string) (bool, error) {
+	args := m.Called(ctx, userID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) CountRemainingRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) DeleteRecoveryCodes(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockTOTPService mocks the TOTPService interface
+type MockTOTPService struct {
+	mock.Mock
+}
+
+func (m *MockTOTPService) GenerateSecret(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTOTPService) ProvisioningURI(secret, accountName string) string {
+	args := m.Called(secret, accountName)
+	return args.String(0)
+}
+
+func (m *MockTOTPService) ValidateCode(secret, code string, at time.Time) (int64, bool) {
+	args := m.Called(secret, code, at)
+	return args.Get(0).(int64), args.Bool(1)
+}
+
+func TestBeginEnrollment(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockTwoFactorRepo := new(MockTwoFactorRepository)
+	mockTOTPService := new(MockTOTPService)
+	mockTokenService := new(MockTokenService)
+	txManager := &FakeTxManager{repos: repositories.TxRepositories{TwoFactor: mockTwoFactorRepo}}
+
+	user := &entities.User{ID: uuid.New().String(), Email: "test@example.com"}
+
+	mockUserRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockTwoFactorRepo.On("GetTOTPCredential", ctx, user.ID).Return(nil, nil)
+	mockTOTPService.On("GenerateSecret", ctx).Return("JBSWY3DPEHPK3PXP", nil)
+	mockTwoFactorRepo.On("SaveTOTPCredential", ctx, mock.MatchedBy(func(c *entities.TOTPCredential) bool {
+		return c.UserID == user.ID && c.Secret == "JBSWY3DPEHPK3PXP" && !c.Enabled()
+	})).Return(nil)
+	mockTOTPService.On("ProvisioningURI", "JBSWY3DPEHPK3PXP", "test@example.com").Return("otpauth://totp/test")
+
+	useCase := use_cases.NewTwoFactorUseCase(mockUserRepo, mockTwoFactorRepo, mockTOTPService, mockTokenService, txManager)
+
+	// Act
+	enrollment, err := useCase.BeginEnrollment(ctx, user.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", enrollment.Secret)
+	assert.Equal(t, "otpauth://totp/test", enrollment.ProvisioningURI)
+
+	mockTwoFactorRepo.AssertExpectations(t)
+	mockTOTPService.AssertExpectations(t)
+}
+
+func TestBeginEnrollment_AlreadyEnabled(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockTwoFactorRepo := new(MockTwoFactorRepository)
+	mockTOTPService := new(MockTOTPService)
+	mockTokenService := new(MockTokenService)
+	txManager := &FakeTxManager{repos: repositories.TxRepositories{TwoFactor: mockTwoFactorRepo}}
+
+	user := &entities.User{ID: uuid.New().String(), Email: "test@example.com"}
+	confirmedAt := time.Now()
+
+	mockUserRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockTwoFactorRepo.On("GetTOTPCredential", ctx, user.ID).Return(&entities.TOTPCredential{
+		UserID:      user.ID,
+		Secret:      "JBSWY3DPEHPK3PXP",
+		ConfirmedAt: &confirmedAt,
+	}, nil)
+
+	useCase := use_cases.NewTwoFactorUseCase(mockUserRepo, mockTwoFactorRepo, mockTOTPService, mockTokenService, txManager)
+
+	// Act
+	enrollment, err := useCase.BeginEnrollment(ctx, user.ID)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, enrollment)
+	assert.ErrorIs(t, err, domainerrors.ErrConflict)
+	mockTwoFactorRepo.AssertNotCalled(t, "SaveTOTPCredential")
+}
+
+func TestConfirmEnrollment(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockTwoFactorRepo := new(MockTwoFactorRepository)
+	mockTOTPService := new(MockTOTPService)
+	mockTokenService := new(MockTokenService)
+	txManager := &FakeTxManager{repos: repositories.TxRepositories{TwoFactor: mockTwoFactorRepo}}
+
+	userID := uuid.New().String()
+	credential := &entities.TOTPCredential{UserID: userID, Secret: "JBSWY3DPEHPK3PXP"}
+
+	mockTwoFactorRepo.On("GetTOTPCredential", ctx, userID).Return(credential, nil)
+	mockTOTPService.On("ValidateCode", "JBSWY3DPEHPK3PXP", "123456", mock.AnythingOfType("time.Time")).Return(int64(100), true)
+	mockTwoFactorRepo.On("UseTOTPStep", ctx, userID, int64(100)).Return(true, nil)
+	mockTwoFactorRepo.On("ConfirmTOTPCredential", ctx, userID, mock.AnythingOfType("time.Time")).Return(true, nil)
+	mockTwoFactorRepo.On("DeleteRecoveryCodes", ctx, userID).Return(nil)
+	mockTokenService.On("GenerateToken", ctx).Return("abcdefghijklmnopqrstuvwxyz234567", nil)
+	mockTokenService.On("HashToken", ctx, "abcdefghij").Return("hashed-code", nil)
+	mockTwoFactorRepo.On("CreateRecoveryCodes", ctx, mock.MatchedBy(func(codes []*entities.RecoveryCode) bool {
+		return len(codes) == 10 && codes[0].ID == "hashed-code" && codes[0].UserID == userID
+	})).Return(nil)
+
+	useCase := use_cases.NewTwoFactorUseCase(mockUserRepo, mockTwoFactorRepo, mockTOTPService, mockTokenService, txManager)
+
+	// Act
+	recoveryCodes, err := useCase.ConfirmEnrollment(ctx, userID, " 123 456 ")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, recoveryCodes, 10)
+	assert.Equal(t, "abcde-fghij", recoveryCodes[0])
+	assert.Equal(t, 1, txManager.Commits)
+
+	mockTwoFactorRepo.AssertExpectations(t)
+	mockTOTPService.AssertExpectations(t)
+}
+
+func TestConfirmEnrollment_InvalidCode(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockTwoFactorRepo := new(MockTwoFactorRepository)
+	mockTOTPService := new(MockTOTPService)
+	mockTokenService := new(MockTokenService)
+	txManager := &FakeTxManager{repos: repositories.TxRepositories{TwoFactor: mockTwoFactorRepo}}
+
+	userID := uuid.New().String()
+	credential := &entities.TOTPCredential{UserID: userID, Secret: "JBSWY3DPEHPK3PXP"}
+
+	mockTwoFactorRepo.On("GetTOTPCredential", ctx, userID).Return(credential, nil)
+	mockTOTPService.On("ValidateCode", "JBSWY3DPEHPK3PXP", "000000", mock.AnythingOfType("time.Time")).Return(int64(0), false)
+
+	useCase := use_cases.NewTwoFactorUseCase(mockUserRepo, mockTwoFactorRepo, mockTOTPService, mockTokenService, txManager)
+
+	// Act
+	recoveryCodes, err := useCase.ConfirmEnrollment(ctx, userID, "000000")
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, recoveryCodes)
+	assert.ErrorIs(t, err, domainerrors.ErrValidation)
+	assert.Equal(t, 0, txManager.Commits)
+	mockTwoFactorRepo.AssertNotCalled(t, "ConfirmTOTPCredential")
+}
+
+func TestVerifyCode_ReplayedCode(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockTwoFactorRepo := new(MockTwoFactorRepository)
+	mockTOTPService := new(MockTOTPService)
+	mockTokenService := new(MockTokenService)
+	txManager := &FakeTxManager{repos: repositories.TxRepositories{TwoFactor: mockTwoFactorRepo}}
+
+	userID := uuid.New().String()
+	confirmedAt := time.Now()
+	credential := &entities.TOTPCredential{
+		UserID:       userID,
+		Secret:       "JBSWY3DPEHPK3PXP",
+		ConfirmedAt:  &confirmedAt,
+		LastUsedStep: 100,
+	}
+
+	// The code belongs to the step that was already used
+	mockTwoFactorRepo.On("GetTOTPCredential", ctx, userID).Return(credential, nil)
+	mockTOTPService.On("ValidateCode", "JBSWY3DPEHPK3PXP", "123456", mock.AnythingOfType("time.Time")).Return(int64(100), true)
+
+	useCase := use_cases.NewTwoFactorUseCase(mockUserRepo, mockTwoFactorRepo, mockTOTPService, mockTokenService, txManager)
+
+	// Act
+	err := useCase.VerifyCode(ctx, userID, "123456")
+
+	// Assert
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid two-factor code")
+	mockTwoFactorRepo.AssertNotCalled(t, "UseTOTPStep")
+}
+
+func TestVerifyCode_RecoveryCode(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockTwoFactorRepo := new(MockTwoFactorRepository)
+	mockTOTPService := new(MockTOTPService)
+	mockTokenService := new(MockTokenService)
+	txManager := &FakeTxManager{repos: repositories.TxRepositories{TwoFactor: mockTwoFactorRepo}}
+
+	userID := uuid.New().String()
+	confirmedAt := time.Now()
+	credential := &entities.TOTPCredential{UserID: userID, Secret: "JBSWY3DPEHPK3PXP", ConfirmedAt: &confirmedAt}
+
+	mockTwoFactorRepo.On("GetTOTPCredential", ctx, userID).Return(credential, nil)
+	mockTokenService.On("HashToken", ctx, "abcdefghij").Return("hashed-code", nil)
+	mockTwoFactorRepo.On("UseRecoveryCode", ctx, userID, "hashed-code").Return(true, nil)
+
+	useCase := use_cases.NewTwoFactorUseCase(mockUserRepo, mockTwoFactorRepo, mockTOTPService, mockTokenService, txManager)
+
+	// Act
+	err := useCase.VerifyCode(ctx, userID, strings.ToUpper("abcde-fghij"))
+
+	// Assert
+	assert.NoError(t, err)
+	mockTwoFactorRepo.AssertExpectations(t)
+	mockTOTPService.AssertNotCalled(t, "ValidateCode")
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockTwoFactorRepo := new(MockTwoFactorRepository)
+	mockTOTPService := new(MockTOTPService)
+	mockTokenService := new(MockTokenService)
+	txManager := &FakeTxManager{repos: repositories.TxRepositories{TwoFactor: mockTwoFactorRepo}}
+
+	userID := uuid.New().String()
+	confirmedAt := time.Now()
+	credential := &entities.TOTPCredential{UserID: userID, Secret: "JBSWY3DPEHPK3PXP", ConfirmedAt: &confirmedAt}
+
+	mockTwoFactorRepo.On("GetTOTPCredential", ctx, userID).Return(credential, nil)
+	mockTOTPService.On("ValidateCode", "JBSWY3DPEHPK3PXP", "123456", mock.AnythingOfType("time.Time")).Return(int64(101), true)
+	mockTwoFactorRepo.On("UseTOTPStep", ctx, userID, int64(101)).Return(true, nil)
+	mockTwoFactorRepo.On("DeleteRecoveryCodes", ctx, userID).Return(nil)
+	mockTwoFactorRepo.On("DeleteTOTPCredential", ctx, userID).Return(nil)
+
+	useCase := use_cases.NewTwoFactorUseCase(mockUserRepo, mockTwoFactorRepo, mockTOTPService, mockTokenService, txManager)
+
+	// Act
+	err := useCase.Disable(ctx, userID, "123456")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, txManager.Commits)
+	mockTwoFactorRepo.AssertExpectations(t)
+}
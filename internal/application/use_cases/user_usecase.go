@@ -2,13 +2,13 @@ package use_cases
 
 import (
 	"context"
-	"errors"
 	"log"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
 	"github.com/LaulauChau/note-nest/internal/domain/entities"
 	"github.com/LaulauChau/note-nest/internal/domain/repositories"
 )
@@ -37,7 +37,7 @@ func (uc *UserUseCase) RegisterUser(ctx context.Context, email, name, password s
 	}
 	if existingUser != nil {
 		log.Printf("email already taken")
-		return nil, errors.New("email already taken")
+		return nil, domainerrors.Conflict("user", "email already taken")
 	}
 
 	// Hash the password
@@ -76,7 +76,7 @@ func (uc *UserUseCase) AuthenticateUser(ctx context.Context, email, password str
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("invalid credentials")
+		return nil, domainerrors.Unauthorized("invalid credentials")
 	}
 
 	// Verify the password
@@ -85,10 +85,70 @@ func (uc *UserUseCase) AuthenticateUser(ctx context.Context, email, password str
 		return nil, err
 	}
 	if !valid {
-		return nil, errors.New("invalid credentials")
+		return nil, domainerrors.Unauthorized("invalid credentials")
+	}
+
+	// Accounts waiting to be deleted must be recovered before signing in
+	if user.DeletionRequestedAt != nil {
+		return nil, domainerrors.Forbidden("account scheduled for deletion")
 	}
 
 	// Don't return the password hash
 	user.Password = ""
 	return user, nil
 }
+
+func (uc *UserUseCase) UpdateProfile(ctx context.Context, userID, name string) (*entities.User, error) {
+	// Get the user
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, domainerrors.NotFound("user")
+	}
+
+	// Update the profile fields
+	user.Name = name
+	user.UpdatedAt = time.Now()
+
+	// Save the user
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// Don't return the password hash
+	user.Password = ""
+	return user, nil
+}
+
+// ChangePassword sets a new password after checking the current one
+func (uc *UserUseCase) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) error {
+	// Get the user
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return domainerrors.NotFound("user")
+	}
+
+	// Verify the current password
+	valid, err := uc.hashService.VerifyPassword(ctx, user.Password, currentPassword)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return incorrectPassword("current password is incorrect", "current_password")
+	}
+
+	// Hash and save the new password
+	hashedPassword, err := uc.hashService.HashPassword(ctx, newPassword)
+	if err != nil {
+		return err
+	}
+	user.Password = hashedPassword
+	user.UpdatedAt = time.Now()
+
+	return uc.userRepo.Update(ctx, user)
+}
@@ -3,12 +3,14 @@ package use_cases_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
 	"github.com/LaulauChau/note-nest/internal/domain/entities"
 )
 
@@ -94,6 +96,7 @@ func TestRegisterUser_EmailAlreadyExists(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, user)
 	assert.Equal(t, "email already taken", err.Error())
+	assert.ErrorIs(t, err, domainerrors.ErrConflict)
 
 	mockUserRepo.AssertExpectations(t)
 	// HashPassword should not be called if email already exists
@@ -171,6 +174,7 @@ func TestAuthenticateUser_InvalidCredentials(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, authenticatedUser)
 	assert.Contains(t, err.Error(), "invalid credentials")
+	assert.ErrorIs(t, err, domainerrors.ErrUnauthorized)
 
 	mockUserRepo.AssertExpectations(t)
 	mockHashService.AssertExpectations(t)
@@ -197,8 +201,121 @@ func TestAuthenticateUser_UserNotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, authenticatedUser)
 	assert.Contains(t, err.Error(), "invalid credentials")
+	assert.ErrorIs(t, err, domainerrors.ErrUnauthorized)
 
 	mockUserRepo.AssertExpectations(t)
 	// VerifyPassword should not be called if user doesn't exist
 	mockHashService.AssertNotCalled(t, "VerifyPassword")
 }
+
+func TestAuthenticateUser_ScheduledForDeletion(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockHashService := new(MockHashService)
+
+	email := "test@example.com"
+	password := "V@lidP@ssword123"
+	hashedPassword := "hashed_password_value"
+	requestedAt := time.Now().Add(-time.Hour)
+
+	user := &entities.User{
+		ID:                  uuid.New().String(),
+		Email:               email,
+		Name:                "Test User",
+		Password:            hashedPassword,
+		DeletionRequestedAt: &requestedAt,
+	}
+
+	mockUserRepo.On("GetByEmail", ctx, email).Return(user, nil)
+	mockHashService.On("VerifyPassword", ctx, hashedPassword, password).Return(true, nil)
+
+	useCase := use_cases.NewUserUseCase(mockUserRepo, mockHashService)
+
+	// Act
+	authenticatedUser, err := useCase.AuthenticateUser(ctx, email, password)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, authenticatedUser)
+	assert.ErrorIs(t, err, domainerrors.ErrForbidden)
+}
+
+func TestUpdateProfile(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockHashService := new(MockHashService)
+
+	user := &entities.User{ID: uuid.New().String(), Email: "test@example.com", Name: "Old Name", Password: "hashed_password_value"}
+
+	mockUserRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockUserRepo.On("Update", ctx, mock.MatchedBy(func(u *entities.User) bool {
+		return u.Name == "New Name" && u.Email == "test@example.com" && u.Password == "hashed_password_value"
+	})).Return(nil)
+
+	useCase := use_cases.NewUserUseCase(mockUserRepo, mockHashService)
+
+	// Act
+	updatedUser, err := useCase.UpdateProfile(ctx, user.ID, "New Name")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "New Name", updatedUser.Name)
+	assert.Empty(t, updatedUser.Password)
+
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestChangePassword(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockHashService := new(MockHashService)
+
+	user := &entities.User{ID: uuid.New().String(), Email: "test@example.com", Password: "old_hash"}
+
+	mockUserRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockHashService.On("VerifyPassword", ctx, "old_hash", "0ld!P@ssw0rd123").Return(true, nil)
+	mockHashService.On("HashPassword", ctx, "N3w!P@ssw0rd123").Return("new_hash", nil)
+	mockUserRepo.On("Update", ctx, mock.MatchedBy(func(u *entities.User) bool {
+		return u.Password == "new_hash"
+	})).Return(nil)
+
+	useCase := use_cases.NewUserUseCase(mockUserRepo, mockHashService)
+
+	// Act
+	err := useCase.ChangePassword(ctx, user.ID, "0ld!P@ssw0rd123", "N3w!P@ssw0rd123")
+
+	// Assert
+	assert.NoError(t, err)
+	mockUserRepo.AssertExpectations(t)
+	mockHashService.AssertExpectations(t)
+}
+
+func TestChangePassword_WrongCurrentPassword(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepository)
+	mockHashService := new(MockHashService)
+
+	user := &entities.User{ID: uuid.New().String(), Email: "test@example.com", Password: "old_hash"}
+
+	mockUserRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockHashService.On("VerifyPassword", ctx, "old_hash", "Wr0ng!P@ssw0rd").Return(false, nil)
+
+	useCase := use_cases.NewUserUseCase(mockUserRepo, mockHashService)
+
+	// Act
+	err := useCase.ChangePassword(ctx, user.ID, "Wr0ng!P@ssw0rd", "N3w!P@ssw0rd123")
+
+	// Assert
+	assert.ErrorIs(t, err, domainerrors.ErrValidation)
+	assert.ErrorIs(t, err, use_cases.ErrIncorrectPassword)
+
+	var validation *domainerrors.ValidationError
+	assert.ErrorAs(t, err, &validation)
+	assert.Equal(t, "current_password", validation.Fields[0].Field)
+	mockHashService.AssertNotCalled(t, "HashPassword")
+	mockUserRepo.AssertNotCalled(t, "Update")
+}
@@ -5,17 +5,107 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
 	DATABASE struct {
-		URL string
+		URL                    string
+		MaxConns               int
+		MinConns               int
+		MaxConnLifetime        time.Duration
+		MaxConnIdleTime        time.Duration
+		HealthCheckPeriod      time.Duration
+		StatementCacheCapacity int
 	}
 
 	Server struct {
-		Port int
+		Port         int
+		InternalPort int // Serves the detailed health report, 0 disables it
+	}
+
+	App struct {
+		BaseURL string // Used to build the links sent by email
+	}
+
+	Mail struct {
+		Driver       string // smtp, file or log
+		From         string
+		FileDir      string
+		SMTPHost     string
+		SMTPPort     int
+		SMTPUsername string
+		SMTPPassword string
+	}
+
+	PasswordReset struct {
+		TokenTTL       time.Duration
+		ResendInterval time.Duration
+		IPLimit        int // 0 disables it
+		IPWindow       time.Duration
+	}
+
+	EmailVerification struct {
+		Policy         string        // optional, restrict or block_login
+		TokenTTL       time.Duration // Also used for email change confirmations
+		ResendInterval time.Duration
+	}
+
+	TwoFactor struct {
+		Issuer string // Shown next to the account in authenticator apps
+	}
+
+	Sessions struct {
+		TTL              time.Duration
+		RenewalThreshold time.Duration // Must be shorter than TTL
+		IdleTimeout      time.Duration // 0 disables it
+		MaxAge           time.Duration // 0 disables it
+		PartialTTL       time.Duration // Time left to submit the second factor
+		CleanupInterval  time.Duration
+		CleanupBatchSize int
+	}
+
+	LoginThrottle struct {
+		EmailFreeAttempts     int
+		EmailLockoutThreshold int // 0 never locks an account out
+		IPFreeAttempts        int
+		IPLockoutThreshold    int // 0 never locks an address out
+		BaseDelay             time.Duration
+		MaxDelay              time.Duration
+		LockoutDuration       time.Duration
+		ResetAfter            time.Duration
+	}
+
+	Migrations struct {
+		RequireCurrent bool
+	}
+
+	Accounts struct {
+		DeletionGraceDays int // 0 deletes accounts immediately
+		PurgeInterval     time.Duration
+	}
+
+	Trash struct {
+		RetentionDays int
+		PurgeInterval time.Duration
+	}
+
+	Storage struct {
+		Driver      string // local or s3
+		LocalDir    string
+		S3Endpoint  string
+		S3Region    string
+		S3Bucket    string
+		S3AccessKey string
+		S3SecretKey string
+	}
+
+	Attachments struct {
+		MaxSize         int64 // In bytes
+		UserQuota       int64 // In bytes, 0 disables it
+		TransferTimeout time.Duration
 	}
 }
 
@@ -32,11 +122,257 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("error parsing DATABASE_URL: %w", err)
 	}
 
+	config.DATABASE.MaxConns, err = parseIntWithDefault("DATABASE_MAX_CONNS", 10, 1)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing DATABASE_MAX_CONNS: %w", err)
+	}
+
+	config.DATABASE.MinConns, err = parseIntWithDefault("DATABASE_MIN_CONNS", 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing DATABASE_MIN_CONNS: %w", err)
+	}
+	if config.DATABASE.MinConns > config.DATABASE.MaxConns {
+		return nil, fmt.Errorf("DATABASE_MIN_CONNS must not exceed DATABASE_MAX_CONNS")
+	}
+
+	config.DATABASE.MaxConnLifetime, err = parseDurationWithDefault("DATABASE_MAX_CONN_LIFETIME", time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing DATABASE_MAX_CONN_LIFETIME: %w", err)
+	}
+
+	config.DATABASE.MaxConnIdleTime, err = parseDurationWithDefault("DATABASE_MAX_CONN_IDLE_TIME", 30*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing DATABASE_MAX_CONN_IDLE_TIME: %w", err)
+	}
+
+	config.DATABASE.HealthCheckPeriod, err = parseDurationWithDefault("DATABASE_HEALTH_CHECK_PERIOD", time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing DATABASE_HEALTH_CHECK_PERIOD: %w", err)
+	}
+
+	config.DATABASE.StatementCacheCapacity, err = parseIntWithDefault("DATABASE_STATEMENT_CACHE_CAPACITY", 512, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing DATABASE_STATEMENT_CACHE_CAPACITY: %w", err)
+	}
+
 	config.Server.Port, err = parseInt("SERVER_PORT")
 	if err != nil {
 		return nil, fmt.Errorf("error parsing SERVER_PORT: %w", err)
 	}
 
+	config.Server.InternalPort, err = parseIntWithDefault("SERVER_INTERNAL_PORT", 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SERVER_INTERNAL_PORT: %w", err)
+	}
+	if config.Server.InternalPort != 0 && config.Server.InternalPort == config.Server.Port {
+		return nil, fmt.Errorf("SERVER_INTERNAL_PORT must differ from SERVER_PORT")
+	}
+
+	config.App.BaseURL, err = parseURLWithDefault("APP_BASE_URL", "http://localhost:8080")
+	if err != nil {
+		return nil, fmt.Errorf("error parsing APP_BASE_URL: %w", err)
+	}
+
+	config.Mail.Driver = getEnvWithDefault("MAIL_DRIVER", "log")
+	config.Mail.From = getEnvWithDefault("MAIL_FROM", "Note Nest <no-reply@localhost>")
+	switch config.Mail.Driver {
+	case "log":
+	case "file":
+		config.Mail.FileDir = getEnvWithDefault("MAIL_FILE_DIR", "tmp/mail")
+	case "smtp":
+		config.Mail.SMTPHost = os.Getenv("SMTP_HOST")
+		if config.Mail.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST is not set")
+		}
+		config.Mail.SMTPPort, err = parseIntWithDefault("SMTP_PORT", 587, 1)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing SMTP_PORT: %w", err)
+		}
+		config.Mail.SMTPUsername = os.Getenv("SMTP_USERNAME")
+		config.Mail.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	default:
+		return nil, fmt.Errorf("invalid MAIL_DRIVER: %q", config.Mail.Driver)
+	}
+
+	config.PasswordReset.TokenTTL, err = parseDurationWithDefault("PASSWORD_RESET_TOKEN_TTL", time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing PASSWORD_RESET_TOKEN_TTL: %w", err)
+	}
+
+	config.PasswordReset.ResendInterval, err = parseDurationWithDefault("PASSWORD_RESET_RESEND_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing PASSWORD_RESET_RESEND_INTERVAL: %w", err)
+	}
+
+	config.PasswordReset.IPLimit, err = parseIntWithDefault("PASSWORD_RESET_IP_LIMIT", 10, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing PASSWORD_RESET_IP_LIMIT: %w", err)
+	}
+
+	config.PasswordReset.IPWindow, err = parseDurationWithDefault("PASSWORD_RESET_IP_WINDOW", time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing PASSWORD_RESET_IP_WINDOW: %w", err)
+	}
+
+	config.EmailVerification.Policy = getEnvWithDefault("EMAIL_VERIFICATION_POLICY", "restrict")
+	switch config.EmailVerification.Policy {
+	case "optional", "restrict", "block_login":
+	default:
+		return nil, fmt.Errorf("invalid EMAIL_VERIFICATION_POLICY: %q", config.EmailVerification.Policy)
+	}
+
+	config.EmailVerification.TokenTTL, err = parseDurationWithDefault("EMAIL_VERIFICATION_TOKEN_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing EMAIL_VERIFICATION_TOKEN_TTL: %w", err)
+	}
+
+	config.EmailVerification.ResendInterval, err = parseDurationWithDefault("EMAIL_VERIFICATION_RESEND_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing EMAIL_VERIFICATION_RESEND_INTERVAL: %w", err)
+	}
+
+	config.TwoFactor.Issuer = getEnvWithDefault("TWO_FACTOR_ISSUER", "Note Nest")
+
+	config.Sessions.TTL, err = parseDurationWithDefault("SESSION_TTL", 30*24*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SESSION_TTL: %w", err)
+	}
+
+	config.Sessions.RenewalThreshold, err = parseDurationWithDefault("SESSION_RENEWAL_THRESHOLD", 15*24*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SESSION_RENEWAL_THRESHOLD: %w", err)
+	}
+	if config.Sessions.RenewalThreshold >= config.Sessions.TTL {
+		return nil, fmt.Errorf("SESSION_RENEWAL_THRESHOLD must be shorter than SESSION_TTL")
+	}
+
+	config.Sessions.IdleTimeout, err = parseOptionalDurationWithDefault("SESSION_IDLE_TIMEOUT", 0)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SESSION_IDLE_TIMEOUT: %w", err)
+	}
+
+	config.Sessions.MaxAge, err = parseOptionalDurationWithDefault("SESSION_MAX_AGE", 90*24*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SESSION_MAX_AGE: %w", err)
+	}
+
+	config.Sessions.PartialTTL, err = parseDurationWithDefault("SESSION_PARTIAL_TTL", 5*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SESSION_PARTIAL_TTL: %w", err)
+	}
+
+	config.Sessions.CleanupInterval, err = parseDurationWithDefault("SESSION_CLEANUP_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SESSION_CLEANUP_INTERVAL: %w", err)
+	}
+
+	config.Sessions.CleanupBatchSize, err = parseIntWithDefault("SESSION_CLEANUP_BATCH_SIZE", 1000, 1)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SESSION_CLEANUP_BATCH_SIZE: %w", err)
+	}
+
+	config.LoginThrottle.EmailFreeAttempts, err = parseIntWithDefault("LOGIN_THROTTLE_EMAIL_FREE_ATTEMPTS", 5, 1)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing LOGIN_THROTTLE_EMAIL_FREE_ATTEMPTS: %w", err)
+	}
+
+	config.LoginThrottle.EmailLockoutThreshold, err = parseIntWithDefault("LOGIN_THROTTLE_EMAIL_LOCKOUT_THRESHOLD", 10, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing LOGIN_THROTTLE_EMAIL_LOCKOUT_THRESHOLD: %w", err)
+	}
+
+	config.LoginThrottle.IPFreeAttempts, err = parseIntWithDefault("LOGIN_THROTTLE_IP_FREE_ATTEMPTS", 20, 1)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing LOGIN_THROTTLE_IP_FREE_ATTEMPTS: %w", err)
+	}
+
+	config.LoginThrottle.IPLockoutThreshold, err = parseIntWithDefault("LOGIN_THROTTLE_IP_LOCKOUT_THRESHOLD", 100, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing LOGIN_THROTTLE_IP_LOCKOUT_THRESHOLD: %w", err)
+	}
+
+	config.LoginThrottle.BaseDelay, err = parseDurationWithDefault("LOGIN_THROTTLE_BASE_DELAY", time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing LOGIN_THROTTLE_BASE_DELAY: %w", err)
+	}
+
+	config.LoginThrottle.MaxDelay, err = parseDurationWithDefault("LOGIN_THROTTLE_MAX_DELAY", time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing LOGIN_THROTTLE_MAX_DELAY: %w", err)
+	}
+
+	config.LoginThrottle.LockoutDuration, err = parseDurationWithDefault("LOGIN_THROTTLE_LOCKOUT_DURATION", 15*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing LOGIN_THROTTLE_LOCKOUT_DURATION: %w", err)
+	}
+
+	config.LoginThrottle.ResetAfter, err = parseDurationWithDefault("LOGIN_THROTTLE_RESET_AFTER", 15*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing LOGIN_THROTTLE_RESET_AFTER: %w", err)
+	}
+
+	config.Migrations.RequireCurrent, err = parseBoolWithDefault("MIGRATIONS_REQUIRE_CURRENT", false)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing MIGRATIONS_REQUIRE_CURRENT: %w", err)
+	}
+
+	config.Accounts.DeletionGraceDays, err = parseIntWithDefault("ACCOUNT_DELETION_GRACE_DAYS", 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing ACCOUNT_DELETION_GRACE_DAYS: %w", err)
+	}
+
+	config.Accounts.PurgeInterval, err = parseDurationWithDefault("ACCOUNT_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing ACCOUNT_PURGE_INTERVAL: %w", err)
+	}
+
+	config.Trash.RetentionDays, err = parseIntWithDefault("TRASH_RETENTION_DAYS", 30, 1)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing TRASH_RETENTION_DAYS: %w", err)
+	}
+
+	config.Trash.PurgeInterval, err = parseDurationWithDefault("TRASH_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing TRASH_PURGE_INTERVAL: %w", err)
+	}
+
+	config.Storage.Driver = getEnvWithDefault("STORAGE_DRIVER", "local")
+	switch config.Storage.Driver {
+	case "local":
+		config.Storage.LocalDir = getEnvWithDefault("STORAGE_LOCAL_DIR", "data/attachments")
+	case "s3":
+		config.Storage.S3Endpoint, err = parseURL("S3_ENDPOINT")
+		if err != nil {
+			return nil, fmt.Errorf("error parsing S3_ENDPOINT: %w", err)
+		}
+		config.Storage.S3Bucket = os.Getenv("S3_BUCKET")
+		if config.Storage.S3Bucket == "" {
+			return nil, fmt.Errorf("S3_BUCKET is not set")
+		}
+		config.Storage.S3Region = getEnvWithDefault("S3_REGION", "us-east-1")
+		config.Storage.S3AccessKey = os.Getenv("S3_ACCESS_KEY_ID")
+		config.Storage.S3SecretKey = os.Getenv("S3_SECRET_ACCESS_KEY")
+	default:
+		return nil, fmt.Errorf("invalid STORAGE_DRIVER: %q", config.Storage.Driver)
+	}
+
+	maxSizeMB, err := parseIntWithDefault("ATTACHMENT_MAX_SIZE_MB", 25, 1)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing ATTACHMENT_MAX_SIZE_MB: %w", err)
+	}
+	config.Attachments.MaxSize = int64(maxSizeMB) << 20
+
+	quotaMB, err := parseIntWithDefault("STORAGE_QUOTA_MB", 1024, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing STORAGE_QUOTA_MB: %w", err)
+	}
+	config.Attachments.UserQuota = int64(quotaMB) << 20
+
+	config.Attachments.TransferTimeout, err = parseDurationWithDefault("ATTACHMENT_TRANSFER_TIMEOUT", 10*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing ATTACHMENT_TRANSFER_TIMEOUT: %w", err)
+	}
+
 	return config, nil
 }
 
@@ -54,6 +390,22 @@ func parseURL(envName string) (string, error) {
 	return envValue, nil
 }
 
+func parseURLWithDefault(envName, defaultValue string) (string, error) {
+	if os.Getenv(envName) == "" {
+		return defaultValue, nil
+	}
+
+	return parseURL(envName)
+}
+
+func getEnvWithDefault(envName, defaultValue string) string {
+	if envValue := os.Getenv(envName); envValue != "" {
+		return envValue
+	}
+
+	return defaultValue
+}
+
 func parseInt(envName string) (int, error) {
 	envValue := os.Getenv(envName)
 	if envValue == "" {
@@ -67,3 +419,69 @@ func parseInt(envName string) (int, error) {
 
 	return port, nil
 }
+
+func parseIntWithDefault(envName string, defaultValue, minValue int) (int, error) {
+	if os.Getenv(envName) == "" {
+		return defaultValue, nil
+	}
+
+	value, err := parseInt(envName)
+	if err != nil {
+		return 0, err
+	}
+	if value < minValue {
+		return 0, fmt.Errorf("%s must be at least %d", envName, minValue)
+	}
+
+	return value, nil
+}
+
+func parseDurationWithDefault(envName string, defaultValue time.Duration) (time.Duration, error) {
+	envValue := os.Getenv(envName)
+	if envValue == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(envValue)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", envName, err)
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("%s must be positive", envName)
+	}
+
+	return duration, nil
+}
+
+// parseOptionalDurationWithDefault is parseDurationWithDefault allowing 0,
+// which disables the setting
+func parseOptionalDurationWithDefault(envName string, defaultValue time.Duration) (time.Duration, error) {
+	envValue := os.Getenv(envName)
+	if envValue == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(envValue)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", envName, err)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("%s must not be negative", envName)
+	}
+
+	return duration, nil
+}
+
+func parseBoolWithDefault(envName string, defaultValue bool) (bool, error) {
+	envValue := os.Getenv(envName)
+	if envValue == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.ParseBool(envValue)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", envName, err)
+	}
+
+	return value, nil
+}
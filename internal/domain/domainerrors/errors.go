@@ -0,0 +1,196 @@
+// Package domainerrors defines the error kinds shared by every layer. Each
+// typed error matches its sentinel with errors.Is, so callers can branch on
+// the kind of failure without comparing messages.
+package domainerrors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("conflict")
+	ErrForbidden     = errors.New("forbidden")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrValidation    = errors.New("validation failed")
+	ErrRateLimited   = errors.New("rate limited")
+	ErrTooLarge      = errors.New("too large")
+	ErrUnprocessable = errors.New("unprocessable")
+)
+
+// NotFoundError reports that a resource does not exist or is not visible to
+// the caller
+type NotFoundError struct {
+	Resource string
+}
+
+func NotFound(resource string) error {
+	return &NotFoundError{Resource: resource}
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s not found", e.Resource)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ConflictError reports that a change clashes with the current state of a
+// resource, such as a duplicate name or a concurrent update
+type ConflictError struct {
+	Resource string
+	Message  string
+	Err      error
+}
+
+func Conflict(resource, message string) error {
+	return &ConflictError{Resource: resource, Message: message}
+}
+
+func (e *ConflictError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s already exists", e.Resource)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// ForbiddenError reports that the caller is known but not allowed to perform
+// the action
+type ForbiddenError struct {
+	Message string
+}
+
+func Forbidden(message string) error {
+	return &ForbiddenError{Message: message}
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Message
+}
+
+func (e *ForbiddenError) Is(target error) bool {
+	return target == ErrForbidden
+}
+
+// UnauthorizedError reports that the caller could not be authenticated
+type UnauthorizedError struct {
+	Message string
+}
+
+func Unauthorized(message string) error {
+	return &UnauthorizedError{Message: message}
+}
+
+func (e *UnauthorizedError) Error() string {
+	return e.Message
+}
+
+func (e *UnauthorizedError) Is(target error) bool {
+	return target == ErrUnauthorized
+}
+
+// RateLimitedError reports that the caller must wait before trying again
+type RateLimitedError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func RateLimited(message string, retryAfter time.Duration) error {
+	return &RateLimitedError{Message: message, RetryAfter: retryAfter}
+}
+
+func (e *RateLimitedError) Error() string {
+	return e.Message
+}
+
+func (e *RateLimitedError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// TooLargeError reports that the input exceeds a size limit or a quota
+type TooLargeError struct {
+	Message string
+}
+
+func TooLarge(message string) error {
+	return &TooLargeError{Message: message}
+}
+
+func (e *TooLargeError) Error() string {
+	return e.Message
+}
+
+func (e *TooLargeError) Is(target error) bool {
+	return target == ErrTooLarge
+}
+
+// UnprocessableError reports that the input is well formed but cannot be
+// processed, for instance because it is too costly to handle
+type UnprocessableError struct {
+	Message string
+}
+
+func Unprocessable(message string) error {
+	return &UnprocessableError{Message: message}
+}
+
+func (e *UnprocessableError) Error() string {
+	return e.Message
+}
+
+func (e *UnprocessableError) Is(target error) bool {
+	return target == ErrUnprocessable
+}
+
+// FieldError describes why a single input field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError reports that the input was rejected, with the offending
+// fields when they are known
+type ValidationError struct {
+	Message string
+	Fields  []FieldError
+}
+
+func Validation(message string, fields ...FieldError) error {
+	return &ValidationError{Message: message, Fields: fields}
+}
+
+// InvalidField is a validation error about a single field
+func InvalidField(field, message string) error {
+	return &ValidationError{
+		Message: message,
+		Fields:  []FieldError{{Field: field, Message: message}},
+	}
+}
+
+func (e *ValidationError) Error() string {
+	if e.Message != "" || len(e.Fields) == 0 {
+		return e.Message
+	}
+
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = fmt.Sprintf("%s: %s", field.Field, field.Message)
+	}
+	return strings.Join(messages, ", ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
@@ -0,0 +1,17 @@
+package entities
+
+import "time"
+
+// Attachment is a file uploaded to a note. Its content lives in the blob
+// store under StorageKey, only the metadata is kept in the database.
+type Attachment struct {
+	ID          string    `json:"id"`
+	NoteID      string    `json:"note_id"`
+	UserID      string    `json:"user_id"` // Uploader, empty once their account is deleted
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"` // Hex encoded SHA-256 of the content
+	StorageKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package entities
+
+import "time"
+
+// MaxChecklistIndent is the deepest an item can be nested under the items
+// above it
+const MaxChecklistIndent = 5
+
+// ChecklistItem is one line of a checklist note. Items are listed by
+// ascending position.
+type ChecklistItem struct {
+	ID        string    `json:"id"`
+	NoteID    string    `json:"note_id"`
+	Text      string    `json:"text"`
+	IsChecked bool      `json:"is_checked"`
+	Position  float64   `json:"position"`
+	Indent    int       `json:"indent"` // Nesting level, 0 for top level items
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ChecklistProgress tells how much of a checklist is done
+type ChecklistProgress struct {
+	Total   int `json:"total"`
+	Checked int `json:"checked"`
+}
@@ -0,0 +1,16 @@
+package entities
+
+import (
+	"time"
+)
+
+// EmailChangeRequest holds a new address until the user confirms they own
+// it. Only the hash of the confirmation token is stored, as its ID.
+type EmailChangeRequest struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	NewEmail  string     `json:"new_email"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
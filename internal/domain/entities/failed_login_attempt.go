@@ -0,0 +1,25 @@
+package entities
+
+import (
+	"time"
+)
+
+// LoginFailureReason tells why a sign-in attempt was rejected
+type LoginFailureReason string
+
+const (
+	LoginFailureInvalidCredentials   LoginFailureReason = "invalid_credentials"
+	LoginFailureInvalidTwoFactorCode LoginFailureReason = "invalid_two_factor_code"
+	LoginFailureRateLimited          LoginFailureReason = "rate_limited"
+	LoginFailureInvalidLinkPassword  LoginFailureReason = "invalid_share_link_password"
+)
+
+// FailedLoginAttempt is the audit record of a rejected sign-in attempt
+type FailedLoginAttempt struct {
+	ID        string             `json:"id"`
+	Email     string             `json:"email"`
+	IPAddress string             `json:"ip_address"`
+	UserAgent string             `json:"user_agent"`
+	Reason    LoginFailureReason `json:"reason"`
+	CreatedAt time.Time          `json:"created_at"`
+}
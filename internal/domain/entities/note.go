@@ -4,13 +4,53 @@ import (
 	"time"
 )
 
+// NoteType tells where the body of a note is kept
+type NoteType string
+
+const (
+	// NoteTypeText notes keep their body in Content
+	NoteTypeText NoteType = "text"
+	// NoteTypeChecklist notes keep their body as checklist items, Content
+	// stays empty
+	NoteTypeChecklist NoteType = "checklist"
+)
+
+// IsValid reports whether the type is known
+func (t NoteType) IsValid() bool {
+	return t == NoteTypeText || t == NoteTypeChecklist
+}
+
 type Note struct {
-	ID         string    `json:"id"`
-	UserID     string    `json:"user_id"`
-	Title      string    `json:"title"`
-	Content    string    `json:"content"`
-	IsArchived bool      `json:"is_archived"`
-	Label      string    `json:"label"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Title      string     `json:"title"`
+	Content    string     `json:"content"`
+	Type       NoteType   `json:"type"`
+	IsArchived bool       `json:"is_archived"`
+	IsPinned   bool       `json:"is_pinned"` // Pinned notes are listed first
+	Position   float64    `json:"position"`  // Notes are listed by ascending position
+	Label      string     `json:"label"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"` // Set while the note is in the trash
+	Version    int        `json:"version"`              // Incremented on every update
+
+	// Checklist counts the items of a checklist note, it is only loaded
+	// when listing notes
+	Checklist *ChecklistProgress `json:"checklist,omitempty"`
+}
+
+// HighlightStart and HighlightStop surround the matched terms in the title
+// highlight and snippet of a search result. They are control characters so
+// they cannot be confused with markup written by the user.
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
+
+type NoteSearchResult struct {
+	Note           *Note   `json:"note"`
+	Rank           float32 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
 }
@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"time"
+)
+
+type NoteSortField string
+
+const (
+	NoteSortByCreatedAt NoteSortField = "created_at"
+	NoteSortByUpdatedAt NoteSortField = "updated_at"
+	NoteSortByTitle     NoteSortField = "title"
+	// NoteSortByPosition lists pinned notes first, then follows the order
+	// the user arranged the notes in
+	NoteSortByPosition NoteSortField = "position"
+)
+
+// NoteCursor marks the position of the last note of a page
+type NoteCursor struct {
+	SortValue string `json:"v"`
+	ID        string `json:"id"`
+	Pinned    bool   `json:"p,omitempty"` // Only used when sorting by position
+}
+
+type NoteFilter struct {
+	UserID        string
+	IsArchived    *bool    // nil returns both active and archived notes
+	LabelIDs      []string // Notes having at least one of these labels
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	SortBy        NoteSortField
+	Descending    bool
+	Cursor        *NoteCursor
+	Limit         int
+}
+
+type NotePage struct {
+	Notes      []*Note `json:"notes"`
+	NextCursor string  `json:"next_cursor"`
+}
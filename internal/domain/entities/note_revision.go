@@ -0,0 +1,44 @@
+package entities
+
+import (
+	"time"
+)
+
+type NoteRevision struct {
+	ID        string    `json:"id"`
+	NoteID    string    `json:"note_id"`
+	Revision  int       `json:"revision"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RevisionRetention limits how many prior versions of a note are kept.
+// A zero value disables the corresponding limit.
+type RevisionRetention struct {
+	UserID       string    `json:"user_id"`
+	MaxRevisions int       `json:"max_revisions"`
+	MaxAgeDays   int       `json:"max_age_days"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert"
+	DiffDelete DiffOp = "delete"
+)
+
+type DiffLine struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+type RevisionDiff struct {
+	From      int        `json:"from"`
+	To        int        `json:"to"`
+	FromTitle string     `json:"from_title"`
+	ToTitle   string     `json:"to_title"`
+	Lines     []DiffLine `json:"lines"`
+}
@@ -0,0 +1,54 @@
+package entities
+
+import (
+	"time"
+)
+
+// NoteRole is the access a user has to a note
+type NoteRole string
+
+const (
+	NoteRoleOwner  NoteRole = "owner"
+	NoteRoleEditor NoteRole = "editor"
+	NoteRoleViewer NoteRole = "viewer"
+)
+
+// noteRoleRanks orders the roles, each one granting everything the lower
+// ones do
+var noteRoleRanks = map[NoteRole]int{
+	NoteRoleViewer: 1,
+	NoteRoleEditor: 2,
+	NoteRoleOwner:  3,
+}
+
+// Includes reports whether the role grants everything the other role does
+func (r NoteRole) Includes(other NoteRole) bool {
+	rank, ok := noteRoleRanks[r]
+	return ok && rank >= noteRoleRanks[other]
+}
+
+// IsShareable reports whether the role can be granted to another user. A
+// note has a single owner.
+func (r NoteRole) IsShareable() bool {
+	return r == NoteRoleEditor || r == NoteRoleViewer
+}
+
+// NoteShare grants a user other than the owner access to a note
+type NoteShare struct {
+	ID     string   `json:"id"`
+	NoteID string   `json:"note_id"`
+	UserID string   `json:"user_id"`
+	Role   NoteRole `json:"role"`
+	// Email and Name are those of the user the note is shared with, only
+	// filled in when listing the shares of a note
+	Email     string    `json:"email,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SharedNote is a note shared with the user, along with their role on it
+type SharedNote struct {
+	Note *Note    `json:"note"`
+	Role NoteRole `json:"role"`
+}
@@ -0,0 +1,15 @@
+package entities
+
+import (
+	"time"
+)
+
+// PasswordResetToken is a single-use token letting a user choose a new
+// password. Only the hash of the token is stored, as its ID.
+type PasswordResetToken struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"slices"
+	"time"
+)
+
+// TokenScope limits what a personal access token may do
+type TokenScope string
+
+const (
+	ScopeProfileRead TokenScope = "profile:read"
+	ScopeNotesRead   TokenScope = "notes:read"
+	ScopeNotesWrite  TokenScope = "notes:write"
+	ScopeLabelsRead  TokenScope = "labels:read"
+	ScopeLabelsWrite TokenScope = "labels:write"
+)
+
+// TokenScopes lists every scope a token can be granted
+var TokenScopes = []TokenScope{
+	ScopeProfileRead,
+	ScopeNotesRead,
+	ScopeNotesWrite,
+	ScopeLabelsRead,
+	ScopeLabelsWrite,
+}
+
+// impliedScopes maps a scope to the scopes it also grants
+var impliedScopes = map[TokenScope][]TokenScope{
+	ScopeNotesWrite:  {ScopeNotesRead},
+	ScopeLabelsWrite: {ScopeLabelsRead},
+}
+
+// IsValid reports whether the scope exists
+func (s TokenScope) IsValid() bool {
+	return slices.Contains(TokenScopes, s)
+}
+
+// PersonalAccessToken lets scripts call the API on behalf of the user. Only
+// the hash of the token is stored.
+type PersonalAccessToken struct {
+	ID         string       `json:"id"`
+	UserID     string       `json:"user_id"`
+	Name       string       `json:"name"`
+	TokenHash  string       `json:"-"`
+	Scopes     []TokenScope `json:"scopes"`
+	ExpiresAt  *time.Time   `json:"expires_at"` // nil never expires
+	LastUsedAt *time.Time   `json:"last_used_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// HasScope reports whether the token was granted the scope, directly or
+// through a scope implying it
+func (t *PersonalAccessToken) HasScope(scope TokenScope) bool {
+	for _, granted := range t.Scopes {
+		if granted == scope || slices.Contains(impliedScopes[granted], scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired reports whether the token can no longer be used at the given time
+func (t *PersonalAccessToken) IsExpired(at time.Time) bool {
+	return t.ExpiresAt != nil && !at.Before(*t.ExpiresAt)
+}
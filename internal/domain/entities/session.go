@@ -5,10 +5,21 @@ import (
 )
 
 type Session struct {
+	// ID is the hash of the session token and must never leave the server.
+	// PublicID identifies the session to its user instead.
 	ID        string    `json:"id"`
+	PublicID  string    `json:"public_id"`
 	UserID    string    `json:"user_id"`
+	UserAgent string    `json:"user_agent"`
+	IPAddress string    `json:"ip_address"`
 	ExpiresAt time.Time `json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
+	// LastSeenAt is only updated every few minutes, not on every request
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// TwoFactorPending marks a partial session, created after the password
+	// check of a user with two-factor authentication and only good for
+	// submitting the second factor
+	TwoFactorPending bool `json:"two_factor_pending"`
 }
 
 type SessionValidationResult struct {
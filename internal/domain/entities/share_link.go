@@ -0,0 +1,25 @@
+package entities
+
+import "time"
+
+// ShareLink lets anybody holding its token read a note without signing in.
+// Only the hash of the token is stored.
+type ShareLink struct {
+	ID           string     `json:"id"`
+	NoteID       string     `json:"note_id"`
+	TokenHash    string     `json:"-"`
+	PasswordHash string     `json:"-"`          // Empty when the link is not password protected
+	ExpiresAt    *time.Time `json:"expires_at"` // nil never expires
+	ViewCount    int64      `json:"view_count"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// HasPassword reports whether a password is needed to open the link
+func (l *ShareLink) HasPassword() bool {
+	return l.PasswordHash != ""
+}
+
+// IsExpired reports whether the link can no longer be opened at the given time
+func (l *ShareLink) IsExpired(at time.Time) bool {
+	return l.ExpiresAt != nil && !at.Before(*l.ExpiresAt)
+}
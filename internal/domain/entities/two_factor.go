@@ -0,0 +1,31 @@
+package entities
+
+import (
+	"time"
+)
+
+// TOTPCredential holds the shared secret of the user's authenticator app. It
+// only protects the account once the enrollment is confirmed with a code.
+type TOTPCredential struct {
+	UserID      string     `json:"user_id"`
+	Secret      string     `json:"-"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+	// LastUsedStep is the time step of the last accepted code, so that a code
+	// cannot be replayed
+	LastUsedStep int64     `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Enabled reports whether the enrollment was confirmed
+func (c *TOTPCredential) Enabled() bool {
+	return c.ConfirmedAt != nil
+}
+
+// RecoveryCode is a single-use code standing in for the authenticator app.
+// Only the hash of the code is stored, as its ID.
+type RecoveryCode struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -5,10 +5,17 @@ import (
 )
 
 type User struct {
-	ID        string    `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	Password  string    `json:"-"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                  string     `json:"id"`
+	Email               string     `json:"email"`
+	Name                string     `json:"name"`
+	Password            string     `json:"-"`
+	EmailVerifiedAt     *time.Time `json:"email_verified_at"`
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// EmailVerified reports whether the user proved they own their address
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
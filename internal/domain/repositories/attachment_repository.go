@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *entities.Attachment) error
+
+	GetByID(ctx context.Context, noteID, id string) (*entities.Attachment, error)
+	// GetByNoteID returns the attachments of the note, oldest first
+	GetByNoteID(ctx context.Context, noteID string) ([]*entities.Attachment, error)
+	// GetUsageByUserID returns the total size in bytes of the attachments of
+	// the notes owned by the user, trashed notes included
+	GetUsageByUserID(ctx context.Context, userID string) (int64, error)
+
+	// Delete deletes an attachment of the note, reporting whether it existed
+	Delete(ctx context.Context, noteID, id string) (bool, error)
+
+	// The bulk deletes mirror those of the NoteRepository and run in the same
+	// transaction. They lock the notes and return the storage keys of the
+	// deleted attachments, so their blobs can be removed after the commit.
+	DeleteAllByUserID(ctx context.Context, userID string) ([]string, error)
+	DeleteTrashedByUserID(ctx context.Context, userID string) ([]string, error)
+	DeleteTrashedBefore(ctx context.Context, cutoff time.Time) ([]string, error)
+}
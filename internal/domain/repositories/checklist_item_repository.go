@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+type ChecklistItemRepository interface {
+	// Create adds the item at the bottom of the checklist, setting its
+	// position
+	Create(ctx context.Context, item *entities.ChecklistItem) error
+
+	GetByID(ctx context.Context, noteID, id string) (*entities.ChecklistItem, error)
+	// GetByNoteID returns the items of the note in checklist order
+	GetByNoteID(ctx context.Context, noteID string) ([]*entities.ChecklistItem, error)
+
+	Update(ctx context.Context, item *entities.ChecklistItem) error
+
+	// Ordering methods
+	// GetAdjacentPosition returns the position of the item listed right
+	// before the given one, or right after it. The excluded item is skipped.
+	// It returns nil at either end of the checklist.
+	GetAdjacentPosition(ctx context.Context, item *entities.ChecklistItem, after bool, excludeID string) (*float64, error)
+	UpdatePosition(ctx context.Context, id string, position float64) error
+	// RebalancePositions spreads the positions of the items of the note
+	// evenly again, keeping their order
+	RebalancePositions(ctx context.Context, noteID string) error
+	// MoveCheckedToBottom lists the checked items after the unchecked ones,
+	// keeping the order within each group
+	MoveCheckedToBottom(ctx context.Context, noteID string) error
+	// UncheckAll unchecks every item of the note, returning how many were
+	// checked
+	UncheckAll(ctx context.Context, noteID string, at time.Time) (int64, error)
+
+	// Delete deletes an item of the note, reporting whether it existed
+	Delete(ctx context.Context, noteID, id string) (bool, error)
+	DeleteByNoteID(ctx context.Context, noteID string) error
+}
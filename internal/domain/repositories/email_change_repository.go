@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+type EmailChangeRepository interface {
+	Create(ctx context.Context, request *entities.EmailChangeRequest) error
+
+	GetByID(ctx context.Context, id string) (*entities.EmailChangeRequest, error)
+
+	// MarkUsed consumes an unused, unexpired request. It returns false when
+	// the request was already used or has expired.
+	MarkUsed(ctx context.Context, id string) (bool, error)
+
+	DeleteAllByUserID(ctx context.Context, userID string) error
+}
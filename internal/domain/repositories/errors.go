@@ -0,0 +1,9 @@
+package repositories
+
+import (
+	"errors"
+)
+
+// ErrVersionConflict is returned by Update when the stored version no longer
+// matches the version of the entity being saved
+var ErrVersionConflict = errors.New("version conflict")
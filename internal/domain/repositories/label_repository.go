@@ -17,6 +17,9 @@ type LabelRepository interface {
 
 	Delete(ctx context.Context, id string) error
 
+	// DeleteAllByUserID removes the user's labels and detaches them from notes
+	DeleteAllByUserID(ctx context.Context, userID string) error
+
 	// Note-Label relationship methods
 	AddLabelToNote(ctx context.Context, noteID, labelID string) error
 
@@ -0,0 +1,11 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+type LoginAuditRepository interface {
+	RecordFailure(ctx context.Context, attempt *entities.FailedLoginAttempt) error
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/LaulauChau/note-nest/internal/domain/entities"
 )
@@ -10,10 +11,41 @@ type NoteRepository interface {
 	Create(ctx context.Context, note *entities.Note) error
 
 	GetByID(ctx context.Context, id string) (*entities.Note, error)
+	// GetByIDForUpdate also locks the note until the transaction ends, so
+	// concurrent writes apply one after the other on the latest version. It
+	// must run inside TxManager.WithinTx.
+	GetByIDForUpdate(ctx context.Context, id string) (*entities.Note, error)
 	GetByUserID(ctx context.Context, userID string) ([]*entities.Note, error)
 	GetArchivedByUserID(ctx context.Context, userID string) ([]*entities.Note, error) // Get archived
+	List(ctx context.Context, filter entities.NoteFilter) ([]*entities.Note, error)
+
+	Search(ctx context.Context, userID, query string, includeArchived bool, limit int) ([]*entities.NoteSearchResult, error)
 
 	Update(ctx context.Context, note *entities.Note) error
+	// Touch marks the note as updated at the given time without changing its
+	// version, for changes made to what belongs to the note
+	Touch(ctx context.Context, id string, at time.Time) error
+
+	// Ordering methods
+	// SetPinned pins or unpins the note, moving it to the top of its group
+	SetPinned(ctx context.Context, note *entities.Note, pinned bool) error
+	// GetAdjacentPosition returns the position of the note listed right
+	// before the given one, or right after it, in the same list. The excluded
+	// note is skipped. It returns nil at either end of the list.
+	GetAdjacentPosition(ctx context.Context, note *entities.Note, after bool, excludeID string) (*float64, error)
+	UpdatePosition(ctx context.Context, id string, position float64) error
+	// RebalancePositions spreads the positions of the notes of the user
+	// evenly again, keeping their order
+	RebalancePositions(ctx context.Context, userID string) error
 
 	Delete(ctx context.Context, id string) error
+	DeleteAllByUserID(ctx context.Context, userID string) error
+
+	// Trash methods
+	Trash(ctx context.Context, id string, deletedAt time.Time) error
+	Restore(ctx context.Context, id string) error
+	GetTrashedByID(ctx context.Context, id string) (*entities.Note, error)
+	GetTrashedByUserID(ctx context.Context, userID string) ([]*entities.Note, error)
+	DeleteTrashedByUserID(ctx context.Context, userID string) (int64, error)
+	DeleteTrashedBefore(ctx context.Context, cutoff time.Time) (int64, error)
 }
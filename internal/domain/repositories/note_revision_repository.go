@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+type NoteRevisionRepository interface {
+	// Create assigns the next revision number. Concurrent writers must hold
+	// the note's lock, see NoteRepository.GetByIDForUpdate.
+	Create(ctx context.Context, revision *entities.NoteRevision) error
+
+	GetByNoteID(ctx context.Context, noteID string) ([]*entities.NoteRevision, error) // Newest first
+	GetByRevision(ctx context.Context, noteID string, revision int) (*entities.NoteRevision, error)
+
+	DeleteAllButLatest(ctx context.Context, noteID string, keep int) error
+	DeleteOlderThan(ctx context.Context, noteID string, cutoff time.Time) error
+
+	// Retention policy methods
+	GetRetention(ctx context.Context, userID string) (*entities.RevisionRetention, error)
+	SaveRetention(ctx context.Context, retention *entities.RevisionRetention) error
+}
@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+type NoteShareRepository interface {
+	// Upsert shares the note with the user, or changes their role when it
+	// already is. The ID and creation time of an existing share are kept.
+	Upsert(ctx context.Context, share *entities.NoteShare) error
+
+	GetByNoteAndUser(ctx context.Context, noteID, userID string) (*entities.NoteShare, error)
+	// GetByNoteID returns the shares of the note with the email and name of
+	// each user, oldest first
+	GetByNoteID(ctx context.Context, noteID string) ([]*entities.NoteShare, error)
+	// GetSharedWithUser returns the notes shared with the user that are not in
+	// the trash, most recently updated first
+	GetSharedWithUser(ctx context.Context, userID string) ([]*entities.SharedNote, error)
+
+	// Delete removes the user's access to the note, reporting whether they had any
+	Delete(ctx context.Context, noteID, userID string) (bool, error)
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *entities.PasswordResetToken) error
+
+	GetByID(ctx context.Context, id string) (*entities.PasswordResetToken, error)
+
+	// GetLatestByUserID returns the most recently issued token of the user,
+	// used to throttle reset requests
+	GetLatestByUserID(ctx context.Context, userID string) (*entities.PasswordResetToken, error)
+
+	// MarkUsed consumes an unused, unexpired token. It returns false when the
+	// token was already used or has expired.
+	MarkUsed(ctx context.Context, id string) (bool, error)
+
+	DeleteAllByUserID(ctx context.Context, userID string) error
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *entities.PersonalAccessToken) error
+
+	GetByHash(ctx context.Context, tokenHash string) (*entities.PersonalAccessToken, error)
+	// GetByUserID returns the tokens of the user, most recent first
+	GetByUserID(ctx context.Context, userID string) ([]*entities.PersonalAccessToken, error)
+
+	UpdateLastUsedAt(ctx context.Context, id string, lastUsedAt time.Time) error
+
+	// Delete deletes a token of the user, reporting whether it existed
+	Delete(ctx context.Context, userID, id string) (bool, error)
+	DeleteAllByUserID(ctx context.Context, userID string) error
+}
@@ -12,9 +12,21 @@ type SessionRepository interface {
 
 	GetByID(ctx context.Context, id string) (*entities.Session, error)
 	GetSessionWithUser(ctx context.Context, sessionID string) (*entities.SessionValidationResult, error)
+	// ListByUserID returns the signed in sessions of the user that have not
+	// expired, most recently used first
+	ListByUserID(ctx context.Context, userID string) ([]*entities.Session, error)
 
 	UpdateExpiresAt(ctx context.Context, sessionID string, expiresAt time.Time) error
+	UpdateLastSeenAt(ctx context.Context, sessionID string, lastSeenAt time.Time) error
 
 	Delete(ctx context.Context, sessionID string) error
+	// DeleteByPublicID deletes a session of the user, reporting whether it existed
+	DeleteByPublicID(ctx context.Context, userID, publicID string) (bool, error)
 	DeleteAllByUserID(ctx context.Context, userID string) error
+	DeleteAllByUserIDExcept(ctx context.Context, userID, sessionID string) error
+	// DeleteExpired deletes up to limit sessions that expired before
+	// expiredBefore, were last seen before idleBefore or were created before
+	// createdBefore, and returns how many it deleted. A zero time disables its
+	// condition.
+	DeleteExpired(ctx context.Context, expiredBefore, idleBefore, createdBefore time.Time, limit int) (int, error)
 }
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+type ShareLinkRepository interface {
+	Create(ctx context.Context, link *entities.ShareLink) error
+
+	GetByHash(ctx context.Context, tokenHash string) (*entities.ShareLink, error)
+	// GetByNoteID returns the links of the note, most recent first
+	GetByNoteID(ctx context.Context, noteID string) ([]*entities.ShareLink, error)
+
+	// IncrementViewCount records a view of the link, returning the new count
+	IncrementViewCount(ctx context.Context, id string) (int64, error)
+
+	// Delete deletes a link of the note, reporting whether it existed
+	Delete(ctx context.Context, noteID, id string) (bool, error)
+	DeleteByNoteID(ctx context.Context, noteID string) error
+}
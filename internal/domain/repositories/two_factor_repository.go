@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+)
+
+type TwoFactorRepository interface {
+	// SaveTOTPCredential stores a new unconfirmed credential, replacing any
+	// previous one of the user
+	SaveTOTPCredential(ctx context.Context, credential *entities.TOTPCredential) error
+
+	GetTOTPCredential(ctx context.Context, userID string) (*entities.TOTPCredential, error)
+
+	// ConfirmTOTPCredential enables the credential. It returns false when it
+	// was already confirmed.
+	ConfirmTOTPCredential(ctx context.Context, userID string, confirmedAt time.Time) (bool, error)
+
+	// UseTOTPStep records the time step of an accepted code. It returns false
+	// when a code of the same or a later step was already accepted.
+	UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error)
+
+	DeleteTOTPCredential(ctx context.Context, userID string) error
+
+	CreateRecoveryCodes(ctx context.Context, codes []*entities.RecoveryCode) error
+
+	// UseRecoveryCode consumes an unused recovery code of the user. It returns
+	// false when there is no such code.
+	UseRecoveryCode(ctx context.Context, userID, id string) (bool, error)
+
+	CountRemainingRecoveryCodes(ctx context.Context, userID string) (int, error)
+
+	DeleteRecoveryCodes(ctx context.Context, userID string) error
+}
@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+)
+
+// TxRepositories gives access to repositories bound to a single transaction
+type TxRepositories struct {
+	Users                UserRepository
+	Sessions             SessionRepository
+	PasswordResets       PasswordResetRepository
+	PersonalAccessTokens PersonalAccessTokenRepository
+	Notes                NoteRepository
+	Labels               LabelRepository
+	Revisions            NoteRevisionRepository
+	TwoFactor            TwoFactorRepository
+	ShareLinks           ShareLinkRepository
+	Checklists           ChecklistItemRepository
+	Attachments          AttachmentRepository
+}
+
+// TxManager runs a unit of work inside a transaction. The transaction is
+// committed when fn returns nil and rolled back when it returns an error.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context, repos TxRepositories) error) error
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/LaulauChau/note-nest/internal/domain/entities"
 )
@@ -14,5 +15,17 @@ type UserRepository interface {
 
 	Update(ctx context.Context, user *entities.User) error
 
+	// UpdateEmail switches the user to a confirmed address, which is
+	// therefore verified
+	UpdateEmail(ctx context.Context, id, email string) error
+
+	// MarkEmailVerified records that the user proved they own their address
+	MarkEmailVerified(ctx context.Context, id string) error
+
 	Delete(ctx context.Context, id string) error
+
+	// Account deletion grace period methods
+	ScheduleDeletion(ctx context.Context, id string, requestedAt time.Time) error
+	CancelDeletion(ctx context.Context, id string) error
+	GetPendingDeletionBefore(ctx context.Context, cutoff time.Time) ([]string, error) // Returns user IDs
 }
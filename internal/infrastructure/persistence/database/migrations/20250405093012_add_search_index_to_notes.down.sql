@@ -0,0 +1 @@
+DROP INDEX notes_search_idx;
\ No newline at end of file
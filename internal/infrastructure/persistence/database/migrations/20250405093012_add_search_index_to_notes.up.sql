@@ -0,0 +1 @@
+CREATE INDEX notes_search_idx ON notes USING GIN (to_tsvector('english', title || ' ' || content));
\ No newline at end of file
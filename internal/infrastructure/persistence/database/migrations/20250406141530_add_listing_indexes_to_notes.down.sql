@@ -0,0 +1,4 @@
+DROP INDEX note_labels_label_id_idx;
+DROP INDEX notes_user_id_title_idx;
+DROP INDEX notes_user_id_updated_at_idx;
+DROP INDEX notes_user_id_created_at_idx;
\ No newline at end of file
@@ -0,0 +1,4 @@
+CREATE INDEX notes_user_id_created_at_idx ON notes (user_id, created_at, id);
+CREATE INDEX notes_user_id_updated_at_idx ON notes (user_id, updated_at, id);
+CREATE INDEX notes_user_id_title_idx ON notes (user_id, title, id);
+CREATE INDEX note_labels_label_id_idx ON note_labels (label_id);
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE note_revisions (
+    id VARCHAR(255) PRIMARY KEY,
+    note_id VARCHAR(255) NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (note_id, revision)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE revision_retention_policies;
\ No newline at end of file
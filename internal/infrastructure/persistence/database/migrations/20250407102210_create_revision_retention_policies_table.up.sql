@@ -0,0 +1,6 @@
+CREATE TABLE revision_retention_policies (
+    user_id VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    max_revisions INTEGER NOT NULL DEFAULT 0,
+    max_age_days INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP INDEX notes_deleted_at_idx;
+
+ALTER TABLE notes DROP COLUMN deleted_at;
\ No newline at end of file
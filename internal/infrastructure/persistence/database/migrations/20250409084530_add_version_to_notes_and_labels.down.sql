@@ -0,0 +1,3 @@
+ALTER TABLE labels DROP COLUMN version;
+
+ALTER TABLE notes DROP COLUMN version;
\ No newline at end of file
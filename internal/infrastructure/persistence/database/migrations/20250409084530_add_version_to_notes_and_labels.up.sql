@@ -0,0 +1,3 @@
+ALTER TABLE notes ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE labels ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE email_change_requests;
\ No newline at end of file
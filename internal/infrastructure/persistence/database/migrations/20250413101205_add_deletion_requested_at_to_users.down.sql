@@ -0,0 +1,3 @@
+DROP INDEX users_deletion_requested_at_idx;
+
+ALTER TABLE users DROP COLUMN deletion_requested_at;
\ No newline at end of file
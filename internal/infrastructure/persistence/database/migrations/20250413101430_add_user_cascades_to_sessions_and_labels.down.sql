@@ -0,0 +1,5 @@
+ALTER TABLE labels DROP CONSTRAINT labels_user_id_fkey;
+
+ALTER TABLE sessions DROP CONSTRAINT sessions_user_id_fkey;
+
+ALTER TABLE sessions ADD CONSTRAINT sessions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE sessions DROP CONSTRAINT sessions_user_id_fkey;
+
+ALTER TABLE sessions ADD CONSTRAINT sessions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+DELETE FROM labels WHERE user_id NOT IN (SELECT id FROM users);
+
+ALTER TABLE labels ADD CONSTRAINT labels_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE sessions DROP COLUMN two_factor_pending;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE sessions ADD COLUMN two_factor_pending BOOLEAN NOT NULL DEFAULT FALSE;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE recovery_codes;
+DROP TABLE totp_credentials;
\ No newline at end of file
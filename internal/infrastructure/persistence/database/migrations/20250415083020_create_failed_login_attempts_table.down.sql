@@ -0,0 +1 @@
+DROP TABLE failed_login_attempts;
\ No newline at end of file
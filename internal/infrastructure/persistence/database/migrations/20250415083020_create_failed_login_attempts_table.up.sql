@@ -0,0 +1,12 @@
+CREATE TABLE failed_login_attempts (
+    id VARCHAR(255) PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    ip_address VARCHAR(255) NOT NULL,
+    user_agent TEXT NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX failed_login_attempts_email_created_at_idx ON failed_login_attempts (email, created_at DESC);
+
+CREATE INDEX failed_login_attempts_ip_address_created_at_idx ON failed_login_attempts (ip_address, created_at DESC);
\ No newline at end of file
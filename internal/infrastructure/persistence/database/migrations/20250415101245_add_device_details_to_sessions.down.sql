@@ -0,0 +1,9 @@
+DROP INDEX sessions_user_id_last_seen_at_idx;
+
+DROP INDEX sessions_public_id_idx;
+
+ALTER TABLE sessions
+    DROP COLUMN last_seen_at,
+    DROP COLUMN ip_address,
+    DROP COLUMN user_agent,
+    DROP COLUMN public_id;
\ No newline at end of file
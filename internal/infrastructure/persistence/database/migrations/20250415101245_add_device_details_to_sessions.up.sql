@@ -0,0 +1,13 @@
+ALTER TABLE sessions
+    ADD COLUMN public_id VARCHAR(255),
+    ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN ip_address VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN last_seen_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE sessions SET public_id = gen_random_uuid()::text, last_seen_at = created_at;
+
+ALTER TABLE sessions ALTER COLUMN public_id SET NOT NULL;
+
+CREATE UNIQUE INDEX sessions_public_id_idx ON sessions (public_id);
+
+CREATE INDEX sessions_user_id_last_seen_at_idx ON sessions (user_id, last_seen_at DESC);
\ No newline at end of file
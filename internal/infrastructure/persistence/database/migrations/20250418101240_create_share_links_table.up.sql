@@ -0,0 +1,11 @@
+CREATE TABLE share_links (
+    id VARCHAR(255) PRIMARY KEY,
+    note_id VARCHAR(255) NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    token_hash VARCHAR(255) NOT NULL UNIQUE,
+    password_hash VARCHAR(255),
+    expires_at TIMESTAMPTZ,
+    view_count BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX share_links_note_id_idx ON share_links (note_id);
\ No newline at end of file
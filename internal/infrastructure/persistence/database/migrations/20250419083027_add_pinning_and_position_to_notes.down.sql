@@ -0,0 +1,5 @@
+DROP INDEX notes_user_id_position_idx;
+
+ALTER TABLE notes DROP COLUMN position;
+
+ALTER TABLE notes DROP COLUMN is_pinned;
\ No newline at end of file
@@ -0,0 +1,14 @@
+ALTER TABLE notes ADD COLUMN is_pinned BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE notes ADD COLUMN position DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- Keep the order the notes were listed in so far, most recently updated first,
+-- leaving gaps so that moving a note rarely needs to renumber its neighbors
+UPDATE notes SET position = ordered.rank * 1024
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY updated_at DESC, id) AS rank
+    FROM notes
+) AS ordered
+WHERE notes.id = ordered.id;
+
+CREATE INDEX notes_user_id_position_idx ON notes (user_id, is_pinned, position, id);
\ No newline at end of file
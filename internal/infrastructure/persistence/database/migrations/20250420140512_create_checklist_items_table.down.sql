@@ -0,0 +1,3 @@
+DROP TABLE checklist_items;
+
+ALTER TABLE notes DROP COLUMN type;
\ No newline at end of file
@@ -0,0 +1,14 @@
+ALTER TABLE notes ADD COLUMN type VARCHAR(20) NOT NULL DEFAULT 'text' CHECK (type IN ('text', 'checklist'));
+
+CREATE TABLE checklist_items (
+    id VARCHAR(255) PRIMARY KEY,
+    note_id VARCHAR(255) NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    text TEXT NOT NULL,
+    is_checked BOOLEAN NOT NULL DEFAULT false,
+    position DOUBLE PRECISION NOT NULL,
+    indent SMALLINT NOT NULL DEFAULT 0 CHECK (indent >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX checklist_items_note_id_position_idx ON checklist_items (note_id, position, id);
\ No newline at end of file
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/config"
+)
+
+// NewPool opens a connection pool configured from the database settings and
+// verifies it can reach the server. Broken connections are discarded by the
+// pool health check and replaced on demand.
+func NewPool(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.DATABASE.URL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing database URL: %w", err)
+	}
+
+	poolConfig.MaxConns = int32(cfg.DATABASE.MaxConns)
+	poolConfig.MinConns = int32(cfg.DATABASE.MinConns)
+	poolConfig.MaxConnLifetime = cfg.DATABASE.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.DATABASE.MaxConnIdleTime
+	poolConfig.HealthCheckPeriod = cfg.DATABASE.HealthCheckPeriod
+	poolConfig.ConnConfig.StatementCacheCapacity = cfg.DATABASE.StatementCacheCapacity
+	if cfg.DATABASE.StatementCacheCapacity == 0 {
+		// The default mode needs the statement cache, without it every query
+		// is sent unprepared, which also suits transaction-mode poolers
+		poolConfig.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeExec
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error creating connection pool: %w", err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("error pinging database: %w", err)
+	}
+
+	return pool, nil
+}
+
+// PoolMonitor reports the health and usage of a connection pool
+type PoolMonitor struct {
+	pool *pgxpool.Pool
+}
+
+func NewPoolMonitor(pool *pgxpool.Pool) *PoolMonitor {
+	return &PoolMonitor{
+		pool: pool,
+	}
+}
+
+func (m *PoolMonitor) Ping(ctx context.Context) error {
+	return m.pool.Ping(ctx)
+}
+
+func (m *PoolMonitor) Stats() services.PoolStats {
+	stat := m.pool.Stat()
+
+	return services.PoolStats{
+		TotalConns:           stat.TotalConns(),
+		AcquiredConns:        stat.AcquiredConns(),
+		IdleConns:            stat.IdleConns(),
+		ConstructingConns:    stat.ConstructingConns(),
+		MaxConns:             stat.MaxConns(),
+		AcquireCount:         stat.AcquireCount(),
+		EmptyAcquireCount:    stat.EmptyAcquireCount(),
+		CanceledAcquireCount: stat.CanceledAcquireCount(),
+		AcquireDuration:      stat.AcquireDuration(),
+		NewConnsCount:        stat.NewConnsCount(),
+		MaxLifetimeDestroys:  stat.MaxLifetimeDestroyCount(),
+		MaxIdleDestroys:      stat.MaxIdleDestroyCount(),
+	}
+}
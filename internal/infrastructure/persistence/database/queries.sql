@@ -12,12 +12,27 @@ SELECT * FROM users WHERE id = $1;
 -- name: UpdateUser :exec
 UPDATE users SET email = $2, name = $3, password = $4 WHERE id = $1;
 
+-- name: MarkUserEmailVerified :exec
+UPDATE users SET email_verified_at = NOW() WHERE id = $1 AND email_verified_at IS NULL;
+
+-- name: UpdateUserEmail :exec
+UPDATE users SET email = $2, email_verified_at = NOW() WHERE id = $1;
+
+-- name: ScheduleUserDeletion :exec
+UPDATE users SET deletion_requested_at = $2 WHERE id = $1;
+
+-- name: CancelUserDeletion :exec
+UPDATE users SET deletion_requested_at = NULL WHERE id = $1;
+
+-- name: ListUsersPendingDeletionBefore :many
+SELECT id FROM users WHERE deletion_requested_at IS NOT NULL AND deletion_requested_at < $1;
+
 -- name: DeleteUser :exec
 DELETE FROM users WHERE id = $1;
 
 -- name: CreateSession :one
-INSERT INTO sessions (user_id, expires_at)
-VALUES ($1, $2)
+INSERT INTO sessions (id, user_id, expires_at, two_factor_pending, public_id, user_agent, ip_address)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
 -- name: GetSessionByID :one
@@ -29,6 +44,7 @@ SELECT
     sessions.user_id AS session_user_id,
     sessions.expires_at AS session_expires_at,
     sessions.created_at AS session_created_at,
+    sessions.two_factor_pending AS session_two_factor_pending,
     users.id AS user_id,
     users.email AS user_email,
     users.name AS user_name
@@ -36,35 +52,136 @@ FROM sessions
 INNER JOIN users ON sessions.user_id = users.id
 WHERE sessions.id = $1;
 
+-- name: ListSessionsByUserID :many
+SELECT * FROM sessions
+WHERE user_id = $1 AND expires_at > NOW() AND two_factor_pending = FALSE
+ORDER BY last_seen_at DESC;
+
 -- name: UpdateSessionExpiresAt :exec
 UPDATE sessions SET expires_at = $2 WHERE id = $1;
 
+-- name: UpdateSessionLastSeenAt :exec
+UPDATE sessions SET last_seen_at = $2 WHERE id = $1;
+
 -- name: DeleteSession :exec
 DELETE FROM sessions WHERE id = $1;
 
 -- name: DeleteAllSessionsByUserID :exec
 DELETE FROM sessions WHERE user_id = $1;
 
+-- name: DeleteOtherSessionsByUserID :exec
+DELETE FROM sessions WHERE user_id = $1 AND id <> $2;
+
+-- name: DeleteSessionByPublicID :execrows
+DELETE FROM sessions WHERE user_id = $1 AND public_id = $2;
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions
+WHERE id IN (
+    SELECT id FROM sessions
+    WHERE expires_at < $1 OR last_seen_at < $2 OR created_at < $3
+    LIMIT $4
+);
+
 -- name: CreateNote :one
-INSERT INTO notes (id, user_id, title, content, is_archived, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
+INSERT INTO notes (id, user_id, title, content, is_archived, created_at, updated_at, position)
+VALUES ($1, $2, $3, $4, $5, $6, $7, (SELECT COALESCE(MIN(position), 0) - 1024 FROM notes WHERE user_id = $2))
 RETURNING *;
 
 -- name: GetNoteByID :one
-SELECT * FROM notes WHERE id = $1;
+SELECT * FROM notes WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: GetNoteByIDForUpdate :one
+SELECT * FROM notes WHERE id = $1 AND deleted_at IS NULL FOR UPDATE;
 
 -- name: GetNotesByUserID :many
-SELECT * FROM notes WHERE user_id = $1 AND is_archived = false ORDER BY updated_at DESC;
+SELECT * FROM notes WHERE user_id = $1 AND is_archived = false AND deleted_at IS NULL ORDER BY is_pinned DESC, position, id;
 
 -- name: GetArchivedNotesByUserID :many
-SELECT * FROM notes WHERE user_id = $1 AND is_archived = true ORDER BY updated_at DESC;
+SELECT * FROM notes WHERE user_id = $1 AND is_archived = true AND deleted_at IS NULL ORDER BY is_pinned DESC, position, id;
+
+-- name: GetTrashedNoteByID :one
+SELECT * FROM notes WHERE id = $1 AND deleted_at IS NOT NULL;
 
--- name: UpdateNote :exec
-UPDATE notes SET title = $2, content = $3, is_archived = $4, updated_at = $5 WHERE id = $1;
+-- name: GetTrashedNotesByUserID :many
+SELECT * FROM notes WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC;
+
+-- name: SearchNotes :many
+WITH matches AS (
+    SELECT
+        id, user_id, title, content, is_archived, is_pinned, type, created_at, updated_at,
+        ts_rank(to_tsvector('english', title || ' ' || content), to_tsquery('english', sqlc.arg(query)::text)) AS rank
+    FROM notes
+    WHERE user_id = sqlc.arg(user_id)
+        AND deleted_at IS NULL
+        AND (is_archived = false OR sqlc.arg(include_archived)::boolean)
+        AND to_tsvector('english', title || ' ' || content) @@ to_tsquery('english', sqlc.arg(query)::text)
+    ORDER BY rank DESC, updated_at DESC
+    LIMIT sqlc.arg(max_results)
+)
+SELECT
+    id, user_id, title, content, is_archived, is_pinned, type, created_at, updated_at, rank,
+    ts_headline('english', translate(title, chr(2) || chr(3), ''), to_tsquery('english', sqlc.arg(query)::text), 'StartSel="' || chr(2) || '", StopSel="' || chr(3) || '", HighlightAll=true')::text AS title_highlight,
+    ts_headline('english', translate(content, chr(2) || chr(3), ''), to_tsquery('english', sqlc.arg(query)::text), 'StartSel="' || chr(2) || '", StopSel="' || chr(3) || '", MaxFragments=2, MaxWords=30, MinWords=10')::text AS snippet
+FROM matches
+ORDER BY rank DESC, updated_at DESC;
+
+-- name: UpdateNote :execrows
+UPDATE notes
+SET title = $2, content = $3, is_archived = $4, updated_at = $5, type = $7, version = version + 1
+WHERE id = $1 AND version = $6;
+
+-- name: TouchNote :exec
+UPDATE notes SET updated_at = $2 WHERE id = $1;
+
+-- name: SetNotePinned :one
+UPDATE notes
+SET is_pinned = $2, position = (SELECT COALESCE(MIN(n.position), 0) - 1024 FROM notes n WHERE n.user_id = notes.user_id), version = version + 1
+WHERE id = $1
+RETURNING position, version;
+
+-- name: GetNotePositionBefore :one
+SELECT position FROM notes
+WHERE user_id = $1 AND is_archived = $2 AND is_pinned = $3 AND deleted_at IS NULL AND id <> $4 AND position < $5
+ORDER BY position DESC
+LIMIT 1;
+
+-- name: GetNotePositionAfter :one
+SELECT position FROM notes
+WHERE user_id = $1 AND is_archived = $2 AND is_pinned = $3 AND deleted_at IS NULL AND id <> $4 AND position > $5
+ORDER BY position
+LIMIT 1;
+
+-- name: UpdateNotePosition :exec
+UPDATE notes SET position = $2 WHERE id = $1;
+
+-- name: RebalanceNotePositions :exec
+UPDATE notes SET position = ordered.rank * 1024
+FROM (
+    SELECT id, ROW_NUMBER() OVER (ORDER BY is_pinned DESC, position, id) AS rank
+    FROM notes
+    WHERE user_id = $1
+) AS ordered
+WHERE notes.id = ordered.id;
+
+-- name: TrashNote :exec
+UPDATE notes SET deleted_at = $2 WHERE id = $1;
+
+-- name: RestoreNote :exec
+UPDATE notes SET deleted_at = NULL WHERE id = $1;
 
 -- name: DeleteNote :exec
 DELETE FROM notes WHERE id = $1;
 
+-- name: DeleteNotesByUserID :exec
+DELETE FROM notes WHERE user_id = $1;
+
+-- name: DeleteTrashedNotesByUserID :execrows
+DELETE FROM notes WHERE user_id = $1 AND deleted_at IS NOT NULL;
+
+-- name: DeleteNotesTrashedBefore :execrows
+DELETE FROM notes WHERE deleted_at < $1;
+
 -- name: CreateLabel :one
 INSERT INTO labels (id, user_id, name, color, created_at, updated_at)
 VALUES ($1, $2, $3, $4, $5, $6)
@@ -79,12 +196,20 @@ SELECT * FROM labels WHERE user_id = $1 ORDER BY name;
 -- name: GetLabelByName :one
 SELECT * FROM labels WHERE user_id = $1 AND name = $2;
 
--- name: UpdateLabel :exec
-UPDATE labels SET name = $2, color = $3, updated_at = $4 WHERE id = $1;
+-- name: UpdateLabel :execrows
+UPDATE labels
+SET name = $2, color = $3, updated_at = $4, version = version + 1
+WHERE id = $1 AND version = $5;
 
 -- name: DeleteLabel :exec
 DELETE FROM labels WHERE id = $1;
 
+-- name: DeleteNoteLabelsByUserID :exec
+DELETE FROM note_labels WHERE label_id IN (SELECT id FROM labels WHERE user_id = $1);
+
+-- name: DeleteLabelsByUserID :exec
+DELETE FROM labels WHERE user_id = $1;
+
 -- name: AddLabelToNote :exec
 INSERT INTO note_labels (note_id, label_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;
 
@@ -98,4 +223,291 @@ WHERE nl.note_id = $1
 ORDER BY l.name;
 
 -- name: GetNotesForLabel :many
-SELECT note_id FROM note_labels WHERE label_id = $1;
+SELECT nl.note_id FROM note_labels nl
+JOIN notes n ON n.id = nl.note_id
+WHERE nl.label_id = $1
+ORDER BY n.is_pinned DESC, n.position, n.id;
+
+-- name: CreateNoteRevision :one
+INSERT INTO note_revisions (id, note_id, revision, title, content, created_at)
+VALUES ($1, $2, (SELECT COALESCE(MAX(revision), 0) + 1 FROM note_revisions WHERE note_id = $2), $3, $4, $5)
+RETURNING *;
+
+-- name: GetNoteRevisions :many
+SELECT * FROM note_revisions WHERE note_id = $1 ORDER BY revision DESC;
+
+-- name: GetNoteRevision :one
+SELECT * FROM note_revisions WHERE note_id = $1 AND revision = $2;
+
+-- name: DeleteNoteRevisionsBeyond :exec
+DELETE FROM note_revisions
+WHERE note_id = $1
+    AND revision NOT IN (
+        SELECT revision FROM note_revisions WHERE note_id = $1 ORDER BY revision DESC LIMIT $2
+    );
+
+-- name: DeleteNoteRevisionsBefore :exec
+DELETE FROM note_revisions WHERE note_id = $1 AND created_at < $2;
+
+-- name: GetRevisionRetentionPolicy :one
+SELECT * FROM revision_retention_policies WHERE user_id = $1;
+
+-- name: UpsertRevisionRetentionPolicy :exec
+INSERT INTO revision_retention_policies (user_id, max_revisions, max_age_days, updated_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE
+SET max_revisions = EXCLUDED.max_revisions, max_age_days = EXCLUDED.max_age_days, updated_at = EXCLUDED.updated_at;
+
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (id, user_id, expires_at, created_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetPasswordResetTokenByID :one
+SELECT * FROM password_reset_tokens WHERE id = $1;
+
+-- name: GetLatestPasswordResetTokenByUserID :one
+SELECT * FROM password_reset_tokens WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1;
+
+-- name: MarkPasswordResetTokenUsed :execrows
+UPDATE password_reset_tokens SET used_at = NOW() WHERE id = $1 AND used_at IS NULL AND expires_at > NOW();
+
+-- name: DeletePasswordResetTokensByUserID :exec
+DELETE FROM password_reset_tokens WHERE user_id = $1;
+
+-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_tokens (id, user_id, expires_at, created_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetEmailVerificationTokenByID :one
+SELECT * FROM email_verification_tokens WHERE id = $1;
+
+-- name: GetLatestEmailVerificationTokenByUserID :one
+SELECT * FROM email_verification_tokens WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1;
+
+-- name: MarkEmailVerificationTokenUsed :execrows
+UPDATE email_verification_tokens SET used_at = NOW() WHERE id = $1 AND used_at IS NULL AND expires_at > NOW();
+
+-- name: DeleteEmailVerificationTokensByUserID :exec
+DELETE FROM email_verification_tokens WHERE user_id = $1;
+
+-- name: CreateEmailChangeRequest :exec
+INSERT INTO email_change_requests (id, user_id, new_email, expires_at, created_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetEmailChangeRequestByID :one
+SELECT * FROM email_change_requests WHERE id = $1;
+
+-- name: MarkEmailChangeRequestUsed :execrows
+UPDATE email_change_requests SET used_at = NOW() WHERE id = $1 AND used_at IS NULL AND expires_at > NOW();
+
+-- name: DeleteEmailChangeRequestsByUserID :exec
+DELETE FROM email_change_requests WHERE user_id = $1;
+
+-- name: UpsertTOTPCredential :exec
+INSERT INTO totp_credentials (user_id, secret, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, confirmed_at = NULL, last_used_step = 0, created_at = EXCLUDED.created_at;
+
+-- name: GetTOTPCredentialByUserID :one
+SELECT * FROM totp_credentials WHERE user_id = $1;
+
+-- name: ConfirmTOTPCredential :execrows
+UPDATE totp_credentials SET confirmed_at = $2 WHERE user_id = $1 AND confirmed_at IS NULL;
+
+-- name: UpdateTOTPCredentialLastUsedStep :execrows
+UPDATE totp_credentials SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2;
+
+-- name: DeleteTOTPCredentialByUserID :exec
+DELETE FROM totp_credentials WHERE user_id = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (id, user_id, created_at)
+VALUES ($1, $2, $3);
+
+-- name: MarkRecoveryCodeUsed :execrows
+UPDATE recovery_codes SET used_at = NOW() WHERE id = $1 AND user_id = $2 AND used_at IS NULL;
+
+-- name: CountUnusedRecoveryCodesByUserID :one
+SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodesByUserID :exec
+DELETE FROM recovery_codes WHERE user_id = $1;
+
+-- name: CreateFailedLoginAttempt :exec
+INSERT INTO failed_login_attempts (id, email, ip_address, user_agent, reason, created_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: CreatePersonalAccessToken :exec
+INSERT INTO personal_access_tokens (id, user_id, name, token_hash, scopes, expires_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: GetPersonalAccessTokenByHash :one
+SELECT * FROM personal_access_tokens WHERE token_hash = $1;
+
+-- name: GetPersonalAccessTokensByUserID :many
+SELECT * FROM personal_access_tokens WHERE user_id = $1 ORDER BY created_at DESC;
+
+-- name: UpdatePersonalAccessTokenLastUsedAt :exec
+UPDATE personal_access_tokens SET last_used_at = $2 WHERE id = $1;
+
+-- name: DeletePersonalAccessToken :execrows
+DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2;
+
+-- name: DeletePersonalAccessTokensByUserID :exec
+DELETE FROM personal_access_tokens WHERE user_id = $1;
+
+-- name: UpsertNoteShare :one
+INSERT INTO note_shares (id, note_id, user_id, role, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (note_id, user_id) DO UPDATE SET role = EXCLUDED.role, updated_at = EXCLUDED.updated_at
+RETURNING *;
+
+-- name: GetNoteShare :one
+SELECT * FROM note_shares WHERE note_id = $1 AND user_id = $2;
+
+-- name: GetNoteSharesByNoteID :many
+SELECT
+    note_shares.id,
+    note_shares.note_id,
+    note_shares.user_id,
+    note_shares.role,
+    note_shares.created_at,
+    note_shares.updated_at,
+    users.email AS user_email,
+    users.name AS user_name
+FROM note_shares
+INNER JOIN users ON note_shares.user_id = users.id
+WHERE note_shares.note_id = $1
+ORDER BY note_shares.created_at;
+
+-- name: GetNotesSharedWithUser :many
+SELECT
+    notes.id,
+    notes.user_id,
+    notes.title,
+    notes.content,
+    notes.is_archived,
+    notes.created_at,
+    notes.updated_at,
+    notes.version,
+    note_shares.role AS share_role
+FROM notes
+INNER JOIN note_shares ON note_shares.note_id = notes.id
+WHERE note_shares.user_id = $1 AND notes.deleted_at IS NULL
+ORDER BY notes.updated_at DESC;
+
+-- name: DeleteNoteShare :execrows
+DELETE FROM note_shares WHERE note_id = $1 AND user_id = $2;
+
+-- name: CreateShareLink :exec
+INSERT INTO share_links (id, note_id, token_hash, password_hash, expires_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetShareLinkByHash :one
+SELECT * FROM share_links WHERE token_hash = $1;
+
+-- name: GetShareLinksByNoteID :many
+SELECT * FROM share_links WHERE note_id = $1 ORDER BY created_at DESC;
+
+-- name: IncrementShareLinkViewCount :one
+UPDATE share_links SET view_count = view_count + 1 WHERE id = $1
+RETURNING view_count;
+
+-- name: DeleteShareLink :execrows
+DELETE FROM share_links WHERE id = $1 AND note_id = $2;
+
+-- name: DeleteShareLinksByNoteID :exec
+DELETE FROM share_links WHERE note_id = $1;
+
+-- name: CreateChecklistItem :one
+INSERT INTO checklist_items (id, note_id, text, is_checked, indent, created_at, updated_at, position)
+VALUES ($1, $2, $3, $4, $5, $6, $7, (SELECT COALESCE(MAX(position), 0) + 1024 FROM checklist_items WHERE note_id = $2))
+RETURNING position;
+
+-- name: GetChecklistItemByID :one
+SELECT * FROM checklist_items WHERE id = $1 AND note_id = $2;
+
+-- name: GetChecklistItemsByNoteID :many
+SELECT * FROM checklist_items WHERE note_id = $1 ORDER BY position, id;
+
+-- name: UpdateChecklistItem :exec
+UPDATE checklist_items SET text = $3, is_checked = $4, indent = $5, updated_at = $6 WHERE id = $1 AND note_id = $2;
+
+-- name: GetChecklistItemPositionBefore :one
+SELECT position FROM checklist_items
+WHERE note_id = $1 AND id <> $2 AND position < $3
+ORDER BY position DESC
+LIMIT 1;
+
+-- name: GetChecklistItemPositionAfter :one
+SELECT position FROM checklist_items
+WHERE note_id = $1 AND id <> $2 AND position > $3
+ORDER BY position
+LIMIT 1;
+
+-- name: UpdateChecklistItemPosition :exec
+UPDATE checklist_items SET position = $2 WHERE id = $1;
+
+-- name: RebalanceChecklistItemPositions :exec
+UPDATE checklist_items SET position = ordered.rank * 1024
+FROM (
+    SELECT id, ROW_NUMBER() OVER (ORDER BY position, id) AS rank
+    FROM checklist_items
+    WHERE note_id = $1
+) AS ordered
+WHERE checklist_items.id = ordered.id;
+
+-- name: MoveCheckedChecklistItemsToBottom :exec
+UPDATE checklist_items SET position = ordered.rank * 1024
+FROM (
+    SELECT id, ROW_NUMBER() OVER (ORDER BY is_checked, position, id) AS rank
+    FROM checklist_items
+    WHERE note_id = $1
+) AS ordered
+WHERE checklist_items.id = ordered.id;
+
+-- name: UncheckChecklistItems :execrows
+UPDATE checklist_items SET is_checked = false, updated_at = $2 WHERE note_id = $1 AND is_checked;
+
+-- name: DeleteChecklistItem :execrows
+DELETE FROM checklist_items WHERE id = $1 AND note_id = $2;
+
+-- name: DeleteChecklistItemsByNoteID :exec
+DELETE FROM checklist_items WHERE note_id = $1;
+
+-- name: CreateAttachment :exec
+INSERT INTO attachments (id, note_id, user_id, filename, content_type, size, checksum, storage_key, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: GetAttachmentByID :one
+SELECT * FROM attachments WHERE id = $1 AND note_id = $2;
+
+-- name: GetAttachmentsByNoteID :many
+SELECT * FROM attachments WHERE note_id = $1 ORDER BY created_at, id;
+
+-- name: GetAttachmentUsageByUserID :one
+SELECT COALESCE(SUM(a.size), 0)::BIGINT FROM attachments a
+JOIN notes n ON n.id = a.note_id
+WHERE n.user_id = $1;
+
+-- name: DeleteAttachment :execrows
+DELETE FROM attachments WHERE id = $1 AND note_id = $2;
+
+-- name: DeleteAttachmentsOfTrashedNotesByUserID :many
+DELETE FROM attachments WHERE note_id IN (
+    SELECT id FROM notes WHERE user_id = $1 AND deleted_at IS NOT NULL FOR UPDATE
+)
+RETURNING storage_key;
+
+-- name: DeleteAttachmentsOfNotesTrashedBefore :many
+DELETE FROM attachments WHERE note_id IN (
+    SELECT id FROM notes WHERE deleted_at < $1 FOR UPDATE
+)
+RETURNING storage_key;
+
+-- name: DeleteAttachmentsOfNotesByUserID :many
+DELETE FROM attachments WHERE note_id IN (
+    SELECT id FROM notes WHERE user_id = $1 FOR UPDATE
+)
+RETURNING storage_key;
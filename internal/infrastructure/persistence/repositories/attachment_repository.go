@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type AttachmentRepositoryImpl struct {
+	q *Queries
+}
+
+func NewAttachmentRepository(q *Queries) repositories.AttachmentRepository {
+	return &AttachmentRepositoryImpl{q: q}
+}
+
+func (r *AttachmentRepositoryImpl) Create(ctx context.Context, attachment *entities.Attachment) error {
+	// Parse the IDs
+	id, err := uuid.Parse(attachment.ID)
+	if err != nil {
+		return err
+	}
+	noteID, err := uuid.Parse(attachment.NoteID)
+	if err != nil {
+		return err
+	}
+	userID, err := uuid.Parse(attachment.UserID)
+	if err != nil {
+		return err
+	}
+
+	return r.q.CreateAttachment(ctx, CreateAttachmentParams{
+		ID:          id.String(),
+		NoteID:      noteID.String(),
+		UserID:      pgtype.Text{String: userID.String(), Valid: true},
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		Checksum:    attachment.Checksum,
+		StorageKey:  attachment.StorageKey,
+		CreatedAt:   attachment.CreatedAt,
+	})
+}
+
+func (r *AttachmentRepositoryImpl) GetByID(ctx context.Context, noteID, id string) (*entities.Attachment, error) {
+	parsedNoteID, err := uuid.Parse(noteID)
+	if err != nil {
+		return nil, err
+	}
+	attachmentID, err := uuid.Parse(id)
+	if err != nil {
+		// A malformed ID cannot match any row
+		return nil, nil
+	}
+
+	attachment, err := r.q.GetAttachmentByID(ctx, GetAttachmentByIDParams{
+		ID:     attachmentID.String(),
+		NoteID: parsedNoteID.String(),
+	})
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toAttachment(attachment), nil
+}
+
+func (r *AttachmentRepositoryImpl) GetByNoteID(ctx context.Context, noteID string) ([]*entities.Attachment, error) {
+	id, err := uuid.Parse(noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := r.q.GetAttachmentsByNoteID(ctx, id.String())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*entities.Attachment, len(attachments))
+	for i, attachment := range attachments {
+		result[i] = toAttachment(attachment)
+	}
+
+	return result, nil
+}
+
+func (r *AttachmentRepositoryImpl) GetUsageByUserID(ctx context.Context, userID string) (int64, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.q.GetAttachmentUsageByUserID(ctx, id.String())
+}
+
+func (r *AttachmentRepositoryImpl) Delete(ctx context.Context, noteID, id string) (bool, error) {
+	parsedNoteID, err := uuid.Parse(noteID)
+	if err != nil {
+		return false, err
+	}
+	attachmentID, err := uuid.Parse(id)
+	if err != nil {
+		// A malformed ID cannot match any row
+		return false, nil
+	}
+
+	deleted, err := r.q.DeleteAttachment(ctx, DeleteAttachmentParams{
+		ID:     attachmentID.String(),
+		NoteID: parsedNoteID.String(),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return deleted > 0, nil
+}
+
+func (r *AttachmentRepositoryImpl) DeleteAllByUserID(ctx context.Context, userID string) ([]string, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.q.DeleteAttachmentsOfNotesByUserID(ctx, id.String())
+}
+
+func (r *AttachmentRepositoryImpl) DeleteTrashedByUserID(ctx context.Context, userID string) ([]string, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.q.DeleteAttachmentsOfTrashedNotesByUserID(ctx, id.String())
+}
+
+func (r *AttachmentRepositoryImpl) DeleteTrashedBefore(ctx context.Context, cutoff time.Time) ([]string, error) {
+	return r.q.DeleteAttachmentsOfNotesTrashedBefore(ctx, pgtype.Timestamptz{Time: cutoff, Valid: true})
+}
+
+func toAttachment(attachment Attachment) *entities.Attachment {
+	return &entities.Attachment{
+		ID:          attachment.ID,
+		NoteID:      attachment.NoteID,
+		UserID:      attachment.UserID.String,
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		Checksum:    attachment.Checksum,
+		StorageKey:  attachment.StorageKey,
+		CreatedAt:   attachment.CreatedAt,
+	}
+}
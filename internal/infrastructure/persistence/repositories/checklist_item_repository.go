@@ -0,0 +1,228 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type ChecklistItemRepositoryImpl struct {
+	q *Queries
+}
+
+func NewChecklistItemRepository(q *Queries) repositories.ChecklistItemRepository {
+	return &ChecklistItemRepositoryImpl{q: q}
+}
+
+func (r *ChecklistItemRepositoryImpl) Create(ctx context.Context, item *entities.ChecklistItem) error {
+	// Parse the IDs
+	id, err := uuid.Parse(item.ID)
+	if err != nil {
+		return err
+	}
+	noteID, err := uuid.Parse(item.NoteID)
+	if err != nil {
+		return err
+	}
+
+	position, err := r.q.CreateChecklistItem(ctx, CreateChecklistItemParams{
+		ID:        id.String(),
+		NoteID:    noteID.String(),
+		Text:      item.Text,
+		IsChecked: item.IsChecked,
+		Indent:    int16(item.Indent),
+		CreatedAt: item.CreatedAt,
+		UpdatedAt: item.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	item.Position = position
+	return nil
+}
+
+func (r *ChecklistItemRepositoryImpl) GetByID(ctx context.Context, noteID, id string) (*entities.ChecklistItem, error) {
+	parsedNoteID, err := uuid.Parse(noteID)
+	if err != nil {
+		return nil, err
+	}
+	itemID, err := uuid.Parse(id)
+	if err != nil {
+		// A malformed ID cannot match any row
+		return nil, nil
+	}
+
+	item, err := r.q.GetChecklistItemByID(ctx, GetChecklistItemByIDParams{
+		ID:     itemID.String(),
+		NoteID: parsedNoteID.String(),
+	})
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toChecklistItem(item), nil
+}
+
+func (r *ChecklistItemRepositoryImpl) GetByNoteID(ctx context.Context, noteID string) ([]*entities.ChecklistItem, error) {
+	id, err := uuid.Parse(noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := r.q.GetChecklistItemsByNoteID(ctx, id.String())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*entities.ChecklistItem, len(items))
+	for i, item := range items {
+		result[i] = toChecklistItem(item)
+	}
+
+	return result, nil
+}
+
+func (r *ChecklistItemRepositoryImpl) Update(ctx context.Context, item *entities.ChecklistItem) error {
+	// Parse the IDs
+	id, err := uuid.Parse(item.ID)
+	if err != nil {
+		return err
+	}
+	noteID, err := uuid.Parse(item.NoteID)
+	if err != nil {
+		return err
+	}
+
+	return r.q.UpdateChecklistItem(ctx, UpdateChecklistItemParams{
+		ID:        id.String(),
+		NoteID:    noteID.String(),
+		Text:      item.Text,
+		IsChecked: item.IsChecked,
+		Indent:    int16(item.Indent),
+		UpdatedAt: item.UpdatedAt,
+	})
+}
+
+func (r *ChecklistItemRepositoryImpl) GetAdjacentPosition(ctx context.Context, item *entities.ChecklistItem, after bool, excludeID string) (*float64, error) {
+	noteID, err := uuid.Parse(item.NoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	var position float64
+	if after {
+		position, err = r.q.GetChecklistItemPositionAfter(ctx, GetChecklistItemPositionAfterParams{
+			NoteID:   noteID.String(),
+			ID:       excludeID,
+			Position: item.Position,
+		})
+	} else {
+		position, err = r.q.GetChecklistItemPositionBefore(ctx, GetChecklistItemPositionBeforeParams{
+			NoteID:   noteID.String(),
+			ID:       excludeID,
+			Position: item.Position,
+		})
+	}
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &position, nil
+}
+
+func (r *ChecklistItemRepositoryImpl) UpdatePosition(ctx context.Context, id string, position float64) error {
+	itemID, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+
+	return r.q.UpdateChecklistItemPosition(ctx, UpdateChecklistItemPositionParams{
+		ID:       itemID.String(),
+		Position: position,
+	})
+}
+
+func (r *ChecklistItemRepositoryImpl) RebalancePositions(ctx context.Context, noteID string) error {
+	id, err := uuid.Parse(noteID)
+	if err != nil {
+		return err
+	}
+
+	return r.q.RebalanceChecklistItemPositions(ctx, id.String())
+}
+
+func (r *ChecklistItemRepositoryImpl) MoveCheckedToBottom(ctx context.Context, noteID string) error {
+	id, err := uuid.Parse(noteID)
+	if err != nil {
+		return err
+	}
+
+	return r.q.MoveCheckedChecklistItemsToBottom(ctx, id.String())
+}
+
+func (r *ChecklistItemRepositoryImpl) UncheckAll(ctx context.Context, noteID string, at time.Time) (int64, error) {
+	id, err := uuid.Parse(noteID)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.q.UncheckChecklistItems(ctx, UncheckChecklistItemsParams{
+		NoteID:    id.String(),
+		UpdatedAt: at,
+	})
+}
+
+func (r *ChecklistItemRepositoryImpl) Delete(ctx context.Context, noteID, id string) (bool, error) {
+	parsedNoteID, err := uuid.Parse(noteID)
+	if err != nil {
+		return false, err
+	}
+	itemID, err := uuid.Parse(id)
+	if err != nil {
+		// A malformed ID cannot match any row
+		return false, nil
+	}
+
+	deleted, err := r.q.DeleteChecklistItem(ctx, DeleteChecklistItemParams{
+		ID:     itemID.String(),
+		NoteID: parsedNoteID.String(),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return deleted > 0, nil
+}
+
+func (r *ChecklistItemRepositoryImpl) DeleteByNoteID(ctx context.Context, noteID string) error {
+	id, err := uuid.Parse(noteID)
+	if err != nil {
+		return err
+	}
+
+	return r.q.DeleteChecklistItemsByNoteID(ctx, id.String())
+}
+
+func toChecklistItem(item ChecklistItem) *entities.ChecklistItem {
+	return &entities.ChecklistItem{
+		ID:        item.ID,
+		NoteID:    item.NoteID,
+		Text:      item.Text,
+		IsChecked: item.IsChecked,
+		Position:  item.Position,
+		Indent:    int(item.Indent),
+		CreatedAt: item.CreatedAt,
+		UpdatedAt: item.UpdatedAt,
+	}
+}
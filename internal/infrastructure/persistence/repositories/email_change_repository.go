@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type EmailChangeRepositoryImpl struct {
+	q *Queries
+}
+
+func NewEmailChangeRepository(q *Queries) repositories.EmailChangeRepository {
+	return &EmailChangeRepositoryImpl{q: q}
+}
+
+func (r *EmailChangeRepositoryImpl) Create(ctx context.Context, request *entities.EmailChangeRequest) error {
+	// Parse the user ID
+	userID, err := uuid.Parse(request.UserID)
+	if err != nil {
+		return err
+	}
+
+	// The request ID is a hash, not a UUID
+	return r.q.CreateEmailChangeRequest(ctx, CreateEmailChangeRequestParams{
+		ID:        request.ID,
+		UserID:    userID.String(),
+		NewEmail:  request.NewEmail,
+		ExpiresAt: request.ExpiresAt,
+		CreatedAt: request.CreatedAt,
+	})
+}
+
+func (r *EmailChangeRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.EmailChangeRequest, error) {
+	request, err := r.q.GetEmailChangeRequestByID(ctx, id)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &entities.EmailChangeRequest{
+		ID:        request.ID,
+		UserID:    request.UserID,
+		NewEmail:  request.NewEmail,
+		ExpiresAt: request.ExpiresAt,
+		UsedAt:    timePtr(request.UsedAt),
+		CreatedAt: request.CreatedAt,
+	}, nil
+}
+
+func (r *EmailChangeRepositoryImpl) MarkUsed(ctx context.Context, id string) (bool, error) {
+	updated, err := r.q.MarkEmailChangeRequestUsed(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	return updated == 1, nil
+}
+
+func (r *EmailChangeRepositoryImpl) DeleteAllByUserID(ctx context.Context, userID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+
+	return r.q.DeleteEmailChangeRequestsByUserID(ctx, id.String())
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type EmailVerificationRepositoryImpl struct {
+	q *Queries
+}
+
+func NewEmailVerificationRepository(q *Queries) repositories.EmailVerificationRepository {
+	return &EmailVerificationRepositoryImpl{q: q}
+}
+
+func (r *EmailVerificationRepositoryImpl) Create(ctx context.Context, token *entities.EmailVerificationToken) error {
+	// Parse the user ID
+	userID, err := uuid.Parse(token.UserID)
+	if err != nil {
+		return err
+	}
+
+	// The token ID is a hash, not a UUID
+	return r.q.CreateEmailVerificationToken(ctx, CreateEmailVerificationTokenParams{
+		ID:        token.ID,
+		UserID:    userID.String(),
+		ExpiresAt: token.ExpiresAt,
+		CreatedAt: token.CreatedAt,
+	})
+}
+
+func (r *EmailVerificationRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.EmailVerificationToken, error) {
+	token, err := r.q.GetEmailVerificationTokenByID(ctx, id)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toEmailVerificationToken(token), nil
+}
+
+func (r *EmailVerificationRepositoryImpl) GetLatestByUserID(ctx context.Context, userID string) (*entities.EmailVerificationToken, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		// A malformed ID cannot match any row
+		return nil, nil
+	}
+
+	token, err := r.q.GetLatestEmailVerificationTokenByUserID(ctx, id.String())
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toEmailVerificationToken(token), nil
+}
+
+func (r *EmailVerificationRepositoryImpl) MarkUsed(ctx context.Context, id string) (bool, error) {
+	updated, err := r.q.MarkEmailVerificationTokenUsed(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	return updated == 1, nil
+}
+
+func (r *EmailVerificationRepositoryImpl) DeleteAllByUserID(ctx context.Context, userID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+
+	return r.q.DeleteEmailVerificationTokensByUserID(ctx, id.String())
+}
+
+func toEmailVerificationToken(token EmailVerificationToken) *entities.EmailVerificationToken {
+	return &entities.EmailVerificationToken{
+		ID:        token.ID,
+		UserID:    token.UserID,
+		ExpiresAt: token.ExpiresAt,
+		UsedAt:    timePtr(token.UsedAt),
+		CreatedAt: token.CreatedAt,
+	}
+}
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+)
+
+// uniqueViolationCode is the SQLSTATE raised when a unique constraint fails
+const uniqueViolationCode = "23505"
+
+// isNoRows reports whether a single row query matched nothing
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// translateUniqueViolation turns a unique constraint violation into a domain
+// conflict error and leaves any other error untouched
+func translateUniqueViolation(err error, resource, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return &domainerrors.ConflictError{Resource: resource, Message: message, Err: err}
+	}
+	return err
+}
@@ -40,19 +40,25 @@ func (r *LabelRepositoryImpl) Create(ctx context.Context, label *entities.Label)
 		UpdatedAt: label.UpdatedAt,
 	}
 
-	_, err = r.q.CreateLabel(ctx, params)
-	return err
+	created, err := r.q.CreateLabel(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	label.Version = int(created.Version)
+	return nil
 }
 
 func (r *LabelRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Label, error) {
 	labelID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, err
+		// A malformed ID cannot match any row
+		return nil, nil
 	}
 
 	label, err := r.q.GetLabelByID(ctx, labelID.String())
 	if err != nil {
-		if err.Error() == "no rows in result set" {
+		if isNoRows(err) {
 			return nil, nil
 		}
 		return nil, err
@@ -65,6 +71,7 @@ func (r *LabelRepositoryImpl) GetByID(ctx context.Context, id string) (*entities
 		Color:     label.Color,
 		CreatedAt: label.CreatedAt,
 		UpdatedAt: label.UpdatedAt,
+		Version:   int(label.Version),
 	}, nil
 }
 
@@ -88,6 +95,7 @@ func (r *LabelRepositoryImpl) GetByUserID(ctx context.Context, userID string) ([
 			Color:     label.Color,
 			CreatedAt: label.CreatedAt,
 			UpdatedAt: label.UpdatedAt,
+			Version:   int(label.Version),
 		}
 	}
 
@@ -107,7 +115,7 @@ func (r *LabelRepositoryImpl) GetByName(ctx context.Context, userID, name string
 
 	label, err := r.q.GetLabelByName(ctx, params)
 	if err != nil {
-		if err.Error() == "no rows in result set" {
+		if isNoRows(err) {
 			return nil, nil
 		}
 		return nil, err
@@ -120,6 +128,7 @@ func (r *LabelRepositoryImpl) GetByName(ctx context.Context, userID, name string
 		Color:     label.Color,
 		CreatedAt: label.CreatedAt,
 		UpdatedAt: label.UpdatedAt,
+		Version:   int(label.Version),
 	}, nil
 }
 
@@ -134,9 +143,20 @@ func (r *LabelRepositoryImpl) Update(ctx context.Context, label *entities.Label)
 		Name:      label.Name,
 		Color:     label.Color,
 		UpdatedAt: time.Now(),
+		Version:   int32(label.Version),
+	}
+
+	// Only write if nobody saved the label since it was read
+	updated, err := r.q.UpdateLabel(ctx, params)
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return repositories.ErrVersionConflict
 	}
 
-	return r.q.UpdateLabel(ctx, params)
+	label.Version++
+	return nil
 }
 
 func (r *LabelRepositoryImpl) Delete(ctx context.Context, id string) error {
@@ -148,6 +168,20 @@ func (r *LabelRepositoryImpl) Delete(ctx context.Context, id string) error {
 	return r.q.DeleteLabel(ctx, labelID.String())
 }
 
+func (r *LabelRepositoryImpl) DeleteAllByUserID(ctx context.Context, userID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+
+	// Detach the labels from notes first
+	if err := r.q.DeleteNoteLabelsByUserID(ctx, id.String()); err != nil {
+		return err
+	}
+
+	return r.q.DeleteLabelsByUserID(ctx, id.String())
+}
+
 func (r *LabelRepositoryImpl) AddLabelToNote(ctx context.Context, noteID, labelID string) error {
 	noteUUID, err := uuid.Parse(noteID)
 	if err != nil {
@@ -206,6 +240,7 @@ func (r *LabelRepositoryImpl) GetLabelsForNote(ctx context.Context, noteID strin
 			Color:     label.Color,
 			CreatedAt: label.CreatedAt,
 			UpdatedAt: label.UpdatedAt,
+			Version:   int(label.Version),
 		}
 	}
 
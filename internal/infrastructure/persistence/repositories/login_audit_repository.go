@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type LoginAuditRepositoryImpl struct {
+	q *Queries
+}
+
+func NewLoginAuditRepository(q *Queries) repositories.LoginAuditRepository {
+	return &LoginAuditRepositoryImpl{q: q}
+}
+
+func (r *LoginAuditRepositoryImpl) RecordFailure(ctx context.Context, attempt *entities.FailedLoginAttempt) error {
+	return r.q.CreateFailedLoginAttempt(ctx, CreateFailedLoginAttemptParams{
+		ID:        attempt.ID,
+		Email:     attempt.Email,
+		IpAddress: attempt.IPAddress,
+		UserAgent: attempt.UserAgent,
+		Reason:    string(attempt.Reason),
+		CreatedAt: attempt.CreatedAt,
+	})
+}
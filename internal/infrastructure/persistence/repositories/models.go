@@ -6,8 +6,59 @@ package repositories
 
 import (
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Attachment struct {
+	ID          string      `json:"id"`
+	NoteID      string      `json:"note_id"`
+	UserID      pgtype.Text `json:"user_id"`
+	Filename    string      `json:"filename"`
+	ContentType string      `json:"content_type"`
+	Size        int64       `json:"size"`
+	Checksum    string      `json:"checksum"`
+	StorageKey  string      `json:"storage_key"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+type ChecklistItem struct {
+	ID        string    `json:"id"`
+	NoteID    string    `json:"note_id"`
+	Text      string    `json:"text"`
+	IsChecked bool      `json:"is_checked"`
+	Position  float64   `json:"position"`
+	Indent    int16     `json:"indent"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type EmailChangeRequest struct {
+	ID        string             `json:"id"`
+	UserID    string             `json:"user_id"`
+	NewEmail  string             `json:"new_email"`
+	ExpiresAt time.Time          `json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type EmailVerificationToken struct {
+	ID        string             `json:"id"`
+	UserID    string             `json:"user_id"`
+	ExpiresAt time.Time          `json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type FailedLoginAttempt struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	IpAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Label struct {
 	ID        string    `json:"id"`
 	UserID    string    `json:"user_id"`
@@ -15,16 +66,22 @@ type Label struct {
 	Color     string    `json:"color"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Version   int32     `json:"version"`
 }
 
 type Note struct {
-	ID         string    `json:"id"`
-	UserID     string    `json:"user_id"`
-	Title      string    `json:"title"`
-	Content    string    `json:"content"`
-	IsArchived bool      `json:"is_archived"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         string             `json:"id"`
+	UserID     string             `json:"user_id"`
+	Title      string             `json:"title"`
+	Content    string             `json:"content"`
+	IsArchived bool               `json:"is_archived"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+	DeletedAt  pgtype.Timestamptz `json:"deleted_at"`
+	Version    int32              `json:"version"`
+	IsPinned   bool               `json:"is_pinned"`
+	Position   float64            `json:"position"`
+	Type       string             `json:"type"`
 }
 
 type NoteLabel struct {
@@ -32,18 +89,94 @@ type NoteLabel struct {
 	LabelID string `json:"label_id"`
 }
 
-type Session struct {
+type NoteRevision struct {
 	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	ExpiresAt time.Time `json:"expires_at"`
+	NoteID    string    `json:"note_id"`
+	Revision  int32     `json:"revision"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
-type User struct {
+type NoteShare struct {
 	ID        string    `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	Password  string    `json:"password"`
+	NoteID    string    `json:"note_id"`
+	UserID    string    `json:"user_id"`
+	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+type PasswordResetToken struct {
+	ID        string             `json:"id"`
+	UserID    string             `json:"user_id"`
+	ExpiresAt time.Time          `json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type PersonalAccessToken struct {
+	ID         string             `json:"id"`
+	UserID     string             `json:"user_id"`
+	Name       string             `json:"name"`
+	TokenHash  string             `json:"token_hash"`
+	Scopes     []string           `json:"scopes"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+type RecoveryCode struct {
+	ID        string             `json:"id"`
+	UserID    string             `json:"user_id"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type RevisionRetentionPolicy struct {
+	UserID       string    `json:"user_id"`
+	MaxRevisions int32     `json:"max_revisions"`
+	MaxAgeDays   int32     `json:"max_age_days"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type Session struct {
+	ID               string    `json:"id"`
+	UserID           string    `json:"user_id"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	CreatedAt        time.Time `json:"created_at"`
+	TwoFactorPending bool      `json:"two_factor_pending"`
+	PublicID         string    `json:"public_id"`
+	UserAgent        string    `json:"user_agent"`
+	IpAddress        string    `json:"ip_address"`
+	LastSeenAt       time.Time `json:"last_seen_at"`
+}
+
+type ShareLink struct {
+	ID           string             `json:"id"`
+	NoteID       string             `json:"note_id"`
+	TokenHash    string             `json:"token_hash"`
+	PasswordHash pgtype.Text        `json:"password_hash"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	ViewCount    int64              `json:"view_count"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+type TotpCredential struct {
+	UserID       string             `json:"user_id"`
+	Secret       string             `json:"secret"`
+	ConfirmedAt  pgtype.Timestamptz `json:"confirmed_at"`
+	LastUsedStep int64              `json:"last_used_step"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+type User struct {
+	ID                  string             `json:"id"`
+	Email               string             `json:"email"`
+	Name                string             `json:"name"`
+	Password            string             `json:"password"`
+	CreatedAt           time.Time          `json:"created_at"`
+	UpdatedAt           time.Time          `json:"updated_at"`
+	EmailVerifiedAt     pgtype.Timestamptz `json:"email_verified_at"`
+	DeletionRequestedAt pgtype.Timestamptz `json:"deletion_requested_at"`
+}
@@ -2,9 +2,15 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 
 	"github.com/LaulauChau/note-nest/internal/domain/entities"
 	"github.com/LaulauChau/note-nest/internal/domain/repositories"
@@ -41,33 +47,51 @@ func (r *NoteRepositoryImpl) Create(ctx context.Context, note *entities.Note) er
 		UpdatedAt:  note.UpdatedAt,
 	}
 
-	_, err = r.q.CreateNote(ctx, params)
-	return err
+	created, err := r.q.CreateNote(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	note.Version = int(created.Version)
+	note.Position = created.Position
+	note.Type = entities.NoteType(created.Type)
+	return nil
 }
 
 func (r *NoteRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Note, error) {
 	noteID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, err
+		// A malformed ID cannot match any row
+		return nil, nil
 	}
 
 	note, err := r.q.GetNoteByID(ctx, noteID.String())
 	if err != nil {
-		if err.Error() == "no rows in result set" {
+		if isNoRows(err) {
 			return nil, nil
 		}
 		return nil, err
 	}
 
-	return &entities.Note{
-		ID:         note.ID,
-		UserID:     note.UserID,
-		Title:      note.Title,
-		Content:    note.Content,
-		IsArchived: note.IsArchived,
-		CreatedAt:  note.CreatedAt,
-		UpdatedAt:  note.UpdatedAt,
-	}, nil
+	return toNote(note), nil
+}
+
+func (r *NoteRepositoryImpl) GetByIDForUpdate(ctx context.Context, id string) (*entities.Note, error) {
+	noteID, err := uuid.Parse(id)
+	if err != nil {
+		// A malformed ID cannot match any row
+		return nil, nil
+	}
+
+	note, err := r.q.GetNoteByIDForUpdate(ctx, noteID.String())
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toNote(note), nil
 }
 
 func (r *NoteRepositoryImpl) GetByUserID(ctx context.Context, userID string) ([]*entities.Note, error) {
@@ -91,6 +115,10 @@ func (r *NoteRepositoryImpl) GetByUserID(ctx context.Context, userID string) ([]
 			IsArchived: note.IsArchived,
 			CreatedAt:  note.CreatedAt,
 			UpdatedAt:  note.UpdatedAt,
+			Version:    int(note.Version),
+			IsPinned:   note.IsPinned,
+			Position:   note.Position,
+			Type:       entities.NoteType(note.Type),
 		}
 	}
 
@@ -118,6 +146,212 @@ func (r *NoteRepositoryImpl) GetArchivedByUserID(ctx context.Context, userID str
 			IsArchived: note.IsArchived,
 			CreatedAt:  note.CreatedAt,
 			UpdatedAt:  note.UpdatedAt,
+			Version:    int(note.Version),
+			IsPinned:   note.IsPinned,
+			Position:   note.Position,
+			Type:       entities.NoteType(note.Type),
+		}
+	}
+
+	return result, nil
+}
+
+// noteSortColumns maps the allowed sort fields to the columns they order by
+var noteSortColumns = map[entities.NoteSortField][]string{
+	entities.NoteSortByCreatedAt: {"created_at"},
+	entities.NoteSortByUpdatedAt: {"updated_at"},
+	entities.NoteSortByTitle:     {"title"},
+	// Pinned notes first, false sorting before true
+	entities.NoteSortByPosition: {"NOT is_pinned", "position"},
+}
+
+func (r *NoteRepositoryImpl) List(ctx context.Context, filter entities.NoteFilter) ([]*entities.Note, error) {
+	userUUID, err := uuid.Parse(filter.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, ok := noteSortColumns[filter.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort field %q", filter.SortBy)
+	}
+
+	// Use manual query - the filters are optional so the statement is built dynamically
+	// Checklist items are counted along, they are 0 for text notes
+	query := "SELECT id, user_id, title, content, is_archived, created_at, updated_at, version, is_pinned, position, type, items.total, items.checked FROM notes" +
+		" LEFT JOIN LATERAL (SELECT COUNT(*) AS total, COUNT(*) FILTER (WHERE is_checked) AS checked FROM checklist_items WHERE note_id = notes.id) AS items ON true" +
+		" WHERE user_id = $1 AND deleted_at IS NULL"
+	args := []interface{}{userUUID.String()}
+
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		query += fmt.Sprintf(" AND "+condition, len(args))
+	}
+
+	if filter.IsArchived != nil {
+		addCondition("is_archived = $%d", *filter.IsArchived)
+	}
+	if len(filter.LabelIDs) > 0 {
+		addCondition("EXISTS (SELECT 1 FROM note_labels nl WHERE nl.note_id = notes.id AND nl.label_id = ANY($%d))", filter.LabelIDs)
+	}
+	if filter.CreatedAfter != nil {
+		addCondition("created_at >= $%d", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		addCondition("created_at < $%d", *filter.CreatedBefore)
+	}
+	if filter.UpdatedAfter != nil {
+		addCondition("updated_at >= $%d", *filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		addCondition("updated_at < $%d", *filter.UpdatedBefore)
+	}
+
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	// Keyset pagination: continue strictly after the last row of the previous page
+	if filter.Cursor != nil {
+		sortValues, err := noteCursorValues(filter.SortBy, filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		placeholders := make([]string, 0, len(sortValues)+1)
+		for _, value := range append(sortValues, filter.Cursor.ID) {
+			args = append(args, value)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		}
+		query += fmt.Sprintf(" AND (%s, id) %s (%s)", strings.Join(columns, ", "), comparison, strings.Join(placeholders, ", "))
+	}
+
+	orderBy := make([]string, 0, len(columns)+1)
+	for _, column := range columns {
+		orderBy = append(orderBy, column+" "+direction)
+	}
+	orderBy = append(orderBy, "id "+direction)
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d", strings.Join(orderBy, ", "), len(args))
+
+	rows, err := r.q.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*entities.Note{}
+	for rows.Next() {
+		var note Note
+		var itemCount, checkedCount int64
+		if err := rows.Scan(
+			&note.ID,
+			&note.UserID,
+			&note.Title,
+			&note.Content,
+			&note.IsArchived,
+			&note.CreatedAt,
+			&note.UpdatedAt,
+			&note.Version,
+			&note.IsPinned,
+			&note.Position,
+			&note.Type,
+			&itemCount,
+			&checkedCount,
+		); err != nil {
+			return nil, err
+		}
+
+		var checklist *entities.ChecklistProgress
+		if entities.NoteType(note.Type) == entities.NoteTypeChecklist {
+			checklist = &entities.ChecklistProgress{Total: int(itemCount), Checked: int(checkedCount)}
+		}
+
+		result = append(result, &entities.Note{
+			ID:         note.ID,
+			UserID:     note.UserID,
+			Title:      note.Title,
+			Content:    note.Content,
+			IsArchived: note.IsArchived,
+			CreatedAt:  note.CreatedAt,
+			UpdatedAt:  note.UpdatedAt,
+			Version:    int(note.Version),
+			IsPinned:   note.IsPinned,
+			Position:   note.Position,
+			Type:       entities.NoteType(note.Type),
+			Checklist:  checklist,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// noteCursorValues converts the sort value of a cursor back to the values of
+// the sort columns
+func noteCursorValues(sortBy entities.NoteSortField, cursor *entities.NoteCursor) ([]interface{}, error) {
+	switch sortBy {
+	case entities.NoteSortByTitle:
+		return []interface{}{cursor.SortValue}, nil
+	case entities.NoteSortByPosition:
+		position, err := strconv.ParseFloat(cursor.SortValue, 64)
+		if err != nil {
+			return nil, errors.New("invalid cursor")
+		}
+		return []interface{}{!cursor.Pinned, position}, nil
+	default:
+		sortValue, err := time.Parse(time.RFC3339Nano, cursor.SortValue)
+		if err != nil {
+			return nil, errors.New("invalid cursor")
+		}
+		return []interface{}{sortValue}, nil
+	}
+}
+
+func (r *NoteRepositoryImpl) Search(ctx context.Context, userID, query string, includeArchived bool, limit int) ([]*entities.NoteSearchResult, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nothing searchable left after sanitizing the input
+	tsQuery := buildTSQuery(query)
+	if tsQuery == "" {
+		return []*entities.NoteSearchResult{}, nil
+	}
+
+	params := SearchNotesParams{
+		Query:           tsQuery,
+		UserID:          userUUID.String(),
+		IncludeArchived: includeArchived,
+		MaxResults:      int32(limit),
+	}
+
+	rows, err := r.q.SearchNotes(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*entities.NoteSearchResult, len(rows))
+	for i, row := range rows {
+		result[i] = &entities.NoteSearchResult{
+			Note: &entities.Note{
+				ID:         row.ID,
+				UserID:     row.UserID,
+				Title:      row.Title,
+				Content:    row.Content,
+				Type:       entities.NoteType(row.Type),
+				IsArchived: row.IsArchived,
+				IsPinned:   row.IsPinned,
+				CreatedAt:  row.CreatedAt,
+				UpdatedAt:  row.UpdatedAt,
+			},
+			Rank:           row.Rank,
+			TitleHighlight: row.TitleHighlight,
+			Snippet:        row.Snippet,
 		}
 	}
 
@@ -136,9 +370,108 @@ func (r *NoteRepositoryImpl) Update(ctx context.Context, note *entities.Note) er
 		Content:    note.Content,
 		IsArchived: note.IsArchived,
 		UpdatedAt:  time.Now(),
+		Version:    int32(note.Version),
+		Type:       string(note.Type),
+	}
+
+	// Only write if nobody saved the note since it was read
+	updated, err := r.q.UpdateNote(ctx, params)
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return repositories.ErrVersionConflict
 	}
 
-	return r.q.UpdateNote(ctx, params)
+	note.Version++
+	return nil
+}
+
+func (r *NoteRepositoryImpl) Touch(ctx context.Context, id string, at time.Time) error {
+	noteID, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+
+	return r.q.TouchNote(ctx, TouchNoteParams{
+		ID:        noteID.String(),
+		UpdatedAt: at,
+	})
+}
+
+func (r *NoteRepositoryImpl) SetPinned(ctx context.Context, note *entities.Note, pinned bool) error {
+	noteID, err := uuid.Parse(note.ID)
+	if err != nil {
+		return err
+	}
+
+	updated, err := r.q.SetNotePinned(ctx, SetNotePinnedParams{
+		ID:       noteID.String(),
+		IsPinned: pinned,
+	})
+	if err != nil {
+		return err
+	}
+
+	note.IsPinned = pinned
+	note.Position = updated.Position
+	note.Version = int(updated.Version)
+	return nil
+}
+
+func (r *NoteRepositoryImpl) GetAdjacentPosition(ctx context.Context, note *entities.Note, after bool, excludeID string) (*float64, error) {
+	userID, err := uuid.Parse(note.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var position float64
+	if after {
+		position, err = r.q.GetNotePositionAfter(ctx, GetNotePositionAfterParams{
+			UserID:     userID.String(),
+			IsArchived: note.IsArchived,
+			IsPinned:   note.IsPinned,
+			ID:         excludeID,
+			Position:   note.Position,
+		})
+	} else {
+		position, err = r.q.GetNotePositionBefore(ctx, GetNotePositionBeforeParams{
+			UserID:     userID.String(),
+			IsArchived: note.IsArchived,
+			IsPinned:   note.IsPinned,
+			ID:         excludeID,
+			Position:   note.Position,
+		})
+	}
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &position, nil
+}
+
+func (r *NoteRepositoryImpl) UpdatePosition(ctx context.Context, id string, position float64) error {
+	noteID, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+
+	return r.q.UpdateNotePosition(ctx, UpdateNotePositionParams{
+		ID:       noteID.String(),
+		Position: position,
+	})
+}
+
+func (r *NoteRepositoryImpl) RebalancePositions(ctx context.Context, userID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+
+	return r.q.RebalanceNotePositions(ctx, id.String())
 }
 
 func (r *NoteRepositoryImpl) Delete(ctx context.Context, id string) error {
@@ -149,3 +482,180 @@ func (r *NoteRepositoryImpl) Delete(ctx context.Context, id string) error {
 
 	return r.q.DeleteNote(ctx, noteID.String())
 }
+
+func (r *NoteRepositoryImpl) DeleteAllByUserID(ctx context.Context, userID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+
+	return r.q.DeleteNotesByUserID(ctx, id.String())
+}
+
+func (r *NoteRepositoryImpl) Trash(ctx context.Context, id string, deletedAt time.Time) error {
+	noteID, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+
+	params := TrashNoteParams{
+		ID:        noteID.String(),
+		DeletedAt: pgtype.Timestamptz{Time: deletedAt, Valid: true},
+	}
+
+	return r.q.TrashNote(ctx, params)
+}
+
+func (r *NoteRepositoryImpl) Restore(ctx context.Context, id string) error {
+	noteID, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+
+	return r.q.RestoreNote(ctx, noteID.String())
+}
+
+func (r *NoteRepositoryImpl) GetTrashedByID(ctx context.Context, id string) (*entities.Note, error) {
+	noteID, err := uuid.Parse(id)
+	if err != nil {
+		// A malformed ID cannot match any row
+		return nil, nil
+	}
+
+	note, err := r.q.GetTrashedNoteByID(ctx, noteID.String())
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &entities.Note{
+		ID:         note.ID,
+		UserID:     note.UserID,
+		Title:      note.Title,
+		Content:    note.Content,
+		IsArchived: note.IsArchived,
+		CreatedAt:  note.CreatedAt,
+		UpdatedAt:  note.UpdatedAt,
+		DeletedAt:  timePtr(note.DeletedAt),
+		Version:    int(note.Version),
+		IsPinned:   note.IsPinned,
+		Position:   note.Position,
+		Type:       entities.NoteType(note.Type),
+	}, nil
+}
+
+func (r *NoteRepositoryImpl) GetTrashedByUserID(ctx context.Context, userID string) ([]*entities.Note, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := r.q.GetTrashedNotesByUserID(ctx, userUUID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*entities.Note, len(notes))
+	for i, note := range notes {
+		result[i] = &entities.Note{
+			ID:         note.ID,
+			UserID:     note.UserID,
+			Title:      note.Title,
+			Content:    note.Content,
+			IsArchived: note.IsArchived,
+			CreatedAt:  note.CreatedAt,
+			UpdatedAt:  note.UpdatedAt,
+			DeletedAt:  timePtr(note.DeletedAt),
+			Version:    int(note.Version),
+			IsPinned:   note.IsPinned,
+			Position:   note.Position,
+			Type:       entities.NoteType(note.Type),
+		}
+	}
+
+	return result, nil
+}
+
+func (r *NoteRepositoryImpl) DeleteTrashedByUserID(ctx context.Context, userID string) (int64, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.q.DeleteTrashedNotesByUserID(ctx, userUUID.String())
+}
+
+func (r *NoteRepositoryImpl) DeleteTrashedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	return r.q.DeleteNotesTrashedBefore(ctx, pgtype.Timestamptz{Time: cutoff, Valid: true})
+}
+
+// timePtr converts a nullable timestamp to a pointer, nil meaning NULL
+func timePtr(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// buildTSQuery converts a user supplied search string into a to_tsquery expression.
+// Quoted text becomes a phrase query, a trailing * turns a word into a prefix match
+// and every other term must be present. Anything that is not a letter or a digit is
+// dropped so the result is always a valid tsquery.
+func buildTSQuery(input string) string {
+	var terms []string
+
+	for i, segment := range strings.Split(input, `"`) {
+		// Odd segments are inside quotes
+		if i%2 == 1 {
+			if phrase := strings.Join(lexemes(segment), " <-> "); phrase != "" {
+				terms = append(terms, "("+phrase+")")
+			}
+			continue
+		}
+
+		for _, word := range strings.Fields(segment) {
+			prefix := strings.HasSuffix(word, "*")
+
+			parts := lexemes(word)
+			if len(parts) == 0 {
+				continue
+			}
+			if prefix {
+				parts[len(parts)-1] += ":*"
+			}
+
+			if len(parts) == 1 {
+				terms = append(terms, parts[0])
+			} else {
+				terms = append(terms, "("+strings.Join(parts, " <-> ")+")")
+			}
+		}
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+// lexemes splits text into runs of letters and digits
+func lexemes(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func toNote(note Note) *entities.Note {
+	return &entities.Note{
+		ID:         note.ID,
+		UserID:     note.UserID,
+		Title:      note.Title,
+		Content:    note.Content,
+		IsArchived: note.IsArchived,
+		CreatedAt:  note.CreatedAt,
+		UpdatedAt:  note.UpdatedAt,
+		Version:    int(note.Version),
+		IsPinned:   note.IsPinned,
+		Position:   note.Position,
+		Type:       entities.NoteType(note.Type),
+	}
+}
@@ -0,0 +1,171 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type NoteRevisionRepositoryImpl struct {
+	q *Queries
+}
+
+func NewNoteRevisionRepository(q *Queries) repositories.NoteRevisionRepository {
+	return &NoteRevisionRepositoryImpl{q: q}
+}
+
+func (r *NoteRevisionRepositoryImpl) Create(ctx context.Context, revision *entities.NoteRevision) error {
+	// Parse the revision ID
+	revisionID, err := uuid.Parse(revision.ID)
+	if err != nil {
+		return err
+	}
+
+	// Parse the note ID
+	noteID, err := uuid.Parse(revision.NoteID)
+	if err != nil {
+		return err
+	}
+
+	params := CreateNoteRevisionParams{
+		ID:        revisionID.String(),
+		NoteID:    noteID.String(),
+		Title:     revision.Title,
+		Content:   revision.Content,
+		CreatedAt: revision.CreatedAt,
+	}
+
+	created, err := r.q.CreateNoteRevision(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	// The revision number is assigned by the database
+	revision.Revision = int(created.Revision)
+	return nil
+}
+
+func (r *NoteRevisionRepositoryImpl) GetByNoteID(ctx context.Context, noteID string) ([]*entities.NoteRevision, error) {
+	noteUUID, err := uuid.Parse(noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := r.q.GetNoteRevisions(ctx, noteUUID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*entities.NoteRevision, len(revisions))
+	for i, revision := range revisions {
+		result[i] = &entities.NoteRevision{
+			ID:        revision.ID,
+			NoteID:    revision.NoteID,
+			Revision:  int(revision.Revision),
+			Title:     revision.Title,
+			Content:   revision.Content,
+			CreatedAt: revision.CreatedAt,
+		}
+	}
+
+	return result, nil
+}
+
+func (r *NoteRevisionRepositoryImpl) GetByRevision(ctx context.Context, noteID string, revision int) (*entities.NoteRevision, error) {
+	noteUUID, err := uuid.Parse(noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	params := GetNoteRevisionParams{
+		NoteID:   noteUUID.String(),
+		Revision: int32(revision),
+	}
+
+	found, err := r.q.GetNoteRevision(ctx, params)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &entities.NoteRevision{
+		ID:        found.ID,
+		NoteID:    found.NoteID,
+		Revision:  int(found.Revision),
+		Title:     found.Title,
+		Content:   found.Content,
+		CreatedAt: found.CreatedAt,
+	}, nil
+}
+
+func (r *NoteRevisionRepositoryImpl) DeleteAllButLatest(ctx context.Context, noteID string, keep int) error {
+	noteUUID, err := uuid.Parse(noteID)
+	if err != nil {
+		return err
+	}
+
+	params := DeleteNoteRevisionsBeyondParams{
+		NoteID: noteUUID.String(),
+		Limit:  int32(keep),
+	}
+
+	return r.q.DeleteNoteRevisionsBeyond(ctx, params)
+}
+
+func (r *NoteRevisionRepositoryImpl) DeleteOlderThan(ctx context.Context, noteID string, cutoff time.Time) error {
+	noteUUID, err := uuid.Parse(noteID)
+	if err != nil {
+		return err
+	}
+
+	params := DeleteNoteRevisionsBeforeParams{
+		NoteID:    noteUUID.String(),
+		CreatedAt: cutoff,
+	}
+
+	return r.q.DeleteNoteRevisionsBefore(ctx, params)
+}
+
+func (r *NoteRevisionRepositoryImpl) GetRetention(ctx context.Context, userID string) (*entities.RevisionRetention, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := r.q.GetRevisionRetentionPolicy(ctx, userUUID.String())
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &entities.RevisionRetention{
+		UserID:       policy.UserID,
+		MaxRevisions: int(policy.MaxRevisions),
+		MaxAgeDays:   int(policy.MaxAgeDays),
+		UpdatedAt:    policy.UpdatedAt,
+	}, nil
+}
+
+func (r *NoteRevisionRepositoryImpl) SaveRetention(ctx context.Context, retention *entities.RevisionRetention) error {
+	userUUID, err := uuid.Parse(retention.UserID)
+	if err != nil {
+		return err
+	}
+
+	params := UpsertRevisionRetentionPolicyParams{
+		UserID:       userUUID.String(),
+		MaxRevisions: int32(retention.MaxRevisions),
+		MaxAgeDays:   int32(retention.MaxAgeDays),
+		UpdatedAt:    retention.UpdatedAt,
+	}
+
+	return r.q.UpsertRevisionRetentionPolicy(ctx, params)
+}
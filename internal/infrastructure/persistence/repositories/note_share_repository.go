@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type NoteShareRepositoryImpl struct {
+	q *Queries
+}
+
+func NewNoteShareRepository(q *Queries) repositories.NoteShareRepository {
+	return &NoteShareRepositoryImpl{q: q}
+}
+
+func (r *NoteShareRepositoryImpl) Upsert(ctx context.Context, share *entities.NoteShare) error {
+	// Parse the IDs
+	id, err := uuid.Parse(share.ID)
+	if err != nil {
+		return err
+	}
+	noteID, err := uuid.Parse(share.NoteID)
+	if err != nil {
+		return err
+	}
+	userID, err := uuid.Parse(share.UserID)
+	if err != nil {
+		return err
+	}
+
+	saved, err := r.q.UpsertNoteShare(ctx, UpsertNoteShareParams{
+		ID:        id.String(),
+		NoteID:    noteID.String(),
+		UserID:    userID.String(),
+		Role:      string(share.Role),
+		CreatedAt: share.CreatedAt,
+		UpdatedAt: share.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	// An existing share keeps its ID and creation time
+	share.ID = saved.ID
+	share.CreatedAt = saved.CreatedAt
+
+	return nil
+}
+
+func (r *NoteShareRepositoryImpl) GetByNoteAndUser(ctx context.Context, noteID, userID string) (*entities.NoteShare, error) {
+	parsedNoteID, err := uuid.Parse(noteID)
+	if err != nil {
+		// A malformed ID cannot match any row
+		return nil, nil
+	}
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	share, err := r.q.GetNoteShare(ctx, GetNoteShareParams{
+		NoteID: parsedNoteID.String(),
+		UserID: parsedUserID.String(),
+	})
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toNoteShare(share), nil
+}
+
+func (r *NoteShareRepositoryImpl) GetByNoteID(ctx context.Context, noteID string) ([]*entities.NoteShare, error) {
+	id, err := uuid.Parse(noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := r.q.GetNoteSharesByNoteID(ctx, id.String())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*entities.NoteShare, len(shares))
+	for i, share := range shares {
+		result[i] = &entities.NoteShare{
+			ID:        share.ID,
+			NoteID:    share.NoteID,
+			UserID:    share.UserID,
+			Role:      entities.NoteRole(share.Role),
+			Email:     share.UserEmail,
+			Name:      share.UserName,
+			CreatedAt: share.CreatedAt,
+			UpdatedAt: share.UpdatedAt,
+		}
+	}
+
+	return result, nil
+}
+
+func (r *NoteShareRepositoryImpl) GetSharedWithUser(ctx context.Context, userID string) ([]*entities.SharedNote, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := r.q.GetNotesSharedWithUser(ctx, id.String())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*entities.SharedNote, len(notes))
+	for i, note := range notes {
+		result[i] = &entities.SharedNote{
+			Note: &entities.Note{
+				ID:         note.ID,
+				UserID:     note.UserID,
+				Title:      note.Title,
+				Content:    note.Content,
+				IsArchived: note.IsArchived,
+				CreatedAt:  note.CreatedAt,
+				UpdatedAt:  note.UpdatedAt,
+				Version:    int(note.Version),
+			},
+			Role: entities.NoteRole(note.ShareRole),
+		}
+	}
+
+	return result, nil
+}
+
+func (r *NoteShareRepositoryImpl) Delete(ctx context.Context, noteID, userID string) (bool, error) {
+	parsedNoteID, err := uuid.Parse(noteID)
+	if err != nil {
+		// A malformed ID cannot match any row
+		return false, nil
+	}
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		// A malformed ID cannot match any row
+		return false, nil
+	}
+
+	deleted, err := r.q.DeleteNoteShare(ctx, DeleteNoteShareParams{
+		NoteID: parsedNoteID.String(),
+		UserID: parsedUserID.String(),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return deleted > 0, nil
+}
+
+func toNoteShare(share NoteShare) *entities.NoteShare {
+	return &entities.NoteShare{
+		ID:        share.ID,
+		NoteID:    share.NoteID,
+		UserID:    share.UserID,
+		Role:      entities.NoteRole(share.Role),
+		CreatedAt: share.CreatedAt,
+		UpdatedAt: share.UpdatedAt,
+	}
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type PasswordResetRepositoryImpl struct {
+	q *Queries
+}
+
+func NewPasswordResetRepository(q *Queries) repositories.PasswordResetRepository {
+	return &PasswordResetRepositoryImpl{q: q}
+}
+
+func (r *PasswordResetRepositoryImpl) Create(ctx context.Context, token *entities.PasswordResetToken) error {
+	// Parse the user ID
+	userID, err := uuid.Parse(token.UserID)
+	if err != nil {
+		return err
+	}
+
+	// The token ID is a hash, not a UUID
+	return r.q.CreatePasswordResetToken(ctx, CreatePasswordResetTokenParams{
+		ID:        token.ID,
+		UserID:    userID.String(),
+		ExpiresAt: token.ExpiresAt,
+		CreatedAt: token.CreatedAt,
+	})
+}
+
+func (r *PasswordResetRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.PasswordResetToken, error) {
+	token, err := r.q.GetPasswordResetTokenByID(ctx, id)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toPasswordResetToken(token), nil
+}
+
+func (r *PasswordResetRepositoryImpl) GetLatestByUserID(ctx context.Context, userID string) (*entities.PasswordResetToken, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		// A malformed ID cannot match any row
+		return nil, nil
+	}
+
+	token, err := r.q.GetLatestPasswordResetTokenByUserID(ctx, id.String())
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toPasswordResetToken(token), nil
+}
+
+func (r *PasswordResetRepositoryImpl) MarkUsed(ctx context.Context, id string) (bool, error) {
+	updated, err := r.q.MarkPasswordResetTokenUsed(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	return updated == 1, nil
+}
+
+func (r *PasswordResetRepositoryImpl) DeleteAllByUserID(ctx context.Context, userID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+
+	return r.q.DeletePasswordResetTokensByUserID(ctx, id.String())
+}
+
+func toPasswordResetToken(token PasswordResetToken) *entities.PasswordResetToken {
+	return &entities.PasswordResetToken{
+		ID:        token.ID,
+		UserID:    token.UserID,
+		ExpiresAt: token.ExpiresAt,
+		UsedAt:    timePtr(token.UsedAt),
+		CreatedAt: token.CreatedAt,
+	}
+}
@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type PersonalAccessTokenRepositoryImpl struct {
+	q *Queries
+}
+
+func NewPersonalAccessTokenRepository(q *Queries) repositories.PersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepositoryImpl{q: q}
+}
+
+func (r *PersonalAccessTokenRepositoryImpl) Create(ctx context.Context, token *entities.PersonalAccessToken) error {
+	// Parse the IDs
+	id, err := uuid.Parse(token.ID)
+	if err != nil {
+		return err
+	}
+	userID, err := uuid.Parse(token.UserID)
+	if err != nil {
+		return err
+	}
+
+	scopes := make([]string, len(token.Scopes))
+	for i, scope := range token.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	var expiresAt pgtype.Timestamptz
+	if token.ExpiresAt != nil {
+		expiresAt = pgtype.Timestamptz{Time: *token.ExpiresAt, Valid: true}
+	}
+
+	return r.q.CreatePersonalAccessToken(ctx, CreatePersonalAccessTokenParams{
+		ID:        id.String(),
+		UserID:    userID.String(),
+		Name:      token.Name,
+		TokenHash: token.TokenHash,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: token.CreatedAt,
+	})
+}
+
+func (r *PersonalAccessTokenRepositoryImpl) GetByHash(ctx context.Context, tokenHash string) (*entities.PersonalAccessToken, error) {
+	token, err := r.q.GetPersonalAccessTokenByHash(ctx, tokenHash)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toPersonalAccessToken(token), nil
+}
+
+func (r *PersonalAccessTokenRepositoryImpl) GetByUserID(ctx context.Context, userID string) ([]*entities.PersonalAccessToken, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := r.q.GetPersonalAccessTokensByUserID(ctx, id.String())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*entities.PersonalAccessToken, len(tokens))
+	for i, token := range tokens {
+		result[i] = toPersonalAccessToken(token)
+	}
+
+	return result, nil
+}
+
+func (r *PersonalAccessTokenRepositoryImpl) UpdateLastUsedAt(ctx context.Context, id string, lastUsedAt time.Time) error {
+	tokenID, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+
+	return r.q.UpdatePersonalAccessTokenLastUsedAt(ctx, UpdatePersonalAccessTokenLastUsedAtParams{
+		ID:         tokenID.String(),
+		LastUsedAt: pgtype.Timestamptz{Time: lastUsedAt, Valid: true},
+	})
+}
+
+func (r *PersonalAccessTokenRepositoryImpl) Delete(ctx context.Context, userID, id string) (bool, error) {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return false, err
+	}
+	tokenID, err := uuid.Parse(id)
+	if err != nil {
+		// A malformed ID cannot match any row
+		return false, nil
+	}
+
+	deleted, err := r.q.DeletePersonalAccessToken(ctx, DeletePersonalAccessTokenParams{
+		ID:     tokenID.String(),
+		UserID: parsedUserID.String(),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return deleted > 0, nil
+}
+
+func (r *PersonalAccessTokenRepositoryImpl) DeleteAllByUserID(ctx context.Context, userID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+
+	return r.q.DeletePersonalAccessTokensByUserID(ctx, id.String())
+}
+
+func toPersonalAccessToken(token PersonalAccessToken) *entities.PersonalAccessToken {
+	scopes := make([]entities.TokenScope, len(token.Scopes))
+	for i, scope := range token.Scopes {
+		scopes[i] = entities.TokenScope(scope)
+	}
+
+	return &entities.PersonalAccessToken{
+		ID:         token.ID,
+		UserID:     token.UserID,
+		Name:       token.Name,
+		TokenHash:  token.TokenHash,
+		Scopes:     scopes,
+		ExpiresAt:  timePtr(token.ExpiresAt),
+		LastUsedAt: timePtr(token.LastUsedAt),
+		CreatedAt:  token.CreatedAt,
+	}
+}
@@ -8,6 +8,8 @@ package repositories
 import (
 	"context"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const addLabelToNote = `-- name: AddLabelToNote :exec
@@ -24,10 +26,182 @@ func (q *Queries) AddLabelToNote(ctx context.Context, arg AddLabelToNoteParams)
 	return err
 }
 
+const cancelUserDeletion = `-- name: CancelUserDeletion :exec
+UPDATE users SET deletion_requested_at = NULL WHERE id = $1
+`
+
+func (q *Queries) CancelUserDeletion(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, cancelUserDeletion, id)
+	return err
+}
+
+const confirmTOTPCredential = `-- name: ConfirmTOTPCredential :execrows
+UPDATE totp_credentials SET confirmed_at = $2 WHERE user_id = $1 AND confirmed_at IS NULL
+`
+
+type ConfirmTOTPCredentialParams struct {
+	UserID      string             `json:"user_id"`
+	ConfirmedAt pgtype.Timestamptz `json:"confirmed_at"`
+}
+
+func (q *Queries) ConfirmTOTPCredential(ctx context.Context, arg ConfirmTOTPCredentialParams) (int64, error) {
+	result, err := q.db.Exec(ctx, confirmTOTPCredential, arg.UserID, arg.ConfirmedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const countUnusedRecoveryCodesByUserID = `-- name: CountUnusedRecoveryCodesByUserID :one
+SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) CountUnusedRecoveryCodesByUserID(ctx context.Context, userID string) (int64, error) {
+	row := q.db.QueryRow(ctx, countUnusedRecoveryCodesByUserID, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAttachment = `-- name: CreateAttachment :exec
+INSERT INTO attachments (id, note_id, user_id, filename, content_type, size, checksum, storage_key, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+`
+
+type CreateAttachmentParams struct {
+	ID          string      `json:"id"`
+	NoteID      string      `json:"note_id"`
+	UserID      pgtype.Text `json:"user_id"`
+	Filename    string      `json:"filename"`
+	ContentType string      `json:"content_type"`
+	Size        int64       `json:"size"`
+	Checksum    string      `json:"checksum"`
+	StorageKey  string      `json:"storage_key"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+func (q *Queries) CreateAttachment(ctx context.Context, arg CreateAttachmentParams) error {
+	_, err := q.db.Exec(ctx, createAttachment,
+		arg.ID,
+		arg.NoteID,
+		arg.UserID,
+		arg.Filename,
+		arg.ContentType,
+		arg.Size,
+		arg.Checksum,
+		arg.StorageKey,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const createChecklistItem = `-- name: CreateChecklistItem :one
+INSERT INTO checklist_items (id, note_id, text, is_checked, indent, created_at, updated_at, position)
+VALUES ($1, $2, $3, $4, $5, $6, $7, (SELECT COALESCE(MAX(position), 0) + 1024 FROM checklist_items WHERE note_id = $2))
+RETURNING position
+`
+
+type CreateChecklistItemParams struct {
+	ID        string    `json:"id"`
+	NoteID    string    `json:"note_id"`
+	Text      string    `json:"text"`
+	IsChecked bool      `json:"is_checked"`
+	Indent    int16     `json:"indent"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (q *Queries) CreateChecklistItem(ctx context.Context, arg CreateChecklistItemParams) (float64, error) {
+	row := q.db.QueryRow(ctx, createChecklistItem,
+		arg.ID,
+		arg.NoteID,
+		arg.Text,
+		arg.IsChecked,
+		arg.Indent,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	var position float64
+	err := row.Scan(&position)
+	return position, err
+}
+
+const createEmailChangeRequest = `-- name: CreateEmailChangeRequest :exec
+INSERT INTO email_change_requests (id, user_id, new_email, expires_at, created_at)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateEmailChangeRequestParams struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	NewEmail  string    `json:"new_email"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) CreateEmailChangeRequest(ctx context.Context, arg CreateEmailChangeRequestParams) error {
+	_, err := q.db.Exec(ctx, createEmailChangeRequest,
+		arg.ID,
+		arg.UserID,
+		arg.NewEmail,
+		arg.ExpiresAt,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const createEmailVerificationToken = `-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_tokens (id, user_id, expires_at, created_at)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateEmailVerificationTokenParams struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) CreateEmailVerificationToken(ctx context.Context, arg CreateEmailVerificationTokenParams) error {
+	_, err := q.db.Exec(ctx, createEmailVerificationToken,
+		arg.ID,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const createFailedLoginAttempt = `-- name: CreateFailedLoginAttempt :exec
+INSERT INTO failed_login_attempts (id, email, ip_address, user_agent, reason, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateFailedLoginAttemptParams struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	IpAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) CreateFailedLoginAttempt(ctx context.Context, arg CreateFailedLoginAttemptParams) error {
+	_, err := q.db.Exec(ctx, createFailedLoginAttempt,
+		arg.ID,
+		arg.Email,
+		arg.IpAddress,
+		arg.UserAgent,
+		arg.Reason,
+		arg.CreatedAt,
+	)
+	return err
+}
+
 const createLabel = `-- name: CreateLabel :one
 INSERT INTO labels (id, user_id, name, color, created_at, updated_at)
 VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, user_id, name, color, created_at, updated_at
+RETURNING id, user_id, name, color, created_at, updated_at, version
 `
 
 type CreateLabelParams struct {
@@ -56,14 +230,15 @@ func (q *Queries) CreateLabel(ctx context.Context, arg CreateLabelParams) (Label
 		&i.Color,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
 const createNote = `-- name: CreateNote :one
-INSERT INTO notes (id, user_id, title, content, is_archived, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, user_id, title, content, is_archived, created_at, updated_at
+INSERT INTO notes (id, user_id, title, content, is_archived, created_at, updated_at, position)
+VALUES ($1, $2, $3, $4, $5, $6, $7, (SELECT COALESCE(MIN(position), 0) - 1024 FROM notes WHERE user_id = $2))
+RETURNING id, user_id, title, content, is_archived, created_at, updated_at, deleted_at, version, is_pinned, position, type
 `
 
 type CreateNoteParams struct {
@@ -95,37 +270,186 @@ func (q *Queries) CreateNote(ctx context.Context, arg CreateNoteParams) (Note, e
 		&i.IsArchived,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.IsPinned,
+		&i.Position,
+		&i.Type,
 	)
 	return i, err
 }
 
-const createSession = `-- name: CreateSession :one
-INSERT INTO sessions (user_id, expires_at)
-VALUES ($1, $2)
-RETURNING id, user_id, expires_at, created_at
+const createNoteRevision = `-- name: CreateNoteRevision :one
+INSERT INTO note_revisions (id, note_id, revision, title, content, created_at)
+VALUES ($1, $2, (SELECT COALESCE(MAX(revision), 0) + 1 FROM note_revisions WHERE note_id = $2), $3, $4, $5)
+RETURNING id, note_id, revision, title, content, created_at
 `
 
-type CreateSessionParams struct {
+type CreateNoteRevisionParams struct {
+	ID        string    `json:"id"`
+	NoteID    string    `json:"note_id"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) CreateNoteRevision(ctx context.Context, arg CreateNoteRevisionParams) (NoteRevision, error) {
+	row := q.db.QueryRow(ctx, createNoteRevision,
+		arg.ID,
+		arg.NoteID,
+		arg.Title,
+		arg.Content,
+		arg.CreatedAt,
+	)
+	var i NoteRevision
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.Revision,
+		&i.Title,
+		&i.Content,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createPasswordResetToken = `-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (id, user_id, expires_at, created_at)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreatePasswordResetTokenParams struct {
+	ID        string    `json:"id"`
 	UserID    string    `json:"user_id"`
 	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) error {
+	_, err := q.db.Exec(ctx, createPasswordResetToken,
+		arg.ID,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const createPersonalAccessToken = `-- name: CreatePersonalAccessToken :exec
+INSERT INTO personal_access_tokens (id, user_id, name, token_hash, scopes, expires_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+
+type CreatePersonalAccessTokenParams struct {
+	ID        string             `json:"id"`
+	UserID    string             `json:"user_id"`
+	Name      string             `json:"name"`
+	TokenHash string             `json:"token_hash"`
+	Scopes    []string           `json:"scopes"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+func (q *Queries) CreatePersonalAccessToken(ctx context.Context, arg CreatePersonalAccessTokenParams) error {
+	_, err := q.db.Exec(ctx, createPersonalAccessToken,
+		arg.ID,
+		arg.UserID,
+		arg.Name,
+		arg.TokenHash,
+		arg.Scopes,
+		arg.ExpiresAt,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (id, user_id, created_at)
+VALUES ($1, $2, $3)
+`
+
+type CreateRecoveryCodeParams struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error {
+	_, err := q.db.Exec(ctx, createRecoveryCode, arg.ID, arg.UserID, arg.CreatedAt)
+	return err
+}
+
+const createSession = `-- name: CreateSession :one
+INSERT INTO sessions (id, user_id, expires_at, two_factor_pending, public_id, user_agent, ip_address)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, user_id, expires_at, created_at, two_factor_pending, public_id, user_agent, ip_address, last_seen_at
+`
+
+type CreateSessionParams struct {
+	ID               string    `json:"id"`
+	UserID           string    `json:"user_id"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	TwoFactorPending bool      `json:"two_factor_pending"`
+	PublicID         string    `json:"public_id"`
+	UserAgent        string    `json:"user_agent"`
+	IpAddress        string    `json:"ip_address"`
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
-	row := q.db.QueryRow(ctx, createSession, arg.UserID, arg.ExpiresAt)
+	row := q.db.QueryRow(ctx, createSession,
+		arg.ID,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.TwoFactorPending,
+		arg.PublicID,
+		arg.UserAgent,
+		arg.IpAddress,
+	)
 	var i Session
 	err := row.Scan(
 		&i.ID,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.TwoFactorPending,
+		&i.PublicID,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastSeenAt,
 	)
 	return i, err
 }
 
+const createShareLink = `-- name: CreateShareLink :exec
+INSERT INTO share_links (id, note_id, token_hash, password_hash, expires_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateShareLinkParams struct {
+	ID           string             `json:"id"`
+	NoteID       string             `json:"note_id"`
+	TokenHash    string             `json:"token_hash"`
+	PasswordHash pgtype.Text        `json:"password_hash"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+func (q *Queries) CreateShareLink(ctx context.Context, arg CreateShareLinkParams) error {
+	_, err := q.db.Exec(ctx, createShareLink,
+		arg.ID,
+		arg.NoteID,
+		arg.TokenHash,
+		arg.PasswordHash,
+		arg.ExpiresAt,
+		arg.CreatedAt,
+	)
+	return err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (email, name, password)
 VALUES ($1, $2, $3)
-RETURNING id, email, name, password, created_at, updated_at
+RETURNING id, email, name, password, created_at, updated_at, email_verified_at, deletion_requested_at
 `
 
 type CreateUserParams struct {
@@ -144,6 +468,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Password,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EmailVerifiedAt,
+		&i.DeletionRequestedAt,
 	)
 	return i, err
 }
@@ -157,67 +483,97 @@ func (q *Queries) DeleteAllSessionsByUserID(ctx context.Context, userID string)
 	return err
 }
 
-const deleteLabel = `-- name: DeleteLabel :exec
-DELETE FROM labels WHERE id = $1
+const deleteAttachment = `-- name: DeleteAttachment :execrows
+DELETE FROM attachments WHERE id = $1 AND note_id = $2
 `
 
-func (q *Queries) DeleteLabel(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteLabel, id)
-	return err
+type DeleteAttachmentParams struct {
+	ID     string `json:"id"`
+	NoteID string `json:"note_id"`
 }
 
-const deleteNote = `-- name: DeleteNote :exec
-DELETE FROM notes WHERE id = $1
-`
-
-func (q *Queries) DeleteNote(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteNote, id)
-	return err
+func (q *Queries) DeleteAttachment(ctx context.Context, arg DeleteAttachmentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteAttachment, arg.ID, arg.NoteID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const deleteSession = `-- name: DeleteSession :exec
-DELETE FROM sessions WHERE id = $1
+const deleteAttachmentsOfNotesByUserID = `-- name: DeleteAttachmentsOfNotesByUserID :many
+DELETE FROM attachments WHERE note_id IN (
+    SELECT id FROM notes WHERE user_id = $1 FOR UPDATE
+)
+RETURNING storage_key
 `
 
-func (q *Queries) DeleteSession(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteSession, id)
-	return err
+func (q *Queries) DeleteAttachmentsOfNotesByUserID(ctx context.Context, userID string) ([]string, error) {
+	rows, err := q.db.Query(ctx, deleteAttachmentsOfNotesByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var storage_key string
+		if err := rows.Scan(&storage_key); err != nil {
+			return nil, err
+		}
+		items = append(items, storage_key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const deleteUser = `-- name: DeleteUser :exec
-DELETE FROM users WHERE id = $1
+const deleteAttachmentsOfNotesTrashedBefore = `-- name: DeleteAttachmentsOfNotesTrashedBefore :many
+DELETE FROM attachments WHERE note_id IN (
+    SELECT id FROM notes WHERE deleted_at < $1 FOR UPDATE
+)
+RETURNING storage_key
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteUser, id)
-	return err
+func (q *Queries) DeleteAttachmentsOfNotesTrashedBefore(ctx context.Context, deletedAt pgtype.Timestamptz) ([]string, error) {
+	rows, err := q.db.Query(ctx, deleteAttachmentsOfNotesTrashedBefore, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var storage_key string
+		if err := rows.Scan(&storage_key); err != nil {
+			return nil, err
+		}
+		items = append(items, storage_key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getArchivedNotesByUserID = `-- name: GetArchivedNotesByUserID :many
-SELECT id, user_id, title, content, is_archived, created_at, updated_at FROM notes WHERE user_id = $1 AND is_archived = true ORDER BY updated_at DESC
+const deleteAttachmentsOfTrashedNotesByUserID = `-- name: DeleteAttachmentsOfTrashedNotesByUserID :many
+DELETE FROM attachments WHERE note_id IN (
+    SELECT id FROM notes WHERE user_id = $1 AND deleted_at IS NOT NULL FOR UPDATE
+)
+RETURNING storage_key
 `
 
-func (q *Queries) GetArchivedNotesByUserID(ctx context.Context, userID string) ([]Note, error) {
-	rows, err := q.db.Query(ctx, getArchivedNotesByUserID, userID)
+func (q *Queries) DeleteAttachmentsOfTrashedNotesByUserID(ctx context.Context, userID string) ([]string, error) {
+	rows, err := q.db.Query(ctx, deleteAttachmentsOfTrashedNotesByUserID, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Note
+	var items []string
 	for rows.Next() {
-		var i Note
-		if err := rows.Scan(
-			&i.ID,
-			&i.UserID,
-			&i.Title,
-			&i.Content,
-			&i.IsArchived,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
+		var storage_key string
+		if err := rows.Scan(&storage_key); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, storage_key)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -225,67 +581,1427 @@ func (q *Queries) GetArchivedNotesByUserID(ctx context.Context, userID string) (
 	return items, nil
 }
 
-const getLabelByID = `-- name: GetLabelByID :one
-SELECT id, user_id, name, color, created_at, updated_at FROM labels WHERE id = $1
+const deleteChecklistItem = `-- name: DeleteChecklistItem :execrows
+DELETE FROM checklist_items WHERE id = $1 AND note_id = $2
 `
 
-func (q *Queries) GetLabelByID(ctx context.Context, id string) (Label, error) {
-	row := q.db.QueryRow(ctx, getLabelByID, id)
-	var i Label
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.Name,
-		&i.Color,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+type DeleteChecklistItemParams struct {
+	ID     string `json:"id"`
+	NoteID string `json:"note_id"`
 }
 
-const getLabelByName = `-- name: GetLabelByName :one
-SELECT id, user_id, name, color, created_at, updated_at FROM labels WHERE user_id = $1 AND name = $2
+func (q *Queries) DeleteChecklistItem(ctx context.Context, arg DeleteChecklistItemParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteChecklistItem, arg.ID, arg.NoteID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteChecklistItemsByNoteID = `-- name: DeleteChecklistItemsByNoteID :exec
+DELETE FROM checklist_items WHERE note_id = $1
 `
 
-type GetLabelByNameParams struct {
-	UserID string `json:"user_id"`
-	Name   string `json:"name"`
+func (q *Queries) DeleteChecklistItemsByNoteID(ctx context.Context, noteID string) error {
+	_, err := q.db.Exec(ctx, deleteChecklistItemsByNoteID, noteID)
+	return err
 }
 
-func (q *Queries) GetLabelByName(ctx context.Context, arg GetLabelByNameParams) (Label, error) {
+const deleteEmailChangeRequestsByUserID = `-- name: DeleteEmailChangeRequestsByUserID :exec
+DELETE FROM email_change_requests WHERE user_id = $1
+`
+
+func (q *Queries) DeleteEmailChangeRequestsByUserID(ctx context.Context, userID string) error {
+	_, err := q.db.Exec(ctx, deleteEmailChangeRequestsByUserID, userID)
+	return err
+}
+
+const deleteEmailVerificationTokensByUserID = `-- name: DeleteEmailVerificationTokensByUserID :exec
+DELETE FROM email_verification_tokens WHERE user_id = $1
+`
+
+func (q *Queries) DeleteEmailVerificationTokensByUserID(ctx context.Context, userID string) error {
+	_, err := q.db.Exec(ctx, deleteEmailVerificationTokensByUserID, userID)
+	return err
+}
+
+const deleteExpiredSessions = `-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions
+WHERE id IN (
+    SELECT id FROM sessions
+    WHERE expires_at < $1 OR last_seen_at < $2 OR created_at < $3
+    LIMIT $4
+)
+`
+
+type DeleteExpiredSessionsParams struct {
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	Limit      int32     `json:"limit"`
+}
+
+func (q *Queries) DeleteExpiredSessions(ctx context.Context, arg DeleteExpiredSessionsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredSessions,
+		arg.ExpiresAt,
+		arg.LastSeenAt,
+		arg.CreatedAt,
+		arg.Limit,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteLabel = `-- name: DeleteLabel :exec
+DELETE FROM labels WHERE id = $1
+`
+
+func (q *Queries) DeleteLabel(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, deleteLabel, id)
+	return err
+}
+
+const deleteLabelsByUserID = `-- name: DeleteLabelsByUserID :exec
+DELETE FROM labels WHERE user_id = $1
+`
+
+func (q *Queries) DeleteLabelsByUserID(ctx context.Context, userID string) error {
+	_, err := q.db.Exec(ctx, deleteLabelsByUserID, userID)
+	return err
+}
+
+const deleteNote = `-- name: DeleteNote :exec
+DELETE FROM notes WHERE id = $1
+`
+
+func (q *Queries) DeleteNote(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, deleteNote, id)
+	return err
+}
+
+const deleteNoteLabelsByUserID = `-- name: DeleteNoteLabelsByUserID :exec
+DELETE FROM note_labels WHERE label_id IN (SELECT id FROM labels WHERE user_id = $1)
+`
+
+func (q *Queries) DeleteNoteLabelsByUserID(ctx context.Context, userID string) error {
+	_, err := q.db.Exec(ctx, deleteNoteLabelsByUserID, userID)
+	return err
+}
+
+const deleteNoteRevisionsBefore = `-- name: DeleteNoteRevisionsBefore :exec
+DELETE FROM note_revisions WHERE note_id = $1 AND created_at < $2
+`
+
+type DeleteNoteRevisionsBeforeParams struct {
+	NoteID    string    `json:"note_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) DeleteNoteRevisionsBefore(ctx context.Context, arg DeleteNoteRevisionsBeforeParams) error {
+	_, err := q.db.Exec(ctx, deleteNoteRevisionsBefore, arg.NoteID, arg.CreatedAt)
+	return err
+}
+
+const deleteNoteRevisionsBeyond = `-- name: DeleteNoteRevisionsBeyond :exec
+DELETE FROM note_revisions
+WHERE note_id = $1
+    AND revision NOT IN (
+        SELECT revision FROM note_revisions WHERE note_id = $1 ORDER BY revision DESC LIMIT $2
+    )
+`
+
+type DeleteNoteRevisionsBeyondParams struct {
+	NoteID string `json:"note_id"`
+	Limit  int32  `json:"limit"`
+}
+
+func (q *Queries) DeleteNoteRevisionsBeyond(ctx context.Context, arg DeleteNoteRevisionsBeyondParams) error {
+	_, err := q.db.Exec(ctx, deleteNoteRevisionsBeyond, arg.NoteID, arg.Limit)
+	return err
+}
+
+const deleteNoteShare = `-- name: DeleteNoteShare :execrows
+DELETE FROM note_shares WHERE note_id = $1 AND user_id = $2
+`
+
+type DeleteNoteShareParams struct {
+	NoteID string `json:"note_id"`
+	UserID string `json:"user_id"`
+}
+
+func (q *Queries) DeleteNoteShare(ctx context.Context, arg DeleteNoteShareParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteNoteShare, arg.NoteID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteNotesByUserID = `-- name: DeleteNotesByUserID :exec
+DELETE FROM notes WHERE user_id = $1
+`
+
+func (q *Queries) DeleteNotesByUserID(ctx context.Context, userID string) error {
+	_, err := q.db.Exec(ctx, deleteNotesByUserID, userID)
+	return err
+}
+
+const deleteNotesTrashedBefore = `-- name: DeleteNotesTrashedBefore :execrows
+DELETE FROM notes WHERE deleted_at < $1
+`
+
+func (q *Queries) DeleteNotesTrashedBefore(ctx context.Context, deletedAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteNotesTrashedBefore, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteOtherSessionsByUserID = `-- name: DeleteOtherSessionsByUserID :exec
+DELETE FROM sessions WHERE user_id = $1 AND id <> $2
+`
+
+type DeleteOtherSessionsByUserIDParams struct {
+	UserID string `json:"user_id"`
+	ID     string `json:"id"`
+}
+
+func (q *Queries) DeleteOtherSessionsByUserID(ctx context.Context, arg DeleteOtherSessionsByUserIDParams) error {
+	_, err := q.db.Exec(ctx, deleteOtherSessionsByUserID, arg.UserID, arg.ID)
+	return err
+}
+
+const deletePasswordResetTokensByUserID = `-- name: DeletePasswordResetTokensByUserID :exec
+DELETE FROM password_reset_tokens WHERE user_id = $1
+`
+
+func (q *Queries) DeletePasswordResetTokensByUserID(ctx context.Context, userID string) error {
+	_, err := q.db.Exec(ctx, deletePasswordResetTokensByUserID, userID)
+	return err
+}
+
+const deletePersonalAccessToken = `-- name: DeletePersonalAccessToken :execrows
+DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2
+`
+
+type DeletePersonalAccessTokenParams struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+func (q *Queries) DeletePersonalAccessToken(ctx context.Context, arg DeletePersonalAccessTokenParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePersonalAccessToken, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deletePersonalAccessTokensByUserID = `-- name: DeletePersonalAccessTokensByUserID :exec
+DELETE FROM personal_access_tokens WHERE user_id = $1
+`
+
+func (q *Queries) DeletePersonalAccessTokensByUserID(ctx context.Context, userID string) error {
+	_, err := q.db.Exec(ctx, deletePersonalAccessTokensByUserID, userID)
+	return err
+}
+
+const deleteRecoveryCodesByUserID = `-- name: DeleteRecoveryCodesByUserID :exec
+DELETE FROM recovery_codes WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodesByUserID(ctx context.Context, userID string) error {
+	_, err := q.db.Exec(ctx, deleteRecoveryCodesByUserID, userID)
+	return err
+}
+
+const deleteSession = `-- name: DeleteSession :exec
+DELETE FROM sessions WHERE id = $1
+`
+
+func (q *Queries) DeleteSession(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, deleteSession, id)
+	return err
+}
+
+const deleteSessionByPublicID = `-- name: DeleteSessionByPublicID :execrows
+DELETE FROM sessions WHERE user_id = $1 AND public_id = $2
+`
+
+type DeleteSessionByPublicIDParams struct {
+	UserID   string `json:"user_id"`
+	PublicID string `json:"public_id"`
+}
+
+func (q *Queries) DeleteSessionByPublicID(ctx context.Context, arg DeleteSessionByPublicIDParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSessionByPublicID, arg.UserID, arg.PublicID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteShareLink = `-- name: DeleteShareLink :execrows
+DELETE FROM share_links WHERE id = $1 AND note_id = $2
+`
+
+type DeleteShareLinkParams struct {
+	ID     string `json:"id"`
+	NoteID string `json:"note_id"`
+}
+
+func (q *Queries) DeleteShareLink(ctx context.Context, arg DeleteShareLinkParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteShareLink, arg.ID, arg.NoteID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteShareLinksByNoteID = `-- name: DeleteShareLinksByNoteID :exec
+DELETE FROM share_links WHERE note_id = $1
+`
+
+func (q *Queries) DeleteShareLinksByNoteID(ctx context.Context, noteID string) error {
+	_, err := q.db.Exec(ctx, deleteShareLinksByNoteID, noteID)
+	return err
+}
+
+const deleteTOTPCredentialByUserID = `-- name: DeleteTOTPCredentialByUserID :exec
+DELETE FROM totp_credentials WHERE user_id = $1
+`
+
+func (q *Queries) DeleteTOTPCredentialByUserID(ctx context.Context, userID string) error {
+	_, err := q.db.Exec(ctx, deleteTOTPCredentialByUserID, userID)
+	return err
+}
+
+const deleteTrashedNotesByUserID = `-- name: DeleteTrashedNotesByUserID :execrows
+DELETE FROM notes WHERE user_id = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) DeleteTrashedNotesByUserID(ctx context.Context, userID string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTrashedNotesByUserID, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, deleteUser, id)
+	return err
+}
+
+const getArchivedNotesByUserID = `-- name: GetArchivedNotesByUserID :many
+SELECT id, user_id, title, content, is_archived, created_at, updated_at, deleted_at, version, is_pinned, position, type FROM notes WHERE user_id = $1 AND is_archived = true AND deleted_at IS NULL ORDER BY is_pinned DESC, position, id
+`
+
+func (q *Queries) GetArchivedNotesByUserID(ctx context.Context, userID string) ([]Note, error) {
+	rows, err := q.db.Query(ctx, getArchivedNotesByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Content,
+			&i.IsArchived,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.IsPinned,
+			&i.Position,
+			&i.Type,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAttachmentByID = `-- name: GetAttachmentByID :one
+SELECT id, note_id, user_id, filename, content_type, size, checksum, storage_key, created_at FROM attachments WHERE id = $1 AND note_id = $2
+`
+
+type GetAttachmentByIDParams struct {
+	ID     string `json:"id"`
+	NoteID string `json:"note_id"`
+}
+
+func (q *Queries) GetAttachmentByID(ctx context.Context, arg GetAttachmentByIDParams) (Attachment, error) {
+	row := q.db.QueryRow(ctx, getAttachmentByID, arg.ID, arg.NoteID)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.UserID,
+		&i.Filename,
+		&i.ContentType,
+		&i.Size,
+		&i.Checksum,
+		&i.StorageKey,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAttachmentUsageByUserID = `-- name: GetAttachmentUsageByUserID :one
+SELECT COALESCE(SUM(a.size), 0)::BIGINT FROM attachments a
+JOIN notes n ON n.id = a.note_id
+WHERE n.user_id = $1
+`
+
+func (q *Queries) GetAttachmentUsageByUserID(ctx context.Context, userID string) (int64, error) {
+	row := q.db.QueryRow(ctx, getAttachmentUsageByUserID, userID)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const getAttachmentsByNoteID = `-- name: GetAttachmentsByNoteID :many
+SELECT id, note_id, user_id, filename, content_type, size, checksum, storage_key, created_at FROM attachments WHERE note_id = $1 ORDER BY created_at, id
+`
+
+func (q *Queries) GetAttachmentsByNoteID(ctx context.Context, noteID string) ([]Attachment, error) {
+	rows, err := q.db.Query(ctx, getAttachmentsByNoteID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Attachment
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoteID,
+			&i.UserID,
+			&i.Filename,
+			&i.ContentType,
+			&i.Size,
+			&i.Checksum,
+			&i.StorageKey,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChecklistItemByID = `-- name: GetChecklistItemByID :one
+SELECT id, note_id, text, is_checked, position, indent, created_at, updated_at FROM checklist_items WHERE id = $1 AND note_id = $2
+`
+
+type GetChecklistItemByIDParams struct {
+	ID     string `json:"id"`
+	NoteID string `json:"note_id"`
+}
+
+func (q *Queries) GetChecklistItemByID(ctx context.Context, arg GetChecklistItemByIDParams) (ChecklistItem, error) {
+	row := q.db.QueryRow(ctx, getChecklistItemByID, arg.ID, arg.NoteID)
+	var i ChecklistItem
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.Text,
+		&i.IsChecked,
+		&i.Position,
+		&i.Indent,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getChecklistItemPositionAfter = `-- name: GetChecklistItemPositionAfter :one
+SELECT position FROM checklist_items
+WHERE note_id = $1 AND id <> $2 AND position > $3
+ORDER BY position
+LIMIT 1
+`
+
+type GetChecklistItemPositionAfterParams struct {
+	NoteID   string  `json:"note_id"`
+	ID       string  `json:"id"`
+	Position float64 `json:"position"`
+}
+
+func (q *Queries) GetChecklistItemPositionAfter(ctx context.Context, arg GetChecklistItemPositionAfterParams) (float64, error) {
+	row := q.db.QueryRow(ctx, getChecklistItemPositionAfter, arg.NoteID, arg.ID, arg.Position)
+	var position float64
+	err := row.Scan(&position)
+	return position, err
+}
+
+const getChecklistItemPositionBefore = `-- name: GetChecklistItemPositionBefore :one
+SELECT position FROM checklist_items
+WHERE note_id = $1 AND id <> $2 AND position < $3
+ORDER BY position DESC
+LIMIT 1
+`
+
+type GetChecklistItemPositionBeforeParams struct {
+	NoteID   string  `json:"note_id"`
+	ID       string  `json:"id"`
+	Position float64 `json:"position"`
+}
+
+func (q *Queries) GetChecklistItemPositionBefore(ctx context.Context, arg GetChecklistItemPositionBeforeParams) (float64, error) {
+	row := q.db.QueryRow(ctx, getChecklistItemPositionBefore, arg.NoteID, arg.ID, arg.Position)
+	var position float64
+	err := row.Scan(&position)
+	return position, err
+}
+
+const getChecklistItemsByNoteID = `-- name: GetChecklistItemsByNoteID :many
+SELECT id, note_id, text, is_checked, position, indent, created_at, updated_at FROM checklist_items WHERE note_id = $1 ORDER BY position, id
+`
+
+func (q *Queries) GetChecklistItemsByNoteID(ctx context.Context, noteID string) ([]ChecklistItem, error) {
+	rows, err := q.db.Query(ctx, getChecklistItemsByNoteID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChecklistItem
+	for rows.Next() {
+		var i ChecklistItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoteID,
+			&i.Text,
+			&i.IsChecked,
+			&i.Position,
+			&i.Indent,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEmailChangeRequestByID = `-- name: GetEmailChangeRequestByID :one
+SELECT id, user_id, new_email, expires_at, used_at, created_at FROM email_change_requests WHERE id = $1
+`
+
+func (q *Queries) GetEmailChangeRequestByID(ctx context.Context, id string) (EmailChangeRequest, error) {
+	row := q.db.QueryRow(ctx, getEmailChangeRequestByID, id)
+	var i EmailChangeRequest
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.NewEmail,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getEmailVerificationTokenByID = `-- name: GetEmailVerificationTokenByID :one
+SELECT id, user_id, expires_at, used_at, created_at FROM email_verification_tokens WHERE id = $1
+`
+
+func (q *Queries) GetEmailVerificationTokenByID(ctx context.Context, id string) (EmailVerificationToken, error) {
+	row := q.db.QueryRow(ctx, getEmailVerificationTokenByID, id)
+	var i EmailVerificationToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLabelByID = `-- name: GetLabelByID :one
+SELECT id, user_id, name, color, created_at, updated_at, version FROM labels WHERE id = $1
+`
+
+func (q *Queries) GetLabelByID(ctx context.Context, id string) (Label, error) {
+	row := q.db.QueryRow(ctx, getLabelByID, id)
+	var i Label
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Color,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getLabelByName = `-- name: GetLabelByName :one
+SELECT id, user_id, name, color, created_at, updated_at, version FROM labels WHERE user_id = $1 AND name = $2
+`
+
+type GetLabelByNameParams struct {
+	UserID string `json:"user_id"`
+	Name   string `json:"name"`
+}
+
+func (q *Queries) GetLabelByName(ctx context.Context, arg GetLabelByNameParams) (Label, error) {
 	row := q.db.QueryRow(ctx, getLabelByName, arg.UserID, arg.Name)
 	var i Label
 	err := row.Scan(
 		&i.ID,
 		&i.UserID,
-		&i.Name,
-		&i.Color,
+		&i.Name,
+		&i.Color,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getLabelsByUserID = `-- name: GetLabelsByUserID :many
+SELECT id, user_id, name, color, created_at, updated_at, version FROM labels WHERE user_id = $1 ORDER BY name
+`
+
+func (q *Queries) GetLabelsByUserID(ctx context.Context, userID string) ([]Label, error) {
+	rows, err := q.db.Query(ctx, getLabelsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Label
+	for rows.Next() {
+		var i Label
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Color,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLabelsForNote = `-- name: GetLabelsForNote :many
+SELECT l.id, l.user_id, l.name, l.color, l.created_at, l.updated_at, l.version FROM labels l
+JOIN note_labels nl ON l.id = nl.label_id
+WHERE nl.note_id = $1
+ORDER BY l.name
+`
+
+func (q *Queries) GetLabelsForNote(ctx context.Context, noteID string) ([]Label, error) {
+	rows, err := q.db.Query(ctx, getLabelsForNote, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Label
+	for rows.Next() {
+		var i Label
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Color,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLatestEmailVerificationTokenByUserID = `-- name: GetLatestEmailVerificationTokenByUserID :one
+SELECT id, user_id, expires_at, used_at, created_at FROM email_verification_tokens WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1
+`
+
+func (q *Queries) GetLatestEmailVerificationTokenByUserID(ctx context.Context, userID string) (EmailVerificationToken, error) {
+	row := q.db.QueryRow(ctx, getLatestEmailVerificationTokenByUserID, userID)
+	var i EmailVerificationToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLatestPasswordResetTokenByUserID = `-- name: GetLatestPasswordResetTokenByUserID :one
+SELECT id, user_id, expires_at, used_at, created_at FROM password_reset_tokens WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1
+`
+
+func (q *Queries) GetLatestPasswordResetTokenByUserID(ctx context.Context, userID string) (PasswordResetToken, error) {
+	row := q.db.QueryRow(ctx, getLatestPasswordResetTokenByUserID, userID)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getNoteByID = `-- name: GetNoteByID :one
+SELECT id, user_id, title, content, is_archived, created_at, updated_at, deleted_at, version, is_pinned, position, type FROM notes WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetNoteByID(ctx context.Context, id string) (Note, error) {
+	row := q.db.QueryRow(ctx, getNoteByID, id)
+	var i Note
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Content,
+		&i.IsArchived,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.IsPinned,
+		&i.Position,
+		&i.Type,
+	)
+	return i, err
+}
+
+const getNoteByIDForUpdate = `-- name: GetNoteByIDForUpdate :one
+SELECT id, user_id, title, content, is_archived, created_at, updated_at, deleted_at, version, is_pinned, position, type FROM notes WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+`
+
+func (q *Queries) GetNoteByIDForUpdate(ctx context.Context, id string) (Note, error) {
+	row := q.db.QueryRow(ctx, getNoteByIDForUpdate, id)
+	var i Note
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Content,
+		&i.IsArchived,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.IsPinned,
+		&i.Position,
+		&i.Type,
+	)
+	return i, err
+}
+
+const getNotePositionAfter = `-- name: GetNotePositionAfter :one
+SELECT position FROM notes
+WHERE user_id = $1 AND is_archived = $2 AND is_pinned = $3 AND deleted_at IS NULL AND id <> $4 AND position > $5
+ORDER BY position
+LIMIT 1
+`
+
+type GetNotePositionAfterParams struct {
+	UserID     string  `json:"user_id"`
+	IsArchived bool    `json:"is_archived"`
+	IsPinned   bool    `json:"is_pinned"`
+	ID         string  `json:"id"`
+	Position   float64 `json:"position"`
+}
+
+func (q *Queries) GetNotePositionAfter(ctx context.Context, arg GetNotePositionAfterParams) (float64, error) {
+	row := q.db.QueryRow(ctx, getNotePositionAfter,
+		arg.UserID,
+		arg.IsArchived,
+		arg.IsPinned,
+		arg.ID,
+		arg.Position,
+	)
+	var position float64
+	err := row.Scan(&position)
+	return position, err
+}
+
+const getNotePositionBefore = `-- name: GetNotePositionBefore :one
+SELECT position FROM notes
+WHERE user_id = $1 AND is_archived = $2 AND is_pinned = $3 AND deleted_at IS NULL AND id <> $4 AND position < $5
+ORDER BY position DESC
+LIMIT 1
+`
+
+type GetNotePositionBeforeParams struct {
+	UserID     string  `json:"user_id"`
+	IsArchived bool    `json:"is_archived"`
+	IsPinned   bool    `json:"is_pinned"`
+	ID         string  `json:"id"`
+	Position   float64 `json:"position"`
+}
+
+func (q *Queries) GetNotePositionBefore(ctx context.Context, arg GetNotePositionBeforeParams) (float64, error) {
+	row := q.db.QueryRow(ctx, getNotePositionBefore,
+		arg.UserID,
+		arg.IsArchived,
+		arg.IsPinned,
+		arg.ID,
+		arg.Position,
+	)
+	var position float64
+	err := row.Scan(&position)
+	return position, err
+}
+
+const getNoteRevision = `-- name: GetNoteRevision :one
+SELECT id, note_id, revision, title, content, created_at FROM note_revisions WHERE note_id = $1 AND revision = $2
+`
+
+type GetNoteRevisionParams struct {
+	NoteID   string `json:"note_id"`
+	Revision int32  `json:"revision"`
+}
+
+func (q *Queries) GetNoteRevision(ctx context.Context, arg GetNoteRevisionParams) (NoteRevision, error) {
+	row := q.db.QueryRow(ctx, getNoteRevision, arg.NoteID, arg.Revision)
+	var i NoteRevision
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.Revision,
+		&i.Title,
+		&i.Content,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getNoteRevisions = `-- name: GetNoteRevisions :many
+SELECT id, note_id, revision, title, content, created_at FROM note_revisions WHERE note_id = $1 ORDER BY revision DESC
+`
+
+func (q *Queries) GetNoteRevisions(ctx context.Context, noteID string) ([]NoteRevision, error) {
+	rows, err := q.db.Query(ctx, getNoteRevisions, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NoteRevision
+	for rows.Next() {
+		var i NoteRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoteID,
+			&i.Revision,
+			&i.Title,
+			&i.Content,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNoteShare = `-- name: GetNoteShare :one
+SELECT id, note_id, user_id, role, created_at, updated_at FROM note_shares WHERE note_id = $1 AND user_id = $2
+`
+
+type GetNoteShareParams struct {
+	NoteID string `json:"note_id"`
+	UserID string `json:"user_id"`
+}
+
+func (q *Queries) GetNoteShare(ctx context.Context, arg GetNoteShareParams) (NoteShare, error) {
+	row := q.db.QueryRow(ctx, getNoteShare, arg.NoteID, arg.UserID)
+	var i NoteShare
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.UserID,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getNoteSharesByNoteID = `-- name: GetNoteSharesByNoteID :many
+SELECT
+    note_shares.id,
+    note_shares.note_id,
+    note_shares.user_id,
+    note_shares.role,
+    note_shares.created_at,
+    note_shares.updated_at,
+    users.email AS user_email,
+    users.name AS user_name
+FROM note_shares
+INNER JOIN users ON note_shares.user_id = users.id
+WHERE note_shares.note_id = $1
+ORDER BY note_shares.created_at
+`
+
+type GetNoteSharesByNoteIDRow struct {
+	ID        string    `json:"id"`
+	NoteID    string    `json:"note_id"`
+	UserID    string    `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserEmail string    `json:"user_email"`
+	UserName  string    `json:"user_name"`
+}
+
+func (q *Queries) GetNoteSharesByNoteID(ctx context.Context, noteID string) ([]GetNoteSharesByNoteIDRow, error) {
+	rows, err := q.db.Query(ctx, getNoteSharesByNoteID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNoteSharesByNoteIDRow
+	for rows.Next() {
+		var i GetNoteSharesByNoteIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoteID,
+			&i.UserID,
+			&i.Role,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserEmail,
+			&i.UserName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotesByUserID = `-- name: GetNotesByUserID :many
+SELECT id, user_id, title, content, is_archived, created_at, updated_at, deleted_at, version, is_pinned, position, type FROM notes WHERE user_id = $1 AND is_archived = false AND deleted_at IS NULL ORDER BY is_pinned DESC, position, id
+`
+
+func (q *Queries) GetNotesByUserID(ctx context.Context, userID string) ([]Note, error) {
+	rows, err := q.db.Query(ctx, getNotesByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Content,
+			&i.IsArchived,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.IsPinned,
+			&i.Position,
+			&i.Type,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotesForLabel = `-- name: GetNotesForLabel :many
+SELECT nl.note_id FROM note_labels nl
+JOIN notes n ON n.id = nl.note_id
+WHERE nl.label_id = $1
+ORDER BY n.is_pinned DESC, n.position, n.id
+`
+
+func (q *Queries) GetNotesForLabel(ctx context.Context, labelID string) ([]string, error) {
+	rows, err := q.db.Query(ctx, getNotesForLabel, labelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var note_id string
+		if err := rows.Scan(&note_id); err != nil {
+			return nil, err
+		}
+		items = append(items, note_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotesSharedWithUser = `-- name: GetNotesSharedWithUser :many
+SELECT
+    notes.id,
+    notes.user_id,
+    notes.title,
+    notes.content,
+    notes.is_archived,
+    notes.created_at,
+    notes.updated_at,
+    notes.version,
+    note_shares.role AS share_role
+FROM notes
+INNER JOIN note_shares ON note_shares.note_id = notes.id
+WHERE note_shares.user_id = $1 AND notes.deleted_at IS NULL
+ORDER BY notes.updated_at DESC
+`
+
+type GetNotesSharedWithUserRow struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Title      string    `json:"title"`
+	Content    string    `json:"content"`
+	IsArchived bool      `json:"is_archived"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Version    int32     `json:"version"`
+	ShareRole  string    `json:"share_role"`
+}
+
+func (q *Queries) GetNotesSharedWithUser(ctx context.Context, userID string) ([]GetNotesSharedWithUserRow, error) {
+	rows, err := q.db.Query(ctx, getNotesSharedWithUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNotesSharedWithUserRow
+	for rows.Next() {
+		var i GetNotesSharedWithUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Content,
+			&i.IsArchived,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.ShareRole,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPasswordResetTokenByID = `-- name: GetPasswordResetTokenByID :one
+SELECT id, user_id, expires_at, used_at, created_at FROM password_reset_tokens WHERE id = $1
+`
+
+func (q *Queries) GetPasswordResetTokenByID(ctx context.Context, id string) (PasswordResetToken, error) {
+	row := q.db.QueryRow(ctx, getPasswordResetTokenByID, id)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPersonalAccessTokenByHash = `-- name: GetPersonalAccessTokenByHash :one
+SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at FROM personal_access_tokens WHERE token_hash = $1
+`
+
+func (q *Queries) GetPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (PersonalAccessToken, error) {
+	row := q.db.QueryRow(ctx, getPersonalAccessTokenByHash, tokenHash)
+	var i PersonalAccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPersonalAccessTokensByUserID = `-- name: GetPersonalAccessTokensByUserID :many
+SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at FROM personal_access_tokens WHERE user_id = $1 ORDER BY created_at DESC
+`
+
+func (q *Queries) GetPersonalAccessTokensByUserID(ctx context.Context, userID string) ([]PersonalAccessToken, error) {
+	rows, err := q.db.Query(ctx, getPersonalAccessTokensByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PersonalAccessToken
+	for rows.Next() {
+		var i PersonalAccessToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.TokenHash,
+			&i.Scopes,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRevisionRetentionPolicy = `-- name: GetRevisionRetentionPolicy :one
+SELECT user_id, max_revisions, max_age_days, updated_at FROM revision_retention_policies WHERE user_id = $1
+`
+
+func (q *Queries) GetRevisionRetentionPolicy(ctx context.Context, userID string) (RevisionRetentionPolicy, error) {
+	row := q.db.QueryRow(ctx, getRevisionRetentionPolicy, userID)
+	var i RevisionRetentionPolicy
+	err := row.Scan(
+		&i.UserID,
+		&i.MaxRevisions,
+		&i.MaxAgeDays,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getSessionByID = `-- name: GetSessionByID :one
+SELECT id, user_id, expires_at, created_at, two_factor_pending, public_id, user_agent, ip_address, last_seen_at FROM sessions WHERE id = $1
+`
+
+func (q *Queries) GetSessionByID(ctx context.Context, id string) (Session, error) {
+	row := q.db.QueryRow(ctx, getSessionByID, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.TwoFactorPending,
+		&i.PublicID,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastSeenAt,
+	)
+	return i, err
+}
+
+const getSessionWithUser = `-- name: GetSessionWithUser :one
+SELECT
+    sessions.id AS session_id,
+    sessions.user_id AS session_user_id,
+    sessions.expires_at AS session_expires_at,
+    sessions.created_at AS session_created_at,
+    sessions.two_factor_pending AS session_two_factor_pending,
+    users.id AS user_id,
+    users.email AS user_email,
+    users.name AS user_name
+FROM sessions
+INNER JOIN users ON sessions.user_id = users.id
+WHERE sessions.id = $1
+`
+
+type GetSessionWithUserRow struct {
+	SessionID               string    `json:"session_id"`
+	SessionUserID           string    `json:"session_user_id"`
+	SessionExpiresAt        time.Time `json:"session_expires_at"`
+	SessionCreatedAt        time.Time `json:"session_created_at"`
+	SessionTwoFactorPending bool      `json:"session_two_factor_pending"`
+	UserID                  string    `json:"user_id"`
+	UserEmail               string    `json:"user_email"`
+	UserName                string    `json:"user_name"`
+}
+
+func (q *Queries) GetSessionWithUser(ctx context.Context, id string) (GetSessionWithUserRow, error) {
+	row := q.db.QueryRow(ctx, getSessionWithUser, id)
+	var i GetSessionWithUserRow
+	err := row.Scan(
+		&i.SessionID,
+		&i.SessionUserID,
+		&i.SessionExpiresAt,
+		&i.SessionCreatedAt,
+		&i.SessionTwoFactorPending,
+		&i.UserID,
+		&i.UserEmail,
+		&i.UserName,
+	)
+	return i, err
+}
+
+const getShareLinkByHash = `-- name: GetShareLinkByHash :one
+SELECT id, note_id, token_hash, password_hash, expires_at, view_count, created_at FROM share_links WHERE token_hash = $1
+`
+
+func (q *Queries) GetShareLinkByHash(ctx context.Context, tokenHash string) (ShareLink, error) {
+	row := q.db.QueryRow(ctx, getShareLinkByHash, tokenHash)
+	var i ShareLink
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.TokenHash,
+		&i.PasswordHash,
+		&i.ExpiresAt,
+		&i.ViewCount,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getShareLinksByNoteID = `-- name: GetShareLinksByNoteID :many
+SELECT id, note_id, token_hash, password_hash, expires_at, view_count, created_at FROM share_links WHERE note_id = $1 ORDER BY created_at DESC
+`
+
+func (q *Queries) GetShareLinksByNoteID(ctx context.Context, noteID string) ([]ShareLink, error) {
+	rows, err := q.db.Query(ctx, getShareLinksByNoteID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ShareLink
+	for rows.Next() {
+		var i ShareLink
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoteID,
+			&i.TokenHash,
+			&i.PasswordHash,
+			&i.ExpiresAt,
+			&i.ViewCount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTOTPCredentialByUserID = `-- name: GetTOTPCredentialByUserID :one
+SELECT user_id, secret, confirmed_at, last_used_step, created_at FROM totp_credentials WHERE user_id = $1
+`
+
+func (q *Queries) GetTOTPCredentialByUserID(ctx context.Context, userID string) (TotpCredential, error) {
+	row := q.db.QueryRow(ctx, getTOTPCredentialByUserID, userID)
+	var i TotpCredential
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.ConfirmedAt,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getTrashedNoteByID = `-- name: GetTrashedNoteByID :one
+SELECT id, user_id, title, content, is_archived, created_at, updated_at, deleted_at, version, is_pinned, position, type FROM notes WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) GetTrashedNoteByID(ctx context.Context, id string) (Note, error) {
+	row := q.db.QueryRow(ctx, getTrashedNoteByID, id)
+	var i Note
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Content,
+		&i.IsArchived,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.IsPinned,
+		&i.Position,
+		&i.Type,
 	)
 	return i, err
 }
 
-const getLabelsByUserID = `-- name: GetLabelsByUserID :many
-SELECT id, user_id, name, color, created_at, updated_at FROM labels WHERE user_id = $1 ORDER BY name
+const getTrashedNotesByUserID = `-- name: GetTrashedNotesByUserID :many
+SELECT id, user_id, title, content, is_archived, created_at, updated_at, deleted_at, version, is_pinned, position, type FROM notes WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC
 `
 
-func (q *Queries) GetLabelsByUserID(ctx context.Context, userID string) ([]Label, error) {
-	rows, err := q.db.Query(ctx, getLabelsByUserID, userID)
+func (q *Queries) GetTrashedNotesByUserID(ctx context.Context, userID string) ([]Note, error) {
+	rows, err := q.db.Query(ctx, getTrashedNotesByUserID, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Label
+	var items []Note
 	for rows.Next() {
-		var i Label
+		var i Note
 		if err := rows.Scan(
 			&i.ID,
 			&i.UserID,
-			&i.Name,
-			&i.Color,
+			&i.Title,
+			&i.Content,
+			&i.IsArchived,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.IsPinned,
+			&i.Position,
+			&i.Type,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, email, name, password, created_at, updated_at, email_verified_at, deletion_requested_at FROM users WHERE email = $1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EmailVerifiedAt,
+		&i.DeletionRequestedAt,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, email, name, password, created_at, updated_at, email_verified_at, deletion_requested_at FROM users WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EmailVerifiedAt,
+		&i.DeletionRequestedAt,
+	)
+	return i, err
+}
+
+const incrementShareLinkViewCount = `-- name: IncrementShareLinkViewCount :one
+UPDATE share_links SET view_count = view_count + 1 WHERE id = $1
+RETURNING view_count
+`
+
+func (q *Queries) IncrementShareLinkViewCount(ctx context.Context, id string) (int64, error) {
+	row := q.db.QueryRow(ctx, incrementShareLinkViewCount, id)
+	var view_count int64
+	err := row.Scan(&view_count)
+	return view_count, err
+}
+
+const listSessionsByUserID = `-- name: ListSessionsByUserID :many
+SELECT id, user_id, expires_at, created_at, two_factor_pending, public_id, user_agent, ip_address, last_seen_at FROM sessions
+WHERE user_id = $1 AND expires_at > NOW() AND two_factor_pending = FALSE
+ORDER BY last_seen_at DESC
+`
+
+func (q *Queries) ListSessionsByUserID(ctx context.Context, userID string) ([]Session, error) {
+	rows, err := q.db.Query(ctx, listSessionsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.TwoFactorPending,
+			&i.PublicID,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.LastSeenAt,
 		); err != nil {
 			return nil, err
 		}
@@ -297,80 +2013,244 @@ func (q *Queries) GetLabelsByUserID(ctx context.Context, userID string) ([]Label
 	return items, nil
 }
 
-const getLabelsForNote = `-- name: GetLabelsForNote :many
-SELECT l.id, l.user_id, l.name, l.color, l.created_at, l.updated_at FROM labels l
-JOIN note_labels nl ON l.id = nl.label_id
-WHERE nl.note_id = $1
-ORDER BY l.name
+const listUsersPendingDeletionBefore = `-- name: ListUsersPendingDeletionBefore :many
+SELECT id FROM users WHERE deletion_requested_at IS NOT NULL AND deletion_requested_at < $1
+`
+
+func (q *Queries) ListUsersPendingDeletionBefore(ctx context.Context, deletionRequestedAt pgtype.Timestamptz) ([]string, error) {
+	rows, err := q.db.Query(ctx, listUsersPendingDeletionBefore, deletionRequestedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markEmailChangeRequestUsed = `-- name: MarkEmailChangeRequestUsed :execrows
+UPDATE email_change_requests SET used_at = NOW() WHERE id = $1 AND used_at IS NULL AND expires_at > NOW()
+`
+
+func (q *Queries) MarkEmailChangeRequestUsed(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.Exec(ctx, markEmailChangeRequestUsed, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const markEmailVerificationTokenUsed = `-- name: MarkEmailVerificationTokenUsed :execrows
+UPDATE email_verification_tokens SET used_at = NOW() WHERE id = $1 AND used_at IS NULL AND expires_at > NOW()
+`
+
+func (q *Queries) MarkEmailVerificationTokenUsed(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.Exec(ctx, markEmailVerificationTokenUsed, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const markPasswordResetTokenUsed = `-- name: MarkPasswordResetTokenUsed :execrows
+UPDATE password_reset_tokens SET used_at = NOW() WHERE id = $1 AND used_at IS NULL AND expires_at > NOW()
+`
+
+func (q *Queries) MarkPasswordResetTokenUsed(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.Exec(ctx, markPasswordResetTokenUsed, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const markRecoveryCodeUsed = `-- name: MarkRecoveryCodeUsed :execrows
+UPDATE recovery_codes SET used_at = NOW() WHERE id = $1 AND user_id = $2 AND used_at IS NULL
+`
+
+type MarkRecoveryCodeUsedParams struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+func (q *Queries) MarkRecoveryCodeUsed(ctx context.Context, arg MarkRecoveryCodeUsedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markRecoveryCodeUsed, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const markUserEmailVerified = `-- name: MarkUserEmailVerified :exec
+UPDATE users SET email_verified_at = NOW() WHERE id = $1 AND email_verified_at IS NULL
+`
+
+func (q *Queries) MarkUserEmailVerified(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, markUserEmailVerified, id)
+	return err
+}
+
+const moveCheckedChecklistItemsToBottom = `-- name: MoveCheckedChecklistItemsToBottom :exec
+UPDATE checklist_items SET position = ordered.rank * 1024
+FROM (
+    SELECT id, ROW_NUMBER() OVER (ORDER BY is_checked, position, id) AS rank
+    FROM checklist_items
+    WHERE note_id = $1
+) AS ordered
+WHERE checklist_items.id = ordered.id
+`
+
+func (q *Queries) MoveCheckedChecklistItemsToBottom(ctx context.Context, noteID string) error {
+	_, err := q.db.Exec(ctx, moveCheckedChecklistItemsToBottom, noteID)
+	return err
+}
+
+const rebalanceChecklistItemPositions = `-- name: RebalanceChecklistItemPositions :exec
+UPDATE checklist_items SET position = ordered.rank * 1024
+FROM (
+    SELECT id, ROW_NUMBER() OVER (ORDER BY position, id) AS rank
+    FROM checklist_items
+    WHERE note_id = $1
+) AS ordered
+WHERE checklist_items.id = ordered.id
+`
+
+func (q *Queries) RebalanceChecklistItemPositions(ctx context.Context, noteID string) error {
+	_, err := q.db.Exec(ctx, rebalanceChecklistItemPositions, noteID)
+	return err
+}
+
+const rebalanceNotePositions = `-- name: RebalanceNotePositions :exec
+UPDATE notes SET position = ordered.rank * 1024
+FROM (
+    SELECT id, ROW_NUMBER() OVER (ORDER BY is_pinned DESC, position, id) AS rank
+    FROM notes
+    WHERE user_id = $1
+) AS ordered
+WHERE notes.id = ordered.id
+`
+
+func (q *Queries) RebalanceNotePositions(ctx context.Context, userID string) error {
+	_, err := q.db.Exec(ctx, rebalanceNotePositions, userID)
+	return err
+}
+
+const removeLabelFromNote = `-- name: RemoveLabelFromNote :exec
+DELETE FROM note_labels WHERE note_id = $1 AND label_id = $2
+`
+
+type RemoveLabelFromNoteParams struct {
+	NoteID  string `json:"note_id"`
+	LabelID string `json:"label_id"`
+}
+
+func (q *Queries) RemoveLabelFromNote(ctx context.Context, arg RemoveLabelFromNoteParams) error {
+	_, err := q.db.Exec(ctx, removeLabelFromNote, arg.NoteID, arg.LabelID)
+	return err
+}
+
+const restoreNote = `-- name: RestoreNote :exec
+UPDATE notes SET deleted_at = NULL WHERE id = $1
+`
+
+func (q *Queries) RestoreNote(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, restoreNote, id)
+	return err
+}
+
+const scheduleUserDeletion = `-- name: ScheduleUserDeletion :exec
+UPDATE users SET deletion_requested_at = $2 WHERE id = $1
 `
 
-func (q *Queries) GetLabelsForNote(ctx context.Context, noteID string) ([]Label, error) {
-	rows, err := q.db.Query(ctx, getLabelsForNote, noteID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Label
-	for rows.Next() {
-		var i Label
-		if err := rows.Scan(
-			&i.ID,
-			&i.UserID,
-			&i.Name,
-			&i.Color,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type ScheduleUserDeletionParams struct {
+	ID                  string             `json:"id"`
+	DeletionRequestedAt pgtype.Timestamptz `json:"deletion_requested_at"`
 }
 
-const getNoteByID = `-- name: GetNoteByID :one
-SELECT id, user_id, title, content, is_archived, created_at, updated_at FROM notes WHERE id = $1
+func (q *Queries) ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) error {
+	_, err := q.db.Exec(ctx, scheduleUserDeletion, arg.ID, arg.DeletionRequestedAt)
+	return err
+}
+
+const searchNotes = `-- name: SearchNotes :many
+WITH matches AS (
+    SELECT
+        id, user_id, title, content, is_archived, is_pinned, type, created_at, updated_at,
+        ts_rank(to_tsvector('english', title || ' ' || content), to_tsquery('english', $1::text)) AS rank
+    FROM notes
+    WHERE user_id = $2
+        AND deleted_at IS NULL
+        AND (is_archived = false OR $3::boolean)
+        AND to_tsvector('english', title || ' ' || content) @@ to_tsquery('english', $1::text)
+    ORDER BY rank DESC, updated_at DESC
+    LIMIT $4
+)
+SELECT
+    id, user_id, title, content, is_archived, is_pinned, type, created_at, updated_at, rank,
+    ts_headline('english', translate(title, chr(2) || chr(3), ''), to_tsquery('english', $1::text), 'StartSel="' || chr(2) || '", StopSel="' || chr(3) || '", HighlightAll=true')::text AS title_highlight,
+    ts_headline('english', translate(content, chr(2) || chr(3), ''), to_tsquery('english', $1::text), 'StartSel="' || chr(2) || '", StopSel="' || chr(3) || '", MaxFragments=2, MaxWords=30, MinWords=10')::text AS snippet
+FROM matches
+ORDER BY rank DESC, updated_at DESC
 `
 
-func (q *Queries) GetNoteByID(ctx context.Context, id string) (Note, error) {
-	row := q.db.QueryRow(ctx, getNoteByID, id)
-	var i Note
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.Title,
-		&i.Content,
-		&i.IsArchived,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+type SearchNotesParams struct {
+	Query           string `json:"query"`
+	UserID          string `json:"user_id"`
+	IncludeArchived bool   `json:"include_archived"`
+	MaxResults      int32  `json:"max_results"`
 }
 
-const getNotesByUserID = `-- name: GetNotesByUserID :many
-SELECT id, user_id, title, content, is_archived, created_at, updated_at FROM notes WHERE user_id = $1 AND is_archived = false ORDER BY updated_at DESC
-`
+type SearchNotesRow struct {
+	ID             string    `json:"id"`
+	UserID         string    `json:"user_id"`
+	Title          string    `json:"title"`
+	Content        string    `json:"content"`
+	IsArchived     bool      `json:"is_archived"`
+	IsPinned       bool      `json:"is_pinned"`
+	Type           string    `json:"type"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Rank           float32   `json:"rank"`
+	TitleHighlight string    `json:"title_highlight"`
+	Snippet        string    `json:"snippet"`
+}
 
-func (q *Queries) GetNotesByUserID(ctx context.Context, userID string) ([]Note, error) {
-	rows, err := q.db.Query(ctx, getNotesByUserID, userID)
+func (q *Queries) SearchNotes(ctx context.Context, arg SearchNotesParams) ([]SearchNotesRow, error) {
+	rows, err := q.db.Query(ctx, searchNotes,
+		arg.Query,
+		arg.UserID,
+		arg.IncludeArchived,
+		arg.MaxResults,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Note
+	var items []SearchNotesRow
 	for rows.Next() {
-		var i Note
+		var i SearchNotesRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.UserID,
 			&i.Title,
 			&i.Content,
 			&i.IsArchived,
+			&i.IsPinned,
+			&i.Type,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Rank,
+			&i.TitleHighlight,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
@@ -382,137 +2262,118 @@ func (q *Queries) GetNotesByUserID(ctx context.Context, userID string) ([]Note,
 	return items, nil
 }
 
-const getNotesForLabel = `-- name: GetNotesForLabel :many
-SELECT note_id FROM note_labels WHERE label_id = $1
+const setNotePinned = `-- name: SetNotePinned :one
+UPDATE notes
+SET is_pinned = $2, position = (SELECT COALESCE(MIN(n.position), 0) - 1024 FROM notes n WHERE n.user_id = notes.user_id), version = version + 1
+WHERE id = $1
+RETURNING position, version
 `
 
-func (q *Queries) GetNotesForLabel(ctx context.Context, labelID string) ([]string, error) {
-	rows, err := q.db.Query(ctx, getNotesForLabel, labelID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []string
-	for rows.Next() {
-		var note_id string
-		if err := rows.Scan(&note_id); err != nil {
-			return nil, err
-		}
-		items = append(items, note_id)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type SetNotePinnedParams struct {
+	ID       string `json:"id"`
+	IsPinned bool   `json:"is_pinned"`
 }
 
-const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, user_id, expires_at, created_at FROM sessions WHERE id = $1
-`
+type SetNotePinnedRow struct {
+	Position float64 `json:"position"`
+	Version  int32   `json:"version"`
+}
 
-func (q *Queries) GetSessionByID(ctx context.Context, id string) (Session, error) {
-	row := q.db.QueryRow(ctx, getSessionByID, id)
-	var i Session
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.ExpiresAt,
-		&i.CreatedAt,
-	)
+func (q *Queries) SetNotePinned(ctx context.Context, arg SetNotePinnedParams) (SetNotePinnedRow, error) {
+	row := q.db.QueryRow(ctx, setNotePinned, arg.ID, arg.IsPinned)
+	var i SetNotePinnedRow
+	err := row.Scan(&i.Position, &i.Version)
 	return i, err
 }
 
-const getSessionWithUser = `-- name: GetSessionWithUser :one
-SELECT
-    sessions.id AS session_id,
-    sessions.user_id AS session_user_id,
-    sessions.expires_at AS session_expires_at,
-    sessions.created_at AS session_created_at,
-    users.id AS user_id,
-    users.email AS user_email,
-    users.name AS user_name
-FROM sessions
-INNER JOIN users ON sessions.user_id = users.id
-WHERE sessions.id = $1
+const touchNote = `-- name: TouchNote :exec
+UPDATE notes SET updated_at = $2 WHERE id = $1
 `
 
-type GetSessionWithUserRow struct {
-	SessionID        string    `json:"session_id"`
-	SessionUserID    string    `json:"session_user_id"`
-	SessionExpiresAt time.Time `json:"session_expires_at"`
-	SessionCreatedAt time.Time `json:"session_created_at"`
-	UserID           string    `json:"user_id"`
-	UserEmail        string    `json:"user_email"`
-	UserName         string    `json:"user_name"`
+type TouchNoteParams struct {
+	ID        string    `json:"id"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
-func (q *Queries) GetSessionWithUser(ctx context.Context, id string) (GetSessionWithUserRow, error) {
-	row := q.db.QueryRow(ctx, getSessionWithUser, id)
-	var i GetSessionWithUserRow
-	err := row.Scan(
-		&i.SessionID,
-		&i.SessionUserID,
-		&i.SessionExpiresAt,
-		&i.SessionCreatedAt,
-		&i.UserID,
-		&i.UserEmail,
-		&i.UserName,
-	)
-	return i, err
+func (q *Queries) TouchNote(ctx context.Context, arg TouchNoteParams) error {
+	_, err := q.db.Exec(ctx, touchNote, arg.ID, arg.UpdatedAt)
+	return err
 }
 
-const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, name, password, created_at, updated_at FROM users WHERE email = $1
+const trashNote = `-- name: TrashNote :exec
+UPDATE notes SET deleted_at = $2 WHERE id = $1
 `
 
-func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
-	row := q.db.QueryRow(ctx, getUserByEmail, email)
-	var i User
-	err := row.Scan(
-		&i.ID,
-		&i.Email,
-		&i.Name,
-		&i.Password,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+type TrashNoteParams struct {
+	ID        string             `json:"id"`
+	DeletedAt pgtype.Timestamptz `json:"deleted_at"`
 }
 
-const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, name, password, created_at, updated_at FROM users WHERE id = $1
+func (q *Queries) TrashNote(ctx context.Context, arg TrashNoteParams) error {
+	_, err := q.db.Exec(ctx, trashNote, arg.ID, arg.DeletedAt)
+	return err
+}
+
+const uncheckChecklistItems = `-- name: UncheckChecklistItems :execrows
+UPDATE checklist_items SET is_checked = false, updated_at = $2 WHERE note_id = $1 AND is_checked
 `
 
-func (q *Queries) GetUserByID(ctx context.Context, id string) (User, error) {
-	row := q.db.QueryRow(ctx, getUserByID, id)
-	var i User
-	err := row.Scan(
-		&i.ID,
-		&i.Email,
-		&i.Name,
-		&i.Password,
-		&i.CreatedAt,
-		&i.UpdatedAt,
+type UncheckChecklistItemsParams struct {
+	NoteID    string    `json:"note_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (q *Queries) UncheckChecklistItems(ctx context.Context, arg UncheckChecklistItemsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, uncheckChecklistItems, arg.NoteID, arg.UpdatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateChecklistItem = `-- name: UpdateChecklistItem :exec
+UPDATE checklist_items SET text = $3, is_checked = $4, indent = $5, updated_at = $6 WHERE id = $1 AND note_id = $2
+`
+
+type UpdateChecklistItemParams struct {
+	ID        string    `json:"id"`
+	NoteID    string    `json:"note_id"`
+	Text      string    `json:"text"`
+	IsChecked bool      `json:"is_checked"`
+	Indent    int16     `json:"indent"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (q *Queries) UpdateChecklistItem(ctx context.Context, arg UpdateChecklistItemParams) error {
+	_, err := q.db.Exec(ctx, updateChecklistItem,
+		arg.ID,
+		arg.NoteID,
+		arg.Text,
+		arg.IsChecked,
+		arg.Indent,
+		arg.UpdatedAt,
 	)
-	return i, err
+	return err
 }
 
-const removeLabelFromNote = `-- name: RemoveLabelFromNote :exec
-DELETE FROM note_labels WHERE note_id = $1 AND label_id = $2
+const updateChecklistItemPosition = `-- name: UpdateChecklistItemPosition :exec
+UPDATE checklist_items SET position = $2 WHERE id = $1
 `
 
-type RemoveLabelFromNoteParams struct {
-	NoteID  string `json:"note_id"`
-	LabelID string `json:"label_id"`
+type UpdateChecklistItemPositionParams struct {
+	ID       string  `json:"id"`
+	Position float64 `json:"position"`
 }
 
-func (q *Queries) RemoveLabelFromNote(ctx context.Context, arg RemoveLabelFromNoteParams) error {
-	_, err := q.db.Exec(ctx, removeLabelFromNote, arg.NoteID, arg.LabelID)
+func (q *Queries) UpdateChecklistItemPosition(ctx context.Context, arg UpdateChecklistItemPositionParams) error {
+	_, err := q.db.Exec(ctx, updateChecklistItemPosition, arg.ID, arg.Position)
 	return err
 }
 
-const updateLabel = `-- name: UpdateLabel :exec
-UPDATE labels SET name = $2, color = $3, updated_at = $4 WHERE id = $1
+const updateLabel = `-- name: UpdateLabel :execrows
+UPDATE labels
+SET name = $2, color = $3, updated_at = $4, version = version + 1
+WHERE id = $1 AND version = $5
 `
 
 type UpdateLabelParams struct {
@@ -520,20 +2381,27 @@ type UpdateLabelParams struct {
 	Name      string    `json:"name"`
 	Color     string    `json:"color"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Version   int32     `json:"version"`
 }
 
-func (q *Queries) UpdateLabel(ctx context.Context, arg UpdateLabelParams) error {
-	_, err := q.db.Exec(ctx, updateLabel,
+func (q *Queries) UpdateLabel(ctx context.Context, arg UpdateLabelParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateLabel,
 		arg.ID,
 		arg.Name,
 		arg.Color,
 		arg.UpdatedAt,
+		arg.Version,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const updateNote = `-- name: UpdateNote :exec
-UPDATE notes SET title = $2, content = $3, is_archived = $4, updated_at = $5 WHERE id = $1
+const updateNote = `-- name: UpdateNote :execrows
+UPDATE notes
+SET title = $2, content = $3, is_archived = $4, updated_at = $5, type = $7, version = version + 1
+WHERE id = $1 AND version = $6
 `
 
 type UpdateNoteParams struct {
@@ -542,16 +2410,51 @@ type UpdateNoteParams struct {
 	Content    string    `json:"content"`
 	IsArchived bool      `json:"is_archived"`
 	UpdatedAt  time.Time `json:"updated_at"`
+	Version    int32     `json:"version"`
+	Type       string    `json:"type"`
 }
 
-func (q *Queries) UpdateNote(ctx context.Context, arg UpdateNoteParams) error {
-	_, err := q.db.Exec(ctx, updateNote,
+func (q *Queries) UpdateNote(ctx context.Context, arg UpdateNoteParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateNote,
 		arg.ID,
 		arg.Title,
 		arg.Content,
 		arg.IsArchived,
 		arg.UpdatedAt,
+		arg.Version,
+		arg.Type,
 	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateNotePosition = `-- name: UpdateNotePosition :exec
+UPDATE notes SET position = $2 WHERE id = $1
+`
+
+type UpdateNotePositionParams struct {
+	ID       string  `json:"id"`
+	Position float64 `json:"position"`
+}
+
+func (q *Queries) UpdateNotePosition(ctx context.Context, arg UpdateNotePositionParams) error {
+	_, err := q.db.Exec(ctx, updateNotePosition, arg.ID, arg.Position)
+	return err
+}
+
+const updatePersonalAccessTokenLastUsedAt = `-- name: UpdatePersonalAccessTokenLastUsedAt :exec
+UPDATE personal_access_tokens SET last_used_at = $2 WHERE id = $1
+`
+
+type UpdatePersonalAccessTokenLastUsedAtParams struct {
+	ID         string             `json:"id"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+}
+
+func (q *Queries) UpdatePersonalAccessTokenLastUsedAt(ctx context.Context, arg UpdatePersonalAccessTokenLastUsedAtParams) error {
+	_, err := q.db.Exec(ctx, updatePersonalAccessTokenLastUsedAt, arg.ID, arg.LastUsedAt)
 	return err
 }
 
@@ -569,6 +2472,37 @@ func (q *Queries) UpdateSessionExpiresAt(ctx context.Context, arg UpdateSessionE
 	return err
 }
 
+const updateSessionLastSeenAt = `-- name: UpdateSessionLastSeenAt :exec
+UPDATE sessions SET last_seen_at = $2 WHERE id = $1
+`
+
+type UpdateSessionLastSeenAtParams struct {
+	ID         string    `json:"id"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+func (q *Queries) UpdateSessionLastSeenAt(ctx context.Context, arg UpdateSessionLastSeenAtParams) error {
+	_, err := q.db.Exec(ctx, updateSessionLastSeenAt, arg.ID, arg.LastSeenAt)
+	return err
+}
+
+const updateTOTPCredentialLastUsedStep = `-- name: UpdateTOTPCredentialLastUsedStep :execrows
+UPDATE totp_credentials SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2
+`
+
+type UpdateTOTPCredentialLastUsedStepParams struct {
+	UserID       string `json:"user_id"`
+	LastUsedStep int64  `json:"last_used_step"`
+}
+
+func (q *Queries) UpdateTOTPCredentialLastUsedStep(ctx context.Context, arg UpdateTOTPCredentialLastUsedStepParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateTOTPCredentialLastUsedStep, arg.UserID, arg.LastUsedStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateUser = `-- name: UpdateUser :exec
 UPDATE users SET email = $2, name = $3, password = $4 WHERE id = $1
 `
@@ -589,3 +2523,96 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
 	)
 	return err
 }
+
+const updateUserEmail = `-- name: UpdateUserEmail :exec
+UPDATE users SET email = $2, email_verified_at = NOW() WHERE id = $1
+`
+
+type UpdateUserEmailParams struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) error {
+	_, err := q.db.Exec(ctx, updateUserEmail, arg.ID, arg.Email)
+	return err
+}
+
+const upsertNoteShare = `-- name: UpsertNoteShare :one
+INSERT INTO note_shares (id, note_id, user_id, role, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (note_id, user_id) DO UPDATE SET role = EXCLUDED.role, updated_at = EXCLUDED.updated_at
+RETURNING id, note_id, user_id, role, created_at, updated_at
+`
+
+type UpsertNoteShareParams struct {
+	ID        string    `json:"id"`
+	NoteID    string    `json:"note_id"`
+	UserID    string    `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (q *Queries) UpsertNoteShare(ctx context.Context, arg UpsertNoteShareParams) (NoteShare, error) {
+	row := q.db.QueryRow(ctx, upsertNoteShare,
+		arg.ID,
+		arg.NoteID,
+		arg.UserID,
+		arg.Role,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	var i NoteShare
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.UserID,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertRevisionRetentionPolicy = `-- name: UpsertRevisionRetentionPolicy :exec
+INSERT INTO revision_retention_policies (user_id, max_revisions, max_age_days, updated_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE
+SET max_revisions = EXCLUDED.max_revisions, max_age_days = EXCLUDED.max_age_days, updated_at = EXCLUDED.updated_at
+`
+
+type UpsertRevisionRetentionPolicyParams struct {
+	UserID       string    `json:"user_id"`
+	MaxRevisions int32     `json:"max_revisions"`
+	MaxAgeDays   int32     `json:"max_age_days"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (q *Queries) UpsertRevisionRetentionPolicy(ctx context.Context, arg UpsertRevisionRetentionPolicyParams) error {
+	_, err := q.db.Exec(ctx, upsertRevisionRetentionPolicy,
+		arg.UserID,
+		arg.MaxRevisions,
+		arg.MaxAgeDays,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const upsertTOTPCredential = `-- name: UpsertTOTPCredential :exec
+INSERT INTO totp_credentials (user_id, secret, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, confirmed_at = NULL, last_used_step = 0, created_at = EXCLUDED.created_at
+`
+
+type UpsertTOTPCredentialParams struct {
+	UserID    string    `json:"user_id"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) UpsertTOTPCredential(ctx context.Context, arg UpsertTOTPCredentialParams) error {
+	_, err := q.db.Exec(ctx, upsertTOTPCredential, arg.UserID, arg.Secret, arg.CreatedAt)
+	return err
+}
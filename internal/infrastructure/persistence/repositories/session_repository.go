@@ -26,8 +26,10 @@ func (r *SessionRepositoryImpl) Create(ctx context.Context, session *entities.Se
 
 	// Use manual query - session ID is a string (hash) not a UUID
 	_, err = r.q.db.Exec(ctx,
-		"INSERT INTO sessions (id, user_id, expires_at, created_at) VALUES ($1, $2, $3, $4)",
-		session.ID, userID, session.ExpiresAt, session.CreatedAt)
+		`INSERT INTO sessions (id, public_id, user_id, user_agent, ip_address, expires_at, created_at, last_seen_at, two_factor_pending)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		session.ID, session.PublicID, userID, session.UserAgent, session.IPAddress,
+		session.ExpiresAt, session.CreatedAt, session.LastSeenAt, session.TwoFactorPending)
 
 	return err
 }
@@ -35,71 +37,106 @@ func (r *SessionRepositoryImpl) Create(ctx context.Context, session *entities.Se
 func (r *SessionRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Session, error) {
 	// Use manual query - the sessionID is a string (hash) not a UUID
 	var session struct {
-		ID        string    `db:"id"`
-		UserID    uuid.UUID `db:"user_id"`
-		ExpiresAt time.Time `db:"expires_at"`
-		CreatedAt time.Time `db:"created_at"`
+		ID               string    `db:"id"`
+		PublicID         string    `db:"public_id"`
+		UserID           uuid.UUID `db:"user_id"`
+		UserAgent        string    `db:"user_agent"`
+		IPAddress        string    `db:"ip_address"`
+		ExpiresAt        time.Time `db:"expires_at"`
+		CreatedAt        time.Time `db:"created_at"`
+		LastSeenAt       time.Time `db:"last_seen_at"`
+		TwoFactorPending bool      `db:"two_factor_pending"`
 	}
 
-	err := r.q.db.QueryRow(ctx, "SELECT * FROM sessions WHERE id = $1", id).Scan(
+	err := r.q.db.QueryRow(ctx,
+		`SELECT id, public_id, user_id, user_agent, ip_address, expires_at, created_at, last_seen_at, two_factor_pending
+		FROM sessions WHERE id = $1`, id).Scan(
 		&session.ID,
+		&session.PublicID,
 		&session.UserID,
+		&session.UserAgent,
+		&session.IPAddress,
 		&session.ExpiresAt,
 		&session.CreatedAt,
+		&session.LastSeenAt,
+		&session.TwoFactorPending,
 	)
 
 	if err != nil {
-		// Handle "no rows in result set" error gracefully
-		if err.Error() == "no rows in result set" {
+		// A missing row is not an error
+		if isNoRows(err) {
 			return nil, nil
 		}
 		return nil, err
 	}
 
 	return &entities.Session{
-		ID:        session.ID,
-		UserID:    session.UserID.String(),
-		ExpiresAt: session.ExpiresAt,
-		CreatedAt: session.CreatedAt,
+		ID:               session.ID,
+		PublicID:         session.PublicID,
+		UserID:           session.UserID.String(),
+		UserAgent:        session.UserAgent,
+		IPAddress:        session.IPAddress,
+		ExpiresAt:        session.ExpiresAt,
+		CreatedAt:        session.CreatedAt,
+		LastSeenAt:       session.LastSeenAt,
+		TwoFactorPending: session.TwoFactorPending,
 	}, nil
 }
 
 func (r *SessionRepositoryImpl) GetSessionWithUser(ctx context.Context, sessionID string) (*entities.SessionValidationResult, error) {
 	// Use manual query - the sessionID is a string (hash) not a UUID
 	var result struct {
-		SessionID        string    `db:"session_id"`
-		SessionUserID    uuid.UUID `db:"session_user_id"`
-		SessionExpiresAt time.Time `db:"session_expires_at"`
-		SessionCreatedAt time.Time `db:"session_created_at"`
-		UserID           uuid.UUID `db:"user_id"`
-		UserEmail        string    `db:"user_email"`
-		UserName         string    `db:"user_name"`
+		SessionID               string     `db:"session_id"`
+		SessionPublicID         string     `db:"session_public_id"`
+		SessionUserID           uuid.UUID  `db:"session_user_id"`
+		SessionUserAgent        string     `db:"session_user_agent"`
+		SessionIPAddress        string     `db:"session_ip_address"`
+		SessionExpiresAt        time.Time  `db:"session_expires_at"`
+		SessionCreatedAt        time.Time  `db:"session_created_at"`
+		SessionLastSeenAt       time.Time  `db:"session_last_seen_at"`
+		SessionTwoFactorPending bool       `db:"session_two_factor_pending"`
+		UserID                  uuid.UUID  `db:"user_id"`
+		UserEmail               string     `db:"user_email"`
+		UserName                string     `db:"user_name"`
+		UserVerifiedAt          *time.Time `db:"user_email_verified_at"`
 	}
 
 	err := r.q.db.QueryRow(ctx,
 		`SELECT
 			sessions.id AS session_id,
+			sessions.public_id AS session_public_id,
 			sessions.user_id AS session_user_id,
+			sessions.user_agent AS session_user_agent,
+			sessions.ip_address AS session_ip_address,
 			sessions.expires_at AS session_expires_at,
 			sessions.created_at AS session_created_at,
+			sessions.last_seen_at AS session_last_seen_at,
+			sessions.two_factor_pending AS session_two_factor_pending,
 			users.id AS user_id,
 			users.email AS user_email,
-			users.name AS user_name
+			users.name AS user_name,
+			users.email_verified_at AS user_email_verified_at
 		FROM sessions
 		INNER JOIN users ON sessions.user_id = users.id
 		WHERE sessions.id = $1`, sessionID).Scan(
 		&result.SessionID,
+		&result.SessionPublicID,
 		&result.SessionUserID,
+		&result.SessionUserAgent,
+		&result.SessionIPAddress,
 		&result.SessionExpiresAt,
 		&result.SessionCreatedAt,
+		&result.SessionLastSeenAt,
+		&result.SessionTwoFactorPending,
 		&result.UserID,
 		&result.UserEmail,
 		&result.UserName,
+		&result.UserVerifiedAt,
 	)
 
 	if err != nil {
-		// Handle "no rows in result set" error gracefully
-		if err.Error() == "no rows in result set" {
+		// A missing row is not an error
+		if isNoRows(err) {
 			return &entities.SessionValidationResult{
 				Session: nil,
 				User:    nil,
@@ -110,31 +147,89 @@ func (r *SessionRepositoryImpl) GetSessionWithUser(ctx context.Context, sessionI
 
 	return &entities.SessionValidationResult{
 		Session: &entities.Session{
-			ID:        result.SessionID,
-			UserID:    result.SessionUserID.String(),
-			ExpiresAt: result.SessionExpiresAt,
-			CreatedAt: result.SessionCreatedAt,
+			ID:               result.SessionID,
+			PublicID:         result.SessionPublicID,
+			UserID:           result.SessionUserID.String(),
+			UserAgent:        result.SessionUserAgent,
+			IPAddress:        result.SessionIPAddress,
+			ExpiresAt:        result.SessionExpiresAt,
+			CreatedAt:        result.SessionCreatedAt,
+			LastSeenAt:       result.SessionLastSeenAt,
+			TwoFactorPending: result.SessionTwoFactorPending,
 		},
 		User: &entities.User{
-			ID:    result.UserID.String(),
-			Email: result.UserEmail,
-			Name:  result.UserName,
+			ID:              result.UserID.String(),
+			Email:           result.UserEmail,
+			Name:            result.UserName,
+			EmailVerifiedAt: result.UserVerifiedAt,
 		},
 	}, nil
 }
 
+func (r *SessionRepositoryImpl) ListByUserID(ctx context.Context, userID string) ([]*entities.Session, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.q.ListSessionsByUserID(ctx, id.String())
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*entities.Session, len(rows))
+	for i, row := range rows {
+		sessions[i] = &entities.Session{
+			ID:               row.ID,
+			PublicID:         row.PublicID,
+			UserID:           row.UserID,
+			UserAgent:        row.UserAgent,
+			IPAddress:        row.IpAddress,
+			ExpiresAt:        row.ExpiresAt,
+			CreatedAt:        row.CreatedAt,
+			LastSeenAt:       row.LastSeenAt,
+			TwoFactorPending: row.TwoFactorPending,
+		}
+	}
+
+	return sessions, nil
+}
+
 func (r *SessionRepositoryImpl) UpdateExpiresAt(ctx context.Context, sessionID string, expiresAt time.Time) error {
 	// Use manual query - the sessionID is a string (hash) not a UUID
 	_, err := r.q.db.Exec(ctx, "UPDATE sessions SET expires_at = $2 WHERE id = $1", sessionID, expiresAt)
 	return err
 }
 
+func (r *SessionRepositoryImpl) UpdateLastSeenAt(ctx context.Context, sessionID string, lastSeenAt time.Time) error {
+	// Use manual query - the sessionID is a string (hash) not a UUID
+	_, err := r.q.db.Exec(ctx, "UPDATE sessions SET last_seen_at = $2 WHERE id = $1", sessionID, lastSeenAt)
+	return err
+}
+
 func (r *SessionRepositoryImpl) Delete(ctx context.Context, sessionID string) error {
 	// Use manual query - the sessionID is a string (hash) not a UUID
 	_, err := r.q.db.Exec(ctx, "DELETE FROM sessions WHERE id = $1", sessionID)
 	return err
 }
 
+func (r *SessionRepositoryImpl) DeleteByPublicID(ctx context.Context, userID, publicID string) (bool, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := r.q.DeleteSessionByPublicID(ctx, DeleteSessionByPublicIDParams{
+		UserID:   id.String(),
+		PublicID: publicID,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return deleted > 0, nil
+}
+
 func (r *SessionRepositoryImpl) DeleteAllByUserID(ctx context.Context, userID string) error {
 	id, err := uuid.Parse(userID)
 	if err != nil {
@@ -143,3 +238,29 @@ func (r *SessionRepositoryImpl) DeleteAllByUserID(ctx context.Context, userID st
 
 	return r.q.DeleteAllSessionsByUserID(ctx, id.String())
 }
+
+func (r *SessionRepositoryImpl) DeleteAllByUserIDExcept(ctx context.Context, userID, sessionID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+
+	return r.q.DeleteOtherSessionsByUserID(ctx, DeleteOtherSessionsByUserIDParams{
+		UserID: id.String(),
+		ID:     sessionID,
+	})
+}
+
+func (r *SessionRepositoryImpl) DeleteExpired(ctx context.Context, expiredBefore, idleBefore, createdBefore time.Time, limit int) (int, error) {
+	deleted, err := r.q.DeleteExpiredSessions(ctx, DeleteExpiredSessionsParams{
+		ExpiresAt:  expiredBefore,
+		LastSeenAt: idleBefore,
+		CreatedAt:  createdBefore,
+		Limit:      int32(limit),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(deleted), nil
+}
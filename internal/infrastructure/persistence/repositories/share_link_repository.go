@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type ShareLinkRepositoryImpl struct {
+	q *Queries
+}
+
+func NewShareLinkRepository(q *Queries) repositories.ShareLinkRepository {
+	return &ShareLinkRepositoryImpl{q: q}
+}
+
+func (r *ShareLinkRepositoryImpl) Create(ctx context.Context, link *entities.ShareLink) error {
+	// Parse the IDs
+	id, err := uuid.Parse(link.ID)
+	if err != nil {
+		return err
+	}
+	noteID, err := uuid.Parse(link.NoteID)
+	if err != nil {
+		return err
+	}
+
+	var passwordHash pgtype.Text
+	if link.PasswordHash != "" {
+		passwordHash = pgtype.Text{String: link.PasswordHash, Valid: true}
+	}
+	var expiresAt pgtype.Timestamptz
+	if link.ExpiresAt != nil {
+		expiresAt = pgtype.Timestamptz{Time: *link.ExpiresAt, Valid: true}
+	}
+
+	return r.q.CreateShareLink(ctx, CreateShareLinkParams{
+		ID:           id.String(),
+		NoteID:       noteID.String(),
+		TokenHash:    link.TokenHash,
+		PasswordHash: passwordHash,
+		ExpiresAt:    expiresAt,
+		CreatedAt:    link.CreatedAt,
+	})
+}
+
+func (r *ShareLinkRepositoryImpl) GetByHash(ctx context.Context, tokenHash string) (*entities.ShareLink, error) {
+	link, err := r.q.GetShareLinkByHash(ctx, tokenHash)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toShareLink(link), nil
+}
+
+func (r *ShareLinkRepositoryImpl) GetByNoteID(ctx context.Context, noteID string) ([]*entities.ShareLink, error) {
+	id, err := uuid.Parse(noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := r.q.GetShareLinksByNoteID(ctx, id.String())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*entities.ShareLink, len(links))
+	for i, link := range links {
+		result[i] = toShareLink(link)
+	}
+
+	return result, nil
+}
+
+func (r *ShareLinkRepositoryImpl) IncrementViewCount(ctx context.Context, id string) (int64, error) {
+	linkID, err := uuid.Parse(id)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.q.IncrementShareLinkViewCount(ctx, linkID.String())
+}
+
+func (r *ShareLinkRepositoryImpl) Delete(ctx context.Context, noteID, id string) (bool, error) {
+	parsedNoteID, err := uuid.Parse(noteID)
+	if err != nil {
+		return false, err
+	}
+	linkID, err := uuid.Parse(id)
+	if err != nil {
+		// A malformed ID cannot match any row
+		return false, nil
+	}
+
+	deleted, err := r.q.DeleteShareLink(ctx, DeleteShareLinkParams{
+		ID:     linkID.String(),
+		NoteID: parsedNoteID.String(),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return deleted > 0, nil
+}
+
+func (r *ShareLinkRepositoryImpl) DeleteByNoteID(ctx context.Context, noteID string) error {
+	id, err := uuid.Parse(noteID)
+	if err != nil {
+		return err
+	}
+
+	return r.q.DeleteShareLinksByNoteID(ctx, id.String())
+}
+
+func toShareLink(link ShareLink) *entities.ShareLink {
+	return &entities.ShareLink{
+		ID:           link.ID,
+		NoteID:       link.NoteID,
+		TokenHash:    link.TokenHash,
+		PasswordHash: link.PasswordHash.String,
+		ExpiresAt:    timePtr(link.ExpiresAt),
+		ViewCount:    link.ViewCount,
+		CreatedAt:    link.CreatedAt,
+	}
+}
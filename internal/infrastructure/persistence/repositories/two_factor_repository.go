@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+type TwoFactorRepositoryImpl struct {
+	q *Queries
+}
+
+func NewTwoFactorRepository(q *Queries) repositories.TwoFactorRepository {
+	return &TwoFactorRepositoryImpl{q: q}
+}
+
+func (r *TwoFactorRepositoryImpl) SaveTOTPCredential(ctx context.Context, credential *entities.TOTPCredential) error {
+	// Parse the user ID
+	userID, err := uuid.Parse(credential.UserID)
+	if err != nil {
+		return err
+	}
+
+	return r.q.UpsertTOTPCredential(ctx, UpsertTOTPCredentialParams{
+		UserID:    userID.String(),
+		Secret:    credential.Secret,
+		CreatedAt: credential.CreatedAt,
+	})
+}
+
+func (r *TwoFactorRepositoryImpl) GetTOTPCredential(ctx context.Context, userID string) (*entities.TOTPCredential, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		// A malformed ID cannot match any row
+		return nil, nil
+	}
+
+	credential, err := r.q.GetTOTPCredentialByUserID(ctx, id.String())
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &entities.TOTPCredential{
+		UserID:       credential.UserID,
+		Secret:       credential.Secret,
+		ConfirmedAt:  timePtr(credential.ConfirmedAt),
+		LastUsedStep: credential.LastUsedStep,
+		CreatedAt:    credential.CreatedAt,
+	}, nil
+}
+
+func (r *TwoFactorRepositoryImpl) ConfirmTOTPCredential(ctx context.Context, userID string, confirmedAt time.Time) (bool, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := r.q.ConfirmTOTPCredential(ctx, ConfirmTOTPCredentialParams{
+		UserID:      id.String(),
+		ConfirmedAt: pgtype.Timestamptz{Time: confirmedAt, Valid: true},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return updated == 1, nil
+}
+
+func (r *TwoFactorRepositoryImpl) UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := r.q.UpdateTOTPCredentialLastUsedStep(ctx, UpdateTOTPCredentialLastUsedStepParams{
+		UserID:       id.String(),
+		LastUsedStep: step,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return updated == 1, nil
+}
+
+func (r *TwoFactorRepositoryImpl) DeleteTOTPCredential(ctx context.Context, userID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+
+	return r.q.DeleteTOTPCredentialByUserID(ctx, id.String())
+}
+
+func (r *TwoFactorRepositoryImpl) CreateRecoveryCodes(ctx context.Context, codes []*entities.RecoveryCode) error {
+	for _, code := range codes {
+		// Parse the user ID
+		userID, err := uuid.Parse(code.UserID)
+		if err != nil {
+			return err
+		}
+
+		// The code ID is a hash, not a UUID
+		if err := r.q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+			ID:        code.ID,
+			UserID:    userID.String(),
+			CreatedAt: code.CreatedAt,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *TwoFactorRepositoryImpl) UseRecoveryCode(ctx context.Context, userID, id string) (bool, error) {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := r.q.MarkRecoveryCodeUsed(ctx, MarkRecoveryCodeUsedParams{
+		ID:     id,
+		UserID: parsedUserID.String(),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return updated == 1, nil
+}
+
+func (r *TwoFactorRepositoryImpl) CountRemainingRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := r.q.CountUnusedRecoveryCodesByUserID(ctx, id.String())
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+func (r *TwoFactorRepositoryImpl) DeleteRecoveryCodes(ctx context.Context, userID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+
+	return r.q.DeleteRecoveryCodesByUserID(ctx, id.String())
+}
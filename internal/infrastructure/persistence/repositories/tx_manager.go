@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/LaulauChau/note-nest/internal/domain/repositories"
+)
+
+// TxBeginner is implemented by both pgx.Conn and pgxpool.Pool
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type TxManagerImpl struct {
+	db TxBeginner
+	q  *Queries
+}
+
+func NewTxManager(db TxBeginner, q *Queries) repositories.TxManager {
+	return &TxManagerImpl{db: db, q: q}
+}
+
+func (m *TxManagerImpl) WithinTx(ctx context.Context, fn func(ctx context.Context, repos repositories.TxRepositories) error) error {
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// Rolling back a committed transaction is a no-op
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Bind the repositories to the transaction
+	q := m.q.WithTx(tx)
+	repos := repositories.TxRepositories{
+		Users:                NewUserRepository(q),
+		Sessions:             NewSessionRepository(q),
+		PasswordResets:       NewPasswordResetRepository(q),
+		PersonalAccessTokens: NewPersonalAccessTokenRepository(q),
+		Notes:                NewNoteRepository(q),
+		Labels:               NewLabelRepository(q),
+		Revisions:            NewNoteRevisionRepository(q),
+		TwoFactor:            NewTwoFactorRepository(q),
+		ShareLinks:           NewShareLinkRepository(q),
+		Checklists:           NewChecklistItemRepository(q),
+		Attachments:          NewAttachmentRepository(q),
+	}
+
+	if err := fn(ctx, repos); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
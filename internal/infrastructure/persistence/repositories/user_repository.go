@@ -2,10 +2,12 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/LaulauChau/note-nest/internal/domain/entities"
 	"github.com/LaulauChau/note-nest/internal/domain/repositories"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type UserRepositoryImpl struct {
@@ -30,48 +32,53 @@ func (r *UserRepositoryImpl) Create(ctx context.Context, user *entities.User) er
 
 	// Use the manual query instead of CreateUser since it doesn't include ID
 	_, err = r.q.db.Exec(ctx,
-		"INSERT INTO users (id, email, name, password, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)",
-		userID, user.Email, user.Name, user.Password, user.CreatedAt, user.UpdatedAt)
+		"INSERT INTO users (id, email, name, password, email_verified_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		userID, user.Email, user.Name, user.Password, user.EmailVerifiedAt, user.CreatedAt, user.UpdatedAt)
 
-	return err
+	return translateUniqueViolation(err, "user", "email already taken")
 }
 
 func (r *UserRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.User, error) {
 	userID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, err
+		// A malformed ID cannot match any row
+		return nil, nil
 	}
-  
+
 	user, err := r.q.GetUserByID(ctx, userID.String())
 	if err != nil {
-		if err.Error() == "no rows in result set" {
+		if isNoRows(err) {
 			return nil, nil
 		}
 		return nil, err
 	}
 
 	return &entities.User{
-		ID:       user.ID,
-		Email:    user.Email,
-		Name:     user.Name,
-		Password: user.Password,
+		ID:                  user.ID,
+		Email:               user.Email,
+		Name:                user.Name,
+		Password:            user.Password,
+		EmailVerifiedAt:     timePtr(user.EmailVerifiedAt),
+		DeletionRequestedAt: timePtr(user.DeletionRequestedAt),
 	}, nil
 }
 
 func (r *UserRepositoryImpl) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
 	user, err := r.q.GetUserByEmail(ctx, email)
 	if err != nil {
-		if err.Error() == "no rows in result set" {
+		if isNoRows(err) {
 			return nil, nil
 		}
 		return nil, err
 	}
 
 	return &entities.User{
-		ID:       user.ID,
-		Email:    user.Email,
-		Name:     user.Name,
-		Password: user.Password,
+		ID:                  user.ID,
+		Email:               user.Email,
+		Name:                user.Name,
+		Password:            user.Password,
+		EmailVerifiedAt:     timePtr(user.EmailVerifiedAt),
+		DeletionRequestedAt: timePtr(user.DeletionRequestedAt),
 	}, nil
 }
 
@@ -88,7 +95,30 @@ func (r *UserRepositoryImpl) Update(ctx context.Context, user *entities.User) er
 		Password: user.Password,
 	}
 
-	return r.q.UpdateUser(ctx, params)
+	return translateUniqueViolation(r.q.UpdateUser(ctx, params), "user", "email already taken")
+}
+
+func (r *UserRepositoryImpl) UpdateEmail(ctx context.Context, id, email string) error {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+
+	params := UpdateUserEmailParams{
+		ID:    userID.String(),
+		Email: email,
+	}
+
+	return translateUniqueViolation(r.q.UpdateUserEmail(ctx, params), "user", "email already taken")
+}
+
+func (r *UserRepositoryImpl) MarkEmailVerified(ctx context.Context, id string) error {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+
+	return r.q.MarkUserEmailVerified(ctx, userID.String())
 }
 
 func (r *UserRepositoryImpl) Delete(ctx context.Context, id string) error {
@@ -99,3 +129,28 @@ func (r *UserRepositoryImpl) Delete(ctx context.Context, id string) error {
 
 	return r.q.DeleteUser(ctx, userID.String())
 }
+
+func (r *UserRepositoryImpl) ScheduleDeletion(ctx context.Context, id string, requestedAt time.Time) error {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+
+	return r.q.ScheduleUserDeletion(ctx, ScheduleUserDeletionParams{
+		ID:                  userID.String(),
+		DeletionRequestedAt: pgtype.Timestamptz{Time: requestedAt, Valid: true},
+	})
+}
+
+func (r *UserRepositoryImpl) CancelDeletion(ctx context.Context, id string) error {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+
+	return r.q.CancelUserDeletion(ctx, userID.String())
+}
+
+func (r *UserRepositoryImpl) GetPendingDeletionBefore(ctx context.Context, cutoff time.Time) ([]string, error) {
+	return r.q.ListUsersPendingDeletionBefore(ctx, pgtype.Timestamptz{Time: cutoff, Valid: true})
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+)
+
+// FileMailer writes every message as an .eml file in a directory, which is
+// handy to inspect emails locally without a mail server
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (m *FileMailer) Send(ctx context.Context, message services.MailMessage) error {
+	body, err := formatMessage(m.from, message)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.dir, 0o750); err != nil {
+		return fmt.Errorf("error creating mail directory: %w", err)
+	}
+
+	// Timestamp first so the files sort in sending order
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+
+	if err := os.WriteFile(filepath.Join(m.dir, name), body, 0o640); err != nil {
+		return fmt.Errorf("error writing mail: %w", err)
+	}
+
+	return nil
+}
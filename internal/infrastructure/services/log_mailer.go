@@ -0,0 +1,21 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+)
+
+// LogMailer prints messages to the application log instead of sending them.
+// It is meant for development only since messages may contain secrets.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(ctx context.Context, message services.MailMessage) error {
+	log.Printf("mail to %s: %s\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
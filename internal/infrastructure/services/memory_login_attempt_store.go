@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+)
+
+// sweepInterval is how often forgotten entries are removed, so that sprayed
+// keys don't accumulate in memory
+const sweepInterval = time.Minute
+
+type loginAttemptEntry struct {
+	attempts  services.LoginAttempts
+	expiresAt time.Time
+}
+
+// MemoryLoginAttemptStore keeps the attempts in the memory of the process
+type MemoryLoginAttemptStore struct {
+	mu        sync.Mutex
+	entries   map[string]*loginAttemptEntry
+	lastSweep time.Time
+}
+
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{
+		entries:   make(map[string]*loginAttemptEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryLoginAttemptStore) Get(ctx context.Context, key string) (*services.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, nil
+	}
+
+	attempts := entry.attempts
+	return &attempts, nil
+}
+
+func (s *MemoryLoginAttemptStore) RecordFailure(ctx context.Context, key string, at time.Time, ttl time.Duration) (*services.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(at)
+
+	// Start over when the previous failures were forgotten
+	entry, ok := s.entries[key]
+	if !ok || at.After(entry.expiresAt) {
+		entry = &loginAttemptEntry{}
+		s.entries[key] = entry
+	}
+
+	entry.attempts.Failures++
+	entry.attempts.LastFailureAt = at
+	entry.expiresAt = at.Add(ttl)
+
+	attempts := entry.attempts
+	return &attempts, nil
+}
+
+func (s *MemoryLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// sweep removes the expired entries, at most once per sweep interval. The
+// caller must hold the lock.
+func (s *MemoryLoginAttemptStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -31,7 +31,7 @@ func TestAuthenticationFlow(t *testing.T) {
 
 	// Initialize use cases
 	userUseCase := use_cases.NewUserUseCase(userRepo, hashService)
-	sessionUseCase := use_cases.NewSessionUseCase(sessionRepo, userRepo, tokenService)
+	sessionUseCase := use_cases.NewSessionUseCase(sessionRepo, userRepo, tokenService, testSessionConfig)
 
 	// Test data
 	email := "authflow@example.com"
@@ -63,7 +63,7 @@ func TestAuthenticationFlow(t *testing.T) {
 		require.NoError(t, err)
 		require.NotEmpty(t, token)
 
-		session, err := sessionUseCase.CreateSession(ctx, token, authenticatedUser.ID)
+		session, err := sessionUseCase.CreateSession(ctx, token, authenticatedUser.ID, use_cases.SessionClient{})
 		require.NoError(t, err)
 		require.NotNil(t, session)
 		assert.Equal(t, authenticatedUser.ID, session.UserID)
@@ -94,7 +94,7 @@ func TestAuthenticationFlow(t *testing.T) {
 		newToken, err := sessionUseCase.GenerateSessionToken(ctx)
 		require.NoError(t, err)
 
-		newSession, err := sessionUseCase.CreateSession(ctx, newToken, authenticatedUser.ID)
+		newSession, err := sessionUseCase.CreateSession(ctx, newToken, authenticatedUser.ID, use_cases.SessionClient{})
 		require.NoError(t, err)
 		require.NotNil(t, newSession)
 
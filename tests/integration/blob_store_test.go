@@ -0,0 +1,239 @@
+package integration
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appServices "github.com/LaulauChau/note-nest/internal/application/services"
+	"github.com/LaulauChau/note-nest/internal/infrastructure/services"
+)
+
+func TestLocalBlobStore(t *testing.T) {
+	testBlobStore(t, services.NewLocalBlobStore(t.TempDir()))
+
+	t.Run("RejectsEscapingKeys", func(t *testing.T) {
+		store := services.NewLocalBlobStore(t.TempDir())
+		err := store.Put(context.Background(), "../outside", strings.NewReader("nope"), "text/plain")
+		assert.Error(t, err)
+	})
+}
+
+func TestS3BlobStore(t *testing.T) {
+	fake := newFakeS3("note-nest", "test-access-key", "test-secret-key")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := services.NewS3BlobStore(server.URL, "us-east-1", "note-nest", "test-access-key", "test-secret-key")
+	require.NoError(t, err)
+	testBlobStore(t, store)
+
+	t.Run("StoresContentType", func(t *testing.T) {
+		require.NoError(t, store.Put(context.Background(), "typed/file name.txt", strings.NewReader("typed"), "text/plain"))
+		assert.Equal(t, "text/plain", fake.contentType("typed/file name.txt"))
+	})
+
+	t.Run("RejectsWrongSecret", func(t *testing.T) {
+		wrong, err := services.NewS3BlobStore(server.URL, "us-east-1", "note-nest", "test-access-key", "wrong-secret-key")
+		require.NoError(t, err)
+		err = wrong.Put(context.Background(), "forbidden", strings.NewReader("nope"), "text/plain")
+		assert.ErrorContains(t, err, "403")
+	})
+}
+
+// testBlobStore runs the behaviour every BlobStore must share
+func testBlobStore(t *testing.T, store appServices.BlobStore) {
+	ctx := context.Background()
+	read := func(key string, offset, length int64) string {
+		content, err := store.Get(ctx, key, offset, length)
+		require.NoError(t, err)
+		defer content.Close()
+		data, err := io.ReadAll(content)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("PutAndGet", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "note/blob", strings.NewReader("hello blob store"), "text/plain"))
+
+		assert.Equal(t, "hello blob store", read("note/blob", 0, -1))
+		assert.Equal(t, "blob", read("note/blob", 6, 4))
+		assert.Equal(t, "store", read("note/blob", 11, -1))
+
+		// Putting again replaces the content
+		require.NoError(t, store.Put(ctx, "note/blob", strings.NewReader("replaced"), "text/plain"))
+		assert.Equal(t, "replaced", read("note/blob", 0, -1))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "note/deleted", strings.NewReader("short lived"), "text/plain"))
+		require.NoError(t, store.Delete(ctx, "note/deleted"))
+
+		_, err := store.Get(ctx, "note/deleted", 0, -1)
+		assert.ErrorIs(t, err, appServices.ErrBlobNotFound)
+
+		// Deleting twice is fine
+		assert.NoError(t, store.Delete(ctx, "note/deleted"))
+	})
+}
+
+// fakeS3 is a minimal S3 compatible server keeping objects in memory. Like
+// MinIO it rejects requests whose Signature Version 4 does not match.
+type fakeS3 struct {
+	bucket    string
+	accessKey string
+	secretKey string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newFakeS3(bucket, accessKey, secretKey string) *fakeS3 {
+	return &fakeS3{
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		objects:   make(map[string][]byte),
+		types:     make(map[string]string),
+	}
+}
+
+func (s *fakeS3) contentType(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.types[key]
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !s.validSignature(r, body) {
+		http.Error(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>", http.StatusForbidden)
+		return
+	}
+
+	key, found := strings.CutPrefix(r.URL.Path, "/"+s.bucket+"/")
+	if !found {
+		http.Error(w, "<Error><Code>NoSuchBucket</Code></Error>", http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		s.objects[key] = body
+		s.types[key] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		object, ok := s.objects[key]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		if spec, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes="); ok {
+			first, last, _ := strings.Cut(spec, "-")
+			start, _ := strconv.Atoi(first)
+			end := len(object) - 1
+			if last != "" {
+				end, _ = strconv.Atoi(last)
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(object)))
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write(object[start : end+1])
+			return
+		}
+		_, _ = w.Write(object)
+	case http.MethodDelete:
+		delete(s.objects, key)
+		delete(s.types, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// validSignature recomputes the signature of the request from the headers it
+// says were signed
+func (s *fakeS3) validSignature(r *http.Request, body []byte) bool {
+	var credential, signedHeaders, signature string
+	authorization, ok := strings.CutPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ")
+	if !ok {
+		return false
+	}
+	for _, field := range strings.Split(authorization, ", ") {
+		name, value, _ := strings.Cut(field, "=")
+		switch name {
+		case "Credential":
+			credential = value
+		case "SignedHeaders":
+			signedHeaders = value
+		case "Signature":
+			signature = value
+		}
+	}
+	accessKey, scope, _ := strings.Cut(credential, "/")
+	if accessKey != s.accessKey {
+		return false
+	}
+
+	payloadHash := sha256.Sum256(body)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(payloadHash[:]) {
+		return false
+	}
+
+	names := strings.Split(signedHeaders, ";")
+	if !sort.StringsAreSorted(names) {
+		return false
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		r.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + r.Header.Get("X-Amz-Date") + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	// The scope is date/region/service/aws4_request
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range strings.Split(scope, "/") {
+		key = hmacSum(key, part)
+	}
+	expected := hex.EncodeToString(hmacSum(key, stringToSign))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func hmacSum(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
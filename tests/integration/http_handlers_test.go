@@ -3,17 +3,29 @@ package integration
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/LaulauChau/note-nest/internal/adapter/http/controller"
+	"github.com/LaulauChau/note-nest/internal/adapter/http/problem"
 	"github.com/LaulauChau/note-nest/internal/adapter/http/router"
 	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/infrastructure/persistence/database"
 	"github.com/LaulauChau/note-nest/internal/infrastructure/persistence/repositories"
 	"github.com/LaulauChau/note-nest/internal/infrastructure/services"
 )
@@ -31,25 +43,111 @@ func TestHTTPHandlers(t *testing.T) {
 	sessionRepo := repositories.NewSessionRepository(queries)
 	noteRepo := repositories.NewNoteRepository(queries)
 	labelRepo := repositories.NewLabelRepository(queries)
+	revisionRepo := repositories.NewNoteRevisionRepository(queries)
+	txManager := repositories.NewTxManager(db.Pool, queries)
+	passwordResetRepo := repositories.NewPasswordResetRepository(queries)
+	emailVerificationRepo := repositories.NewEmailVerificationRepository(queries)
+	emailChangeRepo := repositories.NewEmailChangeRepository(queries)
+	twoFactorRepo := repositories.NewTwoFactorRepository(queries)
+	loginAuditRepo := repositories.NewLoginAuditRepository(queries)
+	personalAccessTokenRepo := repositories.NewPersonalAccessTokenRepository(queries)
+	noteShareRepo := repositories.NewNoteShareRepository(queries)
+	shareLinkRepo := repositories.NewShareLinkRepository(queries)
+	checklistItemRepo := repositories.NewChecklistItemRepository(queries)
+	attachmentRepo := repositories.NewAttachmentRepository(queries)
 
 	// Initialize services
 	tokenService := services.NewTokenService()
 	hashService := services.NewArgonHashService()
+	totpService := services.NewTOTPService("Note Nest")
+	mailer := NewCapturingMailer()
+	loginAttemptStore := services.NewMemoryLoginAttemptStore()
+	blobDir := t.TempDir()
+	blobStore := services.NewLocalBlobStore(blobDir)
 
 	// Initialize use cases
 	userUseCase := use_cases.NewUserUseCase(userRepo, hashService)
-	sessionUseCase := use_cases.NewSessionUseCase(sessionRepo, userRepo, tokenService)
-	noteUseCase := use_cases.NewNoteUseCase(noteRepo, userRepo, labelRepo)
-	labelUseCase := use_cases.NewLabelUseCase(labelRepo, userRepo, noteRepo)
+	sessionUseCase := use_cases.NewSessionUseCase(sessionRepo, userRepo, tokenService, testSessionConfig)
+	notePermissions := use_cases.NewNotePermissionService(noteRepo, noteShareRepo)
+	noteUseCase := use_cases.NewNoteUseCase(noteRepo, userRepo, labelRepo, revisionRepo, txManager, notePermissions, blobStore)
+	labelUseCase := use_cases.NewLabelUseCase(labelRepo, userRepo, noteRepo, notePermissions)
+	revisionUseCase := use_cases.NewNoteRevisionUseCase(revisionRepo, noteRepo, userRepo, txManager, notePermissions)
+	emailVerificationUseCase := use_cases.NewEmailVerificationUseCase(userRepo, emailVerificationRepo, tokenService, mailer, use_cases.EmailVerificationRestrict, "http://localhost:8080", 24*time.Hour, time.Minute)
+	noteShareUseCase := use_cases.NewNoteShareUseCase(noteShareRepo, userRepo, emailVerificationUseCase, notePermissions)
+	checklistUseCase := use_cases.NewChecklistUseCase(checklistItemRepo, txManager, notePermissions)
+	attachmentUseCase := use_cases.NewAttachmentUseCase(attachmentRepo, blobStore, notePermissions, 1<<20, 2<<20)
+	emailChangeUseCase := use_cases.NewEmailChangeUseCase(userRepo, emailChangeRepo, tokenService, hashService, mailer, "http://localhost:8080", 24*time.Hour)
+	personalAccessTokenUseCase := use_cases.NewPersonalAccessTokenUseCase(personalAccessTokenRepo, userRepo, tokenService)
+	twoFactorUseCase := use_cases.NewTwoFactorUseCase(userRepo, twoFactorRepo, totpService, tokenService, txManager)
+	accountUseCase := use_cases.NewAccountUseCase(userRepo, sessionUseCase, hashService, txManager, blobStore, 0)
+	// Every request comes from the same address, so only accounts are throttled
+	loginThrottleUseCase := use_cases.NewLoginThrottleUseCase(loginAttemptStore, loginAuditRepo, use_cases.LoginThrottleConfig{
+		Email:           use_cases.LoginThrottlePolicy{FreeAttempts: 3, LockoutThreshold: 5},
+		IP:              use_cases.LoginThrottlePolicy{FreeAttempts: 1000},
+		BaseDelay:       time.Minute,
+		MaxDelay:        time.Hour,
+		LockoutDuration: time.Hour,
+		ResetAfter:      time.Hour,
+	})
+	shareLinkUseCase := use_cases.NewShareLinkUseCase(shareLinkRepo, noteRepo, checklistItemRepo, tokenService, hashService, notePermissions, loginThrottleUseCase, "http://localhost:8080")
+	passwordResetUseCase := use_cases.NewPasswordResetUseCase(userRepo, passwordResetRepo, txManager, emailVerificationUseCase, tokenService, hashService, mailer, loginAttemptStore, "http://localhost:8080", use_cases.PasswordResetConfig{TokenTTL: time.Hour})
 
 	// Initialize controllers
-	userController := controller.NewUserController(userUseCase, sessionUseCase)
-	sessionController := controller.NewSessionController(sessionUseCase)
+	userController := controller.NewUserController(userUseCase, sessionUseCase, personalAccessTokenUseCase, emailVerificationUseCase, emailChangeUseCase, twoFactorUseCase, loginThrottleUseCase)
+	sessionController := controller.NewSessionController(sessionUseCase, personalAccessTokenUseCase)
 	noteController := controller.NewNoteController(noteUseCase, labelUseCase)
 	labelController := controller.NewLabelController(labelUseCase)
+	revisionController := controller.NewRevisionController(revisionUseCase)
+	healthController := controller.NewHealthController(database.NewPoolMonitor(db.Pool))
+	passwordController := controller.NewPasswordController(passwordResetUseCase)
+	emailVerificationController := controller.NewEmailVerificationController(emailVerificationUseCase)
+	accountController := controller.NewAccountController(accountUseCase, loginThrottleUseCase)
+	twoFactorController := controller.NewTwoFactorController(twoFactorUseCase, sessionUseCase, loginThrottleUseCase)
+	personalAccessTokenController := controller.NewPersonalAccessTokenController(personalAccessTokenUseCase)
+	noteShareController := controller.NewNoteShareController(noteShareUseCase)
+	shareLinkController := controller.NewShareLinkController(shareLinkUseCase)
+	checklistController := controller.NewChecklistController(checklistUseCase)
+	attachmentController := controller.NewAttachmentController(attachmentUseCase, time.Minute)
 
 	// Initialize router
-	r := router.NewRouter(userController, sessionController, noteController, labelController)
+	r := router.NewRouter(userController, sessionController, noteController, labelController, revisionController, healthController, passwordController, emailVerificationController, accountController, twoFactorController, personalAccessTokenController, noteShareController, shareLinkController, checklistController, attachmentController)
+
+	// withCSRFToken adds the CSRF token of the session to a request sent with
+	// its cookie, as the web client does for every state-changing request
+	withCSRFToken := func(t *testing.T, req *http.Request, sessionToken string) {
+		csrfToken, err := sessionUseCase.CSRFToken(ctx, sessionToken)
+		require.NoError(t, err)
+		req.Header.Set(controller.CSRFHeader, csrfToken)
+	}
+
+	t.Run("HealthCheck", func(t *testing.T) {
+		// Create request
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		rec := httptest.NewRecorder()
+
+		// Serve the request
+		r.ServeHTTP(rec, req)
+
+		// Check response
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response controller.HealthResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", response.Status)
+
+		// The pool statistics are only served on the internal router
+		assert.NotContains(t, rec.Body.String(), "pool")
+
+		rec = httptest.NewRecorder()
+		router.NewInternalRouter(healthController).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var detailed controller.DetailedHealthResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detailed))
+		assert.Equal(t, "ok", detailed.Database)
+		assert.Positive(t, detailed.Pool.MaxConns)
+	})
 
 	t.Run("RegisterUser", func(t *testing.T) {
 		// Create request payload
@@ -227,7 +325,7 @@ func TestHTTPHandlers(t *testing.T) {
 		// Generate session token and create session
 		token, err := sessionUseCase.GenerateSessionToken(ctx)
 		require.NoError(t, err)
-		session, err := sessionUseCase.CreateSession(ctx, token, user.ID)
+		session, err := sessionUseCase.CreateSession(ctx, token, user.ID, use_cases.SessionClient{})
 		require.NoError(t, err)
 		require.NotNil(t, session)
 
@@ -267,7 +365,7 @@ func TestHTTPHandlers(t *testing.T) {
 		// Generate session token and create session
 		token, err := sessionUseCase.GenerateSessionToken(ctx)
 		require.NoError(t, err)
-		session, err := sessionUseCase.CreateSession(ctx, token, user.ID)
+		session, err := sessionUseCase.CreateSession(ctx, token, user.ID, use_cases.SessionClient{})
 		require.NoError(t, err)
 		require.NotNil(t, session)
 
@@ -279,6 +377,7 @@ func TestHTTPHandlers(t *testing.T) {
 			Name:  "session",
 			Value: token,
 		})
+		withCSRFToken(t, req, token)
 
 		// Perform request
 		recorder := httptest.NewRecorder()
@@ -299,6 +398,7 @@ func TestHTTPHandlers(t *testing.T) {
 		require.NotNil(t, sessionCookie, "Session cookie should be present")
 		assert.Equal(t, "", sessionCookie.Value, "Session cookie value should be empty")
 		assert.True(t, sessionCookie.MaxAge < 0, "Session cookie should be expired")
+		assert.Equal(t, http.SameSiteStrictMode, sessionCookie.SameSite, "Cleared session cookie should keep SameSite=Strict")
 
 		// Try to access protected route after logout
 		protectedReq := httptest.NewRequest(http.MethodGet, "/api/me", nil)
@@ -313,4 +413,1086 @@ func TestHTTPHandlers(t *testing.T) {
 		// Should return unauthorized
 		assert.Equal(t, http.StatusUnauthorized, protectedRecorder.Code)
 	})
+
+	t.Run("UpdateNoteIfMatch", func(t *testing.T) {
+		// Register a user with a session and a note
+		user, err := userUseCase.RegisterUser(ctx, "ifmatchtest@example.com", "IfMatch Test", "IfM@tch!P@ssw0rd")
+		require.NoError(t, err)
+		token, err := sessionUseCase.GenerateSessionToken(ctx)
+		require.NoError(t, err)
+		_, err = sessionUseCase.CreateSession(ctx, token, user.ID, use_cases.SessionClient{})
+		require.NoError(t, err)
+		note, err := noteUseCase.CreateNote(ctx, user.ID, "Shared", "Original", "")
+		require.NoError(t, err)
+
+		// Read the note to get its ETag
+		getReq := httptest.NewRequest(http.MethodGet, "/api/notes/"+note.ID, nil)
+		getReq.AddCookie(&http.Cookie{Name: "session", Value: token})
+		getRecorder := httptest.NewRecorder()
+		r.ServeHTTP(getRecorder, getReq)
+		require.Equal(t, http.StatusOK, getRecorder.Code)
+		etag := getRecorder.Header().Get("ETag")
+		require.Equal(t, `"1"`, etag)
+
+		update := func(content string) *httptest.ResponseRecorder {
+			payload, err := json.Marshal(map[string]interface{}{"title": "Shared", "content": content})
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPut, "/api/notes/"+note.ID, bytes.NewBuffer(payload))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", etag)
+			req.AddCookie(&http.Cookie{Name: "session", Value: token})
+			withCSRFToken(t, req, token)
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		// The first client saves with the current ETag
+		first := update("First client")
+		require.Equal(t, http.StatusOK, first.Code)
+		assert.Equal(t, `"2"`, first.Header().Get("ETag"))
+
+		// The second client still sends the old ETag
+		second := update("Second client")
+		assert.Equal(t, http.StatusPreconditionFailed, second.Code)
+
+		// Restores check the ETag the same way
+		restore := func(etag string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/api/notes/"+note.ID+"/revisions/1/restore", nil)
+			req.Header.Set("If-Match", etag)
+			req.AddCookie(&http.Cookie{Name: "session", Value: token})
+			withCSRFToken(t, req, token)
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+		assert.Equal(t, http.StatusPreconditionFailed, restore(etag).Code)
+		restored := restore(first.Header().Get("ETag"))
+		require.Equal(t, http.StatusOK, restored.Code)
+		assert.Equal(t, `"3"`, restored.Header().Get("ETag"))
+	})
+
+	t.Run("DomainErrorStatuses", func(t *testing.T) {
+		// Register a user with a session
+		user, err := userUseCase.RegisterUser(ctx, "errorstest@example.com", "Errors Test", "Err0rs!P@ssw0rd")
+		require.NoError(t, err)
+		token, err := sessionUseCase.GenerateSessionToken(ctx)
+		require.NoError(t, err)
+		_, err = sessionUseCase.CreateSession(ctx, token, user.ID, use_cases.SessionClient{})
+		require.NoError(t, err)
+
+		send := func(method, path string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, nil)
+			req.AddCookie(&http.Cookie{Name: "session", Value: token})
+			withCSRFToken(t, req, token)
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		// Missing or malformed IDs are reported as not found
+		assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/api/notes/not-a-uuid").Code)
+		assert.Equal(t, http.StatusNotFound, send(http.MethodDelete, "/api/notes/"+uuid.New().String()).Code)
+		assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/api/labels/not-a-uuid").Code)
+		assert.Equal(t, http.StatusNotFound, send(http.MethodPost, "/api/notes/"+uuid.New().String()+"/restore").Code)
+
+		// Invalid listing options are rejected as bad requests
+		assert.Equal(t, http.StatusBadRequest, send(http.MethodGet, "/api/notes?sort=color").Code)
+		assert.Equal(t, http.StatusBadRequest, send(http.MethodGet, "/api/notes?cursor=garbage").Code)
+
+		// Registering the same email twice is a conflict
+		payload, err := json.Marshal(map[string]string{
+			"email":    "errorstest@example.com",
+			"name":     "Errors Test",
+			"password": "Err0rs!P@ssw0rd",
+		})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/api/register", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+	})
+
+	t.Run("ProblemDetails", func(t *testing.T) {
+		// Register without a name and with a short password
+		payload, err := json.Marshal(map[string]string{
+			"email":    "problemtest@example.com",
+			"password": "short",
+		})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/api/register", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, req)
+
+		// Check the problem details
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, problem.ContentType, recorder.Header().Get("Content-Type"))
+
+		var details problem.Details
+		err = json.Unmarshal(recorder.Body.Bytes(), &details)
+		require.NoError(t, err)
+		assert.Equal(t, problem.TypeValidation, details.Type)
+		assert.Equal(t, http.StatusBadRequest, details.Status)
+		assert.Equal(t, "/api/register", details.Instance)
+		assert.NotEmpty(t, details.RequestID)
+		require.Len(t, details.Errors, 1)
+		assert.Equal(t, "name", details.Errors[0].Field)
+
+		// Unknown routes are problems too
+		req = httptest.NewRequest(http.MethodGet, "/api/unknown", nil)
+		recorder = httptest.NewRecorder()
+		r.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, problem.ContentType, recorder.Header().Get("Content-Type"))
+	})
+
+	t.Run("PasswordReset", func(t *testing.T) {
+		user, err := userUseCase.RegisterUser(ctx, "forgot@example.com", "Forgot Test", "F0rgot!P@ssw0rd")
+		require.NoError(t, err)
+		err = userRepo.MarkEmailVerified(ctx, user.ID)
+		require.NoError(t, err)
+
+		post := func(path string, payload map[string]string) *httptest.ResponseRecorder {
+			body, err := json.Marshal(payload)
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		// Unknown and known addresses get the same answer
+		assert.Equal(t, http.StatusAccepted, post("/api/password/forgot", map[string]string{"email": "unknown@example.com"}).Code)
+		assert.Equal(t, http.StatusAccepted, post("/api/password/forgot", map[string]string{"email": "forgot@example.com"}).Code)
+
+		message, sent := mailer.Last("forgot@example.com")
+		require.True(t, sent)
+		match := linkTokenPattern.FindStringSubmatch(message.Body)
+		require.Len(t, match, 2)
+
+		// Weak passwords are rejected before the token is used
+		weak := post("/api/password/reset", map[string]string{"token": match[1], "password": "weak"})
+		assert.Equal(t, http.StatusBadRequest, weak.Code)
+
+		// Reset the password, then log in with it
+		reset := post("/api/password/reset", map[string]string{"token": match[1], "password": "N3w!F0rgot!P@ss"})
+		assert.Equal(t, http.StatusNoContent, reset.Code)
+
+		login := post("/api/login", map[string]string{"email": "forgot@example.com", "password": "N3w!F0rgot!P@ss"})
+		assert.Equal(t, http.StatusOK, login.Code)
+	})
+
+	t.Run("EmailVerification", func(t *testing.T) {
+		post := func(path string, payload map[string]string) *httptest.ResponseRecorder {
+			body, err := json.Marshal(payload)
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		// Registering sends a verification link
+		register := post("/api/register", map[string]string{"email": "verify@example.com", "name": "Verify Test", "password": "V3rify!P@ssw0rd"})
+		require.Equal(t, http.StatusCreated, register.Code)
+
+		var registered controller.RegisterResponse
+		err := json.Unmarshal(register.Body.Bytes(), &registered)
+		require.NoError(t, err)
+		assert.False(t, registered.EmailVerified)
+
+		message, sent := mailer.Last("verify@example.com")
+		require.True(t, sent)
+		match := linkTokenPattern.FindStringSubmatch(message.Body)
+		require.Len(t, match, 2)
+
+		// Asking for another link right away is dropped, with the same answer
+		// as for an unknown address
+		resend := post("/api/email/verify/resend", map[string]string{"email": "verify@example.com"})
+		assert.Equal(t, http.StatusAccepted, resend.Code)
+		assert.Empty(t, resend.Header().Get("Retry-After"))
+		resent, _ := mailer.Last("verify@example.com")
+		assert.Equal(t, message, resent)
+		assert.Equal(t, http.StatusAccepted, post("/api/email/verify/resend", map[string]string{"email": "nobody@example.com"}).Code)
+
+		// Verify the address, the link only works once
+		assert.Equal(t, http.StatusNoContent, post("/api/email/verify", map[string]string{"token": match[1]}).Code)
+		assert.Equal(t, http.StatusBadRequest, post("/api/email/verify", map[string]string{"token": match[1]}).Code)
+
+		user, err := userRepo.GetByEmail(ctx, "verify@example.com")
+		require.NoError(t, err)
+		assert.True(t, user.EmailVerified())
+
+		// Verified addresses are not sent new links
+		assert.Equal(t, http.StatusAccepted, post("/api/email/verify/resend", map[string]string{"email": "verify@example.com"}).Code)
+	})
+
+	t.Run("AccountManagement", func(t *testing.T) {
+		// Register a user signed in on two devices
+		user, err := userUseCase.RegisterUser(ctx, "account@example.com", "Account Test", "Acc0unt!P@ssw0rd")
+		require.NoError(t, err)
+		currentToken, err := sessionUseCase.GenerateSessionToken(ctx)
+		require.NoError(t, err)
+		_, err = sessionUseCase.CreateSession(ctx, currentToken, user.ID, use_cases.SessionClient{})
+		require.NoError(t, err)
+		otherToken, err := sessionUseCase.GenerateSessionToken(ctx)
+		require.NoError(t, err)
+		_, err = sessionUseCase.CreateSession(ctx, otherToken, user.ID, use_cases.SessionClient{})
+		require.NoError(t, err)
+
+		send := func(method, path string, payload map[string]string) *httptest.ResponseRecorder {
+			body, err := json.Marshal(payload)
+			require.NoError(t, err)
+			req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(&http.Cookie{Name: "session", Value: currentToken})
+			withCSRFToken(t, req, currentToken)
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		// Update the profile
+		profile := send(http.MethodPatch, "/api/me", map[string]string{"name": "Renamed"})
+		require.Equal(t, http.StatusOK, profile.Code)
+		var updated controller.UserResponse
+		err = json.Unmarshal(profile.Body.Bytes(), &updated)
+		require.NoError(t, err)
+		assert.Equal(t, "Renamed", updated.Name)
+
+		// The current password is required and the policy applies
+		assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/api/me/password", map[string]string{"current_password": "Wr0ng!P@ssw0rd", "new_password": "N3w!Acc0unt!P@ss"}).Code)
+		assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/api/me/password", map[string]string{"current_password": "Acc0unt!P@ssw0rd", "new_password": "weak"}).Code)
+
+		// Change the password, only the current session survives
+		assert.Equal(t, http.StatusNoContent, send(http.MethodPost, "/api/me/password", map[string]string{"current_password": "Acc0unt!P@ssw0rd", "new_password": "N3w!Acc0unt!P@ss"}).Code)
+		current, err := sessionUseCase.ValidateSessionToken(ctx, currentToken)
+		require.NoError(t, err)
+		assert.NotNil(t, current.Session)
+		other, err := sessionUseCase.ValidateSessionToken(ctx, otherToken)
+		require.NoError(t, err)
+		assert.Nil(t, other.Session)
+
+		// Request an email change, the address only changes once confirmed
+		assert.Equal(t, http.StatusAccepted, send(http.MethodPost, "/api/me/email", map[string]string{"email": "account-new@example.com", "password": "N3w!Acc0unt!P@ss"}).Code)
+		unchanged, err := userRepo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "account@example.com", unchanged.Email)
+
+		message, sent := mailer.Last("account-new@example.com")
+		require.True(t, sent)
+		match := linkTokenPattern.FindStringSubmatch(message.Body)
+		require.Len(t, match, 2)
+
+		assert.Equal(t, http.StatusNoContent, send(http.MethodPost, "/api/email/change/confirm", map[string]string{"token": match[1]}).Code)
+		changed, err := userRepo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "account-new@example.com", changed.Email)
+		assert.True(t, changed.EmailVerified())
+
+		// The previous address was notified
+		_, notified := mailer.Last("account@example.com")
+		assert.True(t, notified)
+	})
+
+	t.Run("DeleteAccount", func(t *testing.T) {
+		// Register a user owning a note and a label
+		user, err := userUseCase.RegisterUser(ctx, "delete@example.com", "Delete Test", "D3lete!P@ssw0rd")
+		require.NoError(t, err)
+		_, err = noteUseCase.CreateNote(ctx, user.ID, "Doomed", "Content", "")
+		require.NoError(t, err)
+		_, err = labelUseCase.CreateLabel(ctx, user.ID, "doomed", "#ff0000")
+		require.NoError(t, err)
+		sessionToken, err := sessionUseCase.GenerateSessionToken(ctx)
+		require.NoError(t, err)
+		_, err = sessionUseCase.CreateSession(ctx, sessionToken, user.ID, use_cases.SessionClient{})
+		require.NoError(t, err)
+
+		deleteAccount := func(password string) *httptest.ResponseRecorder {
+			body, err := json.Marshal(map[string]string{"password": password})
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodDelete, "/api/me", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(&http.Cookie{Name: "session", Value: sessionToken})
+			withCSRFToken(t, req, sessionToken)
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		// The password is required
+		assert.Equal(t, http.StatusBadRequest, deleteAccount("Wr0ng!P@ssw0rd").Code)
+
+		// Without a grace period everything is removed right away
+		assert.Equal(t, http.StatusNoContent, deleteAccount("D3lete!P@ssw0rd").Code)
+
+		deleted, err := userRepo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Nil(t, deleted)
+		notes, err := noteRepo.GetByUserID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, notes)
+		labels, err := labelRepo.GetByUserID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, labels)
+		session, err := sessionUseCase.ValidateSessionToken(ctx, sessionToken)
+		require.NoError(t, err)
+		assert.Nil(t, session.Session)
+	})
+
+	t.Run("TwoFactorAuthentication", func(t *testing.T) {
+		// Register a signed in user
+		user, err := userUseCase.RegisterUser(ctx, "twofactor@example.com", "Two Factor", "TwoF@ct0r!P@ss")
+		require.NoError(t, err)
+		sessionToken, err := sessionUseCase.GenerateSessionToken(ctx)
+		require.NoError(t, err)
+		_, err = sessionUseCase.CreateSession(ctx, sessionToken, user.ID, use_cases.SessionClient{})
+		require.NoError(t, err)
+
+		send := func(method, path, token string, payload map[string]string) *httptest.ResponseRecorder {
+			body, err := json.Marshal(payload)
+			require.NoError(t, err)
+			req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			if token != "" {
+				req.AddCookie(&http.Cookie{Name: "session", Value: token})
+				withCSRFToken(t, req, token)
+			}
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+		sessionCookie := func(recorder *httptest.ResponseRecorder) string {
+			for _, cookie := range recorder.Result().Cookies() {
+				if cookie.Name == "session" {
+					return cookie.Value
+				}
+			}
+			return ""
+		}
+
+		// Enroll an authenticator app
+		enroll := send(http.MethodPost, "/api/me/2fa/enroll", sessionToken, nil)
+		require.Equal(t, http.StatusOK, enroll.Code)
+		var enrollment controller.TwoFactorEnrollmentResponse
+		require.NoError(t, json.Unmarshal(enroll.Body.Bytes(), &enrollment))
+		assert.Contains(t, enrollment.ProvisioningURI, "otpauth://totp/")
+
+		// Confirm it with a code, which returns the recovery codes
+		assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/api/me/2fa/confirm", sessionToken, map[string]string{"code": "000000"}).Code)
+		confirm := send(http.MethodPost, "/api/me/2fa/confirm", sessionToken, map[string]string{"code": totpCode(t, enrollment.Secret, time.Now())})
+		require.Equal(t, http.StatusOK, confirm.Code)
+		var recovery controller.RecoveryCodesResponse
+		require.NoError(t, json.Unmarshal(confirm.Body.Bytes(), &recovery))
+		require.Len(t, recovery.RecoveryCodes, 10)
+
+		// The password alone only gives a partial session
+		login := send(http.MethodPost, "/api/login", "", map[string]string{"email": "twofactor@example.com", "password": "TwoF@ct0r!P@ss"})
+		require.Equal(t, http.StatusOK, login.Code)
+		var loginResponse controller.LoginResponse
+		require.NoError(t, json.Unmarshal(login.Body.Bytes(), &loginResponse))
+		assert.True(t, loginResponse.TwoFactorRequired)
+		partialToken := sessionCookie(login)
+		require.NotEmpty(t, partialToken)
+		assert.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "/api/me", partialToken, nil).Code)
+
+		// The code upgrades it to a full session under a new token. The code of
+		// the next step is used, the current one was spent on the confirmation.
+		verify := send(http.MethodPost, "/api/login/2fa", partialToken, map[string]string{"code": totpCode(t, enrollment.Secret, time.Now().Add(30*time.Second))})
+		require.Equal(t, http.StatusOK, verify.Code)
+		fullToken := sessionCookie(verify)
+		require.NotEmpty(t, fullToken)
+		assert.NotEqual(t, partialToken, fullToken)
+		assert.Equal(t, http.StatusOK, send(http.MethodGet, "/api/me", fullToken, nil).Code)
+		assert.Equal(t, http.StatusUnauthorized, send(http.MethodPost, "/api/login/2fa", partialToken, map[string]string{"code": "000000"}).Code)
+
+		// A recovery code can stand in for the app to disable it
+		assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, "/api/me/2fa", fullToken, map[string]string{"code": recovery.RecoveryCodes[0]}).Code)
+		status := send(http.MethodGet, "/api/me/2fa", fullToken, nil)
+		require.Equal(t, http.StatusOK, status.Code)
+		var statusResponse controller.TwoFactorStatusResponse
+		require.NoError(t, json.Unmarshal(status.Body.Bytes(), &statusResponse))
+		assert.False(t, statusResponse.Enabled)
+	})
+
+	t.Run("LoginThrottling", func(t *testing.T) {
+		_, err := userUseCase.RegisterUser(ctx, "throttle@example.com", "Throttle Test", "Thr0ttle!P@ss")
+		require.NoError(t, err)
+
+		login := func(password string) *httptest.ResponseRecorder {
+			body, err := json.Marshal(map[string]string{"email": "throttle@example.com", "password": password})
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/api/login", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		// The first failures are only rejected
+		for range 3 {
+			assert.Equal(t, http.StatusUnauthorized, login("Wr0ng!P@ssw0rd").Code)
+		}
+
+		// Past them even the right password has to wait
+		throttled := login("Thr0ttle!P@ss")
+		assert.Equal(t, http.StatusTooManyRequests, throttled.Code)
+		assert.NotEmpty(t, throttled.Header().Get("Retry-After"))
+
+		// Every failure is audited, including the throttled attempt
+		var count int
+		err = db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM failed_login_attempts WHERE email = $1", "throttle@example.com").Scan(&count)
+		require.NoError(t, err)
+		assert.Equal(t, 4, count)
+	})
+
+	t.Run("PasswordRecheckThrottling", func(t *testing.T) {
+		user, err := userUseCase.RegisterUser(ctx, "recheck@example.com", "Recheck Test", "R3check!P@ss")
+		require.NoError(t, err)
+		token, err := sessionUseCase.GenerateSessionToken(ctx)
+		require.NoError(t, err)
+		_, err = sessionUseCase.CreateSession(ctx, token, user.ID, use_cases.SessionClient{})
+		require.NoError(t, err)
+
+		changePassword := func(currentPassword string) *httptest.ResponseRecorder {
+			body, err := json.Marshal(map[string]string{"current_password": currentPassword, "new_password": "N3w!R3check!P@ss"})
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/api/me/password", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(&http.Cookie{Name: "session", Value: token})
+			withCSRFToken(t, req, token)
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		// Wrong current passwords count like failed sign ins
+		for range 3 {
+			assert.Equal(t, http.StatusBadRequest, changePassword("Wr0ng!P@ssw0rd").Code)
+		}
+
+		// Past them even the right password has to wait
+		throttled := changePassword("R3check!P@ss")
+		assert.Equal(t, http.StatusTooManyRequests, throttled.Code)
+		assert.NotEmpty(t, throttled.Header().Get("Retry-After"))
+	})
+
+	t.Run("DeviceSessions", func(t *testing.T) {
+		_, err := userUseCase.RegisterUser(ctx, "devices@example.com", "Device Test", "D3vices!P@ss")
+		require.NoError(t, err)
+
+		send := func(method, path, token, userAgent string, payload map[string]string) *httptest.ResponseRecorder {
+			body, err := json.Marshal(payload)
+			require.NoError(t, err)
+			req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", userAgent)
+			if token != "" {
+				req.AddCookie(&http.Cookie{Name: "session", Value: token})
+				withCSRFToken(t, req, token)
+			}
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+		login := func(userAgent string) string {
+			recorder := send(http.MethodPost, "/api/login", "", userAgent, map[string]string{"email": "devices@example.com", "password": "D3vices!P@ss"})
+			require.Equal(t, http.StatusOK, recorder.Code)
+			for _, cookie := range recorder.Result().Cookies() {
+				if cookie.Name == "session" {
+					return cookie.Value
+				}
+			}
+			t.Fatal("no session cookie")
+			return ""
+		}
+		listSessions := func(token string) []controller.SessionResponse {
+			recorder := send(http.MethodGet, "/api/sessions", token, "laptop", nil)
+			require.Equal(t, http.StatusOK, recorder.Code)
+			var sessions []controller.SessionResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &sessions))
+			return sessions
+		}
+
+		// Sign in from three devices
+		laptopToken := login("laptop")
+		phoneToken := login("phone")
+		tabletToken := login("tablet")
+
+		// Every session is listed with its device, the current one flagged
+		sessions := listSessions(laptopToken)
+		require.Len(t, sessions, 3)
+		var phoneID string
+		for _, session := range sessions {
+			assert.NotEmpty(t, session.ID)
+			assert.Equal(t, "192.0.2.1", session.IPAddress)
+			assert.Equal(t, session.UserAgent == "laptop", session.Current)
+			if session.UserAgent == "phone" {
+				phoneID = session.ID
+			}
+		}
+		require.NotEmpty(t, phoneID)
+
+		// The public ID is not the stored token hash
+		phoneHash, err := tokenService.HashToken(ctx, phoneToken)
+		require.NoError(t, err)
+		assert.NotEqual(t, phoneHash, phoneID)
+
+		// A single device can be signed out
+		assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, "/api/sessions/"+phoneID, laptopToken, "laptop", nil).Code)
+		assert.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "/api/me", phoneToken, "phone", nil).Code)
+		assert.Equal(t, http.StatusNotFound, send(http.MethodDelete, "/api/sessions/"+phoneID, laptopToken, "laptop", nil).Code)
+
+		// Or every device but the current one
+		assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, "/api/sessions", laptopToken, "laptop", nil).Code)
+		assert.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "/api/me", tabletToken, "tablet", nil).Code)
+		sessions = listSessions(laptopToken)
+		require.Len(t, sessions, 1)
+		assert.True(t, sessions[0].Current)
+	})
+
+	t.Run("PersonalAccessTokens", func(t *testing.T) {
+		user, err := userUseCase.RegisterUser(ctx, "tokens@example.com", "Token Test", "T0kens!P@ssw0rd")
+		require.NoError(t, err)
+		sessionToken, err := sessionUseCase.GenerateSessionToken(ctx)
+		require.NoError(t, err)
+		_, err = sessionUseCase.CreateSession(ctx, sessionToken, user.ID, use_cases.SessionClient{})
+		require.NoError(t, err)
+
+		send := func(method, path string, auth func(*http.Request), payload any) *httptest.ResponseRecorder {
+			body, err := json.Marshal(payload)
+			require.NoError(t, err)
+			req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			auth(req)
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+		withSession := func(req *http.Request) {
+			req.AddCookie(&http.Cookie{Name: "session", Value: sessionToken})
+			withCSRFToken(t, req, sessionToken)
+		}
+
+		// Create a read-only token
+		created := send(http.MethodPost, "/api/tokens", withSession, map[string]any{"name": "backup", "scopes": []string{"notes:read"}})
+		require.Equal(t, http.StatusCreated, created.Code)
+		var tokenResponse controller.CreatePersonalAccessTokenResponse
+		require.NoError(t, json.Unmarshal(created.Body.Bytes(), &tokenResponse))
+		require.NotEmpty(t, tokenResponse.Token)
+		withToken := func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+tokenResponse.Token)
+		}
+
+		// It can read notes but nothing else
+		assert.Equal(t, http.StatusOK, send(http.MethodGet, "/api/notes", withToken, nil).Code)
+		forbidden := send(http.MethodPost, "/api/notes", withToken, map[string]string{"title": "Nope", "content": "Nope"})
+		assert.Equal(t, http.StatusForbidden, forbidden.Code)
+		assert.Contains(t, forbidden.Header().Get("WWW-Authenticate"), "insufficient_scope")
+		assert.Equal(t, http.StatusForbidden, send(http.MethodGet, "/api/labels", withToken, nil).Code)
+
+		// Account management stays limited to sessions
+		assert.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "/api/tokens", withToken, nil).Code)
+
+		// The token is listed with its last use, without the secret
+		list := send(http.MethodGet, "/api/tokens", withSession, nil)
+		require.Equal(t, http.StatusOK, list.Code)
+		assert.NotContains(t, list.Body.String(), tokenResponse.Token)
+		var tokens []controller.PersonalAccessTokenResponse
+		require.NoError(t, json.Unmarshal(list.Body.Bytes(), &tokens))
+		require.Len(t, tokens, 1)
+		assert.NotNil(t, tokens[0].LastUsedAt)
+
+		// Revoked tokens are rejected
+		assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, "/api/tokens/"+tokenResponse.ID, withSession, nil).Code)
+		assert.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "/api/notes", withToken, nil).Code)
+	})
+
+	t.Run("CSRFProtection", func(t *testing.T) {
+		user, err := userUseCase.RegisterUser(ctx, "csrf@example.com", "CSRF Test", "Csrf!P@ssw0rd1")
+		require.NoError(t, err)
+		sessionToken, err := sessionUseCase.GenerateSessionToken(ctx)
+		require.NoError(t, err)
+		_, err = sessionUseCase.CreateSession(ctx, sessionToken, user.ID, use_cases.SessionClient{})
+		require.NoError(t, err)
+
+		send := func(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+			body, err := json.Marshal(map[string]string{"title": "Note", "content": "Content"})
+			require.NoError(t, err)
+			req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			for name, value := range headers {
+				req.Header.Set(name, value)
+			}
+			req.AddCookie(&http.Cookie{Name: "session", Value: sessionToken})
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		// Writes with the cookie alone are rejected, reads are not
+		assert.Equal(t, http.StatusForbidden, send(http.MethodPost, "/api/notes", nil).Code)
+		assert.Equal(t, http.StatusForbidden, send(http.MethodPost, "/api/logout", map[string]string{controller.CSRFHeader: "forged"}).Code)
+		assert.Equal(t, http.StatusOK, send(http.MethodGet, "/api/notes", nil).Code)
+
+		// The token is fetched with the session
+		tokenRecorder := send(http.MethodGet, "/api/csrf", nil)
+		require.Equal(t, http.StatusOK, tokenRecorder.Code)
+		assert.Equal(t, "no-store", tokenRecorder.Header().Get("Cache-Control"))
+		var tokenResponse controller.CSRFTokenResponse
+		require.NoError(t, json.Unmarshal(tokenRecorder.Body.Bytes(), &tokenResponse))
+		require.NotEmpty(t, tokenResponse.CSRFToken)
+
+		// And lets the writes through
+		assert.Equal(t, http.StatusCreated, send(http.MethodPost, "/api/notes", map[string]string{controller.CSRFHeader: tokenResponse.CSRFToken}).Code)
+
+		// Personal access tokens need no CSRF token
+		_, secret, err := personalAccessTokenUseCase.CreateToken(ctx, user.ID, "script", []entities.TokenScope{entities.ScopeNotesWrite}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, send(http.MethodPost, "/api/notes", map[string]string{"Authorization": "Bearer " + secret}).Code)
+	})
+
+	t.Run("NoteSharing", func(t *testing.T) {
+		// Register the owner and two verified users to share with
+		newUser := func(email string) (*entities.User, string) {
+			user, err := userUseCase.RegisterUser(ctx, email, "Sharing Test", "Sh@ring!P@ssw0rd")
+			require.NoError(t, err)
+			require.NoError(t, userRepo.MarkEmailVerified(ctx, user.ID))
+			token, err := sessionUseCase.GenerateSessionToken(ctx)
+			require.NoError(t, err)
+			_, err = sessionUseCase.CreateSession(ctx, token, user.ID, use_cases.SessionClient{})
+			require.NoError(t, err)
+			return user, token
+		}
+		owner, ownerToken := newUser("share-owner@example.com")
+		editor, editorToken := newUser("share-editor@example.com")
+		_, viewerToken := newUser("share-viewer@example.com")
+		_, strangerToken := newUser("share-stranger@example.com")
+
+		send := func(method, path, token string, payload any) *httptest.ResponseRecorder {
+			body, err := json.Marshal(payload)
+			require.NoError(t, err)
+			req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(&http.Cookie{Name: "session", Value: token})
+			withCSRFToken(t, req, token)
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		// The owner labels the note privately
+		note, err := noteUseCase.CreateNote(ctx, owner.ID, "Plans", "Secret plans", "")
+		require.NoError(t, err)
+		label, err := labelUseCase.CreateLabel(ctx, owner.ID, "Private", "#000000")
+		require.NoError(t, err)
+		require.NoError(t, labelUseCase.AddLabelToNote(ctx, note.ID, label.ID, owner.ID))
+		notePath := "/api/notes/" + note.ID
+
+		// Nobody else can see the note yet
+		assert.Equal(t, http.StatusNotFound, send(http.MethodGet, notePath, editorToken, nil).Code)
+
+		// Share it with an editor and a viewer
+		shared := send(http.MethodPost, notePath+"/shares", ownerToken, map[string]string{"email": "share-editor@example.com", "role": "editor"})
+		require.Equal(t, http.StatusOK, shared.Code)
+		require.Equal(t, http.StatusOK, send(http.MethodPost, notePath+"/shares", ownerToken, map[string]string{"email": "share-viewer@example.com", "role": "viewer"}).Code)
+		assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, notePath+"/shares", ownerToken, map[string]string{"email": "share-viewer@example.com", "role": "owner"}).Code)
+
+		// Everybody with access sees who else has it
+		list := send(http.MethodGet, notePath+"/shares", viewerToken, nil)
+		require.Equal(t, http.StatusOK, list.Code)
+		var shares []controller.NoteShareResponse
+		require.NoError(t, json.Unmarshal(list.Body.Bytes(), &shares))
+		assert.Len(t, shares, 2)
+		assert.Equal(t, http.StatusNotFound, send(http.MethodGet, notePath+"/shares", strangerToken, nil).Code)
+
+		// The shared note shows up for the editor, without the owner's labels
+		sharedList := send(http.MethodGet, "/api/notes/shared", editorToken, nil)
+		require.Equal(t, http.StatusOK, sharedList.Code)
+		var sharedNotes []controller.SharedNoteResponse
+		require.NoError(t, json.Unmarshal(sharedList.Body.Bytes(), &sharedNotes))
+		require.Len(t, sharedNotes, 1)
+		assert.Equal(t, "editor", sharedNotes[0].Role)
+		got := send(http.MethodGet, notePath, editorToken, nil)
+		require.Equal(t, http.StatusOK, got.Code)
+		assert.NotContains(t, got.Body.String(), "Private")
+
+		// The editor can change the text but not trash the note, the viewer can do neither
+		update := map[string]any{"title": "Plans", "content": "Edited plans"}
+		assert.Equal(t, http.StatusOK, send(http.MethodPut, notePath, editorToken, update).Code)
+		assert.Equal(t, http.StatusForbidden, send(http.MethodDelete, notePath, editorToken, nil).Code)
+		assert.Equal(t, http.StatusForbidden, send(http.MethodPut, notePath, viewerToken, update).Code)
+
+		// Only the owner manages the shares, others may only leave
+		assert.Equal(t, http.StatusForbidden, send(http.MethodPost, notePath+"/shares", editorToken, map[string]string{"email": "share-stranger@example.com", "role": "viewer"}).Code)
+		assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, notePath+"/shares/"+editor.ID, editorToken, nil).Code)
+		assert.Equal(t, http.StatusNotFound, send(http.MethodGet, notePath, editorToken, nil).Code)
+	})
+
+	t.Run("ShareLinks", func(t *testing.T) {
+		user, err := userUseCase.RegisterUser(ctx, "share-links@example.com", "Links Test", "L!nks!P@ssw0rd")
+		require.NoError(t, err)
+		sessionToken, err := sessionUseCase.GenerateSessionToken(ctx)
+		require.NoError(t, err)
+		_, err = sessionUseCase.CreateSession(ctx, sessionToken, user.ID, use_cases.SessionClient{})
+		require.NoError(t, err)
+
+		send := func(method, path string, payload any) *httptest.ResponseRecorder {
+			body, err := json.Marshal(payload)
+			require.NoError(t, err)
+			req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(&http.Cookie{Name: "session", Value: sessionToken})
+			withCSRFToken(t, req, sessionToken)
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+		// open visits a link without being signed in
+		open := func(req *http.Request) *httptest.ResponseRecorder {
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+		createLink := func(notePath string, payload any) controller.CreateShareLinkResponse {
+			created := send(http.MethodPost, notePath+"/links", payload)
+			require.Equal(t, http.StatusCreated, created.Code)
+			var link controller.CreateShareLinkResponse
+			require.NoError(t, json.Unmarshal(created.Body.Bytes(), &link))
+			return link
+		}
+
+		note, err := noteUseCase.CreateNote(ctx, user.ID, "Recipe", "Flour <b>and</b> water", "")
+		require.NoError(t, err)
+		notePath := "/api/notes/" + note.ID
+
+		// Anybody with the link can read the note as HTML or JSON
+		link := createLink(notePath, map[string]any{})
+		assert.False(t, link.HasPassword)
+		linkPath := strings.TrimPrefix(link.URL, "http://localhost:8080")
+		page := open(httptest.NewRequest(http.MethodGet, linkPath, nil))
+		require.Equal(t, http.StatusOK, page.Code)
+		assert.Contains(t, page.Header().Get("Content-Type"), "text/html")
+		assert.Equal(t, "no-store", page.Header().Get("Cache-Control"))
+		assert.Contains(t, page.Body.String(), "Flour &lt;b&gt;and&lt;/b&gt; water")
+
+		req := httptest.NewRequest(http.MethodGet, linkPath, nil)
+		req.Header.Set("Accept", "application/json")
+		viewed := open(req)
+		require.Equal(t, http.StatusOK, viewed.Code)
+		var publicNote controller.PublicNoteResponse
+		require.NoError(t, json.Unmarshal(viewed.Body.Bytes(), &publicNote))
+		assert.Equal(t, "Recipe", publicNote.Title)
+		assert.Equal(t, int64(2), publicNote.ViewCount)
+
+		// The owner sees the views, and the link stops working once revoked
+		list := send(http.MethodGet, notePath+"/links", nil)
+		require.Equal(t, http.StatusOK, list.Code)
+		var links []controller.ShareLinkResponse
+		require.NoError(t, json.Unmarshal(list.Body.Bytes(), &links))
+		require.Len(t, links, 1)
+		assert.Equal(t, int64(2), links[0].ViewCount)
+		assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, notePath+"/links/"+link.ID, nil).Code)
+		assert.Equal(t, http.StatusNotFound, open(httptest.NewRequest(http.MethodGet, linkPath, nil)).Code)
+
+		// Protected links ask for the password, in a header or from the form
+		protected := createLink(notePath, map[string]any{"password": "open sesame"})
+		protectedPath := strings.TrimPrefix(protected.URL, "http://localhost:8080")
+		prompt := open(httptest.NewRequest(http.MethodGet, protectedPath, nil))
+		assert.Equal(t, http.StatusUnauthorized, prompt.Code)
+		assert.Contains(t, prompt.Body.String(), `type="password"`)
+		req = httptest.NewRequest(http.MethodGet, protectedPath+"?format=json", nil)
+		req.Header.Set(controller.ShareLinkPasswordHeader, "wrong password")
+		assert.Equal(t, http.StatusUnauthorized, open(req).Code)
+		req = httptest.NewRequest(http.MethodPost, protectedPath, strings.NewReader("password=open+sesame"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		assert.Equal(t, http.StatusOK, open(req).Code)
+
+		// Archiving the note kills its links for good
+		update := send(http.MethodPut, notePath, map[string]any{"title": "Recipe", "content": "Flour and water", "is_archived": true})
+		require.Equal(t, http.StatusOK, update.Code)
+		update = send(http.MethodPut, notePath, map[string]any{"title": "Recipe", "content": "Flour and water", "is_archived": false})
+		require.Equal(t, http.StatusOK, update.Code)
+		req = httptest.NewRequest(http.MethodGet, protectedPath, nil)
+		req.Header.Set(controller.ShareLinkPasswordHeader, "open sesame")
+		assert.Equal(t, http.StatusNotFound, open(req).Code)
+	})
+
+	t.Run("PinAndMoveNotes", func(t *testing.T) {
+		user, err := userUseCase.RegisterUser(ctx, "ordering@example.com", "Ordering Test", "0rd3r!ngP@ssw0rd")
+		require.NoError(t, err)
+		sessionToken, err := sessionUseCase.GenerateSessionToken(ctx)
+		require.NoError(t, err)
+		_, err = sessionUseCase.CreateSession(ctx, sessionToken, user.ID, use_cases.SessionClient{})
+		require.NoError(t, err)
+
+		send := func(method, path string, payload any) *httptest.ResponseRecorder {
+			body, err := json.Marshal(payload)
+			require.NoError(t, err)
+			req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(&http.Cookie{Name: "session", Value: sessionToken})
+			withCSRFToken(t, req, sessionToken)
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+		titles := func() []string {
+			list := send(http.MethodGet, "/api/notes", nil)
+			require.Equal(t, http.StatusOK, list.Code)
+			var notes []controller.NoteResponse
+			require.NoError(t, json.Unmarshal(list.Body.Bytes(), &notes))
+			titles := make([]string, len(notes))
+			for i, note := range notes {
+				titles[i] = note.Title
+			}
+			return titles
+		}
+
+		// New notes go to the top of the list
+		first, err := noteUseCase.CreateNote(ctx, user.ID, "First", "", "")
+		require.NoError(t, err)
+		second, err := noteUseCase.CreateNote(ctx, user.ID, "Second", "", "")
+		require.NoError(t, err)
+		third, err := noteUseCase.CreateNote(ctx, user.ID, "Third", "", "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Third", "Second", "First"}, titles())
+
+		// Moving a note places it between its new neighbors
+		moved := send(http.MethodPost, "/api/notes/"+third.ID+"/move", map[string]string{"after": second.ID})
+		require.Equal(t, http.StatusOK, moved.Code)
+		assert.Equal(t, []string{"Second", "Third", "First"}, titles())
+		moved = send(http.MethodPost, "/api/notes/"+second.ID+"/move", map[string]string{"before": first.ID})
+		require.Equal(t, http.StatusOK, moved.Code)
+		assert.Equal(t, []string{"Third", "Second", "First"}, titles())
+
+		// Pinned notes come first and are ordered among themselves
+		pinned := send(http.MethodPut, "/api/notes/"+first.ID+"/pin", nil)
+		require.Equal(t, http.StatusOK, pinned.Code)
+		var pinnedNote controller.NoteResponse
+		require.NoError(t, json.Unmarshal(pinned.Body.Bytes(), &pinnedNote))
+		assert.True(t, pinnedNote.IsPinned)
+		assert.Equal(t, []string{"First", "Third", "Second"}, titles())
+
+		// A note cannot be moved next to a note of another list
+		moved = send(http.MethodPost, "/api/notes/"+second.ID+"/move", map[string]string{"before": first.ID})
+		assert.Equal(t, http.StatusBadRequest, moved.Code)
+		moved = send(http.MethodPost, "/api/notes/"+second.ID+"/move", map[string]string{"before": first.ID, "after": third.ID})
+		assert.Equal(t, http.StatusBadRequest, moved.Code)
+
+		unpinned := send(http.MethodDelete, "/api/notes/"+first.ID+"/pin", nil)
+		require.Equal(t, http.StatusOK, unpinned.Code)
+		assert.Equal(t, []string{"First", "Third", "Second"}, titles())
+	})
+
+	t.Run("SearchHighlights", func(t *testing.T) {
+		user, err := userUseCase.RegisterUser(ctx, "highlights@example.com", "Highlight Test", "H!ghl1ghtP@ssw0rd")
+		require.NoError(t, err)
+		sessionToken, err := sessionUseCase.GenerateSessionToken(ctx)
+		require.NoError(t, err)
+		_, err = sessionUseCase.CreateSession(ctx, sessionToken, user.ID, use_cases.SessionClient{})
+		require.NoError(t, err)
+
+		_, err = noteUseCase.CreateNote(ctx, user.ID, `<img src=x onerror="alert(1)"> pancake`, "Flour, eggs & <b>milk</b> for the pancake batter.", "")
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/notes/search?q=pancake", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: sessionToken})
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var results []controller.NoteSearchResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &results))
+		require.Len(t, results, 1)
+
+		// Markup written by the user is escaped, only the matches are marked
+		assert.Contains(t, results[0].TitleHighlight, "<mark>pancake</mark>")
+		assert.NotContains(t, results[0].TitleHighlight, "<img")
+		assert.Contains(t, results[0].Snippet, "<mark>pancake</mark>")
+		assert.NotContains(t, results[0].Snippet, "<b>")
+	})
+
+	t.Run("ChecklistNotes", func(t *testing.T) {
+		user, err := userUseCase.RegisterUser(ctx, "checklists@example.com", "Checklist Test", "Ch3ckl!stP@ssw0rd")
+		require.NoError(t, err)
+		sessionToken, err := sessionUseCase.GenerateSessionToken(ctx)
+		require.NoError(t, err)
+		_, err = sessionUseCase.CreateSession(ctx, sessionToken, user.ID, use_cases.SessionClient{})
+		require.NoError(t, err)
+
+		send := func(method, path string, payload any) *httptest.ResponseRecorder {
+			body, err := json.Marshal(payload)
+			require.NoError(t, err)
+			req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(&http.Cookie{Name: "session", Value: sessionToken})
+			withCSRFToken(t, req, sessionToken)
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+		checklist := func(recorder *httptest.ResponseRecorder) controller.ChecklistResponse {
+			require.Equal(t, http.StatusOK, recorder.Code)
+			var response controller.ChecklistResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			return response
+		}
+
+		note, err := noteUseCase.CreateNote(ctx, user.ID, "Groceries", "- [x] Eggs\n  - [ ] Free range\nFlour", "")
+		require.NoError(t, err)
+		notePath := "/api/notes/" + note.ID
+
+		// Items cannot be added to a text note
+		added := send(http.MethodPost, notePath+"/items", map[string]any{"text": "Milk"})
+		assert.Equal(t, http.StatusBadRequest, added.Code)
+
+		// Converting turns every line into an item
+		converted := send(http.MethodPost, notePath+"/convert", map[string]string{"type": "checklist"})
+		require.Equal(t, http.StatusOK, converted.Code)
+		var convertedNote controller.NoteResponse
+		require.NoError(t, json.Unmarshal(converted.Body.Bytes(), &convertedNote))
+		assert.Equal(t, "checklist", convertedNote.Type)
+		assert.Empty(t, convertedNote.Content)
+
+		items := checklist(send(http.MethodGet, notePath+"/items", nil))
+		require.Len(t, items.Items, 3)
+		assert.Equal(t, "Free range", items.Items[1].Text)
+		assert.Equal(t, 1, items.Items[1].Indent)
+		assert.Equal(t, 1, items.Checked)
+
+		// Items are added at the bottom and checked one by one
+		added = send(http.MethodPost, notePath+"/items", map[string]any{"text": "Milk"})
+		require.Equal(t, http.StatusCreated, added.Code)
+		var milk controller.ChecklistItemResponse
+		require.NoError(t, json.Unmarshal(added.Body.Bytes(), &milk))
+		updated := send(http.MethodPatch, notePath+"/items/"+milk.ID, map[string]any{"is_checked": true})
+		require.Equal(t, http.StatusOK, updated.Code)
+
+		// Checked items move to the bottom, keeping their order
+		items = checklist(send(http.MethodPost, notePath+"/items/move-checked", nil))
+		texts := make([]string, len(items.Items))
+		for i, item := range items.Items {
+			texts[i] = item.Text
+		}
+		assert.Equal(t, []string{"Free range", "Flour", "Eggs", "Milk"}, texts)
+
+		// Listings count the items of checklist notes
+		list := send(http.MethodGet, "/api/notes?limit=10", nil)
+		require.Equal(t, http.StatusOK, list.Code)
+		var page controller.NoteListResponse
+		require.NoError(t, json.Unmarshal(list.Body.Bytes(), &page))
+		require.Len(t, page.Notes, 1)
+		require.NotNil(t, page.Notes[0].Checklist)
+		assert.Equal(t, controller.ChecklistProgressResponse{Total: 4, Checked: 2}, *page.Notes[0].Checklist)
+
+		items = checklist(send(http.MethodPost, notePath+"/items/uncheck-all", nil))
+		assert.Equal(t, 0, items.Checked)
+
+		// Converting back writes the items out as a task list
+		converted = send(http.MethodPost, notePath+"/convert", map[string]string{"type": "text"})
+		require.Equal(t, http.StatusOK, converted.Code)
+		require.NoError(t, json.Unmarshal(converted.Body.Bytes(), &convertedNote))
+		assert.Equal(t, "text", convertedNote.Type)
+		assert.Equal(t, "  - [ ] Free range\n- [ ] Flour\n- [ ] Eggs\n- [ ] Milk", convertedNote.Content)
+	})
+
+	t.Run("Attachments", func(t *testing.T) {
+		user, err := userUseCase.RegisterUser(ctx, "attachments@example.com", "Attachment Test", "Att@chm3ntP@ssw0rd")
+		require.NoError(t, err)
+		sessionToken, err := sessionUseCase.GenerateSessionToken(ctx)
+		require.NoError(t, err)
+		_, err = sessionUseCase.CreateSession(ctx, sessionToken, user.ID, use_cases.SessionClient{})
+		require.NoError(t, err)
+
+		send := func(method, path string, header http.Header, body io.Reader) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, body)
+			for name, values := range header {
+				req.Header[name] = values
+			}
+			req.AddCookie(&http.Cookie{Name: "session", Value: sessionToken})
+			withCSRFToken(t, req, sessionToken)
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			return recorder
+		}
+		upload := func(path, filename string, content []byte) *httptest.ResponseRecorder {
+			var body bytes.Buffer
+			writer := multipart.NewWriter(&body)
+			part, err := writer.CreateFormFile("file", filename)
+			require.NoError(t, err)
+			_, err = part.Write(content)
+			require.NoError(t, err)
+			require.NoError(t, writer.Close())
+			return send(http.MethodPost, path, http.Header{"Content-Type": {writer.FormDataContentType()}}, &body)
+		}
+
+		note, err := noteUseCase.CreateNote(ctx, user.ID, "With files", "See attached", "")
+		require.NoError(t, err)
+		attachmentsPath := "/api/notes/" + note.ID + "/attachments"
+
+		// Upload a file
+		uploaded := upload(attachmentsPath, "hello.txt", []byte("hello attachment world"))
+		require.Equal(t, http.StatusCreated, uploaded.Code)
+		var attachment controller.AttachmentResponse
+		require.NoError(t, json.Unmarshal(uploaded.Body.Bytes(), &attachment))
+		assert.Equal(t, "hello.txt", attachment.Filename)
+		assert.Equal(t, int64(22), attachment.Size)
+		checksum := sha256.Sum256([]byte("hello attachment world"))
+		assert.Equal(t, hex.EncodeToString(checksum[:]), attachment.Checksum)
+
+		listed := send(http.MethodGet, attachmentsPath, nil, nil)
+		require.Equal(t, http.StatusOK, listed.Code)
+		var attachments []controller.AttachmentResponse
+		require.NoError(t, json.Unmarshal(listed.Body.Bytes(), &attachments))
+		require.Len(t, attachments, 1)
+
+		// Download it whole, then a range of it
+		downloaded := send(http.MethodGet, attachmentsPath+"/"+attachment.ID, nil, nil)
+		require.Equal(t, http.StatusOK, downloaded.Code)
+		assert.Equal(t, "hello attachment world", downloaded.Body.String())
+		assert.Equal(t, "bytes", downloaded.Header().Get("Accept-Ranges"))
+		assert.Equal(t, `attachment; filename=hello.txt`, downloaded.Header().Get("Content-Disposition"))
+
+		partial := send(http.MethodGet, attachmentsPath+"/"+attachment.ID, http.Header{"Range": {"bytes=6-15"}}, nil)
+		require.Equal(t, http.StatusPartialContent, partial.Code)
+		assert.Equal(t, "attachment", partial.Body.String())
+		assert.Equal(t, "bytes 6-15/22", partial.Header().Get("Content-Range"))
+
+		outside := send(http.MethodGet, attachmentsPath+"/"+attachment.ID, http.Header{"Range": {"bytes=100-"}}, nil)
+		assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, outside.Code)
+		assert.Equal(t, "bytes */22", outside.Header().Get("Content-Range"))
+
+		// Files are limited to 1 MiB and users to 2 MiB in total
+		tooLarge := upload(attachmentsPath, "large.bin", make([]byte, 1<<20+1))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, tooLarge.Code)
+		full := upload(attachmentsPath, "full.bin", make([]byte, 1<<20))
+		require.Equal(t, http.StatusCreated, full.Code)
+		overQuota := upload(attachmentsPath, "more.bin", make([]byte, 1<<20))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, overQuota.Code)
+		assert.Contains(t, overQuota.Body.String(), "quota")
+
+		blobs, err := filepath.Glob(filepath.Join(blobDir, note.ID, "*"))
+		require.NoError(t, err)
+		assert.Len(t, blobs, 2)
+
+		// Deleting an attachment deletes its blob
+		deleted := send(http.MethodDelete, attachmentsPath+"/"+attachment.ID, nil, nil)
+		require.Equal(t, http.StatusNoContent, deleted.Code)
+		_, err = os.Stat(filepath.Join(blobDir, note.ID, attachment.ID))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		// Permanently deleting the note deletes the remaining blobs
+		trashed := send(http.MethodDelete, "/api/notes/"+note.ID, nil, nil)
+		require.Equal(t, http.StatusNoContent, trashed.Code)
+		emptied := send(http.MethodDelete, "/api/notes/trash", nil, nil)
+		require.Equal(t, http.StatusNoContent, emptied.Code)
+		blobs, err = filepath.Glob(filepath.Join(blobDir, note.ID, "*"))
+		require.NoError(t, err)
+		assert.Empty(t, blobs)
+	})
 }
@@ -23,14 +23,18 @@ func TestLabelUseCaseIntegration(t *testing.T) {
 	userRepo := repositories.NewUserRepository(queries)
 	noteRepo := repositories.NewNoteRepository(queries)
 	labelRepo := repositories.NewLabelRepository(queries)
+	revisionRepo := repositories.NewNoteRevisionRepository(queries)
+	noteShareRepo := repositories.NewNoteShareRepository(queries)
+	txManager := repositories.NewTxManager(db.Pool, queries)
 
 	// Initialize services
 	hashService := services.NewArgonHashService()
 
 	// Initialize use cases
 	userUseCase := use_cases.NewUserUseCase(userRepo, hashService)
-	noteUseCase := use_cases.NewNoteUseCase(noteRepo, userRepo, labelRepo)
-	labelUseCase := use_cases.NewLabelUseCase(labelRepo, userRepo, noteRepo)
+	notePermissions := use_cases.NewNotePermissionService(noteRepo, noteShareRepo)
+	noteUseCase := use_cases.NewNoteUseCase(noteRepo, userRepo, labelRepo, revisionRepo, txManager, notePermissions, services.NewLocalBlobStore(t.TempDir()))
+	labelUseCase := use_cases.NewLabelUseCase(labelRepo, userRepo, noteRepo, notePermissions)
 
 	// Create two test users
 	email1 := "labeluser1@example.com"
@@ -113,14 +117,14 @@ func TestLabelUseCaseIntegration(t *testing.T) {
 		require.NotNil(t, label)
 
 		// User 2 tries to update User 1's label
-		_, err = labelUseCase.UpdateLabel(ctx, label.ID, user2.ID, "Updated by User 2", "#FFFFFF")
+		_, err = labelUseCase.UpdateLabel(ctx, label.ID, user2.ID, "Updated by User 2", "#FFFFFF", 0)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "label not found")
 
 		// User 1 updates their own label
 		updatedName := "Successfully Updated Label"
 		updatedColor := "#654321"
-		updatedLabel, err := labelUseCase.UpdateLabel(ctx, label.ID, user1.ID, updatedName, updatedColor)
+		updatedLabel, err := labelUseCase.UpdateLabel(ctx, label.ID, user1.ID, updatedName, updatedColor, 0)
 		require.NoError(t, err)
 		require.NotNil(t, updatedLabel)
 		assert.Equal(t, updatedName, updatedLabel.Name)
@@ -132,7 +136,7 @@ func TestLabelUseCaseIntegration(t *testing.T) {
 		require.NoError(t, err)
 
 		// Try to update the first label to have the same name as the other label
-		_, err = labelUseCase.UpdateLabel(ctx, label.ID, user1.ID, "Existing Name", "#FFF")
+		_, err = labelUseCase.UpdateLabel(ctx, label.ID, user1.ID, "Existing Name", "#FFF", 0)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "already exists")
 	})
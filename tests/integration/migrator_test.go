@@ -0,0 +1,151 @@
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LaulauChau/note-nest/internal/infrastructure/persistence/database"
+)
+
+func TestMigrator(t *testing.T) {
+	// Set up test database, which applies every migration
+	ctx := context.Background()
+	db, err := SetupTestDatabase(ctx)
+	require.NoError(t, err)
+	defer db.Close(ctx)
+
+	migrator, err := database.NewMigrator(db.Pool)
+	require.NoError(t, err)
+
+	t.Run("Status", func(t *testing.T) {
+		statuses, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, statuses)
+
+		for _, status := range statuses {
+			assert.True(t, status.Applied(), "migration %d should be applied", status.Version)
+		}
+
+		pending, err := migrator.Pending(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("UpIsIdempotent", func(t *testing.T) {
+		applied, err := migrator.Up(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+	})
+
+	t.Run("DownAndUp", func(t *testing.T) {
+		statuses, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		latest := statuses[len(statuses)-1]
+
+		// Roll back the latest migration
+		rolledBack, err := migrator.Down(ctx)
+		require.NoError(t, err)
+		require.Len(t, rolledBack, 1)
+		assert.Equal(t, latest.Version, rolledBack[0].Version)
+
+		pending, err := migrator.Pending(ctx)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, latest.Version, pending[0].Version)
+
+		// Apply it again
+		applied, err := migrator.Up(ctx)
+		require.NoError(t, err)
+		require.Len(t, applied, 1)
+		assert.Equal(t, latest.Version, applied[0].Version)
+	})
+
+	t.Run("ToVersion", func(t *testing.T) {
+		statuses, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(statuses), 3)
+		target := statuses[len(statuses)-3].Version
+
+		// Migrate down to an older version
+		changed, err := migrator.To(ctx, target)
+		require.NoError(t, err)
+		assert.Len(t, changed, 2)
+
+		pending, err := migrator.Pending(ctx)
+		require.NoError(t, err)
+		assert.Len(t, pending, 2)
+
+		// And back to the latest one
+		_, err = migrator.Up(ctx)
+		require.NoError(t, err)
+
+		pending, err = migrator.Pending(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("UnknownVersion", func(t *testing.T) {
+		_, err := migrator.To(ctx, 1)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown migration version")
+	})
+
+	t.Run("Baseline", func(t *testing.T) {
+		statuses, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		latest := statuses[len(statuses)-1]
+
+		// Forget the migrations as if the schema had been created by hand
+		_, err = db.Pool.Exec(ctx, "DELETE FROM schema_migrations")
+		require.NoError(t, err)
+
+		// Applying them again is refused rather than failing halfway
+		_, err = migrator.Up(ctx)
+		assert.ErrorContains(t, err, "migrate baseline")
+
+		// Baselining records them without running them
+		recorded, err := migrator.Baseline(ctx, latest.Version)
+		require.NoError(t, err)
+		assert.Len(t, recorded, len(statuses))
+
+		pending, err := migrator.Pending(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+
+		// Nothing is left to record
+		recorded, err = migrator.Baseline(ctx, latest.Version)
+		require.NoError(t, err)
+		assert.Empty(t, recorded)
+	})
+
+	t.Run("ConcurrentUp", func(t *testing.T) {
+		_, err := migrator.Down(ctx)
+		require.NoError(t, err)
+
+		// Several replicas migrating at once must apply the migration only once
+		var wg sync.WaitGroup
+		results := make([]int, 3)
+		errs := make([]error, 3)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				applied, err := migrator.Up(ctx)
+				results[i] = len(applied)
+				errs[i] = err
+			}(i)
+		}
+		wg.Wait()
+
+		total := 0
+		for i := range results {
+			require.NoError(t, errs[i])
+			total += results[i]
+		}
+		assert.Equal(t, 1, total)
+	})
+}
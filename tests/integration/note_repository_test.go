@@ -24,6 +24,7 @@ func TestNoteRepository(t *testing.T) {
 	queries := repositories.New(db.Pool)
 	userRepo := repositories.NewUserRepository(queries)
 	noteRepo := repositories.NewNoteRepository(queries)
+	labelRepo := repositories.NewLabelRepository(queries)
 
 	// Create a test user first
 	now := time.Now()
@@ -212,6 +213,236 @@ func TestNoteRepository(t *testing.T) {
 		}
 	})
 
+	t.Run("ListNotes", func(t *testing.T) {
+		// Create a separate test user for this test
+		listUserID := uuid.New().String()
+		listUser := &entities.User{
+			ID:        listUserID,
+			Email:     "listuser@example.com",
+			Name:      "List Test User",
+			Password:  "hashedpassword",
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		// Save the test user
+		err = userRepo.Create(ctx, listUser)
+		require.NoError(t, err)
+
+		// Create five active notes one minute apart and one archived note
+		base := now.Add(-time.Hour).Truncate(time.Second)
+		titles := []string{"Echo", "Alpha", "Delta", "Charlie", "Bravo"}
+		notes := make([]*entities.Note, len(titles))
+		for i, title := range titles {
+			notes[i] = &entities.Note{
+				ID:         uuid.New().String(),
+				UserID:     listUserID,
+				Title:      title,
+				Content:    "Content " + title,
+				IsArchived: false,
+				CreatedAt:  base.Add(time.Duration(i) * time.Minute),
+				UpdatedAt:  base.Add(time.Duration(i) * time.Minute),
+			}
+			require.NoError(t, noteRepo.Create(ctx, notes[i]))
+		}
+		archived := &entities.Note{
+			ID:         uuid.New().String(),
+			UserID:     listUserID,
+			Title:      "Archived",
+			Content:    "Archived content",
+			IsArchived: true,
+			CreatedAt:  base,
+			UpdatedAt:  base,
+		}
+		require.NoError(t, noteRepo.Create(ctx, archived))
+
+		isArchived := false
+
+		// Page through the notes by creation date, newest first
+		firstPage, err := noteRepo.List(ctx, entities.NoteFilter{
+			UserID:     listUserID,
+			IsArchived: &isArchived,
+			SortBy:     entities.NoteSortByCreatedAt,
+			Descending: true,
+			Limit:      3,
+		})
+		require.NoError(t, err)
+		require.Len(t, firstPage, 3)
+		assert.Equal(t, notes[4].ID, firstPage[0].ID)
+		assert.Equal(t, notes[2].ID, firstPage[2].ID)
+
+		last := firstPage[len(firstPage)-1]
+		secondPage, err := noteRepo.List(ctx, entities.NoteFilter{
+			UserID:     listUserID,
+			IsArchived: &isArchived,
+			SortBy:     entities.NoteSortByCreatedAt,
+			Descending: true,
+			Cursor: &entities.NoteCursor{
+				SortValue: last.CreatedAt.Format(time.RFC3339Nano),
+				ID:        last.ID,
+			},
+			Limit: 3,
+		})
+		require.NoError(t, err)
+		require.Len(t, secondPage, 2)
+		assert.Equal(t, notes[1].ID, secondPage[0].ID)
+		assert.Equal(t, notes[0].ID, secondPage[1].ID)
+
+		// Sort by title in alphabetical order, including archived notes
+		byTitle, err := noteRepo.List(ctx, entities.NoteFilter{
+			UserID: listUserID,
+			SortBy: entities.NoteSortByTitle,
+			Limit:  10,
+		})
+		require.NoError(t, err)
+		require.Len(t, byTitle, 6)
+		assert.Equal(t, "Alpha", byTitle[0].Title)
+		assert.Equal(t, "Echo", byTitle[5].Title)
+
+		// Filter by date range
+		createdAfter := base.Add(2 * time.Minute)
+		createdBefore := base.Add(4 * time.Minute)
+		inRange, err := noteRepo.List(ctx, entities.NoteFilter{
+			UserID:        listUserID,
+			CreatedAfter:  &createdAfter,
+			CreatedBefore: &createdBefore,
+			SortBy:        entities.NoteSortByCreatedAt,
+			Limit:         10,
+		})
+		require.NoError(t, err)
+		require.Len(t, inRange, 2)
+		assert.Equal(t, notes[2].ID, inRange[0].ID)
+		assert.Equal(t, notes[3].ID, inRange[1].ID)
+
+		// Filter by label
+		label := &entities.Label{
+			ID:        uuid.New().String(),
+			UserID:    listUserID,
+			Name:      "Listed",
+			Color:     "#ffffff",
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		require.NoError(t, labelRepo.Create(ctx, label))
+		require.NoError(t, labelRepo.AddLabelToNote(ctx, notes[3].ID, label.ID))
+
+		labelled, err := noteRepo.List(ctx, entities.NoteFilter{
+			UserID:   listUserID,
+			LabelIDs: []string{label.ID},
+			SortBy:   entities.NoteSortByUpdatedAt,
+			Limit:    10,
+		})
+		require.NoError(t, err)
+		require.Len(t, labelled, 1)
+		assert.Equal(t, notes[3].ID, labelled[0].ID)
+	})
+
+	t.Run("SearchNotes", func(t *testing.T) {
+		// Create a separate test user for this test
+		searchUserID := uuid.New().String()
+		searchUser := &entities.User{
+			ID:        searchUserID,
+			Email:     "searchuser@example.com",
+			Name:      "Search Test User",
+			Password:  "hashedpassword",
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		// Save the test user
+		err = userRepo.Create(ctx, searchUser)
+		require.NoError(t, err)
+
+		// Create notes with searchable content
+		recipeNote := &entities.Note{
+			ID:         uuid.New().String(),
+			UserID:     searchUserID,
+			Title:      "Grandma's bread recipe",
+			Content:    "Knead the sourdough starter with flour and water.",
+			IsArchived: false,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+
+		meetingNote := &entities.Note{
+			ID:         uuid.New().String(),
+			UserID:     searchUserID,
+			Title:      "Team meeting",
+			Content:    "Discuss the bread budget for the office party.",
+			IsArchived: false,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+
+		archivedNote := &entities.Note{
+			ID:         uuid.New().String(),
+			UserID:     searchUserID,
+			Title:      "Old bread notes",
+			Content:    "Archived sourdough experiments.",
+			IsArchived: true,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+
+		// A matching note owned by another user must never be returned
+		otherNote := &entities.Note{
+			ID:         uuid.New().String(),
+			UserID:     user.ID,
+			Title:      "Someone else's bread",
+			Content:    "Sourdough bread from another account.",
+			IsArchived: false,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+
+		// Save the notes
+		require.NoError(t, noteRepo.Create(ctx, recipeNote))
+		require.NoError(t, noteRepo.Create(ctx, meetingNote))
+		require.NoError(t, noteRepo.Create(ctx, archivedNote))
+		require.NoError(t, noteRepo.Create(ctx, otherNote))
+
+		// Plain term search excludes archived notes
+		results, err := noteRepo.Search(ctx, searchUserID, "bread", false, 10)
+		require.NoError(t, err)
+		assert.Len(t, results, 2)
+		for _, result := range results {
+			assert.Equal(t, searchUserID, result.Note.UserID)
+			assert.False(t, result.Note.IsArchived)
+			assert.Greater(t, result.Rank, float32(0))
+		}
+
+		// Archived notes are included when requested
+		results, err = noteRepo.Search(ctx, searchUserID, "bread", true, 10)
+		require.NoError(t, err)
+		assert.Len(t, results, 3)
+
+		// Prefix matching
+		results, err = noteRepo.Search(ctx, searchUserID, "sourd*", false, 10)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, recipeNote.ID, results[0].Note.ID)
+		assert.Contains(t, results[0].Snippet, entities.HighlightStart)
+
+		// Phrase queries only match adjacent words
+		results, err = noteRepo.Search(ctx, searchUserID, `"bread budget"`, false, 10)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, meetingNote.ID, results[0].Note.ID)
+
+		results, err = noteRepo.Search(ctx, searchUserID, `"budget bread"`, false, 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		// Title matches are highlighted
+		results, err = noteRepo.Search(ctx, searchUserID, "meeting", false, 10)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "Team "+entities.HighlightStart+"meeting"+entities.HighlightStop, results[0].TitleHighlight)
+
+		// Input without any searchable characters returns nothing
+		results, err = noteRepo.Search(ctx, searchUserID, "&|!", false, 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
 	t.Run("GetNoteByID", func(t *testing.T) {
 		// Create a separate test user for this test
 		idUserID := uuid.New().String()
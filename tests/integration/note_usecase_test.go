@@ -2,12 +2,20 @@ package integration
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	domainRepositories "github.com/LaulauChau/note-nest/internal/domain/repositories"
 	"github.com/LaulauChau/note-nest/internal/infrastructure/persistence/repositories"
 	"github.com/LaulauChau/note-nest/internal/infrastructure/services"
 )
@@ -24,13 +32,18 @@ func TestNoteUseCaseIntegration(t *testing.T) {
 	userRepo := repositories.NewUserRepository(queries)
 	noteRepo := repositories.NewNoteRepository(queries)
 	labelRepo := repositories.NewLabelRepository(queries)
+	revisionRepo := repositories.NewNoteRevisionRepository(queries)
+	noteShareRepo := repositories.NewNoteShareRepository(queries)
+	txManager := repositories.NewTxManager(db.Pool, queries)
 
 	// Initialize services
 	hashService := services.NewArgonHashService()
 
 	// Initialize use cases
 	userUseCase := use_cases.NewUserUseCase(userRepo, hashService)
-	noteUseCase := use_cases.NewNoteUseCase(noteRepo, userRepo, labelRepo)
+	notePermissions := use_cases.NewNotePermissionService(noteRepo, noteShareRepo)
+	noteUseCase := use_cases.NewNoteUseCase(noteRepo, userRepo, labelRepo, revisionRepo, txManager, notePermissions, services.NewLocalBlobStore(t.TempDir()))
+	revisionUseCase := use_cases.NewNoteRevisionUseCase(revisionRepo, noteRepo, userRepo, txManager, notePermissions)
 
 	// Create two test users
 	email1 := "user1@example.com"
@@ -71,12 +84,12 @@ func TestNoteUseCaseIntegration(t *testing.T) {
 		require.NotNil(t, note)
 
 		// User 2 tries to update User 1's note
-		_, err = noteUseCase.UpdateNote(ctx, note.ID, user2.ID, "Updated by User 2", "This should fail", "user2-label", true)
+		_, err = noteUseCase.UpdateNote(ctx, note.ID, user2.ID, "Updated by User 2", "This should fail", "user2-label", true, 0)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "note not found")
 
 		// User 1 updates their own note
-		updatedNote, err := noteUseCase.UpdateNote(ctx, note.ID, user1.ID, "Updated by User 1", "This should work", "user1-label", true)
+		updatedNote, err := noteUseCase.UpdateNote(ctx, note.ID, user1.ID, "Updated by User 1", "This should work", "user1-label", true, 0)
 		require.NoError(t, err)
 		require.NotNil(t, updatedNote)
 		assert.Equal(t, "Updated by User 1", updatedNote.Title)
@@ -85,6 +98,130 @@ func TestNoteUseCaseIntegration(t *testing.T) {
 		assert.True(t, updatedNote.IsArchived)
 	})
 
+	t.Run("TransactionRollback", func(t *testing.T) {
+		noteID := uuid.New().String()
+
+		// A failing unit of work leaves nothing behind
+		err := txManager.WithinTx(ctx, func(ctx context.Context, repos domainRepositories.TxRepositories) error {
+			now := time.Now()
+			if err := repos.Notes.Create(ctx, &entities.Note{
+				ID:        noteID,
+				UserID:    user1.ID,
+				Title:     "Rolled back",
+				CreatedAt: now,
+				UpdatedAt: now,
+			}); err != nil {
+				return err
+			}
+			return errors.New("abort")
+		})
+		assert.EqualError(t, err, "abort")
+
+		_, err = noteUseCase.GetNoteByID(ctx, noteID, user1.ID)
+		assert.Error(t, err)
+	})
+
+	t.Run("UpdateNoteWithStaleVersion", func(t *testing.T) {
+		note, err := noteUseCase.CreateNote(ctx, user1.ID, "Versioned", "v1", "")
+		require.NoError(t, err)
+		assert.Equal(t, 1, note.Version)
+
+		// First writer wins and bumps the version
+		updated, err := noteUseCase.UpdateNote(ctx, note.ID, user1.ID, "Versioned", "v2", "", false, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, updated.Version)
+
+		// Second writer still holds version 1
+		_, err = noteUseCase.UpdateNote(ctx, note.ID, user1.ID, "Versioned", "v2 bis", "", false, 1)
+		var conflict *domainerrors.ConflictError
+		assert.ErrorAs(t, err, &conflict)
+
+		current, err := noteUseCase.GetNoteByID(ctx, note.ID, user1.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "v2", current.Content)
+		assert.Equal(t, 2, current.Version)
+	})
+
+	t.Run("NoteRevisions", func(t *testing.T) {
+		// User 1 creates a note and edits it twice
+		note, err := noteUseCase.CreateNote(ctx, user1.ID, "Draft", "line one", "")
+		require.NoError(t, err)
+		_, err = noteUseCase.UpdateNote(ctx, note.ID, user1.ID, "Second", "line one\nline two", "", false, 0)
+		require.NoError(t, err)
+		_, err = noteUseCase.UpdateNote(ctx, note.ID, user1.ID, "Third", "line two", "", false, 0)
+		require.NoError(t, err)
+
+		// Both prior versions are kept, newest first
+		revisions, err := revisionUseCase.GetRevisions(ctx, note.ID, user1.ID)
+		require.NoError(t, err)
+		require.Len(t, revisions, 2)
+		assert.Equal(t, 2, revisions[0].Revision)
+		assert.Equal(t, "Second", revisions[0].Title)
+		assert.Equal(t, 1, revisions[1].Revision)
+		assert.Equal(t, "Draft", revisions[1].Title)
+
+		// User 2 cannot see the history
+		_, err = revisionUseCase.GetRevisions(ctx, note.ID, user2.ID)
+		assert.Error(t, err)
+
+		// Diff the first version against the current one
+		diff, err := revisionUseCase.DiffRevisions(ctx, note.ID, user1.ID, 1, use_cases.CurrentRevision)
+		require.NoError(t, err)
+		assert.Equal(t, "Draft", diff.FromTitle)
+		assert.Equal(t, "Third", diff.ToTitle)
+		assert.NotEmpty(t, diff.Lines)
+
+		// Restore the first version, which records the current one
+		restored, err := revisionUseCase.RestoreRevision(ctx, note.ID, user1.ID, 1, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "Draft", restored.Title)
+		assert.Equal(t, "line one", restored.Content)
+
+		revisions, err = revisionUseCase.GetRevisions(ctx, note.ID, user1.ID)
+		require.NoError(t, err)
+		require.Len(t, revisions, 3)
+		assert.Equal(t, "Third", revisions[0].Title)
+
+		// A retention policy prunes older revisions on the next edit
+		_, err = revisionUseCase.UpdateRetention(ctx, user1.ID, 2, 0)
+		require.NoError(t, err)
+		_, err = noteUseCase.UpdateNote(ctx, note.ID, user1.ID, "Fourth", "line four", "", false, 0)
+		require.NoError(t, err)
+
+		revisions, err = revisionUseCase.GetRevisions(ctx, note.ID, user1.ID)
+		require.NoError(t, err)
+		require.Len(t, revisions, 2)
+		assert.Equal(t, 4, revisions[0].Revision)
+		assert.Equal(t, 3, revisions[1].Revision)
+	})
+
+	t.Run("ConcurrentRevisions", func(t *testing.T) {
+		note, err := noteUseCase.CreateNote(ctx, user2.ID, "Busy", "edit 0", "")
+		require.NoError(t, err)
+
+		// Saves racing on the same note each get their own revision number
+		const edits = 8
+		var wg sync.WaitGroup
+		errs := make(chan error, edits)
+		for i := 1; i <= edits; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := noteUseCase.UpdateNote(ctx, note.ID, user2.ID, "Busy", fmt.Sprintf("edit %d", i), "", false, 0)
+				errs <- err
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+
+		revisions, err := revisionUseCase.GetRevisions(ctx, note.ID, user2.ID)
+		require.NoError(t, err)
+		assert.Len(t, revisions, edits)
+	})
+
 	t.Run("DeleteNote", func(t *testing.T) {
 		// User 1 creates a note
 		note, err := noteUseCase.CreateNote(ctx, user1.ID, "Note to Delete", "Delete content", "delete-test")
@@ -111,6 +248,77 @@ func TestNoteUseCaseIntegration(t *testing.T) {
 		assert.Contains(t, err.Error(), "note not found")
 	})
 
+	t.Run("TrashAndRestoreNote", func(t *testing.T) {
+		// User 1 creates a labeled note and trashes it
+		label := &entities.Label{
+			ID:        uuid.New().String(),
+			UserID:    user1.ID,
+			Name:      "trash-test",
+			Color:     "#000000",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		require.NoError(t, labelRepo.Create(ctx, label))
+		note, err := noteUseCase.CreateNoteWithLabels(ctx, user1.ID, "Trashed Note", "Trash content", "", []string{label.ID})
+		require.NoError(t, err)
+		require.NoError(t, noteUseCase.DeleteNote(ctx, note.ID, user1.ID))
+
+		// The note is only listed in the trash
+		active, err := noteUseCase.GetActiveNotes(ctx, user1.ID)
+		require.NoError(t, err)
+		for _, n := range active {
+			assert.NotEqual(t, note.ID, n.ID)
+		}
+		trashed, err := noteUseCase.GetTrashedNotes(ctx, user1.ID)
+		require.NoError(t, err)
+		require.NotEmpty(t, trashed)
+		assert.Equal(t, note.ID, trashed[0].ID)
+		assert.NotNil(t, trashed[0].DeletedAt)
+
+		// User 2 cannot restore it
+		_, err = noteUseCase.RestoreNote(ctx, note.ID, user2.ID)
+		assert.Error(t, err)
+
+		// Restoring brings back the note and its labels
+		restored, err := noteUseCase.RestoreNote(ctx, note.ID, user1.ID)
+		require.NoError(t, err)
+		assert.Nil(t, restored.DeletedAt)
+
+		_, labels, err := noteUseCase.GetNoteWithLabels(ctx, note.ID, user1.ID)
+		require.NoError(t, err)
+		require.Len(t, labels, 1)
+		assert.Equal(t, label.ID, labels[0].ID)
+
+		// Emptying the trash removes trashed notes for good
+		require.NoError(t, noteUseCase.DeleteNote(ctx, note.ID, user1.ID))
+		deleted, err := noteUseCase.EmptyTrash(ctx, user1.ID)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, deleted, int64(1))
+
+		_, err = noteUseCase.RestoreNote(ctx, note.ID, user1.ID)
+		assert.Error(t, err)
+	})
+
+	t.Run("PurgeTrash", func(t *testing.T) {
+		note, err := noteUseCase.CreateNote(ctx, user1.ID, "Old Trash", "Old content", "")
+		require.NoError(t, err)
+		require.NoError(t, noteRepo.Trash(ctx, note.ID, time.Now().Add(-48*time.Hour)))
+
+		recent, err := noteUseCase.CreateNote(ctx, user1.ID, "Recent Trash", "Recent content", "")
+		require.NoError(t, err)
+		require.NoError(t, noteUseCase.DeleteNote(ctx, recent.ID, user1.ID))
+
+		// Only notes trashed before the retention period are purged
+		purged, err := noteUseCase.PurgeTrash(ctx, 24*time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+
+		_, err = noteUseCase.RestoreNote(ctx, note.ID, user1.ID)
+		assert.Error(t, err)
+		_, err = noteUseCase.RestoreNote(ctx, recent.ID, user1.ID)
+		assert.NoError(t, err)
+	})
+
 	t.Run("GetNotesByUserID", func(t *testing.T) {
 		// User 1 creates multiple notes
 		_, err := noteUseCase.CreateNote(ctx, user1.ID, "User 1 Note 1", "Content 1", "label1")
@@ -0,0 +1,170 @@
+package integration
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LaulauChau/note-nest/internal/application/use_cases"
+	"github.com/LaulauChau/note-nest/internal/domain/domainerrors"
+	"github.com/LaulauChau/note-nest/internal/domain/entities"
+	"github.com/LaulauChau/note-nest/internal/infrastructure/persistence/repositories"
+	"github.com/LaulauChau/note-nest/internal/infrastructure/services"
+)
+
+var linkTokenPattern = regexp.MustCompile(`token=([a-z2-7]+)`)
+
+func TestPasswordResetUseCaseIntegration(t *testing.T) {
+	// Set up test database
+	ctx := context.Background()
+	db, err := SetupTestDatabase(ctx)
+	require.NoError(t, err)
+	defer db.Close(ctx)
+
+	// Initialize the SQLC queries struct
+	queries := repositories.New(db.Pool)
+
+	// Initialize repositories
+	userRepo := repositories.NewUserRepository(queries)
+	sessionRepo := repositories.NewSessionRepository(queries)
+	passwordResetRepo := repositories.NewPasswordResetRepository(queries)
+	emailVerificationRepo := repositories.NewEmailVerificationRepository(queries)
+	personalAccessTokenRepo := repositories.NewPersonalAccessTokenRepository(queries)
+	txManager := repositories.NewTxManager(db.Pool, queries)
+
+	// Initialize services
+	tokenService := services.NewTokenService()
+	hashService := services.NewArgonHashService()
+	mailer := NewCapturingMailer()
+	attemptStore := services.NewMemoryLoginAttemptStore()
+
+	// Initialize use cases
+	userUseCase := use_cases.NewUserUseCase(userRepo, hashService)
+	sessionUseCase := use_cases.NewSessionUseCase(sessionRepo, userRepo, tokenService, testSessionConfig)
+	personalAccessTokenUseCase := use_cases.NewPersonalAccessTokenUseCase(personalAccessTokenRepo, userRepo, tokenService)
+	emailVerificationUseCase := use_cases.NewEmailVerificationUseCase(userRepo, emailVerificationRepo, tokenService, mailer, use_cases.EmailVerificationRestrict, "http://localhost:8080", 24*time.Hour, time.Minute)
+	passwordResetUseCase := use_cases.NewPasswordResetUseCase(userRepo, passwordResetRepo, txManager, emailVerificationUseCase, tokenService, hashService, mailer, attemptStore, "http://localhost:8080", use_cases.PasswordResetConfig{TokenTTL: time.Hour})
+
+	// Register a test user with a verified address and an active session
+	email := "reset@example.com"
+	user, err := userUseCase.RegisterUser(ctx, email, "Reset User", "0ld!P@ssw0rd123")
+	require.NoError(t, err)
+	err = userRepo.MarkEmailVerified(ctx, user.ID)
+	require.NoError(t, err)
+	sessionToken, err := sessionUseCase.GenerateSessionToken(ctx)
+	require.NoError(t, err)
+	_, err = sessionUseCase.CreateSession(ctx, sessionToken, user.ID, use_cases.SessionClient{})
+	require.NoError(t, err)
+	_, accessToken, err := personalAccessTokenUseCase.CreateToken(ctx, user.ID, "CLI", []entities.TokenScope{entities.ScopeNotesRead}, nil)
+	require.NoError(t, err)
+
+	t.Run("UnknownEmail", func(t *testing.T) {
+		err := passwordResetUseCase.RequestReset(ctx, "nobody@example.com", "")
+		assert.NoError(t, err)
+
+		_, sent := mailer.Last("nobody@example.com")
+		assert.False(t, sent)
+	})
+
+	t.Run("UnverifiedEmail", func(t *testing.T) {
+		_, err := userUseCase.RegisterUser(ctx, "unverified-reset@example.com", "Unverified User", "0ld!P@ssw0rd123")
+		require.NoError(t, err)
+
+		err = passwordResetUseCase.RequestReset(ctx, "unverified-reset@example.com", "")
+		assert.NoError(t, err)
+
+		_, sent := mailer.Last("unverified-reset@example.com")
+		assert.False(t, sent)
+	})
+
+	t.Run("ResetPassword", func(t *testing.T) {
+		// Request a reset link
+		err := passwordResetUseCase.RequestReset(ctx, email, "")
+		require.NoError(t, err)
+
+		message, sent := mailer.Last(email)
+		require.True(t, sent)
+		match := linkTokenPattern.FindStringSubmatch(message.Body)
+		require.Len(t, match, 2)
+		token := match[1]
+
+		// Reset the password
+		err = passwordResetUseCase.ResetPassword(ctx, token, "N3w!P@ssw0rd123")
+		require.NoError(t, err)
+
+		// The new password works, the old one does not
+		_, err = userUseCase.AuthenticateUser(ctx, email, "N3w!P@ssw0rd123")
+		assert.NoError(t, err)
+		_, err = userUseCase.AuthenticateUser(ctx, email, "0ld!P@ssw0rd123")
+		assert.ErrorIs(t, err, domainerrors.ErrUnauthorized)
+
+		// Every session was invalidated
+		result, err := sessionUseCase.ValidateSessionToken(ctx, sessionToken)
+		require.NoError(t, err)
+		assert.Nil(t, result.Session)
+
+		// Every personal access token was revoked
+		_, _, err = personalAccessTokenUseCase.Authenticate(ctx, accessToken)
+		assert.ErrorIs(t, err, domainerrors.ErrUnauthorized)
+
+		// The token cannot be used twice
+		err = passwordResetUseCase.ResetPassword(ctx, token, "An0ther!P@ssw0rd")
+		assert.ErrorIs(t, err, domainerrors.ErrValidation)
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		err := passwordResetUseCase.ResetPassword(ctx, "notarealtoken", "N3w!P@ssw0rd123")
+		assert.ErrorIs(t, err, domainerrors.ErrValidation)
+	})
+
+	t.Run("ExpiredToken", func(t *testing.T) {
+		expiringUseCase := use_cases.NewPasswordResetUseCase(userRepo, passwordResetRepo, txManager, emailVerificationUseCase, tokenService, hashService, mailer, attemptStore, "http://localhost:8080", use_cases.PasswordResetConfig{TokenTTL: time.Millisecond})
+
+		err := expiringUseCase.RequestReset(ctx, email, "")
+		require.NoError(t, err)
+
+		message, sent := mailer.Last(email)
+		require.True(t, sent)
+		match := linkTokenPattern.FindStringSubmatch(message.Body)
+		require.Len(t, match, 2)
+
+		time.Sleep(10 * time.Millisecond)
+
+		err = expiringUseCase.ResetPassword(ctx, match[1], "Exp1red!P@ssw0rd")
+		assert.ErrorIs(t, err, domainerrors.ErrValidation)
+	})
+
+	t.Run("Throttled", func(t *testing.T) {
+		throttledUseCase := use_cases.NewPasswordResetUseCase(userRepo, passwordResetRepo, txManager, emailVerificationUseCase, tokenService, hashService, mailer, services.NewMemoryLoginAttemptStore(), "http://localhost:8080", use_cases.PasswordResetConfig{
+			TokenTTL:       time.Hour,
+			ResendInterval: time.Minute,
+			IPLimit:        2,
+			IPWindow:       time.Minute,
+		})
+
+		throttled, err := userUseCase.RegisterUser(ctx, "throttled-reset@example.com", "Throttled User", "0ld!P@ssw0rd123")
+		require.NoError(t, err)
+		require.NoError(t, userRepo.MarkEmailVerified(ctx, throttled.ID))
+
+		err = throttledUseCase.RequestReset(ctx, throttled.Email, "203.0.113.7")
+		require.NoError(t, err)
+		first, sent := mailer.Last(throttled.Email)
+		require.True(t, sent)
+
+		// A second link within the resend interval is dropped silently
+		err = throttledUseCase.RequestReset(ctx, throttled.Email, "203.0.113.7")
+		require.NoError(t, err)
+		last, _ := mailer.Last(throttled.Email)
+		assert.Equal(t, first, last)
+
+		// The address is limited whichever email it asks for
+		err = throttledUseCase.RequestReset(ctx, "nobody@example.com", "203.0.113.7")
+		assert.ErrorIs(t, err, domainerrors.ErrRateLimited)
+		err = throttledUseCase.RequestReset(ctx, "nobody@example.com", "203.0.113.8")
+		assert.NoError(t, err)
+	})
+}
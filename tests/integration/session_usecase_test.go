@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -14,6 +15,15 @@ import (
 	"github.com/LaulauChau/note-nest/internal/infrastructure/services"
 )
 
+// testSessionConfig uses a small cleanup batch so that batching is exercised
+var testSessionConfig = use_cases.SessionConfig{
+	TTL:              30 * 24 * time.Hour,
+	RenewalThreshold: 15 * 24 * time.Hour,
+	MaxAge:           90 * 24 * time.Hour,
+	PartialTTL:       5 * time.Minute,
+	CleanupBatchSize: 2,
+}
+
 func TestSessionUseCaseIntegration(t *testing.T) {
 	// Set up test database
 	ctx := context.Background()
@@ -34,7 +44,7 @@ func TestSessionUseCaseIntegration(t *testing.T) {
 
 	// Initialize use cases
 	userUseCase := use_cases.NewUserUseCase(userRepo, hashService)
-	sessionUseCase := use_cases.NewSessionUseCase(sessionRepo, userRepo, tokenService)
+	sessionUseCase := use_cases.NewSessionUseCase(sessionRepo, userRepo, tokenService, testSessionConfig)
 
 	// Test user
 	email := "test@example.com"
@@ -53,7 +63,7 @@ func TestSessionUseCaseIntegration(t *testing.T) {
 		require.NotEmpty(t, token)
 
 		// Create a session
-		session, err := sessionUseCase.CreateSession(ctx, token, user.ID)
+		session, err := sessionUseCase.CreateSession(ctx, token, user.ID, use_cases.SessionClient{})
 		require.NoError(t, err)
 		require.NotNil(t, session)
 
@@ -72,7 +82,7 @@ func TestSessionUseCaseIntegration(t *testing.T) {
 		require.NoError(t, err)
 
 		// Create a session
-		session, err := sessionUseCase.CreateSession(ctx, token, user.ID)
+		session, err := sessionUseCase.CreateSession(ctx, token, user.ID, use_cases.SessionClient{})
 		require.NoError(t, err)
 		require.NotNil(t, session)
 
@@ -119,8 +129,8 @@ func TestSessionUseCaseIntegration(t *testing.T) {
 		token1, _ := sessionUseCase.GenerateSessionToken(ctx)
 		token2, _ := sessionUseCase.GenerateSessionToken(ctx)
 
-		session1, _ := sessionUseCase.CreateSession(ctx, token1, user.ID)
-		session2, _ := sessionUseCase.CreateSession(ctx, token2, user.ID)
+		session1, _ := sessionUseCase.CreateSession(ctx, token1, user.ID, use_cases.SessionClient{})
+		session2, _ := sessionUseCase.CreateSession(ctx, token2, user.ID, use_cases.SessionClient{})
 
 		require.NotNil(t, session1)
 		require.NotNil(t, session2)
@@ -136,4 +146,45 @@ func TestSessionUseCaseIntegration(t *testing.T) {
 		assert.Nil(t, result1.Session)
 		assert.Nil(t, result2.Session)
 	})
+
+	t.Run("PurgeExpiredSessions", func(t *testing.T) {
+		// Create expired sessions, more than a batch of them
+		now := time.Now()
+		expiredIDs := make([]string, 5)
+		for i := range expiredIDs {
+			token, err := sessionUseCase.GenerateSessionToken(ctx)
+			require.NoError(t, err)
+			expiredIDs[i], err = tokenService.HashToken(ctx, token)
+			require.NoError(t, err)
+			err = sessionRepo.Create(ctx, &entities.Session{
+				ID:         expiredIDs[i],
+				PublicID:   uuid.New().String(),
+				UserID:     user.ID,
+				ExpiresAt:  now.Add(-time.Hour),
+				CreatedAt:  now.Add(-2 * time.Hour),
+				LastSeenAt: now.Add(-2 * time.Hour),
+			})
+			require.NoError(t, err)
+		}
+
+		// And one still in use
+		token, err := sessionUseCase.GenerateSessionToken(ctx)
+		require.NoError(t, err)
+		active, err := sessionUseCase.CreateSession(ctx, token, user.ID, use_cases.SessionClient{})
+		require.NoError(t, err)
+
+		// Purge the expired ones
+		purged, err := sessionUseCase.PurgeExpiredSessions(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, len(expiredIDs), purged)
+
+		for _, id := range expiredIDs {
+			session, err := sessionRepo.GetByID(ctx, id)
+			require.NoError(t, err)
+			assert.Nil(t, session)
+		}
+		session, err := sessionRepo.GetByID(ctx, active.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, session)
+	})
 }
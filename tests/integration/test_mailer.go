@@ -0,0 +1,40 @@
+package integration
+
+import (
+	"context"
+	"sync"
+
+	"github.com/LaulauChau/note-nest/internal/application/services"
+)
+
+// CapturingMailer keeps the sent messages in memory so tests can read the
+// links they contain
+type CapturingMailer struct {
+	mu       sync.Mutex
+	messages []services.MailMessage
+}
+
+func NewCapturingMailer() *CapturingMailer {
+	return &CapturingMailer{}
+}
+
+func (m *CapturingMailer) Send(ctx context.Context, message services.MailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+// Last returns the most recent message sent to the address
+func (m *CapturingMailer) Last(to string) (services.MailMessage, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i], true
+		}
+	}
+	return services.MailMessage{}, false
+}
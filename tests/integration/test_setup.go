@@ -3,29 +3,17 @@ package integration
 import (
 	"context"
 	"fmt"
-	"io/fs"
 	"log"
 	"os"
-	"path/filepath"
-	"runtime"
-	"sort"
-	"strings"
 	"testing"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
-)
 
-// projectRoot returns the absolute path to the project root
-func projectRoot() string {
-	// Start with the current working directory
-	_, filename, _, _ := runtime.Caller(0)
-	// Go up from tests/integration to the project root
-	dir := filepath.Dir(filename)
-	return filepath.Join(dir, "../..")
-}
+	"github.com/LaulauChau/note-nest/internal/infrastructure/persistence/database"
+)
 
 type TestDatabase struct {
 	Container testcontainers.Container
@@ -118,47 +106,16 @@ func SetupTestDatabase(ctx context.Context) (*TestDatabase, error) {
 }
 
 func applyMigrations(ctx context.Context, pool *pgxpool.Pool) error {
-	// Path to migrations relative to the project root
-	migrationsPath := filepath.Join(projectRoot(), "internal", "infrastructure", "persistence", "database", "migrations")
-	log.Printf("Looking for migrations in: %s", migrationsPath)
-
-	// Get all migration files
-	var upMigrations []string
-	err := filepath.Walk(migrationsPath, func(path string, info fs.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() && strings.HasSuffix(path, ".up.sql") {
-			upMigrations = append(upMigrations, path)
-		}
-		return nil
-	})
+	migrator, err := database.NewMigrator(pool)
 	if err != nil {
-		return fmt.Errorf("failed to read migrations: %w", err)
-	}
-
-	if len(upMigrations) == 0 {
-		return fmt.Errorf("no migration files found in %s", migrationsPath)
+		return fmt.Errorf("failed to load migrations: %w", err)
 	}
 
-	// Sort migrations by filename (which should include a timestamp)
-	sort.Strings(upMigrations)
-	log.Printf("Found %d migration files", len(upMigrations))
-
-	// Apply each migration
-	for _, migrationPath := range upMigrations {
-		migrationSQL, err := os.ReadFile(migrationPath)
-		if err != nil {
-			return fmt.Errorf("failed to read migration file %s: %w", migrationPath, err)
-		}
-
-		_, err = pool.Exec(ctx, string(migrationSQL))
-		if err != nil {
-			return fmt.Errorf("failed to execute migration %s: %w", migrationPath, err)
-		}
-
-		log.Printf("Applied migration: %s", migrationPath)
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
 	}
+	log.Printf("Applied %d migrations", len(applied))
 
 	return nil
 }